@@ -0,0 +1,130 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"fmt"
+
+	"github.com/rook/rook/cmd/rook/rook"
+	"github.com/rook/rook/pkg/daemon/ceph/backup"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Backs up and restores the mon store and the Rook metadata of a cluster",
+}
+var backupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Writes a backup of a stopped mon store and the exported metadata",
+}
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restores the mon store and the metadata from a backup",
+}
+
+var (
+	backupStoreConfig   backup.StoreConfig
+	backupCreateConfig  backup.CreateConfig
+	backupRestoreConfig backup.RestoreConfig
+	backupNamespace     string
+	skipMetadata        bool
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{backupCreateCmd, backupRestoreCmd} {
+		cmd.Flags().StringVar(&backupStoreConfig.Dir, "backup-dir", "", "the path where the backups are written")
+		cmd.Flags().StringVar(&backupStoreConfig.S3Endpoint, "s3-endpoint", "", "the s3 endpoint where the backups are written. the credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		cmd.Flags().StringVar(&backupStoreConfig.S3Bucket, "s3-bucket", "", "the s3 bucket where the backups are written")
+	}
+
+	backupCreateCmd.Flags().StringVar(&backupCreateConfig.Prefix, "prefix", "", "the prefix of the backup names, usually the namespace of the cluster")
+	backupCreateCmd.Flags().StringVar(&backupCreateConfig.MonStoreDir, "mon-store-dir", "", "the data dir of the stopped mon")
+	backupCreateCmd.Flags().StringVar(&backupCreateConfig.MetadataFile, "metadata-file", "", "the file with the exported metadata")
+	backupCreateCmd.Flags().IntVar(&backupCreateConfig.Retention, "retention", 0, "the number of backups to keep. all the backups are kept if zero")
+
+	backupRestoreCmd.Flags().StringVar(&backupRestoreConfig.Prefix, "prefix", "", "the prefix of the backup names, usually the namespace of the cluster")
+	backupRestoreCmd.Flags().StringVar(&backupRestoreConfig.Name, "backup", backup.LatestBackup, "the name of the backup to restore")
+	backupRestoreCmd.Flags().StringVar(&backupRestoreConfig.MonStoreDir, "mon-store-dir", "", "the empty data dir of the mon where the mon store is restored")
+	backupRestoreCmd.Flags().StringVar(&backupNamespace, "namespace", "", "the namespace of the cluster where the metadata is restored. defaults to the prefix")
+	backupRestoreCmd.Flags().BoolVar(&skipMetadata, "skip-metadata", false, "only restore the mon store")
+
+	backupCmd.AddCommand(backupCreateCmd, backupRestoreCmd)
+
+	flags.SetFlagsFromEnv(backupCreateCmd.Flags(), rook.RookEnvVarPrefix)
+	flags.SetFlagsFromEnv(backupRestoreCmd.Flags(), rook.RookEnvVarPrefix)
+
+	backupCreateCmd.RunE = createBackup
+	backupRestoreCmd.RunE = restoreBackup
+}
+
+func createBackup(cmd *cobra.Command, args []string) error {
+	required := []string{"prefix", "mon-store-dir", "metadata-file"}
+	if err := flags.VerifyRequiredFlags(backupCreateCmd, required); err != nil {
+		return err
+	}
+
+	rook.SetLogLevel()
+	rook.LogStartupInfo(backupCreateCmd.Flags())
+
+	store, err := backup.NewStore(backupStoreConfig)
+	if err != nil {
+		rook.TerminateFatal(err)
+	}
+	if _, err := backup.Create(backupCreateConfig, store); err != nil {
+		rook.TerminateFatal(err)
+	}
+	return nil
+}
+
+func restoreBackup(cmd *cobra.Command, args []string) error {
+	required := []string{"prefix", "mon-store-dir"}
+	if err := flags.VerifyRequiredFlags(backupRestoreCmd, required); err != nil {
+		return err
+	}
+
+	rook.SetLogLevel()
+	rook.LogStartupInfo(backupRestoreCmd.Flags())
+
+	store, err := backup.NewStore(backupStoreConfig)
+	if err != nil {
+		rook.TerminateFatal(err)
+	}
+	metadata, err := backup.Restore(backupRestoreConfig, store)
+	if err != nil {
+		rook.TerminateFatal(err)
+	}
+	logger.Infof("restored the mon store to %s", backupRestoreConfig.MonStoreDir)
+
+	if skipMetadata {
+		return nil
+	}
+	namespace := backupNamespace
+	if namespace == "" {
+		namespace = backupRestoreConfig.Prefix
+	}
+	clientset, _, _, err := rook.GetClientset()
+	if err != nil {
+		rook.TerminateFatal(fmt.Errorf("failed to restore metadata. %+v", err))
+	}
+	if err := backup.ApplyMetadata(clientset, namespace, metadata); err != nil {
+		rook.TerminateFatal(err)
+	}
+	logger.Infof("restored the metadata in namespace %s", namespace)
+	return nil
+}
@@ -59,7 +59,9 @@ func init() {
 		agentCmd,
 		osdCmd,
 		configCmd,
-		nfsCmd)
+		nfsCmd,
+		cleanupCmd,
+		backupCmd)
 }
 
 func createContext() *clusterd.Context {
@@ -0,0 +1,51 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"github.com/rook/rook/cmd/rook/rook"
+	"github.com/rook/rook/pkg/daemon/ceph/cleanup"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+)
+
+var cleanupCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Removes the data left on the host by a deleted cluster",
+}
+
+var cleanupConfig cleanup.Config
+
+func init() {
+	cleanupCmd.Flags().StringVar(&cleanupConfig.DataDir, "data-dir", "/var/lib/rook", "the path where the dataDirHostPath of the cluster is mounted")
+	cleanupCmd.Flags().StringSliceVar(&cleanupConfig.Devices, "devices", nil, "the devices of the osds of the cluster on the host")
+	cleanupCmd.Flags().BoolVar(&cleanupConfig.SanitizeDevices, "sanitize-devices", false, "securely erase the devices after the rook partitions are removed. BE CAREFUL!")
+	flags.SetFlagsFromEnv(cleanupCmd.Flags(), rook.RookEnvVarPrefix)
+
+	cleanupCmd.RunE = startCleanup
+}
+
+func startCleanup(cmd *cobra.Command, args []string) error {
+	rook.SetLogLevel()
+	rook.LogStartupInfo(cleanupCmd.Flags())
+
+	context := createContext()
+	if err := cleanup.Run(context, cleanupConfig); err != nil {
+		rook.TerminateFatal(err)
+	}
+	return nil
+}
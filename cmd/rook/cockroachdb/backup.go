@@ -0,0 +1,115 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cockroachdb
+
+import (
+	"github.com/rook/rook/cmd/rook/rook"
+	"github.com/rook/rook/pkg/daemon/cockroachdb/backup"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/rook/rook/pkg/util/s3"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Backs up and restores the databases of a cockroachdb cluster",
+}
+var backupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Backs up the databases of a cluster to an s3 bucket",
+}
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restores the databases of a backup to a cluster",
+}
+
+var (
+	backupConfig        backup.Config
+	backupCreateConfig  backup.CreateConfig
+	backupRestoreConfig backup.RestoreConfig
+	s3Endpoint          string
+	s3Bucket            string
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{backupCreateCmd, backupRestoreCmd} {
+		cmd.Flags().StringVar(&backupConfig.Host, "host", "", "the address of the cluster")
+		cmd.Flags().IntVar(&backupConfig.Port, "port", 26257, "the grpc port of the cluster")
+		cmd.Flags().StringVar(&backupConfig.CertsDir, "certs-dir", "", "the dir with the certificate of the root user. the cluster is insecure if empty")
+		cmd.Flags().StringVar(&backupConfig.Prefix, "prefix", "", "the prefix of the backups of the cluster, usually <namespace>/<cluster>")
+		cmd.Flags().StringVar(&s3Endpoint, "s3-endpoint", "", "the s3 endpoint where the backups are stored. the credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		cmd.Flags().StringVar(&s3Bucket, "s3-bucket", "", "the s3 bucket where the backups are stored")
+	}
+
+	backupCreateCmd.Flags().StringVar(&backupCreateConfig.Name, "name", "", "the name of the backup")
+	backupCreateCmd.Flags().StringVar(&backupCreateConfig.Mode, "mode", backup.ModeBackup, "backup to use the BACKUP statement of the enterprise edition or dump to use cockroach dump")
+	backupCreateCmd.Flags().StringSliceVar(&backupCreateConfig.Databases, "databases", nil, "the databases to back up")
+	backupCreateCmd.Flags().IntVar(&backupCreateConfig.Retention, "retention", 0, "the number of backups to keep. all the backups are kept if zero")
+
+	backupRestoreCmd.Flags().StringVar(&backupRestoreConfig.Name, "name", backup.LatestBackup, "the name of the backup to restore")
+
+	backupCmd.AddCommand(backupCreateCmd, backupRestoreCmd)
+
+	flags.SetFlagsFromEnv(backupCreateCmd.Flags(), rook.RookEnvVarPrefix)
+	flags.SetFlagsFromEnv(backupRestoreCmd.Flags(), rook.RookEnvVarPrefix)
+
+	backupCreateCmd.RunE = createBackup
+	backupRestoreCmd.RunE = restoreBackup
+}
+
+func createBackup(cmd *cobra.Command, args []string) error {
+	required := []string{"host", "prefix", "s3-endpoint", "s3-bucket", "name"}
+	if err := flags.VerifyRequiredFlags(backupCreateCmd, required); err != nil {
+		return err
+	}
+
+	rook.SetLogLevel()
+	rook.LogStartupInfo(backupCreateCmd.Flags())
+
+	backupCreateConfig.Config = backupConfig
+	backupCreateConfig.S3 = s3.ConfigFromEnv(s3Endpoint, s3Bucket)
+	store, err := s3.NewClient(backupCreateConfig.S3)
+	if err != nil {
+		rook.TerminateFatal(err)
+	}
+	if err := backup.Create(backupCreateConfig, store, backup.RunCockroach); err != nil {
+		rook.TerminateFatal(err)
+	}
+	return nil
+}
+
+func restoreBackup(cmd *cobra.Command, args []string) error {
+	required := []string{"host", "prefix", "s3-endpoint", "s3-bucket"}
+	if err := flags.VerifyRequiredFlags(backupRestoreCmd, required); err != nil {
+		return err
+	}
+
+	rook.SetLogLevel()
+	rook.LogStartupInfo(backupRestoreCmd.Flags())
+
+	backupRestoreConfig.Config = backupConfig
+	backupRestoreConfig.S3 = s3.ConfigFromEnv(s3Endpoint, s3Bucket)
+	store, err := s3.NewClient(backupRestoreConfig.S3)
+	if err != nil {
+		rook.TerminateFatal(err)
+	}
+	name, err := backup.Restore(backupRestoreConfig, store, backup.RunCockroach)
+	if err != nil {
+		rook.TerminateFatal(err)
+	}
+	logger.Infof("restored backup %s", name)
+	return nil
+}
@@ -36,7 +36,7 @@ var (
 )
 
 func init() {
-	Cmd.AddCommand(operatorCmd)
+	Cmd.AddCommand(operatorCmd, backupCmd)
 }
 
 func createContext() *clusterd.Context {
@@ -22,6 +22,7 @@ import (
 	"github.com/rook/rook/cmd/rook/rook"
 	"github.com/rook/rook/pkg/clusterd"
 	edgefsoperator "github.com/rook/rook/pkg/operator/edgefs"
+	"github.com/rook/rook/pkg/operator/edgefs/csi"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/util/flags"
 	"github.com/spf13/cobra"
@@ -37,6 +38,22 @@ https://github.com/rook/rook`,
 }
 
 func init() {
+	operatorCmd.Flags().BoolVar(&csi.EnableNFS, "csi-enable-nfs", false, "enable edgefs-csi nfs support")
+	operatorCmd.Flags().BoolVar(&csi.EnableISCSI, "csi-enable-iscsi", false, "enable edgefs-csi iscsi support")
+	// csi images
+	operatorCmd.Flags().StringVar(&csi.CSIParam.PluginImage, "csi-edgefs-image", csi.DefaultPluginImage, "edgefs-csi plugin image")
+	operatorCmd.Flags().StringVar(&csi.CSIParam.RegistrarImage, "csi-registrar-image", csi.DefaultRegistrarImage, "csi registrar image")
+	operatorCmd.Flags().StringVar(&csi.CSIParam.ProvisionerImage, "csi-provisioner-image", csi.DefaultProvisionerImage, "csi provisioner image")
+	operatorCmd.Flags().StringVar(&csi.CSIParam.AttacherImage, "csi-attacher-image", csi.DefaultAttacherImage, "csi attacher image")
+	operatorCmd.Flags().StringVar(&csi.CSIParam.SnapshotterImage, "csi-snapshotter-image", csi.DefaultSnapshotterImage, "csi snapshotter image")
+
+	// csi deployment templates
+	operatorCmd.Flags().StringVar(&csi.NFSNodeTemplatePath, "csi-nfs-node-template-path", csi.DefaultNFSNodeTemplatePath, "path to edgefs-csi nfs node plugin template")
+	operatorCmd.Flags().StringVar(&csi.NFSControllerTemplatePath, "csi-nfs-controller-template-path", csi.DefaultNFSControllerTemplatePath, "path to edgefs-csi nfs controller template")
+
+	operatorCmd.Flags().StringVar(&csi.ISCSINodeTemplatePath, "csi-iscsi-node-template-path", csi.DefaultISCSINodeTemplatePath, "path to edgefs-csi iscsi node plugin template")
+	operatorCmd.Flags().StringVar(&csi.ISCSIControllerTemplatePath, "csi-iscsi-controller-template-path", csi.DefaultISCSIControllerTemplatePath, "path to edgefs-csi iscsi controller template")
+
 	flags.SetFlagsFromEnv(operatorCmd.Flags(), rook.RookEnvVarPrefix)
 
 	operatorCmd.RunE = startOperator
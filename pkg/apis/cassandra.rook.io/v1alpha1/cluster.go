@@ -0,0 +1,59 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// GetDatacenters returns all the datacenters of the cluster. A datacenter set with the
+// deprecated datacenter field comes first, followed by the datacenters list.
+func (s *ClusterSpec) GetDatacenters() []DatacenterSpec {
+	dcs := []DatacenterSpec{}
+	if s.Datacenter != nil {
+		dcs = append(dcs, *s.Datacenter)
+	}
+	return append(dcs, s.Datacenters...)
+}
+
+// GetDatacenter returns the datacenter with the given name, or nil if the cluster has no
+// such datacenter.
+func (s *ClusterSpec) GetDatacenter(name string) *DatacenterSpec {
+	for _, dc := range s.GetDatacenters() {
+		if dc.Name == name {
+			return &dc
+		}
+	}
+	return nil
+}
+
+// GetRackStatus returns the status of a rack of the given datacenter, or nil if the rack
+// is not created yet.
+func (s *ClusterStatus) GetRackStatus(dc, rack string) *RackStatus {
+	dcStatus, ok := s.Datacenters[dc]
+	if !ok || dcStatus == nil {
+		return nil
+	}
+	return dcStatus.Racks[rack]
+}
+
+// SetRackStatus sets the status of a rack of the given datacenter.
+func (s *ClusterStatus) SetRackStatus(dc, rack string, status *RackStatus) {
+	if s.Datacenters == nil {
+		s.Datacenters = map[string]*DatacenterStatus{}
+	}
+	if s.Datacenters[dc] == nil {
+		s.Datacenters[dc] = &DatacenterStatus{Racks: map[string]*RackStatus{}}
+	}
+	s.Datacenters[dc].Racks[rack] = status
+}
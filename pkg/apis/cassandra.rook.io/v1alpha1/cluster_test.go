@@ -0,0 +1,49 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDatacenters(t *testing.T) {
+	spec := ClusterSpec{}
+	assert.Equal(t, 0, len(spec.GetDatacenters()))
+	assert.Nil(t, spec.GetDatacenter("dc1"))
+
+	spec.Datacenters = []DatacenterSpec{{Name: "dc2"}, {Name: "dc3", RebuildFrom: "dc2"}}
+	spec.Datacenter = &DatacenterSpec{Name: "dc1"}
+	dcs := spec.GetDatacenters()
+	assert.Equal(t, 3, len(dcs))
+	assert.Equal(t, "dc1", dcs[0].Name)
+	assert.Equal(t, "dc2", dcs[1].Name)
+	assert.Equal(t, "dc2", spec.GetDatacenter("dc3").RebuildFrom)
+	assert.Nil(t, spec.GetDatacenter("dc4"))
+}
+
+func TestRackStatus(t *testing.T) {
+	status := ClusterStatus{}
+	assert.Nil(t, status.GetRackStatus("dc1", "rack1"))
+
+	status.SetRackStatus("dc1", "rack1", &RackStatus{Members: 3})
+	status.SetRackStatus("dc2", "rack1", &RackStatus{Members: 1})
+	assert.Equal(t, int32(3), status.GetRackStatus("dc1", "rack1").Members)
+	assert.Equal(t, int32(1), status.GetRackStatus("dc2", "rack1").Members)
+	assert.Nil(t, status.GetRackStatus("dc1", "rack2"))
+}
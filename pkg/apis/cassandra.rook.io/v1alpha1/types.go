@@ -67,9 +67,22 @@ type ClusterSpec struct {
 	// Mode selects an operating mode.
 	Mode ClusterMode `json:"mode,omitempty"`
 	// Datacenter that will make up this cluster.
-	Datacenter DatacenterSpec `json:"datacenter"`
+	// Deprecated: use Datacenters instead.
+	Datacenter *DatacenterSpec `json:"datacenter,omitempty"`
+	// Datacenters that will make up this cluster.
+	Datacenters []DatacenterSpec `json:"datacenters,omitempty"`
 	// User-provided image for the sidecar that replaces default.
 	SidecarImage *ImageSpec `json:"sidecarImage,omitempty"`
+	// Repair schedules the anti-entropy repairs of the cluster.
+	Repair *RepairSpec `json:"repair,omitempty"`
+	// Backup schedules the backups of the cluster to an S3 compatible object store.
+	Backup *BackupSpec `json:"backup,omitempty"`
+	// RestoreFrom is the backup that seeds the data of a new cluster.
+	RestoreFrom *RestoreSpec `json:"restoreFrom,omitempty"`
+	// Security configures the authentication of the clients and the encryption of the connections.
+	Security *SecuritySpec `json:"security,omitempty"`
+	// JVM tunes the JVM of the members. Only used in Cassandra mode.
+	JVM *JVMSpec `json:"jvm,omitempty"`
 }
 
 type ClusterMode string
@@ -85,6 +98,10 @@ type DatacenterSpec struct {
 	Name string `json:"name"`
 	// Racks of the specific Datacenter.
 	Racks []RackSpec `json:"racks"`
+	// RebuildFrom is the name of an existing Datacenter to stream the data from when
+	// this Datacenter is added to a running cluster. Its members join without bootstrapping
+	// and run a rebuild from the given Datacenter once all of them are ready.
+	RebuildFrom string `json:"rebuildFrom,omitempty"`
 }
 
 // RackSpec is the desired state for a Cassandra Rack.
@@ -105,6 +122,118 @@ type RackSpec struct {
 	Resources corev1.ResourceRequirements `json:"resources"`
 }
 
+// RepairSpec is the schedule and the options of the repairs of a Cassandra Cluster.
+type RepairSpec struct {
+	// Schedule of the repairs in cron format, e.g. "0 2 * * 0" for every Sunday at 2am.
+	Schedule string `json:"schedule"`
+	// Keyspaces to repair, one after the other.
+	Keyspaces []string `json:"keyspaces"`
+	// Incremental repairs only the data that wasn't repaired yet instead of running a full repair.
+	Incremental bool `json:"incremental,omitempty"`
+	// Parallelism of the repair of a member's ranges across its replicas.
+	Parallelism RepairParallelism `json:"parallelism,omitempty"`
+}
+
+type RepairParallelism string
+
+const (
+	RepairParallelismSequential RepairParallelism = "sequential"
+	RepairParallelismParallel   RepairParallelism = "parallel"
+	RepairParallelismDCParallel RepairParallelism = "dc-parallel"
+)
+
+// BackupSpec is the schedule and the destination of the backups of a Cassandra Cluster.
+type BackupSpec struct {
+	// Schedule of the backups in cron format, e.g. "0 3 * * *" for every day at 3am.
+	Schedule string `json:"schedule"`
+	// Keyspaces to back up. All the keyspaces except the system keyspaces are backed up if empty.
+	Keyspaces []string `json:"keyspaces,omitempty"`
+	// Retention is the number of backups to keep. All the backups are kept if zero.
+	Retention int `json:"retention,omitempty"`
+	// DeadlineSeconds is the time the members have to upload their data once a backup
+	// started, after which the backup fails. Defaults to 12 hours.
+	DeadlineSeconds int64 `json:"deadlineSeconds,omitempty"`
+	// S3 is the bucket where the backups are uploaded.
+	S3 S3Spec `json:"s3"`
+}
+
+// RestoreSpec is the backup that seeds the data of a new Cassandra Cluster.
+type RestoreSpec struct {
+	// Cluster is the name of the backed up cluster.
+	Cluster string `json:"cluster"`
+	// Namespace of the backed up cluster. Defaults to the namespace of the restored cluster.
+	Namespace string `json:"namespace,omitempty"`
+	// Backup is the name of the backup to restore.
+	Backup string `json:"backup"`
+	// S3 is the bucket where the backup was uploaded.
+	S3 S3Spec `json:"s3"`
+}
+
+// S3Spec is a bucket of an S3 compatible object store, like a Ceph object store or Minio.
+type S3Spec struct {
+	// Endpoint is the URL of the object store, e.g. http://rook-ceph-rgw-my-store.rook-ceph
+	Endpoint string `json:"endpoint"`
+	// Bucket is the name of the bucket.
+	Bucket string `json:"bucket"`
+	// SecretName is the secret in the namespace of the cluster with the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+	SecretName string `json:"secretName"`
+}
+
+// SecuritySpec is the authentication and encryption settings of a Cassandra Cluster.
+type SecuritySpec struct {
+	// Authentication enables the PasswordAuthenticator, with a superuser created from a Secret.
+	Authentication *AuthenticationSpec `json:"authentication,omitempty"`
+	// TLS encrypts the connections of the clients and between the members.
+	TLS *TLSSpec `json:"tls,omitempty"`
+}
+
+// AuthenticationSpec is the superuser of a Cassandra Cluster with authentication enabled.
+type AuthenticationSpec struct {
+	// SuperuserSecretName is the secret in the namespace of the cluster with the username and password of the superuser.
+	SuperuserSecretName string `json:"superuserSecretName"`
+}
+
+// TLSSpec is the certificates and the connections encrypted with them.
+type TLSSpec struct {
+	// SecretName is the secret in the namespace of the cluster with the tls.crt, tls.key and ca.crt of the members.
+	SecretName string `json:"secretName"`
+	// Client encrypts the connections of the clients.
+	Client bool `json:"client,omitempty"`
+	// RequireClientAuth requires the clients to present a certificate signed by the CA.
+	RequireClientAuth bool `json:"requireClientAuth,omitempty"`
+	// Internode selects the connections between the members that are encrypted.
+	Internode InternodeEncryption `json:"internode,omitempty"`
+}
+
+type InternodeEncryption string
+
+const (
+	InternodeEncryptionNone InternodeEncryption = "none"
+	InternodeEncryptionAll  InternodeEncryption = "all"
+	InternodeEncryptionDC   InternodeEncryption = "dc"
+	InternodeEncryptionRack InternodeEncryption = "rack"
+)
+
+// JVMSpec is the heap and garbage collector settings of the Cassandra members.
+type JVMSpec struct {
+	// MaxHeapSize is the size of the heap, e.g. "4G". Derived from the memory limit of the rack if empty.
+	MaxHeapSize string `json:"maxHeapSize,omitempty"`
+	// HeapNewSize is the size of the young generation with the CMS collector, e.g. "400M".
+	// Derived from the heap size and the cpu limit of the rack if empty.
+	HeapNewSize string `json:"heapNewSize,omitempty"`
+	// GC is the garbage collector. G1 is used for heaps of 8G and more, and CMS otherwise, if empty.
+	GC GarbageCollector `json:"gc,omitempty"`
+	// Options are additional options of the JVM.
+	Options []string `json:"options,omitempty"`
+}
+
+type GarbageCollector string
+
+const (
+	GarbageCollectorCMS GarbageCollector = "CMS"
+	GarbageCollectorG1  GarbageCollector = "G1"
+)
+
 // ImageSpec is the desired state for a container image.
 type ImageSpec struct {
 	// Version of the image.
@@ -115,9 +244,78 @@ type ImageSpec struct {
 
 // ClusterStatus is the status of a Cassandra Cluster
 type ClusterStatus struct {
+	Datacenters map[string]*DatacenterStatus `json:"datacenters,omitempty"`
+	// Repair is the status of the scheduled repairs of the cluster
+	Repair *RepairStatus `json:"repair,omitempty"`
+	// Upgrade is the status of the version upgrade in progress, if any
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+	// Backup is the status of the scheduled backups of the cluster
+	Backup *BackupStatus `json:"backup,omitempty"`
+	// Restore is the status of the restore of the backup that seeds the cluster
+	Restore *RestoreStatus `json:"restore,omitempty"`
+	// SuperuserCreated is true once the superuser of the authentication settings is created
+	SuperuserCreated bool `json:"superuserCreated,omitempty"`
+}
+
+// DatacenterStatus is the status of a Cassandra Datacenter
+type DatacenterStatus struct {
 	Racks map[string]*RackStatus `json:"racks,omitempty"`
 }
 
+// RepairStatus is the status of the repairs of a Cassandra Cluster
+type RepairStatus struct {
+	// LastScheduleTime is the last time a repair was started
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// Keyspace is the keyspace being repaired, empty if no repair is in progress
+	Keyspace string `json:"keyspace,omitempty"`
+	// Member is the member repairing its ranges of the keyspace
+	Member string `json:"member,omitempty"`
+	// RepairedMembers is the number of members that repaired the keyspace
+	RepairedMembers int32 `json:"repairedMembers,omitempty"`
+	// LastSuccessfulRepairs is the time the last repair of each keyspace finished
+	LastSuccessfulRepairs map[string]metav1.Time `json:"lastSuccessfulRepairs,omitempty"`
+}
+
+// BackupStatus is the status of the backups of a Cassandra Cluster
+type BackupStatus struct {
+	// LastScheduleTime is the last time a backup was started
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// Name is the name of the backup in progress, empty if no backup is in progress
+	Name string `json:"name,omitempty"`
+	// LastSuccessfulBackup is the name of the last backup uploaded by all the members
+	LastSuccessfulBackup string `json:"lastSuccessfulBackup,omitempty"`
+	// LastSuccessfulTime is the time the last successful backup finished
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+	// LastFailedBackup is the name of the last backup that some members didn't upload before its deadline
+	LastFailedBackup string `json:"lastFailedBackup,omitempty"`
+	// LastFailedTime is the time the last failed backup failed
+	LastFailedTime *metav1.Time `json:"lastFailedTime,omitempty"`
+}
+
+// RestoreStatus is the status of the restore of a backup into a Cassandra Cluster
+type RestoreStatus struct {
+	// Backup is the name of the restored backup
+	Backup string `json:"backup"`
+	// SchemaRestored is true once the schema of the backup is created
+	SchemaRestored bool `json:"schemaRestored,omitempty"`
+	// Finished is true once all the members have loaded their data
+	Finished bool `json:"finished,omitempty"`
+}
+
+// UpgradeStatus is the status of a rolling version upgrade of a Cassandra Cluster
+type UpgradeStatus struct {
+	// FromVersion is the version the cluster is upgraded from
+	FromVersion string `json:"fromVersion"`
+	// ToVersion is the version the cluster is upgraded to
+	ToVersion string `json:"toVersion"`
+	// SnapshotTag is the tag of the snapshot taken by every member before the upgrade
+	SnapshotTag string `json:"snapshotTag"`
+	// StartTime is the time the upgrade started
+	StartTime metav1.Time `json:"startTime"`
+	// Member is the member being upgraded, empty while the snapshot is taken
+	Member string `json:"member,omitempty"`
+}
+
 // RackStatus is the status of a Cassandra Rack
 type RackStatus struct {
 	// Members is the current number of members requested in the specific Rack
@@ -137,7 +335,9 @@ type RackCondition struct {
 type RackConditionType string
 
 const (
-	RackConditionTypeMemberLeaving RackConditionType = "MemberLeaving"
+	RackConditionTypeMemberLeaving    RackConditionType = "MemberLeaving"
+	RackConditionTypeMemberRebuilding RackConditionType = "MemberRebuilding"
+	RackConditionTypeMemberReplacing  RackConditionType = "MemberReplacing"
 )
 
 type ConditionStatus string
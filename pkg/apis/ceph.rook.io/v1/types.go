@@ -77,6 +77,18 @@ type ClusterSpec struct {
 
 	// Dashboard settings
 	Dashboard DashboardSpec `json:"dashboard,omitempty"`
+
+	// The cleanup actions taken on the hosts when the cluster is deleted
+	CleanupPolicy CleanupPolicySpec `json:"cleanupPolicy,omitempty"`
+
+	// The schedule and destination of the backups of the mon store and the cluster metadata
+	Backup BackupSpec `json:"backup,omitempty"`
+
+	// How the daemons are protected from voluntary disruptions such as node drains
+	DisruptionManagement DisruptionManagementSpec `json:"disruptionManagement,omitempty"`
+
+	// The settings of the ceph-csi drivers for the cluster
+	CSI CSISpec `json:"csi,omitempty"`
 }
 
 // VersionSpec represents the settings for the Ceph version that Rook is orchestrating.
@@ -100,10 +112,80 @@ type DashboardSpec struct {
 	SSL *bool `json:"ssl,omitempty"`
 }
 
+// CleanupPolicySpec represents the actions taken on the hosts when the cluster is deleted
+type CleanupPolicySpec struct {
+	// Confirmation must be set to "yes-really-destroy-data" for the hosts to be cleaned up
+	Confirmation CleanupConfirmationProperty `json:"confirmation,omitempty"`
+
+	// Whether the devices owned by Rook should be securely erased after their partitions are removed
+	SanitizeDevices bool `json:"sanitizeDevices,omitempty"`
+}
+
+// CleanupConfirmationProperty is the confirmation required before the hosts are cleaned up
+type CleanupConfirmationProperty string
+
+const (
+	// DeleteDataDirOnHostsConfirmation confirms that the data on the hosts can be destroyed
+	DeleteDataDirOnHostsConfirmation CleanupConfirmationProperty = "yes-really-destroy-data"
+)
+
+// BackupSpec represents the schedule and the destination of the backups of the mon store and the cluster metadata
+type BackupSpec struct {
+	// Schedule in the cron format, e.g. "0 2 * * *". Backups are disabled if the schedule is empty.
+	Schedule string `json:"schedule,omitempty"`
+
+	// The number of backups to keep. All the backups are kept if zero.
+	Retention int `json:"retention,omitempty"`
+
+	// The name of a claim in the cluster namespace where the backups are written
+	PersistentVolumeClaim string `json:"persistentVolumeClaim,omitempty"`
+
+	// The S3 bucket where the backups are written
+	S3 *BackupS3Spec `json:"s3,omitempty"`
+}
+
+// BackupS3Spec represents an S3 bucket where the backups are written
+type BackupS3Spec struct {
+	// The URL of the S3 endpoint, e.g. http://rook-ceph-rgw-my-store.rook-ceph-backup
+	Endpoint string `json:"endpoint"`
+	// The name of the bucket
+	Bucket string `json:"bucket"`
+	// The secret in the cluster namespace with the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+	SecretName string `json:"secretName"`
+}
+
 type ClusterStatus struct {
-	State      ClusterState `json:"state,omitempty"`
-	Message    string       `json:"message,omitempty"`
-	CephStatus *CephStatus  `json:"ceph,omitempty"`
+	State      ClusterState  `json:"state,omitempty"`
+	Message    string        `json:"message,omitempty"`
+	CephStatus *CephStatus   `json:"ceph,omitempty"`
+	Backup     *BackupStatus `json:"backup,omitempty"`
+}
+
+// CSISpec represents the settings of the ceph-csi drivers for a cluster
+type CSISpec struct {
+	// Whether the operator creates a StorageClass for each CephBlockPool and CephFilesystem of the cluster
+	CreateStorageClasses bool `json:"createStorageClasses,omitempty"`
+}
+
+// DisruptionManagementSpec represents how the operator protects the daemons from voluntary disruptions such as node drains
+type DisruptionManagementSpec struct {
+	// Whether the operator creates and manages the PodDisruptionBudgets of the mons, osds, mds and rgw daemons
+	ManagePodBudgets bool `json:"managePodBudgets,omitempty"`
+
+	// The crush bucket type of the failure domain in which the osds may be disrupted at the same time, "host" by default.
+	// It should match the failure domain of the pools.
+	OSDFailureDomain string `json:"osdFailureDomain,omitempty"`
+
+	// The minutes that the osds of a draining failure domain are kept in the cluster with noout before the data
+	// is recovered on other osds. 30 minutes by default.
+	OSDMaintenanceTimeout int `json:"osdMaintenanceTimeout,omitempty"`
+}
+
+// BackupStatus represents the outcome of the scheduled backups
+type BackupStatus struct {
+	LastAttempted string `json:"lastAttempted,omitempty"`
+	LastSucceeded string `json:"lastSucceeded,omitempty"`
+	Message       string `json:"message,omitempty"`
 }
 
 type CephStatus struct {
@@ -34,7 +34,8 @@ import (
 type Cluster struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              ClusterSpec `json:"spec"`
+	Spec              ClusterSpec   `json:"spec"`
+	Status            ClusterStatus `json:"status,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -53,4 +54,130 @@ type ClusterSpec struct {
 	Secure              bool                  `json:"secure,omitempty"`
 	CachePercent        int                   `json:"cachePercent,omitempty"`
 	MaxSQLMemoryPercent int                   `json:"maxSQLMemoryPercent,omitempty"`
+	// The image of CockroachDB to run. Defaults to the image of the operator.
+	Image string `json:"image,omitempty"`
+	// The schedule and location of the backups of the databases. The cluster is not backed up if nil.
+	Backup *BackupSpec `json:"backup,omitempty"`
 }
+
+type ClusterStatus struct {
+	State   ClusterState `json:"state,omitempty"`
+	Message string       `json:"message,omitempty"`
+	// The nodes of the cluster, as reported by the admin endpoint of CockroachDB.
+	Nodes []NodeStatus `json:"nodes,omitempty"`
+	// The scheduled backups of the cluster.
+	Backup *BackupStatus `json:"backup,omitempty"`
+}
+
+type ClusterState string
+
+const (
+	ClusterStateCreating ClusterState = "Creating"
+	ClusterStateCreated  ClusterState = "Created"
+	ClusterStateUpdating ClusterState = "Updating"
+	ClusterStateError    ClusterState = "Error"
+)
+
+type NodeStatus struct {
+	NodeID  int    `json:"nodeID"`
+	Address string `json:"address"`
+	// Live is true while the node heartbeats its liveness record.
+	Live            bool `json:"live"`
+	Draining        bool `json:"draining,omitempty"`
+	Decommissioning bool `json:"decommissioning,omitempty"`
+}
+
+type BackupSpec struct {
+	// The schedule of the backups in cron format, e.g. "0 2 * * *"
+	Schedule string `json:"schedule"`
+	// The databases to back up
+	Databases []string `json:"databases"`
+	// Mode is "backup" to use the BACKUP statement of the enterprise edition, or "dump" to use cockroach dump.
+	// Defaults to "backup".
+	Mode BackupMode `json:"mode,omitempty"`
+	// The number of backups to keep. All the backups are kept if zero.
+	Retention int `json:"retention,omitempty"`
+	// The bucket where the backups are stored
+	S3 S3Spec `json:"s3"`
+}
+
+type BackupMode string
+
+const (
+	BackupModeBackup BackupMode = "backup"
+	BackupModeDump   BackupMode = "dump"
+)
+
+type S3Spec struct {
+	// The URL of the S3 compatible endpoint, e.g. http://rook-ceph-rgw-my-store:80
+	Endpoint string `json:"endpoint"`
+	Bucket   string `json:"bucket"`
+	// The secret with the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY of the bucket
+	SecretName string `json:"secretName"`
+}
+
+type BackupStatus struct {
+	// The name of the backup that is running
+	Running string `json:"running,omitempty"`
+	// The last time a backup was started
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// The name of the last backup that succeeded
+	LastSuccessfulBackup string `json:"lastSuccessfulBackup,omitempty"`
+	// The last time a backup succeeded
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+	// The error of the last backup if it failed
+	LastError string `json:"lastError,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type Restore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              RestoreSpec   `json:"spec"`
+	Status            RestoreStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type RestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []Restore `json:"items"`
+}
+
+type RestoreSpec struct {
+	// The name of the cluster that is created in the namespace of the restore
+	ClusterName string `json:"clusterName"`
+	// The spec of the cluster that is created
+	Cluster ClusterSpec `json:"cluster"`
+	// The backup that is restored into the cluster
+	Source BackupSource `json:"source"`
+}
+
+type BackupSource struct {
+	// The name of the cluster the backup was taken from
+	Cluster string `json:"cluster"`
+	// The namespace of the cluster the backup was taken from. Defaults to the namespace of the restore.
+	Namespace string `json:"namespace,omitempty"`
+	// The name of the backup. Defaults to the latest backup.
+	Backup string `json:"backup,omitempty"`
+	// The bucket where the backup is stored
+	S3 S3Spec `json:"s3"`
+}
+
+type RestoreStatus struct {
+	State   RestoreState `json:"state,omitempty"`
+	Message string       `json:"message,omitempty"`
+}
+
+type RestoreState string
+
+const (
+	RestoreStateCreating  RestoreState = "Creating"
+	RestoreStateRestoring RestoreState = "Restoring"
+	RestoreStateCompleted RestoreState = "Completed"
+	RestoreStateError     RestoreState = "Error"
+)
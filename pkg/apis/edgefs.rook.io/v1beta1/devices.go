@@ -50,7 +50,8 @@ type RtlfsDevice struct {
 }
 
 type CcowTenant struct {
-	FailureDomain int `json:"failure_domain"`
+	FailureDomain    int `json:"failure_domain"`
+	ReplicationCount int `json:"replication_count,omitempty"`
 }
 
 type CcowNetwork struct {
@@ -42,6 +42,43 @@ type Cluster struct {
 type ClusterStatus struct {
 	State   ClusterState `json:"state,omitempty"`
 	Message string       `json:"message,omitempty"`
+	// The progress of the evacuation of the nodes removed from the cluster
+	Evacuations []NodeEvacuationStatus `json:"evacuations,omitempty"`
+	// The EdgeFS image the cluster runs
+	Image string `json:"image,omitempty"`
+	// The progress of the last upgrade of the cluster to a new EdgeFS image
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+	// The results of the validation of the hosts of the nodes prior to the deployment of their targets
+	HostValidations []NodeValidationStatus `json:"hostValidations,omitempty"`
+}
+
+// UpgradeStatus reports the rolling upgrade of the mgr, the targets and the gateway services to a new EdgeFS image
+type UpgradeStatus struct {
+	State UpgradeState `json:"state"`
+	// The image the cluster ran before the upgrade, which a rollback returns to
+	PreviousImage string `json:"previousImage"`
+	// The image the cluster is upgraded to
+	Image           string `json:"image"`
+	Targets         int32  `json:"targets"`
+	UpgradedTargets int32  `json:"upgradedTargets"`
+	Message         string `json:"message,omitempty"`
+}
+
+// NodeEvacuationStatus reports the evacuation of the devices of a node prior to its removal from the cluster
+type NodeEvacuationStatus struct {
+	Node             string              `json:"node"`
+	State            NodeEvacuationState `json:"state"`
+	Devices          int                 `json:"devices"`
+	EvacuatedDevices int                 `json:"evacuatedDevices"`
+	Message          string              `json:"message,omitempty"`
+}
+
+// NodeValidationStatus reports the checks of the host of a node failed by the validation
+type NodeValidationStatus struct {
+	Node         string              `json:"node"`
+	State        NodeValidationState `json:"state"`
+	FailedChecks []string            `json:"failedChecks,omitempty"`
+	Message      string              `json:"message,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -60,6 +97,12 @@ type ClusterSpec struct {
 	Placement rook.PlacementSpec `json:"placement,omitempty"`
 	Network   NetworkSpec        `json:"network,omitempty"`
 	Dashboard DashboardSpec      `json:"dashboard,omitempty"`
+	// The Prometheus integration of the cluster
+	Monitoring MonitoringSpec `json:"monitoring,omitempty"`
+	// The failure domains the replicas of the data are placed across
+	Zoning ZoningSpec `json:"zoning,omitempty"`
+	// The rollout of a new edgefsImageName
+	Upgrade UpgradeSpec `json:"upgrade,omitempty"`
 	// Resources set resource requests and limits
 	Resources v1.ResourceRequirements `json:"resources,omitempty"`
 	// The path on the host where config and data can be persisted.
@@ -69,6 +112,7 @@ type ClusterSpec struct {
 	DevicesResurrectMode    string            `json:"devicesResurrectMode,omitempty"`
 	EdgefsImageName         string            `json:"edgefsImageName,omitempty"`
 	SkipHostPrepare         bool              `json:"skipHostPrepare,omitempty"`
+	SkipHostValidation      bool              `json:"skipHostValidation,omitempty"`
 	ResourceProfile         string            `json:"resourceProfile,omitempty"`
 	ChunkCacheSize          resource.Quantity `json:"chunkCacheSize,omitempty"`
 	TrlogProcessingInterval int               `json:"trlogProcessingInterval,omitempty"`
@@ -80,6 +124,44 @@ type DashboardSpec struct {
 	LocalAddr string `json:"localAddr"`
 }
 
+// MonitoringSpec represents the Prometheus integration of the cluster
+type MonitoringSpec struct {
+	// Whether to create a ServiceMonitor of the Prometheus operator scraping the metrics of the cluster
+	ServiceMonitor bool `json:"serviceMonitor,omitempty"`
+	// The labels of the ServiceMonitor, matched by the serviceMonitorSelector of the Prometheus
+	Labels map[string]string `json:"labels,omitempty"`
+	// The interval the metrics are scraped at (default is 30s)
+	Interval string `json:"interval,omitempty"`
+}
+
+// ZoningSpec represents the zones of the nodes and the placement of the replicas of the data
+type ZoningSpec struct {
+	// The node label the zones of the nodes are derived from, e.g. failure-domain.beta.kubernetes.io/zone.
+	// The zone of a node in its storage config takes precedence over its label.
+	LabelKey string `json:"labelKey,omitempty"`
+	// The failure domain the replicas of the data are placed across, zone or host (default is zone when the nodes have zones)
+	ReplicaPlacement ReplicaPlacement `json:"replicaPlacement,omitempty"`
+	// The count of replicas of the data (default is 3)
+	ReplicationCount int `json:"replicationCount,omitempty"`
+}
+
+type ReplicaPlacement string
+
+const (
+	// Every replica of the data is placed in a different zone
+	ReplicaPlacementZone ReplicaPlacement = "zone"
+	// Every replica of the data is placed on a different host, whatever its zone
+	ReplicaPlacementHost ReplicaPlacement = "host"
+)
+
+// UpgradeSpec controls the rolling upgrade of the cluster when edgefsImageName changes
+type UpgradeSpec struct {
+	// Whether to hold the upgrade after the target being upgraded, until unset
+	Paused bool `json:"paused,omitempty"`
+	// Whether to roll the cluster back to the image it ran before the last upgrade
+	Rollback bool `json:"rollback,omitempty"`
+}
+
 type NetworkSpec struct {
 	ServerIfName string `json:"serverIfName"`
 	BrokerIfName string `json:"brokerIfName"`
@@ -95,6 +177,74 @@ const (
 	ClusterStateError    ClusterState = "Error"
 )
 
+type UpgradeState string
+
+const (
+	UpgradeStateUpgrading   UpgradeState = "Upgrading"
+	UpgradeStatePaused      UpgradeState = "Paused"
+	UpgradeStateCompleted   UpgradeState = "Completed"
+	UpgradeStateRollingBack UpgradeState = "RollingBack"
+	UpgradeStateRolledBack  UpgradeState = "RolledBack"
+	UpgradeStateFailed      UpgradeState = "Failed"
+)
+
+type NodeEvacuationState string
+
+const (
+	NodeEvacuationStateEvacuating NodeEvacuationState = "Evacuating"
+	NodeEvacuationStateRemoved    NodeEvacuationState = "Removed"
+	NodeEvacuationStateFailed     NodeEvacuationState = "Failed"
+)
+
+type NodeValidationState string
+
+const (
+	NodeValidationStatePassed NodeValidationState = "Passed"
+	NodeValidationStateFailed NodeValidationState = "Failed"
+)
+
+// ServiceStatus represents the status of an EdgeFS service
+type ServiceStatus struct {
+	State   ServiceState `json:"state,omitempty"`
+	Message string       `json:"message,omitempty"`
+	// The number of pods of the service ready to serve
+	ReadyInstances int32 `json:"readyInstances"`
+	// The addresses the service is reached at
+	Endpoints []string `json:"endpoints,omitempty"`
+	// The tenants and buckets served by the service, as reported by the EdgeFS mgr
+	ServiceObjects []string `json:"serviceObjects,omitempty"`
+}
+
+type ServiceState string
+
+const (
+	ServiceStateCreating ServiceState = "Creating"
+	ServiceStateCreated  ServiceState = "Created"
+	ServiceStateError    ServiceState = "Error"
+)
+
+// ISGWStatus represents the status of an ISGW service and of its replication link
+type ISGWStatus struct {
+	ServiceStatus `json:",inline"`
+	Link          ISGWLinkStatus `json:"link"`
+}
+
+// ISGWLinkStatus reports the replication link of an ISGW service to its remote segment
+type ISGWLinkStatus struct {
+	State ISGWLinkState `json:"state,omitempty"`
+	// The time in seconds the replication lags behind the local segment
+	LagSeconds int64  `json:"lagSeconds"`
+	Message    string `json:"message,omitempty"`
+}
+
+type ISGWLinkState string
+
+const (
+	ISGWLinkStateUp      ISGWLinkState = "Up"
+	ISGWLinkStateDown    ISGWLinkState = "Down"
+	ISGWLinkStateUnknown ISGWLinkState = "Unknown"
+)
+
 // +genclient
 // +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -102,7 +252,8 @@ const (
 type NFS struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              NFSSpec `json:"spec"`
+	Spec              NFSSpec       `json:"spec"`
+	Status            ServiceStatus `json:"status,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -135,7 +286,8 @@ type NFSSpec struct {
 type S3 struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              S3Spec `json:"spec"`
+	Spec              S3Spec        `json:"spec"`
+	Status            ServiceStatus `json:"status,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -175,7 +327,8 @@ type S3Spec struct {
 type SWIFT struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              SWIFTSpec `json:"spec"`
+	Spec              SWIFTSpec     `json:"spec"`
+	Status            ServiceStatus `json:"status,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -211,7 +364,8 @@ type SWIFTSpec struct {
 type S3X struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              S3XSpec `json:"spec"`
+	Spec              S3XSpec       `json:"spec"`
+	Status            ServiceStatus `json:"status,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -249,7 +403,8 @@ type S3XSpec struct {
 type ISCSI struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              ISCSISpec `json:"spec"`
+	Spec              ISCSISpec     `json:"spec"`
+	Status            ServiceStatus `json:"status,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -294,7 +449,8 @@ type TargetParametersSpec struct {
 type ISGW struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              ISGWSpec `json:"spec"`
+	Spec              ISGWSpec   `json:"spec"`
+	Status            ISGWStatus `json:"status,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -169,7 +169,7 @@ func (in *Cluster) DeepCopyInto(out *Cluster) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -282,6 +282,9 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 	}
 	out.Network = in.Network
 	out.Dashboard = in.Dashboard
+	in.Monitoring.DeepCopyInto(&out.Monitoring)
+	out.Zoning = in.Zoning
+	out.Upgrade = in.Upgrade
 	in.Resources.DeepCopyInto(&out.Resources)
 	out.DataVolumeSize = in.DataVolumeSize.DeepCopy()
 	out.ChunkCacheSize = in.ChunkCacheSize.DeepCopy()
@@ -302,6 +305,23 @@ func (in *ClusterSpec) DeepCopy() *ClusterSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 	*out = *in
+	if in.Evacuations != nil {
+		in, out := &in.Evacuations, &out.Evacuations
+		*out = make([]NodeEvacuationStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeStatus)
+		**out = **in
+	}
+	if in.HostValidations != nil {
+		in, out := &in.HostValidations, &out.HostValidations
+		*out = make([]NodeValidationStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -378,6 +398,7 @@ func (in *ISCSI) DeepCopyInto(out *ISCSI) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -465,6 +486,7 @@ func (in *ISGW) DeepCopyInto(out *ISGW) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -486,6 +508,22 @@ func (in *ISGW) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ISGWLinkStatus) DeepCopyInto(out *ISGWLinkStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ISGWLinkStatus.
+func (in *ISGWLinkStatus) DeepCopy() *ISGWLinkStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ISGWLinkStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ISGWList) DeepCopyInto(out *ISGWList) {
 	*out = *in
@@ -545,12 +583,54 @@ func (in *ISGWSpec) DeepCopy() *ISGWSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ISGWStatus) DeepCopyInto(out *ISGWStatus) {
+	*out = *in
+	in.ServiceStatus.DeepCopyInto(&out.ServiceStatus)
+	out.Link = in.Link
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ISGWStatus.
+func (in *ISGWStatus) DeepCopy() *ISGWStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ISGWStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitoringSpec) DeepCopyInto(out *MonitoringSpec) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringSpec.
+func (in *MonitoringSpec) DeepCopy() *MonitoringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitoringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NFS) DeepCopyInto(out *NFS) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -647,6 +727,43 @@ func (in *NetworkSpec) DeepCopy() *NetworkSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeEvacuationStatus) DeepCopyInto(out *NodeEvacuationStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeEvacuationStatus.
+func (in *NodeEvacuationStatus) DeepCopy() *NodeEvacuationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeEvacuationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeValidationStatus) DeepCopyInto(out *NodeValidationStatus) {
+	*out = *in
+	if in.FailedChecks != nil {
+		in, out := &in.FailedChecks, &out.FailedChecks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeValidationStatus.
+func (in *NodeValidationStatus) DeepCopy() *NodeValidationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeValidationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RTDevice) DeepCopyInto(out *RTDevice) {
 	*out = *in
@@ -727,6 +844,7 @@ func (in *S3) DeepCopyInto(out *S3) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -813,6 +931,7 @@ func (in *S3X) DeepCopyInto(out *S3X) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -899,6 +1018,7 @@ func (in *SWIFT) DeepCopyInto(out *SWIFT) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -972,6 +1092,32 @@ func (in *SWIFTSpec) DeepCopy() *SWIFTSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceStatus) DeepCopyInto(out *ServiceStatus) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServiceObjects != nil {
+		in, out := &in.ServiceObjects, &out.ServiceObjects
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceStatus.
+func (in *ServiceStatus) DeepCopy() *ServiceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SetupNode) DeepCopyInto(out *SetupNode) {
 	*out = *in
@@ -1020,3 +1166,51 @@ func (in *TargetParametersSpec) DeepCopy() *TargetParametersSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeSpec) DeepCopyInto(out *UpgradeSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeSpec.
+func (in *UpgradeSpec) DeepCopy() *UpgradeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeStatus) DeepCopyInto(out *UpgradeStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeStatus.
+func (in *UpgradeStatus) DeepCopy() *UpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZoningSpec) DeepCopyInto(out *ZoningSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZoningSpec.
+func (in *ZoningSpec) DeepCopy() *ZoningSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ZoningSpec)
+	in.DeepCopyInto(out)
+	return out
+}
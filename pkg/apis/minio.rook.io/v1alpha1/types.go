@@ -25,6 +25,8 @@ import (
 const (
 	// ClusterDomainDefault is the default local cluster domain
 	ClusterDomainDefault = "cluster.local"
+	// PortDefault is the default port the Minio servers listen on
+	PortDefault = int32(9000)
 )
 
 // ***************************************************************************
@@ -40,7 +42,8 @@ const (
 type ObjectStore struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              ObjectStoreSpec `json:"spec"`
+	Spec              ObjectStoreSpec   `json:"spec"`
+	Status            ObjectStoreStatus `json:"status,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -76,4 +79,105 @@ type ObjectStoreSpec struct {
 	// This field is needed to workaround https://github.com/minio/minio/issues/6775, and is
 	// expected to be removed in the future.
 	ClusterDomain string `json:"clusterDomain,omitempty"`
+
+	// The port the Minio servers listen on. If not set, then the default of 9000 will be assumed.
+	Port int32 `json:"port,omitempty"`
+
+	// The resource requirements of the Minio containers.
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+
+	// The TLS configuration of the Minio servers. The servers are served over https if set.
+	TLS *TLSSpec `json:"tls,omitempty"`
+
+	// The server pools added to expand the object store. The servers in the scope above form the
+	// first pool, and every pool is run by its own stateful set.
+	Pools []PoolSpec `json:"pools,omitempty"`
+
+	// The buckets created in the object store. The buckets removed from the list are kept.
+	Buckets []BucketSpec `json:"buckets,omitempty"`
+
+	// The users of the object store. The credentials of every user are stored in a Secret.
+	Users []UserSpec `json:"users,omitempty"`
+
+	// The custom IAM policies that can be attached to the users.
+	Policies []PolicySpec `json:"policies,omitempty"`
 }
+
+// TLSSpec represents the TLS configuration of a Minio object store.
+type TLSSpec struct {
+	// The name of the secret in the namespace of the object store with the public.crt and
+	// private.key of the servers, and optionally the ca.crt that signed them.
+	SecretName string `json:"secretName"`
+}
+
+// PoolSpec represents a pool of servers added to a Minio object store.
+type PoolSpec struct {
+	// The name of the pool, unique in the object store.
+	Name string `json:"name"`
+
+	// The servers of the pool and their storage.
+	Storage rook.StorageScopeSpec `json:"scope"`
+}
+
+// BucketSpec represents a bucket of a Minio object store.
+type BucketSpec struct {
+	// The name of the bucket.
+	Name string `json:"name"`
+
+	// The hard quota of the bucket, e.g. 10Gi. The bucket has no quota if not set.
+	Quota string `json:"quota,omitempty"`
+
+	// The anonymous access to the bucket: none, download, upload or public. If not set, then the
+	// default of none will be assumed.
+	AnonymousAccess string `json:"anonymousAccess,omitempty"`
+}
+
+// UserSpec represents a user of a Minio object store.
+type UserSpec struct {
+	// The name of the user, which is its access key.
+	Name string `json:"name"`
+
+	// The policy of the user, either a canned policy of Minio (readonly, readwrite or writeonly) or
+	// the name of a custom policy.
+	Policy string `json:"policy"`
+
+	// The name of the Secret created with the credentials of the user. If not set, then the
+	// default of <object store>-<user>-access-keys will be assumed.
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// PolicySpec represents a custom IAM policy of a Minio object store.
+type PolicySpec struct {
+	// The name of the policy.
+	Name string `json:"name"`
+
+	// The IAM policy document in JSON.
+	Document string `json:"document"`
+}
+
+// ObjectStoreStatus represents the status of a Minio object store.
+type ObjectStoreStatus struct {
+	State   ObjectStoreState `json:"state,omitempty"`
+	Message string           `json:"message,omitempty"`
+
+	// The servers and drives reported by the admin API of Minio.
+	OnlineServers  int `json:"onlineServers"`
+	OfflineServers int `json:"offlineServers"`
+	OnlineDrives   int `json:"onlineDrives"`
+	OfflineDrives  int `json:"offlineDrives"`
+
+	// The users and custom policies created by the operator, which are removed from Minio when
+	// they are removed from the spec.
+	Users    []string `json:"users,omitempty"`
+	Policies []string `json:"policies,omitempty"`
+}
+
+// ObjectStoreState represents the state of a Minio object store.
+type ObjectStoreState string
+
+const (
+	ObjectStoreStateCreating ObjectStoreState = "Creating"
+	ObjectStoreStateCreated  ObjectStoreState = "Created"
+	ObjectStoreStateUpdating ObjectStoreState = "Updating"
+	ObjectStoreStateError    ObjectStoreState = "Error"
+)
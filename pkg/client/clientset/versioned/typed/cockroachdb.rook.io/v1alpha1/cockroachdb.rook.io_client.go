@@ -28,6 +28,7 @@ import (
 type CockroachdbV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	ClustersGetter
+	RestoresGetter
 }
 
 // CockroachdbV1alpha1Client is used to interact with features provided by the cockroachdb.rook.io group.
@@ -39,6 +40,10 @@ func (c *CockroachdbV1alpha1Client) Clusters(namespace string) ClusterInterface
 	return newClusters(c, namespace)
 }
 
+func (c *CockroachdbV1alpha1Client) Restores(namespace string) RestoreInterface {
+	return newRestores(c, namespace)
+}
+
 // NewForConfig creates a new CockroachdbV1alpha1Client for the given config.
 func NewForConfig(c *rest.Config) (*CockroachdbV1alpha1Client, error) {
 	config := *c
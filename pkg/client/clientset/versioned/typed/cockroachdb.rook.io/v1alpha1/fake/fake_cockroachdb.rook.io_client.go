@@ -32,6 +32,10 @@ func (c *FakeCockroachdbV1alpha1) Clusters(namespace string) v1alpha1.ClusterInt
 	return &FakeClusters{c, namespace}
 }
 
+func (c *FakeCockroachdbV1alpha1) Restores(namespace string) v1alpha1.RestoreInterface {
+	return &FakeRestores{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeCockroachdbV1alpha1) RESTClient() rest.Interface {
@@ -92,6 +92,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		// Group=cockroachdb.rook.io, Version=v1alpha1
 	case cockroachdbrookiov1alpha1.SchemeGroupVersion.WithResource("clusters"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Cockroachdb().V1alpha1().Clusters().Informer()}, nil
+	case cockroachdbrookiov1alpha1.SchemeGroupVersion.WithResource("restores"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Cockroachdb().V1alpha1().Restores().Informer()}, nil
 
 		// Group=edgefs.rook.io, Version=v1beta1
 	case edgefsrookiov1beta1.SchemeGroupVersion.WithResource("clusters"):
@@ -0,0 +1,313 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package backup creates and restores the backups of the mon store and the Rook metadata of a cluster.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "backup")
+
+const (
+	// LatestBackup can be given instead of a backup name to restore the most recent backup
+	LatestBackup = "latest"
+
+	monStorePrefix = "mon-store/"
+	metadataEntry  = "metadata.json"
+	bundleSuffix   = ".tar.gz"
+	timeFormat     = "20060102-150405"
+)
+
+// Store is where the backups are kept
+type Store interface {
+	Put(name string, content io.ReadSeeker) error
+	Get(name string, w io.Writer) error
+	List(prefix string) ([]string, error)
+	Delete(name string) error
+}
+
+// CreateConfig is the configuration of a backup
+type CreateConfig struct {
+	// Prefix is the prefix of the backup names, usually the namespace of the cluster
+	Prefix string
+	// MonStoreDir is the data dir of the stopped mon
+	MonStoreDir string
+	// MetadataFile is the file with the exported metadata objects
+	MetadataFile string
+	// Retention is the number of backups to keep. All the backups are kept if zero.
+	Retention int
+}
+
+// RestoreConfig is the configuration to restore a backup
+type RestoreConfig struct {
+	// Prefix is the prefix of the backup names, usually the namespace of the cluster
+	Prefix string
+	// Name is the name of the backup or "latest"
+	Name string
+	// MonStoreDir is the data dir where the mon store is restored. It must be empty.
+	MonStoreDir string
+}
+
+// Create writes a bundle with the mon store and the metadata to the store and returns the name of the backup.
+// The oldest backups are then removed according to the retention.
+func Create(config CreateConfig, store Store) (string, error) {
+	bundle, err := ioutil.TempFile("", "rook-backup")
+	if err != nil {
+		return "", fmt.Errorf("failed to create bundle file. %+v", err)
+	}
+	defer os.Remove(bundle.Name())
+	defer bundle.Close()
+
+	if err := writeBundle(bundle, config); err != nil {
+		return "", err
+	}
+	if _, err := bundle.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to rewind bundle. %+v", err)
+	}
+
+	name := fmt.Sprintf("%s-%s%s", config.Prefix, time.Now().UTC().Format(timeFormat), bundleSuffix)
+	if err := store.Put(name, bundle); err != nil {
+		return "", fmt.Errorf("failed to store backup %s. %+v", name, err)
+	}
+	logger.Infof("stored backup %s", name)
+
+	if err := prune(store, config.Prefix, config.Retention); err != nil {
+		// the backup succeeded, the old backups will be removed next time
+		logger.Warningf("failed to remove old backups. %+v", err)
+	}
+	return name, nil
+}
+
+// Restore extracts the mon store of the backup to the mon data dir and returns the exported metadata
+func Restore(config RestoreConfig, store Store) ([]byte, error) {
+	name := config.Name
+	if name == "" || name == LatestBackup {
+		backups, err := listBackups(store, config.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		if len(backups) == 0 {
+			return nil, fmt.Errorf("no backups found with prefix %s", config.Prefix)
+		}
+		name = backups[len(backups)-1]
+	}
+
+	entries, err := ioutil.ReadDir(config.MonStoreDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read mon data dir %s. %+v", config.MonStoreDir, err)
+	}
+	if len(entries) > 0 {
+		return nil, fmt.Errorf("mon data dir %s is not empty. move the existing mon store aside before restoring", config.MonStoreDir)
+	}
+
+	bundle, err := ioutil.TempFile("", "rook-backup")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bundle file. %+v", err)
+	}
+	defer os.Remove(bundle.Name())
+	defer bundle.Close()
+
+	logger.Infof("restoring backup %s", name)
+	if err := store.Get(name, bundle); err != nil {
+		return nil, fmt.Errorf("failed to get backup %s. %+v", name, err)
+	}
+	if _, err := bundle.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind bundle. %+v", err)
+	}
+
+	return readBundle(bundle, config.MonStoreDir)
+}
+
+func writeBundle(w io.Writer, config CreateConfig) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	metadata, err := ioutil.ReadFile(config.MetadataFile)
+	if err != nil {
+		return fmt.Errorf("failed to read metadata %s. %+v", config.MetadataFile, err)
+	}
+	header := &tar.Header{Name: metadataEntry, Mode: 0600, Size: int64(len(metadata)), ModTime: time.Now()}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write metadata. %+v", err)
+	}
+	if _, err := tw.Write(metadata); err != nil {
+		return fmt.Errorf("failed to write metadata. %+v", err)
+	}
+
+	err = filepath.Walk(config.MonStoreDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(config.MonStoreDir, filePath)
+		if err != nil || relPath == "." {
+			return err
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			logger.Warningf("skipping %s in the mon store", filePath)
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = monStorePrefix + filepath.ToSlash(relPath)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write mon store %s. %+v", config.MonStoreDir, err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to close bundle. %+v", err)
+	}
+	return gz.Close()
+}
+
+func readBundle(r io.Reader, monStoreDir string) ([]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle. %+v", err)
+	}
+	defer gz.Close()
+
+	var metadata []byte
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle. %+v", err)
+		}
+
+		if header.Name == metadataEntry {
+			if metadata, err = ioutil.ReadAll(tr); err != nil {
+				return nil, fmt.Errorf("failed to read metadata. %+v", err)
+			}
+			continue
+		}
+		if !strings.HasPrefix(header.Name, monStorePrefix) {
+			logger.Warningf("skipping unknown entry %s", header.Name)
+			continue
+		}
+
+		target := path.Join(monStoreDir, path.Clean("/"+strings.TrimPrefix(header.Name, monStorePrefix)))
+		if err := extractEntry(tr, header, target); err != nil {
+			return nil, err
+		}
+	}
+
+	if metadata == nil {
+		return nil, fmt.Errorf("metadata not found in the bundle")
+	}
+	return metadata, nil
+}
+
+func extractEntry(r io.Reader, header *tar.Header, target string) error {
+	if header.Typeflag == tar.TypeDir {
+		if err := os.MkdirAll(target, os.FileMode(header.Mode)); err != nil {
+			return fmt.Errorf("failed to create %s. %+v", target, err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(path.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create %s. %+v", path.Dir(target), err)
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+	if err != nil {
+		return fmt.Errorf("failed to create %s. %+v", target, err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("failed to write %s. %+v", target, err)
+	}
+	return nil
+}
+
+// listBackups returns the names of the backups with the prefix from the oldest to the most recent
+func listBackups(store Store, prefix string) ([]string, error) {
+	names, err := store.List(prefix + "-")
+	if err != nil {
+		return nil, err
+	}
+	backups := []string{}
+	for _, name := range names {
+		if isBackupName(name, prefix) {
+			backups = append(backups, name)
+		}
+	}
+	// the timestamp in the names sorts in chronological order
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// isBackupName returns whether the name is exactly <prefix>-<timestamp>.tar.gz. The store lists the names starting
+// with the prefix, which include the backups of the clusters whose prefix starts with the same prefix and a dash.
+func isBackupName(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix+"-") || !strings.HasSuffix(name, bundleSuffix) {
+		return false
+	}
+	timestamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix+"-"), bundleSuffix)
+	_, err := time.Parse(timeFormat, timestamp)
+	return err == nil
+}
+
+func prune(store Store, prefix string, retention int) error {
+	if retention <= 0 {
+		return nil
+	}
+	backups, err := listBackups(store, prefix)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(backups)-retention; i++ {
+		logger.Infof("removing old backup %s", backups[i])
+		if err := store.Delete(backups[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
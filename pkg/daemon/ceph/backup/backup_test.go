@@ -0,0 +1,159 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCreateAndRestore(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tmp)
+
+	monStore := path.Join(tmp, "mon-a")
+	os.MkdirAll(path.Join(monStore, "store.db"), 0755)
+	ioutil.WriteFile(path.Join(monStore, "keyring"), []byte("key"), 0600)
+	ioutil.WriteFile(path.Join(monStore, "store.db", "000001.sst"), []byte("sst"), 0644)
+	metadataFile := path.Join(tmp, "metadata.json")
+	ioutil.WriteFile(metadataFile, []byte(`{"configMaps":[]}`), 0644)
+	backupDir := path.Join(tmp, "backups")
+	os.MkdirAll(backupDir, 0755)
+
+	store, err := NewStore(StoreConfig{Dir: backupDir})
+	assert.Nil(t, err)
+	config := CreateConfig{Prefix: "ns", MonStoreDir: monStore, MetadataFile: metadataFile}
+	name, err := Create(config, store)
+	assert.Nil(t, err)
+	assert.Contains(t, name, "ns-")
+
+	// the mon store and the metadata are restored
+	restoreDir := path.Join(tmp, "restore")
+	metadata, err := Restore(RestoreConfig{Prefix: "ns", Name: LatestBackup, MonStoreDir: restoreDir}, store)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"configMaps":[]}`, string(metadata))
+	content, err := ioutil.ReadFile(path.Join(restoreDir, "store.db", "000001.sst"))
+	assert.Nil(t, err)
+	assert.Equal(t, "sst", string(content))
+	content, _ = ioutil.ReadFile(path.Join(restoreDir, "keyring"))
+	assert.Equal(t, "key", string(content))
+
+	// the existing mon store is not overwritten
+	_, err = Restore(RestoreConfig{Prefix: "ns", Name: name, MonStoreDir: restoreDir}, store)
+	assert.NotNil(t, err)
+
+	// unknown prefix
+	_, err = Restore(RestoreConfig{Prefix: "other", MonStoreDir: path.Join(tmp, "other")}, store)
+	assert.NotNil(t, err)
+}
+
+func TestPrune(t *testing.T) {
+	backupDir, err := ioutil.TempDir("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(backupDir)
+
+	for _, name := range []string{"ns-20190101-000000.tar.gz", "ns-20190103-000000.tar.gz", "ns-20190102-000000.tar.gz", "other-20190101-000000.tar.gz"} {
+		ioutil.WriteFile(path.Join(backupDir, name), []byte("x"), 0644)
+	}
+	store := &dirStore{dir: backupDir}
+
+	// all the backups are kept without retention
+	assert.Nil(t, prune(store, "ns", 0))
+	backups, _ := listBackups(store, "ns")
+	assert.Equal(t, 3, len(backups))
+
+	assert.Nil(t, prune(store, "ns", 2))
+	backups, _ = listBackups(store, "ns")
+	assert.Equal(t, []string{"ns-20190102-000000.tar.gz", "ns-20190103-000000.tar.gz"}, backups)
+	backups, _ = listBackups(store, "other")
+	assert.Equal(t, 1, len(backups))
+}
+
+func TestListBackupsSharedPrefix(t *testing.T) {
+	backupDir, err := ioutil.TempDir("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(backupDir)
+
+	for _, name := range []string{"rook-ceph-20190101-000000.tar.gz", "rook-ceph-20190102-000000.tar.gz",
+		"rook-ceph-2-20190103-000000.tar.gz", "rook-ceph-2-20190104-000000.tar.gz", "rook-ceph-2-20190105-000000.tar.gz"} {
+		ioutil.WriteFile(path.Join(backupDir, name), []byte("x"), 0644)
+	}
+	store := &dirStore{dir: backupDir}
+
+	// the backups of rook-ceph-2 are not backups of rook-ceph
+	backups, err := listBackups(store, "rook-ceph")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"rook-ceph-20190101-000000.tar.gz", "rook-ceph-20190102-000000.tar.gz"}, backups)
+
+	// the retention of a cluster doesn't prune the backups of the other cluster
+	assert.Nil(t, prune(store, "rook-ceph", 1))
+	backups, _ = listBackups(store, "rook-ceph")
+	assert.Equal(t, []string{"rook-ceph-20190102-000000.tar.gz"}, backups)
+	backups, _ = listBackups(store, "rook-ceph-2")
+	assert.Equal(t, 3, len(backups))
+
+	// the latest backup of the other cluster is not restored
+	os.Remove(path.Join(backupDir, "rook-ceph-20190102-000000.tar.gz"))
+	_, err = Restore(RestoreConfig{Prefix: "rook-ceph", Name: LatestBackup, MonStoreDir: path.Join(backupDir, "restore")}, store)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no backups found")
+}
+
+func TestMetadata(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	owner := []metav1.OwnerReference{{UID: "cluster-uid"}}
+	clientset.CoreV1().ConfigMaps("ns").Create(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-endpoints", Namespace: "ns", OwnerReferences: owner},
+		Data:       map[string]string{"data": "a=1.2.3.4:6789"},
+	})
+	clientset.CoreV1().ConfigMaps("ns").Create(&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "ns"}})
+	clientset.CoreV1().Secrets("ns").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon", Namespace: "ns", OwnerReferences: owner},
+		Data:       map[string][]byte{"mon-secret": []byte("secret")},
+	})
+	clientset.CoreV1().Secrets("ns").Create(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: MetadataSecretName, Namespace: "ns", OwnerReferences: owner}})
+
+	data, err := ExportMetadata(clientset, "ns", "cluster-uid")
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), "rook-ceph-mon-endpoints")
+	assert.Contains(t, string(data), `"rook-ceph-mon"`)
+	assert.NotContains(t, string(data), "unrelated")
+	assert.NotContains(t, string(data), MetadataSecretName)
+
+	// restore in a new namespace without the owner references
+	newClientset := fake.NewSimpleClientset()
+	err = ApplyMetadata(newClientset, "restored", data)
+	assert.Nil(t, err)
+	cm, err := newClientset.CoreV1().ConfigMaps("restored").Get("rook-ceph-mon-endpoints", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "a=1.2.3.4:6789", cm.Data["data"])
+	assert.Equal(t, 0, len(cm.OwnerReferences))
+	secret, err := newClientset.CoreV1().Secrets("restored").Get("rook-ceph-mon", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", string(secret.Data["mon-secret"]))
+
+	// applying again replaces the existing objects
+	assert.Nil(t, ApplyMetadata(newClientset, "restored", data))
+}
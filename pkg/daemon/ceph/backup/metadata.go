@@ -0,0 +1,123 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// MetadataSecretName is the secret where the operator exports the metadata for the backup job
+	MetadataSecretName = "rook-ceph-backup-metadata"
+	// MetadataKey is the key of the exported metadata in the secret
+	MetadataKey = metadataEntry
+)
+
+// Metadata is the export of the configmaps and secrets that Rook created for a cluster, such as the mon endpoints,
+// the keyrings and the osd config.
+type Metadata struct {
+	ConfigMaps []v1.ConfigMap `json:"configMaps"`
+	Secrets    []v1.Secret    `json:"secrets"`
+}
+
+// ExportMetadata returns the configmaps and secrets in the namespace that are owned by the cluster
+func ExportMetadata(clientset kubernetes.Interface, namespace string, clusterUID types.UID) ([]byte, error) {
+	metadata := Metadata{ConfigMaps: []v1.ConfigMap{}, Secrets: []v1.Secret{}}
+
+	configMaps, err := clientset.CoreV1().ConfigMaps(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configmaps. %+v", err)
+	}
+	for _, cm := range configMaps.Items {
+		if ownedBy(cm.ObjectMeta, clusterUID) {
+			metadata.ConfigMaps = append(metadata.ConfigMaps, cm)
+		}
+	}
+
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets. %+v", err)
+	}
+	for _, secret := range secrets.Items {
+		// the previous export must not be nested in the new one
+		if secret.Name != MetadataSecretName && ownedBy(secret.ObjectMeta, clusterUID) {
+			metadata.Secrets = append(metadata.Secrets, secret)
+		}
+	}
+
+	logger.Infof("exported %d configmaps and %d secrets", len(metadata.ConfigMaps), len(metadata.Secrets))
+	return json.Marshal(metadata)
+}
+
+// ApplyMetadata creates or replaces the exported configmaps and secrets in the namespace. The owner references are
+// removed since the cluster they referred to may not exist anymore.
+func ApplyMetadata(clientset kubernetes.Interface, namespace string, data []byte) error {
+	var metadata Metadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return fmt.Errorf("failed to parse metadata. %+v", err)
+	}
+
+	for _, cm := range metadata.ConfigMaps {
+		cm.ObjectMeta = restoredObjectMeta(cm.ObjectMeta, namespace)
+		_, err := clientset.CoreV1().ConfigMaps(namespace).Create(&cm)
+		if errors.IsAlreadyExists(err) {
+			_, err = clientset.CoreV1().ConfigMaps(namespace).Update(&cm)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to restore configmap %s. %+v", cm.Name, err)
+		}
+		logger.Infof("restored configmap %s", cm.Name)
+	}
+
+	for _, secret := range metadata.Secrets {
+		secret.ObjectMeta = restoredObjectMeta(secret.ObjectMeta, namespace)
+		_, err := clientset.CoreV1().Secrets(namespace).Create(&secret)
+		if errors.IsAlreadyExists(err) {
+			_, err = clientset.CoreV1().Secrets(namespace).Update(&secret)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to restore secret %s. %+v", secret.Name, err)
+		}
+		logger.Infof("restored secret %s", secret.Name)
+	}
+	return nil
+}
+
+func ownedBy(meta metav1.ObjectMeta, uid types.UID) bool {
+	for _, ref := range meta.OwnerReferences {
+		if ref.UID == uid {
+			return true
+		}
+	}
+	return false
+}
+
+func restoredObjectMeta(meta metav1.ObjectMeta, namespace string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        meta.Name,
+		Namespace:   namespace,
+		Labels:      meta.Labels,
+		Annotations: meta.Annotations,
+	}
+}
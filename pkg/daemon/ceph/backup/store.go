@@ -0,0 +1,99 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/rook/rook/pkg/util/s3"
+)
+
+// StoreConfig is the destination of the backups. Either the dir or the S3 bucket must be set.
+type StoreConfig struct {
+	// Dir is the path where the backup volume is mounted
+	Dir        string
+	S3Endpoint string
+	S3Bucket   string
+}
+
+// NewStore returns the store for the config. The S3 credentials are read from the AWS env vars.
+func NewStore(config StoreConfig) (Store, error) {
+	if config.S3Endpoint != "" {
+		return s3.NewClient(s3.ConfigFromEnv(config.S3Endpoint, config.S3Bucket))
+	}
+	if config.Dir == "" {
+		return nil, fmt.Errorf("a backup dir or an s3 endpoint is required")
+	}
+	return &dirStore{dir: config.Dir}, nil
+}
+
+// dirStore keeps the backups as files in a dir
+type dirStore struct {
+	dir string
+}
+
+func (s *dirStore) Put(name string, content io.ReadSeeker) error {
+	// write to a temp file first so that a partial backup is never mistaken for a complete one
+	tmpPath := path.Join(s.dir, "."+name+".tmp")
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, content); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path.Join(s.dir, name))
+}
+
+func (s *dirStore) Get(name string, w io.Writer) error {
+	f, err := os.Open(path.Join(s.dir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func (s *dirStore) List(prefix string) ([]string, error) {
+	entries, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), prefix) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func (s *dirStore) Delete(name string) error {
+	return os.Remove(path.Join(s.dir, name))
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cleanup removes the data left on a host by a Ceph cluster that has been deleted.
+package cleanup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/sys"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "cleanup")
+
+const shredCmd = "shred"
+
+// Config is the cleanup configuration for a single host
+type Config struct {
+	// DataDir is the path where the dataDirHostPath of the cluster is mounted
+	DataDir string
+	// Devices are the devices of the OSDs of the cluster on the host, as recorded in its OSD config
+	Devices []string
+	// SanitizeDevices will securely erase the devices after the Rook partitions are removed
+	SanitizeDevices bool
+}
+
+// Run removes the contents of the data dir and zaps the devices of the OSDs of the cluster
+func Run(context *clusterd.Context, config Config) error {
+	var errorMessages []string
+
+	if err := cleanDataDir(config.DataDir); err != nil {
+		errorMessages = append(errorMessages, err.Error())
+	}
+
+	if err := cleanDevices(context, config.Devices, config.SanitizeDevices); err != nil {
+		errorMessages = append(errorMessages, err.Error())
+	}
+
+	if len(errorMessages) > 0 {
+		return fmt.Errorf("failed to clean up the host: %s", strings.Join(errorMessages, "; "))
+	}
+
+	logger.Infof("host cleanup completed")
+	return nil
+}
+
+// remove everything under the data dir, but not the data dir itself since it is a mount point
+func cleanDataDir(dataDir string) error {
+	if dataDir == "" {
+		logger.Infof("no data dir to clean")
+		return nil
+	}
+
+	entries, err := ioutil.ReadDir(dataDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			logger.Infof("data dir %s does not exist", dataDir)
+			return nil
+		}
+		return fmt.Errorf("failed to read data dir %s. %+v", dataDir, err)
+	}
+
+	for _, entry := range entries {
+		entryPath := path.Join(dataDir, entry.Name())
+		logger.Infof("removing %s", entryPath)
+		if err := os.RemoveAll(entryPath); err != nil {
+			return fmt.Errorf("failed to remove %s. %+v", entryPath, err)
+		}
+	}
+
+	return nil
+}
+
+// cleanDevices zaps the devices of the cluster. The Rook partition labels don't tell the clusters apart, so only
+// the devices recorded for the cluster are considered, and a device is still skipped unless it has Rook partitions.
+func cleanDevices(context *clusterd.Context, devices []string, sanitize bool) error {
+	var errorMessages []string
+	for _, device := range devices {
+		device = strings.TrimPrefix(strings.TrimSpace(device), "/dev/")
+		if device == "" {
+			continue
+		}
+
+		if !rookOwnsDevice(context, device) {
+			continue
+		}
+
+		logger.Infof("zapping rook partitions on device %s", device)
+		if err := sys.RemovePartitions(device, context.Executor); err != nil {
+			errorMessages = append(errorMessages, err.Error())
+			continue
+		}
+
+		if sanitize {
+			logger.Infof("sanitizing device %s", device)
+			if err := sanitizeDevice(context, device); err != nil {
+				errorMessages = append(errorMessages, err.Error())
+			}
+		}
+	}
+
+	if len(errorMessages) > 0 {
+		return fmt.Errorf("%d devices failed to be cleaned: %s", len(errorMessages), strings.Join(errorMessages, "; "))
+	}
+	return nil
+}
+
+// rookOwnsDevice returns whether the device is a disk that has only partitions created by Rook.
+// Disks without any partitions are left alone since Rook did not consume them.
+func rookOwnsDevice(context *clusterd.Context, device string) bool {
+	props, err := sys.GetDeviceProperties(device, context.Executor)
+	if err != nil {
+		logger.Warningf("skipping device %s. failed to get properties. %+v", device, err)
+		return false
+	}
+	if props["TYPE"] != sys.DiskType {
+		return false
+	}
+
+	partitions, _, err := sys.GetDevicePartitions(device, context.Executor)
+	if err != nil {
+		logger.Warningf("skipping device %s. %+v", device, err)
+		return false
+	}
+
+	return len(partitions) > 0 && sys.RookOwnsPartitions(partitions)
+}
+
+func sanitizeDevice(context *clusterd.Context, device string) error {
+	devicePath := "/dev/" + device
+	cmd := fmt.Sprintf("shred %s", devicePath)
+	if err := context.Executor.ExecuteCommand(false, cmd, shredCmd, "--iterations=1", "--zero", devicePath); err != nil {
+		return fmt.Errorf("failed to sanitize %s. %+v", devicePath, err)
+	}
+	return nil
+}
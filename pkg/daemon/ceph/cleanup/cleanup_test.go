@@ -0,0 +1,106 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cleanup
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanDataDir(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dataDir)
+
+	os.MkdirAll(path.Join(dataDir, "mon-a", "data"), 0755)
+	ioutil.WriteFile(path.Join(dataDir, "rook-ceph.config"), []byte("foo"), 0644)
+
+	err = cleanDataDir(dataDir)
+	assert.Nil(t, err)
+
+	entries, err := ioutil.ReadDir(dataDir)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(entries))
+
+	// a missing data dir is not an error
+	assert.Nil(t, cleanDataDir(path.Join(dataDir, "missing")))
+}
+
+func TestCleanDevices(t *testing.T) {
+	zapped := []string{}
+	shredded := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			logger.Infof("OUTPUT %s %v", command, args)
+			switch {
+			case command == "lsblk" && args[1] == "--bytes" && args[2] == "--nodeps":
+				if args[0] == "/dev/sda1" || args[0] == "/dev/sdb1" {
+					return `SIZE="1024" ROTA="1" RO="0" TYPE="part" PKNAME="sda"`, nil
+				}
+				return `SIZE="10240" ROTA="1" RO="0" TYPE="disk" PKNAME=""`, nil
+			case command == "lsblk" && args[0] == "/dev/sda":
+				return `NAME="sda" SIZE="10240" TYPE="disk" PKNAME=""
+NAME="sda1" SIZE="1024" TYPE="part" PKNAME="sda"`, nil
+			case command == "lsblk" && args[0] == "/dev/sdb":
+				return `NAME="sdb" SIZE="10240" TYPE="disk" PKNAME=""
+NAME="sdb1" SIZE="1024" TYPE="part" PKNAME="sdb"`, nil
+			case command == "lsblk" && args[0] == "/dev/sdc":
+				return `NAME="sdc" SIZE="10240" TYPE="disk" PKNAME=""`, nil
+			case command == "udevadm" && args[2] == "/dev/sda1":
+				return "ID_PART_ENTRY_NAME=ROOK-OSD0-BLOCK", nil
+			case command == "udevadm" && args[2] == "/dev/sdb1":
+				return "ID_PART_ENTRY_NAME=root", nil
+			}
+			return "", nil
+		},
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			logger.Infof("RUN %s %v", command, args)
+			if command == "sgdisk" && args[0] == "--zap-all" {
+				zapped = append(zapped, args[1])
+			}
+			if command == shredCmd {
+				shredded = append(shredded, args[2])
+			}
+			return nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	// only the device with rook partitions is zapped
+	err := cleanDevices(context, []string{"sda", "sdb", "sdc"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/dev/sda"}, zapped)
+	assert.Equal(t, 0, len(shredded))
+
+	// the device is also shredded when sanitizing
+	zapped = []string{}
+	err = cleanDevices(context, []string{"/dev/sda"}, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/dev/sda"}, zapped)
+	assert.Equal(t, []string{"/dev/sda"}, shredded)
+
+	// the rook partitions of the devices not recorded for the cluster belong to another cluster
+	zapped = []string{}
+	err = cleanDevices(context, []string{"sdb", "sdc"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(zapped))
+}
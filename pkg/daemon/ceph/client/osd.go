@@ -91,6 +91,93 @@ func (dump *OSDDump) StatusByID(id int64) (int64, int64, error) {
 	return 0, 0, fmt.Errorf("not found osd.%d in OSDDump", id)
 }
 
+// OSDTree is the crush hierarchy of the buckets and osds
+type OSDTree struct {
+	Nodes []OSDTreeNode `json:"nodes"`
+}
+
+// OSDTreeNode is a crush bucket or an osd of the crush hierarchy
+type OSDTreeNode struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Children []int  `json:"children"`
+}
+
+// FailureDomains returns the name of the crush bucket of the given type (e.g. "host") that contains each osd
+func (tree *OSDTree) FailureDomains(bucketType string) map[int]string {
+	parents := map[int]int{}
+	nodes := map[int]OSDTreeNode{}
+	for _, node := range tree.Nodes {
+		nodes[node.ID] = node
+		for _, child := range node.Children {
+			parents[child] = node.ID
+		}
+	}
+
+	domains := map[int]string{}
+	for _, node := range tree.Nodes {
+		if node.Type != "osd" {
+			continue
+		}
+		id := node.ID
+		for {
+			parent, ok := parents[id]
+			if !ok {
+				break
+			}
+			if nodes[parent].Type == bucketType {
+				domains[node.ID] = nodes[parent].Name
+				break
+			}
+			id = parent
+		}
+	}
+	return domains
+}
+
+func GetOSDTree(context *clusterd.Context, clusterName string) (*OSDTree, error) {
+	args := []string{"osd", "tree"}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get osd tree: %+v", err)
+	}
+
+	var tree OSDTree
+	if err := json.Unmarshal(buf, &tree); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal osd tree response: %+v", err)
+	}
+
+	return &tree, nil
+}
+
+// SetNoOut prevents the osds from being marked out while they are down
+func SetNoOut(context *clusterd.Context, clusterName string, osdIDs []int) error {
+	return setOSDFlag(context, clusterName, "add-noout", osdIDs)
+}
+
+// UnsetNoOut allows the osds to be marked out again while they are down
+func UnsetNoOut(context *clusterd.Context, clusterName string, osdIDs []int) error {
+	return setOSDFlag(context, clusterName, "rm-noout", osdIDs)
+}
+
+func setOSDFlag(context *clusterd.Context, clusterName, action string, osdIDs []int) error {
+	if len(osdIDs) == 0 {
+		return nil
+	}
+	args := []string{"osd", action}
+	for _, id := range osdIDs {
+		args = append(args, fmt.Sprintf("osd.%d", id))
+	}
+	cmd := NewCephCommand(context, clusterName, args)
+	cmd.JsonOutput = false
+	buf, err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("failed to %s for osds %v: %+v, %s", action, osdIDs, err, string(buf))
+	}
+	return nil
+}
+
 func GetOSDUsage(context *clusterd.Context, clusterName string) (*OSDUsage, error) {
 	args := []string{"osd", "df"}
 	buf, err := NewCephCommand(context, clusterName, args).Run()
@@ -0,0 +1,360 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package backup creates and restores the backups of the databases of a CockroachDB cluster in an S3 bucket.
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/rook/rook/pkg/util/s3"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "cockroachdb-backup")
+
+// The backups of a cluster are stored in the bucket with the layout:
+// <prefix>/<backup>/<files written by BACKUP, or a <database>.sql file for each database when dumped>
+// <prefix>/<backup>/manifest.json
+// The manifest is written last, so that only the complete backups are restored.
+
+const (
+	// LatestBackup can be given instead of a backup name to restore the most recent backup
+	LatestBackup = "latest"
+	// ModeBackup backs up the databases with the BACKUP statement of the enterprise edition of CockroachDB
+	ModeBackup = "backup"
+	// ModeDump backs up the databases with cockroach dump, which is available in the OSS edition
+	ModeDump = "dump"
+
+	manifestName    = "manifest.json"
+	dumpSuffix      = ".sql"
+	cockroachBinary = "/cockroach/cockroach"
+)
+
+// Store is where the backups are kept
+type Store interface {
+	Put(key string, content io.ReadSeeker) error
+	Get(key string, w io.Writer) error
+	List(prefix string) ([]string, error)
+	Delete(key string) error
+}
+
+// Runner runs the cockroach client with the given arguments, reading from stdin and writing to stdout
+type Runner func(stdin io.Reader, stdout io.Writer, args ...string) error
+
+// Config is the connection to the cluster and the location of its backups
+type Config struct {
+	// Host is the address of the cluster, usually its public service
+	Host string
+	Port int
+	// CertsDir is the dir with the certificate of the root user. The cluster is insecure if empty.
+	CertsDir string
+	// S3 is the bucket where the backups are stored
+	S3 s3.Config
+	// Prefix is the prefix of the keys of the backups of the cluster, usually <namespace>/<cluster>
+	Prefix string
+}
+
+// CreateConfig is the configuration of a backup
+type CreateConfig struct {
+	Config
+	// Name is the name of the backup
+	Name string
+	// Mode is either ModeBackup or ModeDump
+	Mode      string
+	Databases []string
+	// Retention is the number of backups to keep. All the backups are kept if zero.
+	Retention int
+}
+
+// RestoreConfig is the configuration to restore a backup
+type RestoreConfig struct {
+	Config
+	// Name is the name of the backup or "latest"
+	Name string
+}
+
+// manifest describes how a backup was taken, so that it can be restored
+type manifest struct {
+	Mode      string   `json:"mode"`
+	Databases []string `json:"databases"`
+}
+
+// Create backs up the databases to the store. The oldest backups are then removed according to the retention.
+func Create(config CreateConfig, store Store, run Runner) error {
+	if config.Name == "" {
+		return fmt.Errorf("the name of the backup is required")
+	}
+	if len(config.Databases) == 0 {
+		return fmt.Errorf("no databases to back up")
+	}
+
+	logger.Infof("creating backup %s of databases %s", config.Name, strings.Join(config.Databases, ","))
+	switch config.Mode {
+	case ModeBackup:
+		statement := fmt.Sprintf("BACKUP DATABASE %s TO %s;", quoteIdentifiers(config.Databases),
+			quoteString(createBackupURL(config.S3, backupPrefix(config.Prefix, config.Name))))
+		if err := runSQL(run, config.Config, statement); err != nil {
+			return fmt.Errorf("failed to back up databases: %+v", err)
+		}
+	case ModeDump:
+		for _, database := range config.Databases {
+			if err := dumpDatabase(config, database, store, run); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("invalid backup mode %s", config.Mode)
+	}
+
+	m, err := json.Marshal(manifest{Mode: config.Mode, Databases: config.Databases})
+	if err != nil {
+		return fmt.Errorf("failed to create manifest: %+v", err)
+	}
+	if err := store.Put(backupPrefix(config.Prefix, config.Name)+manifestName, bytes.NewReader(m)); err != nil {
+		return fmt.Errorf("failed to store manifest of backup %s: %+v", config.Name, err)
+	}
+	logger.Infof("stored backup %s", config.Name)
+
+	if err := prune(store, config.Prefix, config.Retention); err != nil {
+		// the backup succeeded, the old backups will be removed next time
+		logger.Warningf("failed to remove old backups: %+v", err)
+	}
+	return nil
+}
+
+// Restore restores the databases of the backup to the cluster and returns the name of the restored backup
+func Restore(config RestoreConfig, store Store, run Runner) (string, error) {
+	name := config.Name
+	if name == "" || name == LatestBackup {
+		backups, err := ListBackups(store, config.Prefix)
+		if err != nil {
+			return "", err
+		}
+		if len(backups) == 0 {
+			return "", fmt.Errorf("no backups found with prefix %s", config.Prefix)
+		}
+		name = backups[len(backups)-1]
+	}
+
+	var buf bytes.Buffer
+	if err := store.Get(backupPrefix(config.Prefix, name)+manifestName, &buf); err != nil {
+		return "", fmt.Errorf("failed to get manifest of backup %s: %+v", name, err)
+	}
+	var m manifest
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		return "", fmt.Errorf("failed to parse manifest of backup %s: %+v", name, err)
+	}
+
+	logger.Infof("restoring backup %s of databases %s", name, strings.Join(m.Databases, ","))
+	switch m.Mode {
+	case ModeBackup:
+		statement := fmt.Sprintf("RESTORE DATABASE %s FROM %s;", quoteIdentifiers(m.Databases),
+			quoteString(createBackupURL(config.S3, backupPrefix(config.Prefix, name))))
+		if err := runSQL(run, config.Config, statement); err != nil {
+			return "", fmt.Errorf("failed to restore databases: %+v", err)
+		}
+	case ModeDump:
+		for _, database := range m.Databases {
+			if err := restoreDump(config, name, database, store, run); err != nil {
+				return "", err
+			}
+		}
+	default:
+		return "", fmt.Errorf("invalid mode %s of backup %s", m.Mode, name)
+	}
+
+	logger.Infof("restored backup %s", name)
+	return name, nil
+}
+
+// ListBackups returns the sorted names of the complete backups with the prefix. The names of the backups sort in
+// the order they were taken.
+func ListBackups(store Store, prefix string) ([]string, error) {
+	complete, _, err := listBackups(store, prefix)
+	return complete, err
+}
+
+// RunCockroach runs the cockroach client. The statements and the dumps are passed through stdin and stdout rather
+// than the arguments, so that the credentials of the bucket are never logged.
+func RunCockroach(stdin io.Reader, stdout io.Writer, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command(cockroachBinary, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run cockroach %s: %+v. %s", args[0], err, stderr.String())
+	}
+	return nil
+}
+
+func dumpDatabase(config CreateConfig, database string, store Store, run Runner) error {
+	dump, err := ioutil.TempFile("", "rook-cockroachdb-dump")
+	if err != nil {
+		return fmt.Errorf("failed to create dump file: %+v", err)
+	}
+	defer os.Remove(dump.Name())
+	defer dump.Close()
+
+	args := append([]string{"dump", database}, clientArgs(config.Config)...)
+	if err := run(nil, dump, args...); err != nil {
+		return fmt.Errorf("failed to dump database %s: %+v", database, err)
+	}
+	if _, err := dump.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind dump of database %s: %+v", database, err)
+	}
+	if err := store.Put(backupPrefix(config.Prefix, config.Name)+database+dumpSuffix, dump); err != nil {
+		return fmt.Errorf("failed to store dump of database %s: %+v", database, err)
+	}
+	return nil
+}
+
+func restoreDump(config RestoreConfig, name, database string, store Store, run Runner) error {
+	dump, err := ioutil.TempFile("", "rook-cockroachdb-dump")
+	if err != nil {
+		return fmt.Errorf("failed to create dump file: %+v", err)
+	}
+	defer os.Remove(dump.Name())
+	defer dump.Close()
+
+	if err := store.Get(backupPrefix(config.Prefix, name)+database+dumpSuffix, dump); err != nil {
+		return fmt.Errorf("failed to get dump of database %s: %+v", database, err)
+	}
+	if _, err := dump.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind dump of database %s: %+v", database, err)
+	}
+
+	// the dump only contains the tables of the database
+	if err := runSQL(run, config.Config, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s;", quoteIdentifiers([]string{database}))); err != nil {
+		return fmt.Errorf("failed to create database %s: %+v", database, err)
+	}
+	args := append([]string{"sql", "--database=" + database}, clientArgs(config.Config)...)
+	if err := run(dump, ioutil.Discard, args...); err != nil {
+		return fmt.Errorf("failed to restore database %s: %+v", database, err)
+	}
+	return nil
+}
+
+// prune removes the oldest complete backups that exceed the retention, and the incomplete backups older than the
+// oldest backup that is kept
+func prune(store Store, prefix string, retention int) error {
+	if retention <= 0 {
+		return nil
+	}
+	complete, all, err := listBackups(store, prefix)
+	if err != nil {
+		return err
+	}
+	if len(complete) <= retention {
+		return nil
+	}
+
+	oldestKept := complete[len(complete)-retention]
+	for _, name := range all {
+		if name >= oldestKept {
+			break
+		}
+		keys, err := store.List(backupPrefix(prefix, name))
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := store.Delete(key); err != nil {
+				return err
+			}
+		}
+		logger.Infof("removed backup %s", name)
+	}
+	return nil
+}
+
+// listBackups returns the sorted names of the complete backups and of all the backups with the prefix
+func listBackups(store Store, prefix string) ([]string, []string, error) {
+	keys, err := store.List(strings.TrimSuffix(prefix, "/") + "/")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list backups: %+v", err)
+	}
+
+	found := map[string]bool{}
+	complete := []string{}
+	all := []string{}
+	for _, key := range keys {
+		parts := strings.SplitN(strings.TrimPrefix(key, strings.TrimSuffix(prefix, "/")+"/"), "/", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			continue
+		}
+		if !found[parts[0]] {
+			found[parts[0]] = true
+			all = append(all, parts[0])
+		}
+		if parts[1] == manifestName {
+			complete = append(complete, parts[0])
+		}
+	}
+	sort.Strings(complete)
+	sort.Strings(all)
+	return complete, all, nil
+}
+
+func runSQL(run Runner, config Config, statement string) error {
+	args := append([]string{"sql"}, clientArgs(config)...)
+	return run(strings.NewReader(statement), ioutil.Discard, args...)
+}
+
+func clientArgs(config Config) []string {
+	args := []string{fmt.Sprintf("--host=%s", config.Host), fmt.Sprintf("--port=%d", config.Port)}
+	if config.CertsDir == "" {
+		return append(args, "--insecure")
+	}
+	return append(args, "--certs-dir="+config.CertsDir)
+}
+
+func backupPrefix(prefix, name string) string {
+	return fmt.Sprintf("%s/%s/", strings.TrimSuffix(prefix, "/"), name)
+}
+
+// createBackupURL returns the location of a backup for the BACKUP and RESTORE statements, with the credentials of
+// the bucket
+func createBackupURL(config s3.Config, keyPrefix string) string {
+	query := url.Values{}
+	query.Set("AWS_ACCESS_KEY_ID", config.AccessKey)
+	query.Set("AWS_SECRET_ACCESS_KEY", config.SecretKey)
+	query.Set("AWS_ENDPOINT", config.Endpoint)
+	u := url.URL{Scheme: "s3", Host: config.Bucket, Path: "/" + strings.TrimSuffix(keyPrefix, "/"), RawQuery: query.Encode()}
+	return u.String()
+}
+
+func quoteIdentifiers(names []string) string {
+	quoted := []string{}
+	for _, name := range names {
+		quoted = append(quoted, `"`+strings.Replace(name, `"`, `""`, -1)+`"`)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func quoteString(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/rook/rook/pkg/util/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+type memStore struct {
+	objects map[string][]byte
+}
+
+func (s *memStore) Put(key string, content io.ReadSeeker) error {
+	b, err := ioutil.ReadAll(content)
+	s.objects[key] = b
+	return err
+}
+
+func (s *memStore) Get(key string, w io.Writer) error {
+	b, ok := s.objects[key]
+	if !ok {
+		return fmt.Errorf("%s not found", key)
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+func (s *memStore) List(prefix string) ([]string, error) {
+	keys := []string{}
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (s *memStore) Delete(key string) error {
+	delete(s.objects, key)
+	return nil
+}
+
+// fakeRunner records the commands and their input, and dumps every database as a single statement
+type fakeRunner struct {
+	commands []string
+	inputs   []string
+}
+
+func (r *fakeRunner) run(stdin io.Reader, stdout io.Writer, args ...string) error {
+	r.commands = append(r.commands, strings.Join(args, " "))
+	input := ""
+	if stdin != nil {
+		b, _ := ioutil.ReadAll(stdin)
+		input = string(b)
+	}
+	r.inputs = append(r.inputs, input)
+	if args[0] == "dump" {
+		fmt.Fprintf(stdout, "CREATE TABLE %s.t (id INT);", args[1])
+	}
+	return nil
+}
+
+func newConfigForTest() Config {
+	return Config{
+		Host:   "cockroachdb-public.rook-cockroachdb",
+		Port:   26257,
+		S3:     s3.Config{Endpoint: "http://minio:9000", Bucket: "backups", AccessKey: "access", SecretKey: "se&cret"},
+		Prefix: "rook-cockroachdb/cluster-1",
+	}
+}
+
+func TestCreateAndRestoreDump(t *testing.T) {
+	store := &memStore{objects: map[string][]byte{}}
+	runner := &fakeRunner{}
+
+	config := CreateConfig{Config: newConfigForTest(), Name: "20190102-030405", Mode: ModeDump, Databases: []string{"bank", "shop"}}
+	assert.Nil(t, Create(config, store, runner.run))
+	assert.Equal(t, []string{
+		"dump bank --host=cockroachdb-public.rook-cockroachdb --port=26257 --insecure",
+		"dump shop --host=cockroachdb-public.rook-cockroachdb --port=26257 --insecure",
+	}, runner.commands)
+	assert.Equal(t, "CREATE TABLE bank.t (id INT);", string(store.objects["rook-cockroachdb/cluster-1/20190102-030405/bank.sql"]))
+	assert.Contains(t, string(store.objects["rook-cockroachdb/cluster-1/20190102-030405/manifest.json"]), `"mode":"dump"`)
+
+	// the latest backup is restored into the databases it was taken from
+	runner = &fakeRunner{}
+	restoreConfig := RestoreConfig{Config: newConfigForTest(), Name: LatestBackup}
+	restoreConfig.CertsDir = "/etc/cockroach-certs"
+	name, err := Restore(restoreConfig, store, runner.run)
+	assert.Nil(t, err)
+	assert.Equal(t, "20190102-030405", name)
+	assert.Equal(t, 4, len(runner.commands))
+	assert.Equal(t, "sql --host=cockroachdb-public.rook-cockroachdb --port=26257 --certs-dir=/etc/cockroach-certs", runner.commands[0])
+	assert.Equal(t, `CREATE DATABASE IF NOT EXISTS "bank";`, runner.inputs[0])
+	assert.Equal(t, "sql --database=bank --host=cockroachdb-public.rook-cockroachdb --port=26257 --certs-dir=/etc/cockroach-certs", runner.commands[1])
+	assert.Equal(t, "CREATE TABLE bank.t (id INT);", runner.inputs[1])
+}
+
+func TestCreateAndRestoreBackup(t *testing.T) {
+	store := &memStore{objects: map[string][]byte{}}
+	runner := &fakeRunner{}
+
+	config := CreateConfig{Config: newConfigForTest(), Name: "20190102-030405", Mode: ModeBackup, Databases: []string{"bank"}}
+	assert.Nil(t, Create(config, store, runner.run))
+	assert.Equal(t, []string{"sql --host=cockroachdb-public.rook-cockroachdb --port=26257 --insecure"}, runner.commands)
+	// the credentials are passed in the statement rather than the arguments
+	assert.Equal(t, `BACKUP DATABASE "bank" TO 's3://backups/rook-cockroachdb/cluster-1/20190102-030405?`+
+		`AWS_ACCESS_KEY_ID=access&AWS_ENDPOINT=http%3A%2F%2Fminio%3A9000&AWS_SECRET_ACCESS_KEY=se%26cret';`, runner.inputs[0])
+
+	runner = &fakeRunner{}
+	name, err := Restore(RestoreConfig{Config: newConfigForTest(), Name: "20190102-030405"}, store, runner.run)
+	assert.Nil(t, err)
+	assert.Equal(t, "20190102-030405", name)
+	assert.True(t, strings.HasPrefix(runner.inputs[0], `RESTORE DATABASE "bank" FROM 's3://backups/rook-cockroachdb/cluster-1/20190102-030405?`))
+
+	// a backup that does not exist
+	_, err = Restore(RestoreConfig{Config: newConfigForTest(), Name: "20180102-030405"}, store, runner.run)
+	assert.NotNil(t, err)
+
+	// invalid configs
+	config.Mode = "copy"
+	assert.NotNil(t, Create(config, store, runner.run))
+	config.Mode = ModeBackup
+	config.Databases = nil
+	assert.NotNil(t, Create(config, store, runner.run))
+}
+
+func TestPrune(t *testing.T) {
+	store := &memStore{objects: map[string][]byte{}}
+	runner := &fakeRunner{}
+
+	// an incomplete backup is never restored
+	store.objects["rook-cockroachdb/cluster-1/20190101-000000/bank.sql"] = []byte{}
+	for _, name := range []string{"20190102-000000", "20190103-000000", "20190104-000000"} {
+		config := CreateConfig{Config: newConfigForTest(), Name: name, Mode: ModeDump, Databases: []string{"bank"}, Retention: 2}
+		assert.Nil(t, Create(config, store, runner.run))
+	}
+	store.objects["rook-cockroachdb/cluster-2/20190101-000000/manifest.json"] = []byte{}
+
+	backups, err := ListBackups(store, "rook-cockroachdb/cluster-1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"20190103-000000", "20190104-000000"}, backups)
+	_, ok := store.objects["rook-cockroachdb/cluster-1/20190101-000000/bank.sql"]
+	assert.False(t, ok)
+	_, ok = store.objects["rook-cockroachdb/cluster-2/20190101-000000/manifest.json"]
+	assert.True(t, ok)
+
+	var buf bytes.Buffer
+	assert.Nil(t, store.Get("rook-cockroachdb/cluster-1/20190104-000000/bank.sql", &buf))
+}
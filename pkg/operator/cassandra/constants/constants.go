@@ -35,6 +35,115 @@ const (
 	// Values: {true, false}
 	DecommissionLabel = "cassandra.rook.io/decommissioned"
 
+	// RebuildLabel expresses the intent to rebuild the data of
+	// the specific member from another datacenter. The presence
+	// of the label expresses the intent to rebuild. If the value
+	// is true, it means the member has finished rebuilding.
+	// Values: {true, false}
+	RebuildLabel = "cassandra.rook.io/rebuilt"
+
+	// SkipBootstrapLabel records that the specific member joined
+	// a datacenter that was added with the rebuildFrom field, so
+	// it joins the ring without bootstrapping and gets its data
+	// with a rebuild instead. Members added to the datacenter
+	// after its rebuild bootstrap normally.
+	SkipBootstrapLabel = "cassandra.rook.io/skip-bootstrap"
+
+	// RepairLabel expresses the intent to repair the primary
+	// ranges of the specific member for the keyspace in the
+	// RepairKeyspaceAnnotation. The presence of the label
+	// expresses the intent to repair. If the value is true,
+	// it means the member has finished repairing the keyspace.
+	// Values: {true, false}
+	RepairLabel = "cassandra.rook.io/repaired"
+
+	// RepairKeyspaceAnnotation is the keyspace that the member
+	// must repair when it has the RepairLabel.
+	RepairKeyspaceAnnotation = "cassandra.rook.io/repair-keyspace"
+
+	// SnapshotLabel expresses the intent to take a snapshot of
+	// the data of the specific member, with the tag in the
+	// SnapshotTagAnnotation. The presence of the label expresses
+	// the intent to take the snapshot. If the value is true, it
+	// means the member has taken the snapshot.
+	// Values: {true, false}
+	SnapshotLabel = "cassandra.rook.io/snapshot-taken"
+
+	// SnapshotTagAnnotation is the tag of the snapshot that the
+	// member must take when it has the SnapshotLabel.
+	SnapshotTagAnnotation = "cassandra.rook.io/snapshot-tag"
+
+	// ClearSnapshotLabel expresses the intent to clear the
+	// snapshot with the tag in the SnapshotTagAnnotation once
+	// the upgrade it was taken for is finished. The presence of
+	// the label expresses the intent to clear the snapshot. If
+	// the value is true, it means the member has cleared it.
+	// Values: {true, false}
+	ClearSnapshotLabel = "cassandra.rook.io/snapshot-cleared"
+
+	// DrainLabel expresses the intent to drain the specific
+	// member before it is restarted with a new version. The
+	// presence of the label expresses the intent to drain. If
+	// the value is true, it means the member has drained.
+	// Values: {true, false}
+	DrainLabel = "cassandra.rook.io/drained"
+
+	// UpgradeSSTablesLabel expresses the intent to rewrite the
+	// sstables of the specific member in the format of its new
+	// version. The presence of the label expresses the intent to
+	// upgrade the sstables. If the value is true, it means the
+	// member has upgraded its sstables.
+	// Values: {true, false}
+	UpgradeSSTablesLabel = "cassandra.rook.io/sstables-upgraded"
+
+	// BackupLabel expresses the intent to back up the data of
+	// the specific member to the backup in the
+	// BackupNameAnnotation. The presence of the label expresses
+	// the intent to back up. If the value is true, it means the
+	// member has uploaded its data.
+	// Values: {true, false}
+	BackupLabel = "cassandra.rook.io/backed-up"
+
+	// BackupNameAnnotation is the name of the backup that the
+	// member must upload its data to when it has the BackupLabel.
+	BackupNameAnnotation = "cassandra.rook.io/backup-name"
+
+	// RestoreSchemaLabel expresses the intent to create the
+	// schema of the backup that seeds the cluster through the
+	// specific member. The presence of the label expresses the
+	// intent to create the schema. If the value is true, it
+	// means the schema is created.
+	// Values: {true, false}
+	RestoreSchemaLabel = "cassandra.rook.io/schema-restored"
+
+	// RestoreLabel expresses the intent to load the data of
+	// the backup that seeds the cluster through the specific
+	// member. The presence of the label expresses the intent to
+	// load the data. If the value is true, it means the member
+	// has loaded its data.
+	// Values: {true, false}
+	RestoreLabel = "cassandra.rook.io/restored"
+
+	// SuperuserLabel expresses the intent to create the
+	// superuser of the authentication settings through the
+	// specific member. The presence of the label expresses the
+	// intent to create the superuser. If the value is true, it
+	// means the superuser is created.
+	// Values: {true, false}
+	SuperuserLabel = "cassandra.rook.io/superuser-created"
+
+	// ReplaceLabel records that the specific member lost its
+	// data and is replacing its previous self in the ring.
+	// Unlike the other labels, it is set by the member itself.
+	// If the value is true, it means the member has finished
+	// bootstrapping as a replacement.
+	// Values: {true, false}
+	ReplaceLabel = "cassandra.rook.io/replaced"
+
+	// HostIDAnnotation is the host ID of the member in the ring,
+	// recorded by the member once it has joined the ring.
+	HostIDAnnotation = "cassandra.rook.io/host-id"
+
 	// DeveloperModeAnnotation is present when the user wishes
 	// to bypass production-readiness checks and start the database
 	// either way. Currently useful for scylla, may get removed
@@ -78,4 +187,10 @@ const (
 	ReadinessProbePath = "/readyz"
 	LivenessProbePath  = "/healthz"
 	ProbePort          = 8080
+
+	// The sidecar serves the metrics and the view of the ring of the member
+	// on the probe port
+	MetricsPath          = "/metrics"
+	RingPath             = "/ring"
+	ScyllaPrometheusPort = 9180
 )
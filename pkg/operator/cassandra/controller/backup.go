@@ -0,0 +1,297 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
+	"github.com/rook/rook/pkg/operator/cassandra/constants"
+	"github.com/rook/rook/pkg/operator/cassandra/controller/util"
+	"github.com/rook/rook/pkg/util/cron"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// backupNameFormat is the format of the names of the backups. The names
+	// sort in the order the backups were taken.
+	backupNameFormat = "20060102-150405"
+	// defaultBackupDeadline is the time the members have to upload their
+	// data when the backup settings don't set a deadline.
+	defaultBackupDeadline = 12 * time.Hour
+)
+
+// syncBackup starts the scheduled backups of the given Cassandra Cluster and
+// tracks the backup in progress. All the members take a snapshot with the
+// name of the backup at the same time and upload it to the bucket of the
+// backup settings. Once all of them are done, the oldest backups are pruned.
+// A backup that isn't done by its deadline fails, so that the next scheduled
+// backups still run.
+func (cc *ClusterController) syncBackup(c *cassandrav1alpha1.Cluster, now time.Time) error {
+	if c.Spec.Backup == nil {
+		if c.Status.Backup != nil && c.Status.Backup.Name != "" {
+			logger.Infof("Backups of cluster %s are disabled, abandoning backup %s", c.Name, c.Status.Backup.Name)
+			c.Status.Backup.Name = ""
+		}
+		return nil
+	}
+
+	schedule, err := cron.Parse(c.Spec.Backup.Schedule)
+	if err != nil {
+		return fmt.Errorf("invalid backup schedule %s: %s", c.Spec.Backup.Schedule, err.Error())
+	}
+
+	if c.Status.Backup == nil {
+		c.Status.Backup = &cassandrav1alpha1.BackupStatus{}
+	}
+	status := c.Status.Backup
+
+	members, err := util.GetMemberServicesForCluster(c, cc.serviceLister)
+	if err != nil {
+		return fmt.Errorf("error trying to get member services: %s", err.Error())
+	}
+
+	// Check if a new backup must start
+	if status.Name == "" {
+		lastRun := c.CreationTimestamp.Time
+		if status.LastScheduleTime != nil {
+			lastRun = status.LastScheduleTime.Time
+		}
+		if !schedule.Due(lastRun, now) {
+			return nil
+		}
+		// The snapshots are only consistent across the cluster while all the members are up
+		if !clusterReady(c) || !superuserReady(c) || len(members) == 0 {
+			logger.Infof("Cluster %s is not ready, waiting to back it up", c.Name)
+			return nil
+		}
+
+		startTime := metav1.NewTime(now)
+		status.LastScheduleTime = &startTime
+		status.Name = now.UTC().Format(backupNameFormat)
+		logger.Infof("Starting backup %s of cluster %s", status.Name, c.Name)
+		for _, member := range members {
+			if err := cc.requestBackup(member, status.Name); err != nil {
+				return err
+			}
+		}
+		cc.recorder.Event(
+			c,
+			corev1.EventTypeNormal,
+			SuccessSynced,
+			fmt.Sprintf(MessageBackupStarted, status.Name),
+		)
+		return nil
+	}
+
+	// Wait for every member to upload its data
+	done := true
+	for _, member := range members {
+		if member.Annotations[constants.BackupNameAnnotation] != status.Name {
+			// The member was created after the backup started
+			if err := cc.requestBackup(member, status.Name); err != nil {
+				return err
+			}
+		}
+		if member.Labels[constants.BackupLabel] != constants.LabelValueTrue {
+			done = false
+		}
+	}
+	if !done {
+		deadline := backupDeadline(c.Spec.Backup)
+		if status.LastScheduleTime == nil || now.Sub(status.LastScheduleTime.Time) < deadline {
+			return nil
+		}
+
+		logger.Warningf("Backup %s of cluster %s didn't finish within %s, failing it", status.Name, c.Name, deadline)
+		failTime := metav1.NewTime(now)
+		status.LastFailedBackup = status.Name
+		status.LastFailedTime = &failTime
+		status.Name = ""
+		cc.recorder.Event(
+			c,
+			corev1.EventTypeWarning,
+			ErrSyncFailed,
+			fmt.Sprintf(MessageBackupFailed, status.LastFailedBackup),
+		)
+		return nil
+	}
+
+	logger.Infof("Finished backup %s of cluster %s", status.Name, c.Name)
+	finishTime := metav1.NewTime(now)
+	status.LastSuccessfulBackup = status.Name
+	status.LastSuccessfulTime = &finishTime
+	status.Name = ""
+	cc.recorder.Event(
+		c,
+		corev1.EventTypeNormal,
+		SuccessSynced,
+		fmt.Sprintf(MessageBackupFinished, status.LastSuccessfulBackup),
+	)
+
+	// A failure to prune the old backups doesn't fail the backup
+	if err := cc.pruneBackups(c); err != nil {
+		logger.Warningf("Error pruning the backups of cluster %s: %s", c.Name, err.Error())
+		cc.recorder.Event(
+			c,
+			corev1.EventTypeWarning,
+			ErrSyncFailed,
+			MessageBackupPruneFailed,
+		)
+	}
+	return nil
+}
+
+// backupDeadline returns the time the members have to upload their data
+// once a backup started.
+func backupDeadline(spec *cassandrav1alpha1.BackupSpec) time.Duration {
+	if spec.DeadlineSeconds > 0 {
+		return time.Duration(spec.DeadlineSeconds) * time.Second
+	}
+	return defaultBackupDeadline
+}
+
+// pruneBackups deletes the oldest backups of the given Cassandra Cluster,
+// so that only the number of backups of the retention setting is kept.
+func (cc *ClusterController) pruneBackups(c *cassandrav1alpha1.Cluster) error {
+	retention := c.Spec.Backup.Retention
+	if retention <= 0 {
+		return nil
+	}
+
+	client, err := util.S3ClientForSpec(c.Spec.Backup.S3, c.Namespace, cc.kubeClient)
+	if err != nil {
+		return err
+	}
+	prefix := util.BackupPrefix(c.Namespace, c.Name)
+	keys, err := client.List(prefix)
+	if err != nil {
+		return err
+	}
+
+	names := util.BackupNames(keys, prefix)
+	if len(names) <= retention {
+		return nil
+	}
+	for _, name := range names[:len(names)-retention] {
+		logger.Infof("Deleting backup %s of cluster %s", name, c.Name)
+		backupPrefix := util.BackupKeyPrefix(c.Namespace, c.Name, name)
+		for _, key := range keys {
+			if !strings.HasPrefix(key, backupPrefix) {
+				continue
+			}
+			if err := client.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// syncRestore seeds a new Cassandra Cluster with the data of the backup of
+// the restoreFrom settings, once all its members are ready. The schema of the
+// backup is created through a single member first, then every member loads
+// the data uploaded by the member with the same index in the same rack.
+func (cc *ClusterController) syncRestore(c *cassandrav1alpha1.Cluster) error {
+	if c.Spec.RestoreFrom == nil || (c.Status.Restore != nil && c.Status.Restore.Finished) {
+		return nil
+	}
+
+	if c.Status.Restore == nil {
+		if !clusterReady(c) || !superuserReady(c) {
+			logger.Infof("Cluster %s is not ready, waiting to restore backup %s", c.Name, c.Spec.RestoreFrom.Backup)
+			return nil
+		}
+		c.Status.Restore = &cassandrav1alpha1.RestoreStatus{Backup: c.Spec.RestoreFrom.Backup}
+		cc.recorder.Event(
+			c,
+			corev1.EventTypeNormal,
+			SuccessSynced,
+			fmt.Sprintf(MessageRestoreStarted, c.Status.Restore.Backup),
+		)
+	}
+	status := c.Status.Restore
+
+	members, err := util.GetMemberServicesForCluster(c, cc.serviceLister)
+	if err != nil {
+		return fmt.Errorf("error trying to get member services: %s", err.Error())
+	}
+	if len(members) == 0 {
+		return nil
+	}
+
+	// Create the schema through the first member
+	if !status.SchemaRestored {
+		switch members[0].Labels[constants.RestoreSchemaLabel] {
+		case constants.LabelValueTrue:
+			status.SchemaRestored = true
+		case constants.LabelValueFalse:
+			logger.Infof("Waiting for member %s to create the schema of backup %s", members[0].Name, status.Backup)
+			return nil
+		default:
+			logger.Infof("Member %s will create the schema of backup %s", members[0].Name, status.Backup)
+			return cc.requestMemberAction(members[0], constants.RestoreSchemaLabel)
+		}
+	}
+
+	// Then, load the data through every member
+	done := true
+	for _, member := range members {
+		switch member.Labels[constants.RestoreLabel] {
+		case constants.LabelValueTrue:
+		case constants.LabelValueFalse:
+			done = false
+		default:
+			logger.Infof("Member %s will load its data from backup %s", member.Name, status.Backup)
+			if err := cc.requestMemberAction(member, constants.RestoreLabel); err != nil {
+				return err
+			}
+			done = false
+		}
+	}
+	if !done {
+		return nil
+	}
+
+	logger.Infof("Restored backup %s to cluster %s", status.Backup, c.Name)
+	status.Finished = true
+	cc.recorder.Event(
+		c,
+		corev1.EventTypeNormal,
+		SuccessSynced,
+		fmt.Sprintf(MessageRestoreFinished, status.Backup),
+	)
+	return nil
+}
+
+// requestBackup records the intent of the member to upload its data to the
+// given backup.
+func (cc *ClusterController) requestBackup(memberService *corev1.Service, name string) error {
+	updated := memberService.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Labels[constants.BackupLabel] = constants.LabelValueFalse
+	updated.Annotations[constants.BackupNameAnnotation] = name
+	if err := util.PatchService(memberService, updated, cc.kubeClient); err != nil {
+		return fmt.Errorf("error patching member service %s: %s", memberService.Name, err.Error())
+	}
+	return nil
+}
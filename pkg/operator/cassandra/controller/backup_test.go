@@ -0,0 +1,196 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
+	"github.com/rook/rook/pkg/operator/cassandra/constants"
+	casstest "github.com/rook/rook/pkg/operator/cassandra/test"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// syncMembers runs the given sync with the member services of the previous
+// sync and returns the member services after the sync.
+func syncMembers(t *testing.T, c *cassandrav1alpha1.Cluster, services []runtime.Object, sync func(cc *ClusterController) error) []runtime.Object {
+	cc := newFakeClusterController(services, []runtime.Object{c})
+	require.NoError(t, sync(cc))
+
+	list, err := cc.kubeClient.CoreV1().Services(c.Namespace).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	services = []runtime.Object{}
+	for i := range list.Items {
+		services = append(services, &list.Items[i])
+	}
+	return services
+}
+
+// memberService returns the member service with the given name.
+func memberService(t *testing.T, services []runtime.Object, name string) *corev1.Service {
+	for _, obj := range services {
+		if svc := obj.(*corev1.Service); svc.Name == name {
+			return svc
+		}
+	}
+	t.Fatalf("member service %s not found", name)
+	return nil
+}
+
+// setMemberLabel sets the given label of all the member services.
+func setMemberLabel(services []runtime.Object, label, value string) {
+	for _, obj := range services {
+		obj.(*corev1.Service).Labels[label] = value
+	}
+}
+
+func TestSyncBackup(t *testing.T) {
+
+	c := casstest.NewSimpleCluster(2)
+	dc := c.Spec.Datacenters[0]
+	c.CreationTimestamp = metav1.NewTime(time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC))
+	c.Spec.Backup = &cassandrav1alpha1.BackupSpec{
+		Schedule: "0 3 * * *",
+		S3: cassandrav1alpha1.S3Spec{
+			Endpoint:   "http://minio:9000",
+			Bucket:     "cassandra-backups",
+			SecretName: "backup-credentials",
+		},
+	}
+	c.Status.SetRackStatus(dc.Name, dc.Racks[0].Name, &cassandrav1alpha1.RackStatus{Members: 2, ReadyMembers: 2})
+
+	services := casstest.MemberServicesForCluster(c)
+	sync := func(now time.Time) {
+		services = syncMembers(t, c, services, func(cc *ClusterController) error {
+			return cc.syncBackup(c, now)
+		})
+	}
+
+	// not due yet
+	sync(time.Date(2019, 5, 1, 2, 0, 0, 0, time.UTC))
+	require.Equal(t, "", c.Status.Backup.Name)
+
+	// all the members back up at the same time
+	start := time.Date(2019, 5, 1, 3, 0, 0, 0, time.UTC)
+	sync(start)
+	require.Equal(t, "20190501-030000", c.Status.Backup.Name)
+	require.Equal(t, start, c.Status.Backup.LastScheduleTime.Time)
+	for _, obj := range services {
+		svc := obj.(*corev1.Service)
+		require.Equal(t, constants.LabelValueFalse, svc.Labels[constants.BackupLabel])
+		require.Equal(t, "20190501-030000", svc.Annotations[constants.BackupNameAnnotation])
+	}
+
+	// the backup is in progress until all the members uploaded their data
+	services[0].(*corev1.Service).Labels[constants.BackupLabel] = constants.LabelValueTrue
+	sync(start.Add(time.Minute))
+	require.Equal(t, "20190501-030000", c.Status.Backup.Name)
+	require.Equal(t, "", c.Status.Backup.LastSuccessfulBackup)
+
+	end := start.Add(time.Hour)
+	setMemberLabel(services, constants.BackupLabel, constants.LabelValueTrue)
+	sync(end)
+	require.Equal(t, "", c.Status.Backup.Name)
+	require.Equal(t, "20190501-030000", c.Status.Backup.LastSuccessfulBackup)
+	require.Equal(t, end, c.Status.Backup.LastSuccessfulTime.Time)
+
+	// the next backup waits for the members to be ready
+	c.Status.GetRackStatus(dc.Name, dc.Racks[0].Name).ReadyMembers = 1
+	day2 := time.Date(2019, 5, 2, 3, 0, 0, 0, time.UTC)
+	sync(day2)
+	require.Equal(t, "", c.Status.Backup.Name)
+	c.Status.GetRackStatus(dc.Name, dc.Racks[0].Name).ReadyMembers = 2
+	sync(day2)
+	require.Equal(t, "20190502-030000", c.Status.Backup.Name)
+	for _, obj := range services {
+		require.Equal(t, constants.LabelValueFalse, obj.(*corev1.Service).Labels[constants.BackupLabel])
+	}
+
+	// a backup that isn't uploaded by its deadline fails
+	sync(day2.Add(11 * time.Hour))
+	require.Equal(t, "20190502-030000", c.Status.Backup.Name)
+	failed := day2.Add(defaultBackupDeadline)
+	sync(failed)
+	require.Equal(t, "", c.Status.Backup.Name)
+	require.Equal(t, "20190502-030000", c.Status.Backup.LastFailedBackup)
+	require.Equal(t, failed, c.Status.Backup.LastFailedTime.Time)
+	require.Equal(t, "20190501-030000", c.Status.Backup.LastSuccessfulBackup)
+
+	// and the schedule continues
+	day3 := time.Date(2019, 5, 3, 3, 0, 0, 0, time.UTC)
+	sync(day3)
+	require.Equal(t, "20190503-030000", c.Status.Backup.Name)
+	for _, obj := range services {
+		require.Equal(t, "20190503-030000", obj.(*corev1.Service).Annotations[constants.BackupNameAnnotation])
+	}
+}
+
+func TestSyncRestore(t *testing.T) {
+
+	c := casstest.NewSimpleCluster(2)
+	dc := c.Spec.Datacenters[0]
+	c.Spec.RestoreFrom = &cassandrav1alpha1.RestoreSpec{
+		Cluster: "old-cluster",
+		Backup:  "20190501-030000",
+		S3: cassandrav1alpha1.S3Spec{
+			Endpoint:   "http://minio:9000",
+			Bucket:     "cassandra-backups",
+			SecretName: "backup-credentials",
+		},
+	}
+	c.Status.SetRackStatus(dc.Name, dc.Racks[0].Name, &cassandrav1alpha1.RackStatus{Members: 2, ReadyMembers: 1})
+
+	services := casstest.MemberServicesForCluster(c)
+	sync := func() {
+		services = syncMembers(t, c, services, func(cc *ClusterController) error {
+			return cc.syncRestore(c)
+		})
+	}
+	members := []string{"test-cluster-test-dc-test-rack-0", "test-cluster-test-dc-test-rack-1"}
+	label := func(i int, label string) string {
+		return memberService(t, services, members[i]).Labels[label]
+	}
+
+	// the restore waits for the cluster to be ready
+	sync()
+	require.Nil(t, c.Status.Restore)
+	c.Status.GetRackStatus(dc.Name, dc.Racks[0].Name).ReadyMembers = 2
+
+	// the schema is created through a single member
+	sync()
+	require.Equal(t, "20190501-030000", c.Status.Restore.Backup)
+	require.Equal(t, constants.LabelValueFalse, label(0, constants.RestoreSchemaLabel))
+	require.Equal(t, "", label(1, constants.RestoreSchemaLabel))
+	sync()
+	require.False(t, c.Status.Restore.SchemaRestored)
+
+	// then every member loads its data
+	memberService(t, services, members[0]).Labels[constants.RestoreSchemaLabel] = constants.LabelValueTrue
+	sync()
+	require.True(t, c.Status.Restore.SchemaRestored)
+	require.Equal(t, constants.LabelValueFalse, label(0, constants.RestoreLabel))
+	require.Equal(t, constants.LabelValueFalse, label(1, constants.RestoreLabel))
+	require.False(t, c.Status.Restore.Finished)
+
+	setMemberLabel(services, constants.RestoreLabel, constants.LabelValueTrue)
+	sync()
+	require.True(t, c.Status.Restore.Finished)
+}
@@ -27,35 +27,37 @@ import (
 // cleanup deletes all resources remaining because of cluster scale downs
 func (cc *ClusterController) cleanup(c *cassandrav1alpha1.Cluster) error {
 
-	for _, r := range c.Spec.Datacenter.Racks {
-		services, err := cc.serviceLister.Services(c.Namespace).List(util.RackSelector(r, c))
-		if err != nil {
-			return fmt.Errorf("error listing member services: %s", err.Error())
-		}
-		// Get rack status. If it doesn't exist, the rack isn't yet created.
-		stsName := util.StatefulSetNameForRack(r, c)
-		sts, err := cc.statefulSetLister.StatefulSets(c.Namespace).Get(stsName)
-		if apierrors.IsNotFound(err) {
-			continue
-		}
-		if err != nil {
-			return fmt.Errorf("error getting statefulset %s: %s", stsName, err.Error())
-		}
-		memberCount := *sts.Spec.Replicas
-		memberServiceCount := int32(len(services))
-		// If there are more services than members, some services need to be cleaned up
-		if memberServiceCount > memberCount {
-			maxIndex := memberCount - 1
-			for _, svc := range services {
-				svcIndex, err := util.IndexFromName(svc.Name)
-				if err != nil {
-					logger.Errorf("Unexpected error while parsing index from name %s : %s", svc.Name, err.Error())
-					continue
-				}
-				if svcIndex > maxIndex {
-					err := cc.cleanupMemberResources(svc.Name, r, c)
+	for _, dc := range c.Spec.GetDatacenters() {
+		for _, r := range dc.Racks {
+			services, err := cc.serviceLister.Services(c.Namespace).List(util.RackSelector(r, dc, c))
+			if err != nil {
+				return fmt.Errorf("error listing member services: %s", err.Error())
+			}
+			// Get rack status. If it doesn't exist, the rack isn't yet created.
+			stsName := util.StatefulSetNameForRack(r, dc, c)
+			sts, err := cc.statefulSetLister.StatefulSets(c.Namespace).Get(stsName)
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("error getting statefulset %s: %s", stsName, err.Error())
+			}
+			memberCount := *sts.Spec.Replicas
+			memberServiceCount := int32(len(services))
+			// If there are more services than members, some services need to be cleaned up
+			if memberServiceCount > memberCount {
+				maxIndex := memberCount - 1
+				for _, svc := range services {
+					svcIndex, err := util.IndexFromName(svc.Name)
 					if err != nil {
-						return fmt.Errorf("error cleaning up member resources: %s", err.Error())
+						logger.Errorf("Unexpected error while parsing index from name %s : %s", svc.Name, err.Error())
+						continue
+					}
+					if svcIndex > maxIndex {
+						err := cc.cleanupMemberResources(svc.Name, r, c)
+						if err != nil {
+							return fmt.Errorf("error cleaning up member resources: %s", err.Error())
+						}
 					}
 				}
 			}
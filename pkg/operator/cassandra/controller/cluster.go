@@ -31,57 +31,81 @@ import (
 // That will be done at the end of the sync loop.
 func (cc *ClusterController) updateStatus(c *cassandrav1alpha1.Cluster) error {
 	clusterStatus := cassandrav1alpha1.ClusterStatus{
-		Racks: map[string]*cassandrav1alpha1.RackStatus{},
+		Datacenters: map[string]*cassandrav1alpha1.DatacenterStatus{},
+		// The statuses of the repairs, upgrades, backups, restores and superuser are updated by them
+		Repair:           c.Status.Repair,
+		Upgrade:          c.Status.Upgrade,
+		Backup:           c.Status.Backup,
+		Restore:          c.Status.Restore,
+		SuperuserCreated: c.Status.SuperuserCreated,
 	}
 	logger.Infof("Updating Status for cluster %s in namespace %s", c.Name, c.Namespace)
 
-	for _, rack := range c.Spec.Datacenter.Racks {
+	for _, dc := range c.Spec.GetDatacenters() {
+		for _, rack := range dc.Racks {
 
-		status := &cassandrav1alpha1.RackStatus{}
+			status := &cassandrav1alpha1.RackStatus{}
 
-		// Get corresponding StatefulSet from lister
-		sts, err := cc.statefulSetLister.StatefulSets(c.Namespace).
-			Get(util.StatefulSetNameForRack(rack, c))
-		// If it wasn't found, continue
-		if apierrors.IsNotFound(err) {
-			continue
-		}
-		// If we got a different error, requeue and log it
-		if err != nil {
-			return fmt.Errorf("error trying to get StatefulSet %s in namespace %s: %s", sts.Name, sts.Namespace, err.Error())
-		}
+			// Get corresponding StatefulSet from lister
+			sts, err := cc.statefulSetLister.StatefulSets(c.Namespace).
+				Get(util.StatefulSetNameForRack(rack, dc, c))
+			// If it wasn't found, continue
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			// If we got a different error, requeue and log it
+			if err != nil {
+				return fmt.Errorf("error trying to get StatefulSet %s in namespace %s: %s", sts.Name, sts.Namespace, err.Error())
+			}
 
-		// Update Members
-		status.Members = *sts.Spec.Replicas
-		// Update ReadyMembers
-		status.ReadyMembers = sts.Status.ReadyReplicas
+			// Update Members
+			status.Members = *sts.Spec.Replicas
+			// Update ReadyMembers
+			status.ReadyMembers = sts.Status.ReadyReplicas
 
-		// Update Scaling Down condition
-		services, err := util.GerMemberServicesForRack(rack, c, cc.serviceLister)
-		if err != nil {
-			return fmt.Errorf("error trying to get Pods for rack %s", rack.Name)
-		}
-		for _, svc := range services {
-			// Check if there is a decommission in progress
-			if _, ok := svc.Labels[constants.DecommissionLabel]; ok {
-				// Add MemberLeaving Condition to rack status
-				status.Conditions = append(status.Conditions, cassandrav1alpha1.RackCondition{
-					Type:   cassandrav1alpha1.RackConditionTypeMemberLeaving,
-					Status: cassandrav1alpha1.ConditionTrue,
-				})
-				// Sanity check. Only the last member should be decommissioning.
-				index, err := util.IndexFromName(svc.Name)
-				if err != nil {
-					return err
+			// Update Scaling Down, Rebuilding and Replacing conditions
+			services, err := util.GerMemberServicesForRack(rack, dc, c, cc.serviceLister)
+			if err != nil {
+				return fmt.Errorf("error trying to get Pods for rack %s", rack.Name)
+			}
+			for _, svc := range services {
+				// Check if there is a rebuild in progress
+				if svc.Labels[constants.RebuildLabel] == constants.LabelValueFalse &&
+					!util.IsRackConditionTrue(status, cassandrav1alpha1.RackConditionTypeMemberRebuilding) {
+					status.Conditions = append(status.Conditions, cassandrav1alpha1.RackCondition{
+						Type:   cassandrav1alpha1.RackConditionTypeMemberRebuilding,
+						Status: cassandrav1alpha1.ConditionTrue,
+					})
 				}
-				if index != status.Members-1 {
-					return fmt.Errorf("only last member of each rack should be decommissioning, but %d-th member of %s found decommissioning while rack had %d members", index, rack.Name, status.Members)
+				// Check if a member that lost its data is replacing itself
+				if svc.Labels[constants.ReplaceLabel] == constants.LabelValueFalse &&
+					!util.IsRackConditionTrue(status, cassandrav1alpha1.RackConditionTypeMemberReplacing) {
+					status.Conditions = append(status.Conditions, cassandrav1alpha1.RackCondition{
+						Type:   cassandrav1alpha1.RackConditionTypeMemberReplacing,
+						Status: cassandrav1alpha1.ConditionTrue,
+					})
+				}
+				// Check if there is a decommission in progress
+				if _, ok := svc.Labels[constants.DecommissionLabel]; ok {
+					// Add MemberLeaving Condition to rack status
+					status.Conditions = append(status.Conditions, cassandrav1alpha1.RackCondition{
+						Type:   cassandrav1alpha1.RackConditionTypeMemberLeaving,
+						Status: cassandrav1alpha1.ConditionTrue,
+					})
+					// Sanity check. Only the last member should be decommissioning.
+					index, err := util.IndexFromName(svc.Name)
+					if err != nil {
+						return err
+					}
+					if index != status.Members-1 {
+						return fmt.Errorf("only last member of each rack should be decommissioning, but %d-th member of %s found decommissioning while rack had %d members", index, rack.Name, status.Members)
+					}
 				}
 			}
-		}
 
-		// Update Status for Rack
-		clusterStatus.Racks[rack.Name] = status
+			// Update Status for Rack
+			clusterStatus.SetRackStatus(dc.Name, rack.Name, status)
+		}
 	}
 
 	c.Status = clusterStatus
@@ -91,50 +115,75 @@ func (cc *ClusterController) updateStatus(c *cassandrav1alpha1.Cluster) error {
 // SyncCluster checks the Status and performs reconciliation for
 // the given Cassandra Cluster.
 func (cc *ClusterController) syncCluster(c *cassandrav1alpha1.Cluster) error {
+	dcs := c.Spec.GetDatacenters()
+
 	// Check if any rack isn't created
-	for _, rack := range c.Spec.Datacenter.Racks {
-		// For each rack, check if a status entry exists
-		if _, ok := c.Status.Racks[rack.Name]; !ok {
-			logger.Infof("Attempting to create Rack %s", rack.Name)
-			err := cc.createRack(rack, c)
-			return err
+	for _, dc := range dcs {
+		for _, rack := range dc.Racks {
+			// For each rack, check if a status entry exists
+			if c.Status.GetRackStatus(dc.Name, rack.Name) == nil {
+				logger.Infof("Attempting to create Rack %s of datacenter %s", rack.Name, dc.Name)
+				err := cc.createRack(rack, dc, c)
+				return err
+			}
 		}
 	}
 
 	// Check if there is a scale-down in progress
-	for _, rack := range c.Spec.Datacenter.Racks {
-		if util.IsRackConditionTrue(c.Status.Racks[rack.Name], cassandrav1alpha1.RackConditionTypeMemberLeaving) {
-			// Resume scale down
-			err := cc.scaleDownRack(rack, c)
-			return err
+	for _, dc := range dcs {
+		for _, rack := range dc.Racks {
+			if util.IsRackConditionTrue(c.Status.GetRackStatus(dc.Name, rack.Name), cassandrav1alpha1.RackConditionTypeMemberLeaving) {
+				// Resume scale down
+				err := cc.scaleDownRack(rack, dc, c)
+				return err
+			}
 		}
 	}
 
+	// Check if there is a version upgrade to perform
+	if upgrading, err := cc.syncUpgrade(c); err != nil || upgrading {
+		return err
+	}
+
 	// Check that all racks are ready before taking any action
-	for _, rack := range c.Spec.Datacenter.Racks {
-		rackStatus := c.Status.Racks[rack.Name]
-		if rackStatus.Members != rackStatus.ReadyMembers {
-			logger.Infof("Rack %s is not ready, %+v", rack.Name, *rackStatus)
-			return nil
+	for _, dc := range dcs {
+		for _, rack := range dc.Racks {
+			rackStatus := c.Status.GetRackStatus(dc.Name, rack.Name)
+			if rackStatus.Members != rackStatus.ReadyMembers {
+				logger.Infof("Rack %s of datacenter %s is not ready, %+v", rack.Name, dc.Name, *rackStatus)
+				return nil
+			}
 		}
 	}
 
 	// Check if any rack needs to scale down
-	for _, rack := range c.Spec.Datacenter.Racks {
-		if rack.Members < c.Status.Racks[rack.Name].Members {
-			// scale down
-			err := cc.scaleDownRack(rack, c)
-			return err
+	for _, dc := range dcs {
+		for _, rack := range dc.Racks {
+			if rack.Members < c.Status.GetRackStatus(dc.Name, rack.Name).Members {
+				// scale down
+				err := cc.scaleDownRack(rack, dc, c)
+				return err
+			}
 		}
 	}
 
 	// Check if any rack needs to scale up
-	for _, rack := range c.Spec.Datacenter.Racks {
+	for _, dc := range dcs {
+		for _, rack := range dc.Racks {
+			if rack.Members > c.Status.GetRackStatus(dc.Name, rack.Name).Members {
+				logger.Infof("Attempting to scale rack %s of datacenter %s", rack.Name, dc.Name)
+				err := cc.scaleUpRack(rack, dc, c)
+				return err
+			}
+		}
+	}
 
-		if rack.Members > c.Status.Racks[rack.Name].Members {
-			logger.Infof("Attempting to scale rack %s", rack.Name)
-			err := cc.scaleUpRack(rack, c)
-			return err
+	// Once all the members of a new datacenter are ready, rebuild its data
+	for _, dc := range dcs {
+		if dc.RebuildFrom != "" {
+			if err := cc.rebuildDatacenter(dc, c); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -142,8 +191,8 @@ func (cc *ClusterController) syncCluster(c *cassandrav1alpha1.Cluster) error {
 }
 
 // createRack creates a new Cassandra Rack with 0 Members.
-func (cc *ClusterController) createRack(r cassandrav1alpha1.RackSpec, c *cassandrav1alpha1.Cluster) error {
-	sts := util.StatefulSetForRack(r, c, cc.rookImage)
+func (cc *ClusterController) createRack(r cassandrav1alpha1.RackSpec, dc cassandrav1alpha1.DatacenterSpec, c *cassandrav1alpha1.Cluster) error {
+	sts := util.StatefulSetForRack(r, dc, c, cc.rookImage)
 	c.Spec.Annotations.Merge(r.Annotations).ApplyToObjectMeta(&sts.Spec.Template.ObjectMeta)
 	c.Spec.Annotations.Merge(r.Annotations).ApplyToObjectMeta(&sts.ObjectMeta)
 	existingStatefulset, err := cc.statefulSetLister.StatefulSets(sts.Namespace).Get(sts.Name)
@@ -174,8 +223,8 @@ func (cc *ClusterController) createRack(r cassandrav1alpha1.RackSpec, c *cassand
 
 // scaleUpRack handles scaling up for an existing Cassandra Rack.
 // Calling this action implies all members of the Rack are Ready.
-func (cc *ClusterController) scaleUpRack(r cassandrav1alpha1.RackSpec, c *cassandrav1alpha1.Cluster) error {
-	sts, err := cc.statefulSetLister.StatefulSets(c.Namespace).Get(util.StatefulSetNameForRack(r, c))
+func (cc *ClusterController) scaleUpRack(r cassandrav1alpha1.RackSpec, dc cassandrav1alpha1.DatacenterSpec, c *cassandrav1alpha1.Cluster) error {
+	sts, err := cc.statefulSetLister.StatefulSets(c.Namespace).Get(util.StatefulSetNameForRack(r, dc, c))
 	if err != nil {
 		return fmt.Errorf("error trying to scale rack %s in namespace %s, underlying StatefulSet not found", r.Name, c.Namespace)
 	}
@@ -199,14 +248,14 @@ func (cc *ClusterController) scaleUpRack(r cassandrav1alpha1.RackSpec, c *cassan
 
 // scaleDownRack handles scaling down for an existing Cassandra Rack.
 // Calling this action implies all members of the Rack are Ready.
-func (cc *ClusterController) scaleDownRack(r cassandrav1alpha1.RackSpec, c *cassandrav1alpha1.Cluster) error {
-	logger.Infof("Scaling down rack %s", r.Name)
+func (cc *ClusterController) scaleDownRack(r cassandrav1alpha1.RackSpec, dc cassandrav1alpha1.DatacenterSpec, c *cassandrav1alpha1.Cluster) error {
+	logger.Infof("Scaling down rack %s of datacenter %s", r.Name, dc.Name)
 
 	// Get the current actual number of Members
-	members := c.Status.Racks[r.Name].Members
+	members := c.Status.GetRackStatus(dc.Name, r.Name).Members
 
 	// Find the member to decommission
-	memberName := fmt.Sprintf("%s-%d", util.StatefulSetNameForRack(r, c), members-1)
+	memberName := fmt.Sprintf("%s-%d", util.StatefulSetNameForRack(r, dc, c), members-1)
 	logger.Infof("Member of interest: %s", memberName)
 	memberService, err := cc.serviceLister.Services(c.Namespace).Get(memberName)
 	if err != nil {
@@ -219,7 +268,7 @@ func (cc *ClusterController) scaleDownRack(r cassandrav1alpha1.RackSpec, c *cass
 		logger.Infof("Found decommissioned member: %s", memberName)
 
 		// Get rack's statefulset
-		stsName := util.StatefulSetNameForRack(r, c)
+		stsName := util.StatefulSetNameForRack(r, dc, c)
 		sts, err := cc.statefulSetLister.StatefulSets(c.Namespace).Get(stsName)
 		if err != nil {
 			return fmt.Errorf("error trying to get StatefulSet %s", stsName)
@@ -240,9 +289,9 @@ func (cc *ClusterController) scaleDownRack(r cassandrav1alpha1.RackSpec, c *cass
 		return nil
 	}
 
-	logger.Infof("Checking for scale down. Desired: %d. Actual: %d", r.Members, c.Status.Racks[r.Name].Members)
+	logger.Infof("Checking for scale down. Desired: %d. Actual: %d", r.Members, members)
 	// Then, check if there is a requested scale down.
-	if r.Members < c.Status.Racks[r.Name].Members {
+	if r.Members < members {
 
 		logger.Infof("Scale down requested, member %s will decommission", memberName)
 		// Record the intent to decommission the member
@@ -262,3 +311,44 @@ func (cc *ClusterController) scaleDownRack(r cassandrav1alpha1.RackSpec, c *cass
 
 	return nil
 }
+
+// rebuildDatacenter records the intent to rebuild every member of a datacenter
+// that was added with the rebuildFrom field. The members stream their data from
+// the given datacenter. Only the members that joined without bootstrapping are
+// rebuilt, the members added after the rebuild bootstrap normally.
+// Calling this action implies all members of the Cluster are Ready.
+func (cc *ClusterController) rebuildDatacenter(dc cassandrav1alpha1.DatacenterSpec, c *cassandrav1alpha1.Cluster) error {
+	if dc.RebuildFrom == dc.Name || c.Spec.GetDatacenter(dc.RebuildFrom) == nil {
+		return fmt.Errorf("datacenter %s can't be rebuilt from unknown datacenter %s", dc.Name, dc.RebuildFrom)
+	}
+
+	services, err := util.GetMemberServicesForDatacenter(dc, c, cc.serviceLister)
+	if err != nil {
+		return fmt.Errorf("error trying to get member services of datacenter %s: %s", dc.Name, err.Error())
+	}
+	for _, svc := range services {
+		// Members that rebuilt or are rebuilding already have the label
+		if _, ok := svc.Labels[constants.RebuildLabel]; ok {
+			continue
+		}
+		if _, ok := svc.Labels[constants.SkipBootstrapLabel]; !ok {
+			continue
+		}
+
+		logger.Infof("Member %s will rebuild from datacenter %s", svc.Name, dc.RebuildFrom)
+		memberService := svc.DeepCopy()
+		memberService.Labels[constants.RebuildLabel] = constants.LabelValueFalse
+		if err := util.PatchService(svc, memberService, cc.kubeClient); err != nil {
+			return fmt.Errorf("error patching member service %s: %s", svc.Name, err.Error())
+		}
+
+		cc.recorder.Event(
+			c,
+			corev1.EventTypeNormal,
+			SuccessSynced,
+			fmt.Sprintf(MessageMemberRebuildStarted, svc.Name, dc.RebuildFrom),
+		)
+	}
+
+	return nil
+}
@@ -26,12 +26,14 @@ import (
 	casstest "github.com/rook/rook/pkg/operator/cassandra/test"
 	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
 func TestCreateRack(t *testing.T) {
 	simpleCluster := casstest.NewSimpleCluster(3)
+	dc := simpleCluster.Spec.Datacenters[0]
 
 	tests := []struct {
 		name        string
@@ -43,16 +45,16 @@ func TestCreateRack(t *testing.T) {
 		{
 			name:        "new rack",
 			kubeObjects: nil,
-			rack:        simpleCluster.Spec.Datacenter.Racks[0],
+			rack:        dc.Racks[0],
 			cluster:     simpleCluster,
 			expectedErr: false,
 		},
 		{
 			name: "sts already exists",
 			kubeObjects: []runtime.Object{
-				util.StatefulSetForRack(simpleCluster.Spec.Datacenter.Racks[0], simpleCluster, ""),
+				util.StatefulSetForRack(dc.Racks[0], dc, simpleCluster, ""),
 			},
-			rack:        simpleCluster.Spec.Datacenter.Racks[0],
+			rack:        dc.Racks[0],
 			cluster:     simpleCluster,
 			expectedErr: false,
 		},
@@ -61,14 +63,14 @@ func TestCreateRack(t *testing.T) {
 			kubeObjects: []runtime.Object{
 				&appsv1.StatefulSet{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:            util.StatefulSetNameForRack(simpleCluster.Spec.Datacenter.Racks[0], simpleCluster),
+						Name:            util.StatefulSetNameForRack(dc.Racks[0], dc, simpleCluster),
 						Namespace:       simpleCluster.Namespace,
 						OwnerReferences: nil,
 					},
 					Spec: appsv1.StatefulSetSpec{},
 				},
 			},
-			rack:        simpleCluster.Spec.Datacenter.Racks[0],
+			rack:        dc.Racks[0],
 			cluster:     simpleCluster,
 			expectedErr: true,
 		},
@@ -78,14 +80,14 @@ func TestCreateRack(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			cc := newFakeClusterController(test.kubeObjects, nil)
 
-			if err := cc.createRack(test.rack, test.cluster); err == nil {
+			if err := cc.createRack(test.rack, dc, test.cluster); err == nil {
 				if test.expectedErr {
 					t.Errorf("Expected an error, got none.")
 				} else {
 
 					var sts *appsv1.StatefulSet
 					sts, err = cc.kubeClient.AppsV1().StatefulSets(test.cluster.Namespace).
-						Get(util.StatefulSetNameForRack(test.rack, test.cluster), metav1.GetOptions{})
+						Get(util.StatefulSetNameForRack(test.rack, dc, test.cluster), metav1.GetOptions{})
 					if err != nil {
 						t.Errorf("Couldn't retrieve expected StatefulSet: %s", err.Error())
 					} else {
@@ -108,8 +110,9 @@ func TestScaleUpRack(t *testing.T) {
 	currMembers := int32(2)
 	expMembers := int32(3)
 	c := casstest.NewSimpleCluster(expMembers)
-	r := c.Spec.Datacenter.Racks[0]
-	sts := util.StatefulSetForRack(r, c, "")
+	dc := c.Spec.Datacenters[0]
+	r := dc.Racks[0]
+	sts := util.StatefulSetForRack(r, dc, c, "")
 	*sts.Spec.Replicas = currMembers
 
 	tests := []struct {
@@ -143,19 +146,16 @@ func TestScaleUpRack(t *testing.T) {
 
 			cc := newFakeClusterController(test.kubeObjects, nil)
 
-			test.cluster.Status = cassandrav1alpha1.ClusterStatus{
-				Racks: map[string]*cassandrav1alpha1.RackStatus{
-					"test-rack": test.rackStatus,
-				},
-			}
-			err := cc.scaleUpRack(test.rack, test.cluster)
+			test.cluster.Status = cassandrav1alpha1.ClusterStatus{}
+			test.cluster.Status.SetRackStatus(dc.Name, "test-rack", test.rackStatus)
+			err := cc.scaleUpRack(test.rack, dc, test.cluster)
 
 			if err == nil {
 				if test.expectedErr {
 					t.Errorf("Expected an error, got none.")
 				} else {
 					sts, err := cc.kubeClient.AppsV1().StatefulSets(test.cluster.Namespace).
-						Get(util.StatefulSetNameForRack(test.rack, test.cluster), metav1.GetOptions{})
+						Get(util.StatefulSetNameForRack(test.rack, dc, test.cluster), metav1.GetOptions{})
 					if err != nil {
 						t.Errorf("Couldn't retrieve expected StatefulSet: %s", err.Error())
 						return
@@ -184,20 +184,18 @@ func TestScaleDownRack(t *testing.T) {
 	actual := int32(3)
 
 	c := casstest.NewSimpleCluster(desired)
-	r := c.Spec.Datacenter.Racks[0]
-	c.Status = cassandrav1alpha1.ClusterStatus{
-		Racks: map[string]*cassandrav1alpha1.RackStatus{
-			r.Name: {
-				Members:      actual,
-				ReadyMembers: actual,
-			},
-		},
-	}
-	sts := util.StatefulSetForRack(r, c, "")
+	dc := c.Spec.Datacenters[0]
+	r := dc.Racks[0]
+	c.Status = cassandrav1alpha1.ClusterStatus{}
+	c.Status.SetRackStatus(dc.Name, r.Name, &cassandrav1alpha1.RackStatus{
+		Members:      actual,
+		ReadyMembers: actual,
+	})
+	sts := util.StatefulSetForRack(r, dc, c, "")
 	memberServices := casstest.MemberServicesForCluster(c)
 
 	// Find the member to decommission
-	memberName := fmt.Sprintf("%s-%d", util.StatefulSetNameForRack(r, c), actual-1)
+	memberName := fmt.Sprintf("%s-%d", util.StatefulSetNameForRack(r, dc, c), actual-1)
 
 	t.Run("scale down requested and started", func(t *testing.T) {
 
@@ -205,7 +203,7 @@ func TestScaleDownRack(t *testing.T) {
 		rookObjects := []runtime.Object{c}
 		cc := newFakeClusterController(kubeObjects, rookObjects)
 
-		err := cc.scaleDownRack(r, c)
+		err := cc.scaleDownRack(r, dc, c)
 		require.NoErrorf(t, err, "Unexpected error while scaling down: %v", err)
 
 		// Check that MemberService has the decommissioned label
@@ -236,7 +234,7 @@ func TestScaleDownRack(t *testing.T) {
 		require.Nilf(t, err, "Unexpected error while updating MemberService: %v", err)
 
 		// Resume decommission
-		err = cc.scaleDownRack(r, c)
+		err = cc.scaleDownRack(r, dc, c)
 		require.NoErrorf(t, err, "Unexpected error while resuming scale down: %v", err)
 
 		// Check that StatefulSet is scaled
@@ -247,3 +245,126 @@ func TestScaleDownRack(t *testing.T) {
 	})
 
 }
+
+func TestRebuildDatacenter(t *testing.T) {
+
+	c := casstest.NewSimpleCluster(2)
+	analytics := cassandrav1alpha1.DatacenterSpec{
+		Name:        "analytics",
+		RebuildFrom: c.Spec.Datacenters[0].Name,
+		Racks:       []cassandrav1alpha1.RackSpec{{Name: "test-rack", Members: 2}},
+	}
+	c.Spec.Datacenters = append(c.Spec.Datacenters, analytics)
+	for _, dc := range c.Spec.Datacenters {
+		c.Status.SetRackStatus(dc.Name, "test-rack", &cassandrav1alpha1.RackStatus{Members: 2, ReadyMembers: 2})
+	}
+	memberServices := casstest.MemberServicesForCluster(c)
+	require.Len(t, memberServices, 4)
+	// the last member was added after the datacenter and bootstrapped
+	for _, obj := range memberServices[2:3] {
+		obj.(*corev1.Service).Labels[constants.SkipBootstrapLabel] = ""
+	}
+
+	t.Run("rebuild started on the new datacenter only", func(t *testing.T) {
+
+		cc := newFakeClusterController(memberServices, []runtime.Object{c})
+
+		err := cc.rebuildDatacenter(analytics, c)
+		require.NoErrorf(t, err, "Unexpected error while rebuilding datacenter: %v", err)
+
+		for _, obj := range memberServices {
+			name := obj.(*corev1.Service).Name
+			svc, err := cc.kubeClient.CoreV1().Services(c.Namespace).Get(name, metav1.GetOptions{})
+			require.NoErrorf(t, err, "Unexpected error while getting MemberService: %v", err)
+			val, ok := svc.Labels[constants.RebuildLabel]
+			if _, skipped := svc.Labels[constants.SkipBootstrapLabel]; skipped {
+				require.Truef(t, ok, "Service %s didn't have the rebuild label as expected", name)
+				require.Equal(t, constants.LabelValueFalse, val)
+			} else {
+				require.Falsef(t, ok, "Service %s of a member that bootstrapped had the rebuild label", name)
+			}
+		}
+	})
+
+	t.Run("unknown source datacenter", func(t *testing.T) {
+
+		cc := newFakeClusterController(memberServices, []runtime.Object{c})
+
+		invalid := analytics
+		invalid.RebuildFrom = "unknown"
+		err := cc.rebuildDatacenter(invalid, c)
+		require.Error(t, err)
+	})
+}
+
+func TestSyncMemberServicesSkipBootstrap(t *testing.T) {
+
+	c := casstest.NewSimpleCluster(1)
+	analytics := cassandrav1alpha1.DatacenterSpec{
+		Name:        "analytics",
+		RebuildFrom: c.Spec.Datacenters[0].Name,
+		Racks:       []cassandrav1alpha1.RackSpec{{Name: "test-rack", Members: 2}},
+	}
+	c.Spec.Datacenters = append(c.Spec.Datacenters, analytics)
+
+	podFor := func(dc cassandrav1alpha1.DatacenterSpec, i int) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%s-%s-%d", c.Name, dc.Name, dc.Racks[0].Name, i),
+				Namespace: c.Namespace,
+				Labels:    util.RackLabels(dc.Racks[0], dc, c),
+			},
+		}
+	}
+	sourcePod := podFor(c.Spec.Datacenters[0], 0)
+	firstPod := podFor(analytics, 0)
+	laterPod := podFor(analytics, 1)
+
+	t.Run("members of the new datacenter skip the bootstrap", func(t *testing.T) {
+
+		cc := newFakeClusterController([]runtime.Object{sourcePod, firstPod}, []runtime.Object{c})
+		require.NoError(t, cc.syncMemberServices(c))
+
+		svc, err := cc.kubeClient.CoreV1().Services(c.Namespace).Get(sourcePod.Name, metav1.GetOptions{})
+		require.NoError(t, err)
+		require.NotContains(t, svc.Labels, constants.SkipBootstrapLabel)
+		svc, err = cc.kubeClient.CoreV1().Services(c.Namespace).Get(firstPod.Name, metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Contains(t, svc.Labels, constants.SkipBootstrapLabel)
+	})
+
+	t.Run("members added after the rebuild bootstrap", func(t *testing.T) {
+
+		rebuilt := memberServiceForPod(firstPod, c)
+		rebuilt.Labels[constants.SkipBootstrapLabel] = ""
+		rebuilt.Labels[constants.RebuildLabel] = constants.LabelValueTrue
+		cc := newFakeClusterController([]runtime.Object{firstPod, laterPod, rebuilt}, []runtime.Object{c})
+		require.NoError(t, cc.syncMemberServices(c))
+
+		svc, err := cc.kubeClient.CoreV1().Services(c.Namespace).Get(laterPod.Name, metav1.GetOptions{})
+		require.NoError(t, err)
+		require.NotContains(t, svc.Labels, constants.SkipBootstrapLabel)
+	})
+}
+
+func TestUpdateStatusReplacing(t *testing.T) {
+
+	c := casstest.NewSimpleCluster(2)
+	dc := c.Spec.Datacenters[0]
+	r := dc.Racks[0]
+	c.Status.SetRackStatus(dc.Name, r.Name, &cassandrav1alpha1.RackStatus{Members: 2, ReadyMembers: 1})
+	sts := util.StatefulSetForRack(r, dc, c, "")
+	*sts.Spec.Replicas = 2
+	sts.Status.ReadyReplicas = 1
+	memberServices := casstest.MemberServicesForCluster(c)
+	memberServices[1].(*corev1.Service).Labels[constants.ReplaceLabel] = constants.LabelValueFalse
+
+	cc := newFakeClusterController(append(memberServices, sts), []runtime.Object{c})
+	require.NoError(t, cc.updateStatus(c))
+
+	rackStatus := c.Status.GetRackStatus(dc.Name, r.Name)
+	require.Equal(t, int32(2), rackStatus.Members)
+	require.Equal(t, int32(1), rackStatus.ReadyMembers)
+	require.True(t, util.IsRackConditionTrue(rackStatus, cassandrav1alpha1.RackConditionTypeMemberReplacing))
+	require.False(t, util.IsRackConditionTrue(rackStatus, cassandrav1alpha1.RackConditionTypeMemberLeaving))
+}
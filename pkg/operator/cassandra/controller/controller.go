@@ -30,6 +30,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	appsinformers "k8s.io/client-go/informers/apps/v1"
@@ -49,6 +50,10 @@ import (
 const (
 	controllerName   = "cassandra-controller"
 	clusterQueueName = "cluster-queue"
+
+	// scheduleCheckInterval is how often the clusters with a schedule
+	// are synced, so that their repairs and backups start on time.
+	scheduleCheckInterval = time.Minute
 )
 
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", "cassandra-controller")
@@ -187,6 +192,22 @@ func New(
 	return cc
 }
 
+// enqueueScheduledClusters enqueues the clusters that have a repair or a
+// backup schedule. The clusters are otherwise only synced when their objects
+// change.
+func (cc *ClusterController) enqueueScheduledClusters() {
+	clusters, err := cc.clusterLister.List(labels.Everything())
+	if err != nil {
+		logger.Errorf("Error listing clusters to check their schedules: %s", err.Error())
+		return
+	}
+	for _, c := range clusters {
+		if c.Spec.Repair != nil || c.Spec.Backup != nil {
+			cc.enqueueCluster(c)
+		}
+	}
+}
+
 // Run starts the ClusterController process loop
 func (cc *ClusterController) Run(threadiness int, stopCh <-chan struct{}) error {
 	defer runtime.HandleCrash()
@@ -211,6 +232,7 @@ func (cc *ClusterController) Run(threadiness int, stopCh <-chan struct{}) error
 	for i := 0; i < threadiness; i++ {
 		go wait.Until(cc.runWorker, time.Second, stopCh)
 	}
+	go wait.Until(cc.enqueueScheduledClusters, scheduleCheckInterval, stopCh)
 
 	logger.Info("started workers")
 	<-stopCh
@@ -0,0 +1,223 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
+	"github.com/rook/rook/pkg/operator/cassandra/constants"
+	"github.com/rook/rook/pkg/operator/cassandra/controller/util"
+	"github.com/rook/rook/pkg/util/cron"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// syncRepair starts the scheduled repairs of the given Cassandra Cluster
+// and drives the repair in progress. Each member repairs its primary ranges
+// of a keyspace in turn, in the order of their names, so that every range of
+// the keyspace is repaired once and only one member is repairing at a time.
+func (cc *ClusterController) syncRepair(c *cassandrav1alpha1.Cluster, now time.Time) error {
+	if c.Spec.Repair == nil {
+		if c.Status.Repair != nil && c.Status.Repair.Keyspace != "" {
+			logger.Infof("Repairs of cluster %s are disabled, abandoning the repair of keyspace %s", c.Name, c.Status.Repair.Keyspace)
+			c.Status.Repair.Keyspace = ""
+			c.Status.Repair.Member = ""
+			c.Status.Repair.RepairedMembers = 0
+		}
+		return nil
+	}
+
+	if len(c.Spec.Repair.Keyspaces) == 0 {
+		return fmt.Errorf("no keyspaces to repair in the repair settings of cluster %s", c.Name)
+	}
+	schedule, err := cron.Parse(c.Spec.Repair.Schedule)
+	if err != nil {
+		return fmt.Errorf("invalid repair schedule %s: %s", c.Spec.Repair.Schedule, err.Error())
+	}
+
+	if c.Status.Repair == nil {
+		c.Status.Repair = &cassandrav1alpha1.RepairStatus{}
+	}
+	status := c.Status.Repair
+
+	// Repairs only make progress while all the members are up
+	if !clusterReady(c) {
+		return nil
+	}
+
+	members, err := util.GetMemberServicesForCluster(c, cc.serviceLister)
+	if err != nil {
+		return fmt.Errorf("error trying to get member services: %s", err.Error())
+	}
+	if len(members) == 0 {
+		return nil
+	}
+
+	// Check if a new repair must start
+	if status.Keyspace == "" {
+		lastRun := c.CreationTimestamp.Time
+		if status.LastScheduleTime != nil {
+			lastRun = status.LastScheduleTime.Time
+		}
+		if !schedule.Due(lastRun, now) {
+			return nil
+		}
+
+		logger.Infof("Starting the scheduled repair of cluster %s", c.Name)
+		startTime := metav1.NewTime(now)
+		status.LastScheduleTime = &startTime
+		status.Keyspace = c.Spec.Repair.Keyspaces[0]
+		status.Member = members[0].Name
+		status.RepairedMembers = 0
+		cc.recorder.Event(
+			c,
+			corev1.EventTypeNormal,
+			SuccessSynced,
+			fmt.Sprintf(MessageRepairStarted, status.Keyspace),
+		)
+
+		// Members still have the labels of the previous repair
+		cleared := false
+		for _, member := range members {
+			if _, ok := member.Labels[constants.RepairLabel]; ok {
+				if err := cc.clearRepair(member); err != nil {
+					return err
+				}
+				cleared = true
+			}
+		}
+		// Wait for the cleared member services to be synced before requesting a repair
+		if cleared {
+			return nil
+		}
+	}
+
+	index := sort.Search(len(members), func(i int) bool { return members[i].Name >= status.Member })
+	if index == len(members) || members[index].Name != status.Member {
+		// The member was removed by a scale down, continue with the next one
+		logger.Infof("Member %s repairing keyspace %s not found", status.Member, status.Keyspace)
+		return cc.nextRepair(c, members, index, now)
+	}
+
+	member := members[index]
+	repair, ok := member.Labels[constants.RepairLabel]
+	if ok && member.Annotations[constants.RepairKeyspaceAnnotation] == status.Keyspace {
+		// Check if the member has finished repairing the keyspace
+		if repair == constants.LabelValueTrue {
+			logger.Infof("Member %s repaired keyspace %s", member.Name, status.Keyspace)
+			status.RepairedMembers++
+			return cc.nextRepair(c, members, index+1, now)
+		}
+		// Else, the repair is in progress
+		return nil
+	}
+
+	return cc.requestRepair(member, status.Keyspace)
+}
+
+// nextRepair moves the repair in progress on to the member with the given index,
+// or to the next keyspace once all the members have repaired the keyspace.
+func (cc *ClusterController) nextRepair(c *cassandrav1alpha1.Cluster, members []*corev1.Service, index int, now time.Time) error {
+	status := c.Status.Repair
+
+	if index < len(members) {
+		status.Member = members[index].Name
+		return cc.requestRepair(members[index], status.Keyspace)
+	}
+
+	// All the members have repaired the keyspace
+	if status.LastSuccessfulRepairs == nil {
+		status.LastSuccessfulRepairs = map[string]metav1.Time{}
+	}
+	status.LastSuccessfulRepairs[status.Keyspace] = metav1.NewTime(now)
+	cc.recorder.Event(
+		c,
+		corev1.EventTypeNormal,
+		SuccessSynced,
+		fmt.Sprintf(MessageKeyspaceRepaired, status.Keyspace),
+	)
+
+	next := 0
+	for i, keyspace := range c.Spec.Repair.Keyspaces {
+		if keyspace == status.Keyspace {
+			next = i + 1
+		}
+	}
+	status.RepairedMembers = 0
+	if next < len(c.Spec.Repair.Keyspaces) {
+		status.Keyspace = c.Spec.Repair.Keyspaces[next]
+		status.Member = members[0].Name
+		return cc.requestRepair(members[0], status.Keyspace)
+	}
+
+	logger.Infof("Finished the scheduled repair of cluster %s", c.Name)
+	status.Keyspace = ""
+	status.Member = ""
+	return nil
+}
+
+// requestRepair records the intent of the member to repair the keyspace.
+func (cc *ClusterController) requestRepair(memberService *corev1.Service, keyspace string) error {
+	logger.Infof("Member %s will repair keyspace %s", memberService.Name, keyspace)
+	updated := memberService.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Labels[constants.RepairLabel] = constants.LabelValueFalse
+	updated.Annotations[constants.RepairKeyspaceAnnotation] = keyspace
+	if err := util.PatchService(memberService, updated, cc.kubeClient); err != nil {
+		return fmt.Errorf("error patching member service %s: %s", memberService.Name, err.Error())
+	}
+	return nil
+}
+
+// clearRepair removes the repair intent of a previous repair from the member.
+func (cc *ClusterController) clearRepair(memberService *corev1.Service) error {
+	updated := memberService.DeepCopy()
+	delete(updated.Labels, constants.RepairLabel)
+	delete(updated.Annotations, constants.RepairKeyspaceAnnotation)
+	if err := util.PatchService(memberService, updated, cc.kubeClient); err != nil {
+		return fmt.Errorf("error patching member service %s: %s", memberService.Name, err.Error())
+	}
+	return nil
+}
+
+// clusterReady returns true if all the racks of the cluster are created and
+// all their members are ready, with no member leaving, rebuilding or replacing
+// and no upgrade in progress.
+func clusterReady(c *cassandrav1alpha1.Cluster) bool {
+	if c.Status.Upgrade != nil {
+		return false
+	}
+	for _, dc := range c.Spec.GetDatacenters() {
+		for _, rack := range dc.Racks {
+			rackStatus := c.Status.GetRackStatus(dc.Name, rack.Name)
+			if rackStatus == nil ||
+				rackStatus.Members != rack.Members ||
+				rackStatus.ReadyMembers != rackStatus.Members ||
+				util.IsRackConditionTrue(rackStatus, cassandrav1alpha1.RackConditionTypeMemberLeaving) ||
+				util.IsRackConditionTrue(rackStatus, cassandrav1alpha1.RackConditionTypeMemberRebuilding) ||
+				util.IsRackConditionTrue(rackStatus, cassandrav1alpha1.RackConditionTypeMemberReplacing) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
+	"github.com/rook/rook/pkg/operator/cassandra/constants"
+	casstest "github.com/rook/rook/pkg/operator/cassandra/test"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestSyncRepair(t *testing.T) {
+
+	c := casstest.NewSimpleCluster(2)
+	dc := c.Spec.Datacenters[0]
+	c.CreationTimestamp = metav1.NewTime(time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC))
+	c.Spec.Repair = &cassandrav1alpha1.RepairSpec{
+		Schedule:  "0 2 * * *",
+		Keyspaces: []string{"ks1", "ks2"},
+	}
+	c.Status.SetRackStatus(dc.Name, dc.Racks[0].Name, &cassandrav1alpha1.RackStatus{Members: 2, ReadyMembers: 2})
+	members := []string{"test-cluster-test-dc-test-rack-0", "test-cluster-test-dc-test-rack-1"}
+
+	// sync runs a sync of the repairs with the member services of the previous sync,
+	// after marking the given member as done repairing
+	services := casstest.MemberServicesForCluster(c)
+	sync := func(now time.Time, repaired string) {
+		for _, obj := range services {
+			svc := obj.(*corev1.Service)
+			if svc.Name == repaired {
+				svc.Labels[constants.RepairLabel] = constants.LabelValueTrue
+			}
+		}
+		cc := newFakeClusterController(services, []runtime.Object{c})
+		require.NoError(t, cc.syncRepair(c, now))
+
+		list, err := cc.kubeClient.CoreV1().Services(c.Namespace).List(metav1.ListOptions{})
+		require.NoError(t, err)
+		services = []runtime.Object{}
+		for i := range list.Items {
+			services = append(services, &list.Items[i])
+		}
+	}
+	// requested returns the keyspace that the member was asked to repair
+	requested := func(member string) string {
+		for _, obj := range services {
+			svc := obj.(*corev1.Service)
+			if svc.Name == member && svc.Labels[constants.RepairLabel] == constants.LabelValueFalse {
+				return svc.Annotations[constants.RepairKeyspaceAnnotation]
+			}
+		}
+		return ""
+	}
+
+	// not due yet
+	day1 := time.Date(2019, 5, 1, 1, 0, 0, 0, time.UTC)
+	sync(day1, "")
+	require.Equal(t, "", c.Status.Repair.Keyspace)
+	require.Equal(t, "", requested(members[0]))
+
+	// the repair starts with the first member
+	start := time.Date(2019, 5, 1, 2, 1, 0, 0, time.UTC)
+	sync(start, "")
+	require.Equal(t, "ks1", c.Status.Repair.Keyspace)
+	require.Equal(t, members[0], c.Status.Repair.Member)
+	require.Equal(t, start, c.Status.Repair.LastScheduleTime.Time)
+	require.Equal(t, "ks1", requested(members[0]))
+	require.Equal(t, "", requested(members[1]))
+
+	// the repair is in progress
+	sync(start, "")
+	require.Equal(t, members[0], c.Status.Repair.Member)
+
+	// one member at a time
+	sync(start, members[0])
+	require.Equal(t, members[1], c.Status.Repair.Member)
+	require.Equal(t, int32(1), c.Status.Repair.RepairedMembers)
+	require.Equal(t, "ks1", requested(members[1]))
+
+	// the next keyspace once all the members repaired the keyspace
+	end := start.Add(time.Hour)
+	sync(end, members[1])
+	require.Equal(t, "ks2", c.Status.Repair.Keyspace)
+	require.Equal(t, members[0], c.Status.Repair.Member)
+	require.Equal(t, int32(0), c.Status.Repair.RepairedMembers)
+	require.Equal(t, end, c.Status.Repair.LastSuccessfulRepairs["ks1"].Time)
+	require.Equal(t, "ks2", requested(members[0]))
+
+	sync(end, members[0])
+	sync(end, members[1])
+	require.Equal(t, "", c.Status.Repair.Keyspace)
+	require.Equal(t, "", c.Status.Repair.Member)
+	require.Equal(t, end, c.Status.Repair.LastSuccessfulRepairs["ks2"].Time)
+
+	// the next repair starts the next day, after clearing the labels of the previous repair
+	day2 := time.Date(2019, 5, 2, 2, 0, 0, 0, time.UTC)
+	sync(day2.Add(-time.Minute), "")
+	require.Equal(t, "", c.Status.Repair.Keyspace)
+	sync(day2, "")
+	require.Equal(t, "ks1", c.Status.Repair.Keyspace)
+	for _, obj := range services {
+		_, ok := obj.(*corev1.Service).Labels[constants.RepairLabel]
+		require.False(t, ok)
+	}
+	sync(day2, "")
+	require.Equal(t, "ks1", requested(members[0]))
+
+	// repairs wait for the members to be ready
+	c.Status.GetRackStatus(dc.Name, dc.Racks[0].Name).ReadyMembers = 1
+	sync(day2, members[0])
+	require.Equal(t, members[0], c.Status.Repair.Member)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
+	"github.com/rook/rook/pkg/operator/cassandra/constants"
+	"github.com/rook/rook/pkg/operator/cassandra/controller/util"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// syncSuperuser creates the superuser of the authentication settings of the
+// given Cassandra Cluster once all its members are ready. The superuser is
+// created through a single member, which also replicates the system_auth
+// keyspace to every datacenter and disables the default superuser.
+func (cc *ClusterController) syncSuperuser(c *cassandrav1alpha1.Cluster) error {
+	if superuserReady(c) {
+		return nil
+	}
+	if !clusterReady(c) {
+		logger.Infof("Cluster %s is not ready, waiting to create the superuser", c.Name)
+		return nil
+	}
+
+	members, err := util.GetMemberServicesForCluster(c, cc.serviceLister)
+	if err != nil {
+		return fmt.Errorf("error trying to get member services: %s", err.Error())
+	}
+	if len(members) == 0 {
+		return nil
+	}
+
+	switch members[0].Labels[constants.SuperuserLabel] {
+	case constants.LabelValueTrue:
+		logger.Infof("Created the superuser of cluster %s", c.Name)
+		c.Status.SuperuserCreated = true
+		cc.recorder.Event(
+			c,
+			corev1.EventTypeNormal,
+			SuccessSynced,
+			fmt.Sprintf(MessageSuperuserCreated, c.Spec.Security.Authentication.SuperuserSecretName),
+		)
+	case constants.LabelValueFalse:
+		logger.Infof("Waiting for member %s to create the superuser", members[0].Name)
+	default:
+		logger.Infof("Member %s will create the superuser", members[0].Name)
+		return cc.requestMemberAction(members[0], constants.SuperuserLabel)
+	}
+	return nil
+}
+
+// superuserReady returns true if the given Cassandra Cluster doesn't use
+// authentication or if its superuser is created. The operations that run
+// cql statements wait for the superuser.
+func superuserReady(c *cassandrav1alpha1.Cluster) bool {
+	if c.Spec.Security == nil || c.Spec.Security.Authentication == nil {
+		return true
+	}
+	return c.Status.SuperuserCreated
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
+	"github.com/rook/rook/pkg/operator/cassandra/constants"
+	casstest "github.com/rook/rook/pkg/operator/cassandra/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncSuperuser(t *testing.T) {
+
+	c := casstest.NewSimpleCluster(2)
+	dc := c.Spec.Datacenters[0]
+	c.Status.SetRackStatus(dc.Name, dc.Racks[0].Name, &cassandrav1alpha1.RackStatus{Members: 2, ReadyMembers: 1})
+
+	// without authentication there is no superuser to create
+	require.True(t, superuserReady(c))
+	c.Spec.Security = &cassandrav1alpha1.SecuritySpec{
+		Authentication: &cassandrav1alpha1.AuthenticationSpec{SuperuserSecretName: "cassandra-superuser"},
+	}
+	require.False(t, superuserReady(c))
+
+	services := casstest.MemberServicesForCluster(c)
+	sync := func() {
+		services = syncMembers(t, c, services, func(cc *ClusterController) error {
+			return cc.syncSuperuser(c)
+		})
+	}
+	members := []string{"test-cluster-test-dc-test-rack-0", "test-cluster-test-dc-test-rack-1"}
+	label := func(i int) string {
+		return memberService(t, services, members[i]).Labels[constants.SuperuserLabel]
+	}
+
+	// the superuser waits for the cluster to be ready
+	sync()
+	require.Equal(t, "", label(0))
+	c.Status.GetRackStatus(dc.Name, dc.Racks[0].Name).ReadyMembers = 2
+
+	// the superuser is created through a single member
+	sync()
+	require.Equal(t, constants.LabelValueFalse, label(0))
+	require.Equal(t, "", label(1))
+	sync()
+	require.False(t, c.Status.SuperuserCreated)
+
+	memberService(t, services, members[0]).Labels[constants.SuperuserLabel] = constants.LabelValueTrue
+	sync()
+	require.True(t, c.Status.SuperuserCreated)
+	require.True(t, superuserReady(c))
+}
@@ -17,6 +17,9 @@ limitations under the License.
 package controller
 
 import (
+	"fmt"
+	"strings"
+
 	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
 	"github.com/rook/rook/pkg/operator/cassandra/constants"
 	"github.com/rook/rook/pkg/operator/cassandra/controller/util"
@@ -24,7 +27,6 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kubernetes/pkg/controller/endpoint"
-	"strings"
 )
 
 // SyncClusterHeadlessService checks if a Headless Service exists
@@ -59,6 +61,43 @@ func (cc *ClusterController) syncClusterHeadlessService(c *cassandrav1alpha1.Clu
 	return cc.syncService(clusterHeadlessService, c)
 }
 
+// syncClusterMetricsService checks if a Service exposing the metrics of
+// all the members of the given Cluster exists. If it doesn't exist, then
+// create it. The metrics of every member are served by its sidecar, and in
+// Scylla mode, by Scylla itself too.
+func (cc *ClusterController) syncClusterMetricsService(c *cassandrav1alpha1.Cluster) error {
+	ports := []corev1.ServicePort{
+		{
+			Name: "metrics",
+			Port: constants.ProbePort,
+		},
+	}
+	if c.Spec.Mode == cassandrav1alpha1.ClusterModeScylla {
+		ports = append(ports, corev1.ServicePort{
+			Name: "prometheus",
+			Port: constants.ScyllaPrometheusPort,
+		})
+	}
+
+	clusterMetricsService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            util.MetricsServiceNameForCluster(c),
+			Namespace:       c.Namespace,
+			Labels:          util.ClusterLabels(c),
+			OwnerReferences: []metav1.OwnerReference{util.NewControllerRef(c)},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: util.ClusterLabels(c),
+			Ports:    ports,
+		},
+	}
+
+	logger.Infof("Syncing ClusterMetricsService `%s` for Cluster `%s`", clusterMetricsService.Name, c.Name)
+
+	return cc.syncService(clusterMetricsService, c)
+}
+
 // SyncMemberServices checks, for every Pod of the Cluster that
 // has been created, if a corresponding ClusterIP Service exists,
 // which will serve as a static ip.
@@ -71,12 +110,29 @@ func (cc *ClusterController) syncMemberServices(c *cassandrav1alpha1.Cluster) er
 		return err
 	}
 
+	// The members of a datacenter being added with rebuildFrom skip the bootstrap
+	skipBootstrap := map[string]bool{}
+	for _, dc := range c.Spec.Datacenters {
+		if dc.RebuildFrom == "" {
+			continue
+		}
+		rebuilt, err := cc.isDatacenterRebuilt(dc, c)
+		if err != nil {
+			return err
+		}
+		skipBootstrap[dc.Name] = !rebuilt
+	}
+
 	// For every Pod of the cluster that exists, check that a
 	// a corresponding ClusterIP Service exists, and if it doesn't,
 	// create it.
 	logger.Infof("Syncing MemberServices for Cluster `%s`", c.Name)
 	for _, pod := range pods {
-		if err := cc.syncService(memberServiceForPod(pod, c), c); err != nil {
+		memberService := memberServiceForPod(pod, c)
+		if skipBootstrap[pod.Labels[constants.DatacenterNameLabel]] {
+			memberService.Labels[constants.SkipBootstrapLabel] = ""
+		}
+		if err := cc.syncService(memberService, c); err != nil {
 			logger.Errorf("Error syncing member service for '%s'", pod.Name)
 			return err
 		}
@@ -84,6 +140,21 @@ func (cc *ClusterController) syncMemberServices(c *cassandrav1alpha1.Cluster) er
 	return nil
 }
 
+// isDatacenterRebuilt returns true if a member of the given datacenter has
+// already rebuilt its data, meaning the datacenter was rebuilt.
+func (cc *ClusterController) isDatacenterRebuilt(dc cassandrav1alpha1.DatacenterSpec, c *cassandrav1alpha1.Cluster) (bool, error) {
+	services, err := util.GetMemberServicesForDatacenter(dc, c, cc.serviceLister)
+	if err != nil {
+		return false, fmt.Errorf("error trying to get member services of datacenter %s: %s", dc.Name, err.Error())
+	}
+	for _, svc := range services {
+		if svc.Labels[constants.RebuildLabel] == constants.LabelValueTrue {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // syncService checks if the given Service exists and creates it if it doesn't
 // it creates it
 func (cc *ClusterController) syncService(s *corev1.Service, c *cassandrav1alpha1.Cluster) error {
@@ -17,6 +17,8 @@ limitations under the License.
 package controller
 
 import (
+	"time"
+
 	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
 	"github.com/rook/rook/pkg/operator/cassandra/controller/util"
 	corev1 "k8s.io/api/core/v1"
@@ -35,13 +37,31 @@ const (
 	MessageRackScaledUp            = "Rack %s scaled up to %d members"
 	MessageRackScaleDownInProgress = "Rack %s scaling down to %d members"
 	MessageRackScaledDown          = "Rack %s scaled down to %d members"
+	MessageMemberRebuildStarted    = "Member %s rebuilding from datacenter %s"
+	MessageRepairStarted           = "Repair started, repairing keyspace %s"
+	MessageKeyspaceRepaired        = "Keyspace %s repaired"
+	MessageUpgradeStarted          = "Upgrade from version %s to %s started, taking snapshot %s"
+	MessageMemberUpgraded          = "Member %s restarting with version %s"
+	MessageUpgradeFinished         = "Upgrade to version %s finished"
+	MessageBackupStarted           = "Backup %s started"
+	MessageBackupFinished          = "Backup %s finished"
+	MessageRestoreStarted          = "Restore of backup %s started"
+	MessageRestoreFinished         = "Restore of backup %s finished"
+	MessageSuperuserCreated        = "Superuser %s created"
 
 	// Messages to display when experiencing an error.
 	MessageHeadlessServiceSyncFailed = "Failed to sync Headless Service for cluster"
+	MessageMetricsServiceSyncFailed  = "Failed to sync Metrics Service for cluster"
 	MessageMemberServicesSyncFailed  = "Failed to sync MemberServices for cluster"
 	MessageUpdateStatusFailed        = "Failed to update status for cluster"
 	MessageCleanupFailed             = "Failed to clean up cluster resources"
 	MessageClusterSyncFailed         = "Failed to sync cluster"
+	MessageRepairSyncFailed          = "Failed to sync cluster repair"
+	MessageBackupSyncFailed          = "Failed to sync cluster backup"
+	MessageBackupPruneFailed         = "Failed to prune old cluster backups"
+	MessageBackupFailed              = "Backup %s failed, not all the members uploaded their data before its deadline"
+	MessageRestoreSyncFailed         = "Failed to sync cluster restore"
+	MessageSuperuserSyncFailed       = "Failed to sync cluster superuser"
 )
 
 // Sync attempts to sync the given Cassandra Cluster.
@@ -78,6 +98,17 @@ func (cc *ClusterController) Sync(c *cassandrav1alpha1.Cluster) error {
 		return err
 	}
 
+	// Sync Metrics Service for Cluster
+	if err := cc.syncClusterMetricsService(c); err != nil {
+		cc.recorder.Event(
+			c,
+			corev1.EventTypeWarning,
+			ErrSyncFailed,
+			MessageMetricsServiceSyncFailed,
+		)
+		return err
+	}
+
 	// Sync Cluster Member Services
 	if err := cc.syncMemberServices(c); err != nil {
 		cc.recorder.Event(
@@ -111,5 +142,49 @@ func (cc *ClusterController) Sync(c *cassandrav1alpha1.Cluster) error {
 		return err
 	}
 
+	// Sync Superuser
+	if err := cc.syncSuperuser(c); err != nil {
+		cc.recorder.Event(
+			c,
+			corev1.EventTypeWarning,
+			ErrSyncFailed,
+			MessageSuperuserSyncFailed,
+		)
+		return err
+	}
+
+	// Sync Repairs
+	if err := cc.syncRepair(c, time.Now()); err != nil {
+		cc.recorder.Event(
+			c,
+			corev1.EventTypeWarning,
+			ErrSyncFailed,
+			MessageRepairSyncFailed,
+		)
+		return err
+	}
+
+	// Sync Backups
+	if err := cc.syncBackup(c, time.Now()); err != nil {
+		cc.recorder.Event(
+			c,
+			corev1.EventTypeWarning,
+			ErrSyncFailed,
+			MessageBackupSyncFailed,
+		)
+		return err
+	}
+
+	// Sync Restore
+	if err := cc.syncRestore(c); err != nil {
+		cc.recorder.Event(
+			c,
+			corev1.EventTypeWarning,
+			ErrSyncFailed,
+			MessageRestoreSyncFailed,
+		)
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,338 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
+	"github.com/rook/rook/pkg/operator/cassandra/constants"
+	"github.com/rook/rook/pkg/operator/cassandra/controller/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// syncUpgrade performs the rolling upgrade of the given Cassandra Cluster
+// when its version changes. Every member first takes a snapshot of its data.
+// Then the racks are upgraded one after the other, one member at a time:
+// the member drains, restarts with the new version and, when the major
+// version changes, upgrades its sstables once it has rejoined the cluster.
+// Finally every member clears the snapshot.
+// It returns true while an upgrade is pending or in progress, so that no
+// other action is taken on the cluster in the meantime.
+func (cc *ClusterController) syncUpgrade(c *cassandrav1alpha1.Cluster) (bool, error) {
+
+	status := c.Status.Upgrade
+
+	// Check if a rack runs a different version than the spec
+	if status == nil {
+		image := util.ImageForCluster(c)
+		for _, dc := range c.Spec.GetDatacenters() {
+			for _, rack := range dc.Racks {
+				if c.Status.GetRackStatus(dc.Name, rack.Name) == nil {
+					continue
+				}
+				sts, err := cc.statefulSetLister.StatefulSets(c.Namespace).Get(util.StatefulSetNameForRack(rack, dc, c))
+				if err != nil {
+					return false, fmt.Errorf("error trying to get StatefulSet of rack %s: %s", rack.Name, err.Error())
+				}
+				if current := util.ImageForStatefulSet(sts); current != image {
+					return true, cc.startUpgrade(c, util.VersionFromImage(current))
+				}
+			}
+		}
+		return false, nil
+	}
+
+	// The upgrade in progress is finished first, then a new upgrade starts
+	// from its version
+	if status.ToVersion != c.Spec.Version {
+		logger.Infof("Cluster %s will be upgraded to version %s once its upgrade to version %s is finished", c.Name, c.Spec.Version, status.ToVersion)
+	}
+
+	// Wait for every member to take the snapshot
+	members, err := util.GetMemberServicesForCluster(c, cc.serviceLister)
+	if err != nil {
+		return true, fmt.Errorf("error trying to get member services: %s", err.Error())
+	}
+	snapshotTaken := true
+	for _, member := range members {
+		if member.Annotations[constants.SnapshotTagAnnotation] != status.SnapshotTag {
+			// The member was created after the upgrade started
+			if err := cc.requestSnapshot(member, status.SnapshotTag); err != nil {
+				return true, err
+			}
+		}
+		if member.Labels[constants.SnapshotLabel] != constants.LabelValueTrue {
+			logger.Infof("Waiting for member %s to take snapshot %s", member.Name, status.SnapshotTag)
+			snapshotTaken = false
+		}
+	}
+	if !snapshotTaken {
+		return true, nil
+	}
+
+	// Upgrade the racks one after the other
+	for _, dc := range c.Spec.GetDatacenters() {
+		for _, rack := range dc.Racks {
+			upgraded, err := cc.upgradeRack(rack, dc, c)
+			if err != nil || !upgraded {
+				return true, err
+			}
+		}
+	}
+
+	// Wait for every member to clear the snapshot
+	snapshotCleared := true
+	for _, member := range members {
+		switch member.Labels[constants.ClearSnapshotLabel] {
+		case constants.LabelValueTrue:
+		case constants.LabelValueFalse:
+			logger.Infof("Waiting for member %s to clear snapshot %s", member.Name, status.SnapshotTag)
+			snapshotCleared = false
+		default:
+			if err := cc.requestMemberAction(member, constants.ClearSnapshotLabel); err != nil {
+				return true, err
+			}
+			snapshotCleared = false
+		}
+	}
+	if !snapshotCleared {
+		return true, nil
+	}
+
+	logger.Infof("Upgraded cluster %s to version %s", c.Name, status.ToVersion)
+	cc.recorder.Event(
+		c,
+		corev1.EventTypeNormal,
+		SuccessSynced,
+		fmt.Sprintf(MessageUpgradeFinished, status.ToVersion),
+	)
+	c.Status.Upgrade = nil
+	return false, nil
+}
+
+// startUpgrade starts the upgrade of the given Cassandra Cluster by
+// recording the intent of every member to take a snapshot of its data.
+// The upgrade only starts once all the members are ready and no repair
+// or backup is in progress.
+func (cc *ClusterController) startUpgrade(c *cassandrav1alpha1.Cluster, fromVersion string) error {
+	if !clusterReady(c) {
+		logger.Infof("Cluster %s is not ready, waiting to upgrade it to version %s", c.Name, c.Spec.Version)
+		return nil
+	}
+	if c.Status.Repair != nil && c.Status.Repair.Keyspace != "" {
+		logger.Infof("Waiting for the repair of keyspace %s to finish before upgrading cluster %s", c.Status.Repair.Keyspace, c.Name)
+		return nil
+	}
+	if c.Status.Backup != nil && c.Status.Backup.Name != "" {
+		logger.Infof("Waiting for backup %s to finish before upgrading cluster %s", c.Status.Backup.Name, c.Name)
+		return nil
+	}
+
+	members, err := util.GetMemberServicesForCluster(c, cc.serviceLister)
+	if err != nil {
+		return fmt.Errorf("error trying to get member services: %s", err.Error())
+	}
+
+	now := metav1.Now()
+	status := &cassandrav1alpha1.UpgradeStatus{
+		FromVersion: fromVersion,
+		ToVersion:   c.Spec.Version,
+		SnapshotTag: fmt.Sprintf("upgrade-%s-%d", fromVersion, now.Unix()),
+		StartTime:   now,
+	}
+	logger.Infof("Upgrading cluster %s from version %s to %s", c.Name, status.FromVersion, status.ToVersion)
+	for _, member := range members {
+		if err := cc.requestSnapshot(member, status.SnapshotTag); err != nil {
+			return err
+		}
+	}
+
+	c.Status.Upgrade = status
+	cc.recorder.Event(
+		c,
+		corev1.EventTypeNormal,
+		SuccessSynced,
+		fmt.Sprintf(MessageUpgradeStarted, status.FromVersion, status.ToVersion, status.SnapshotTag),
+	)
+	return nil
+}
+
+// upgradeRack restarts the members of the given rack with the new version,
+// one at a time, starting from the member with the highest ordinal.
+// The StatefulSet partition only lets the members with an ordinal greater
+// or equal to it run the new version. It returns true once all the members
+// of the rack run the new version.
+func (cc *ClusterController) upgradeRack(r cassandrav1alpha1.RackSpec, dc cassandrav1alpha1.DatacenterSpec, c *cassandrav1alpha1.Cluster) (bool, error) {
+	status := c.Status.Upgrade
+
+	rackStatus := c.Status.GetRackStatus(dc.Name, r.Name)
+	if rackStatus == nil {
+		return true, nil
+	}
+	stsName := util.StatefulSetNameForRack(r, dc, c)
+	sts, err := cc.statefulSetLister.StatefulSets(c.Namespace).Get(stsName)
+	if err != nil {
+		return false, fmt.Errorf("error trying to get StatefulSet %s: %s", stsName, err.Error())
+	}
+
+	// Update the version of the rack, without restarting any member yet
+	replicas := *sts.Spec.Replicas
+	image := util.ImageForVersion(c, status.ToVersion)
+	if util.ImageForStatefulSet(sts) != image {
+		logger.Infof("Upgrading rack %s of datacenter %s to version %s", r.Name, dc.Name, status.ToVersion)
+		updatedSts := sts.DeepCopy()
+		util.SetImageForStatefulSet(updatedSts, image)
+		updatedSts.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{
+			Partition: util.RefFromInt32(replicas),
+		}
+		if err := util.PatchStatefulSet(sts, updatedSts, cc.kubeClient); err != nil {
+			return false, fmt.Errorf("error patching StatefulSet %s: %s", stsName, err.Error())
+		}
+		return false, nil
+	}
+
+	partition := int32(0)
+	if sts.Spec.UpdateStrategy.RollingUpdate != nil && sts.Spec.UpdateStrategy.RollingUpdate.Partition != nil {
+		partition = *sts.Spec.UpdateStrategy.RollingUpdate.Partition
+	}
+
+	// Check the member that was restarted last, if any
+	if partition < replicas {
+		memberName := fmt.Sprintf("%s-%d", stsName, partition)
+		// Wait for the member to rejoin the cluster with the new version
+		if sts.Status.UpdatedReplicas < replicas-partition || rackStatus.ReadyMembers != rackStatus.Members {
+			logger.Infof("Waiting for member %s to rejoin the cluster with version %s", memberName, status.ToVersion)
+			return false, nil
+		}
+		// The format of the sstables changes with the major version
+		if util.MajorVersion(status.FromVersion) != util.MajorVersion(status.ToVersion) {
+			done, err := cc.upgradeSSTables(memberName, c)
+			if err != nil || !done {
+				return false, err
+			}
+		}
+	}
+	if partition == 0 {
+		return true, nil
+	}
+
+	// Pause the upgrade while any member is down
+	if !membersReady(c) {
+		logger.Infof("Upgrade of cluster %s paused until all the members are ready", c.Name)
+		return false, nil
+	}
+
+	// Drain the next member, then restart it with the new version
+	next := partition - 1
+	memberName := fmt.Sprintf("%s-%d", stsName, next)
+	memberService, err := cc.serviceLister.Services(c.Namespace).Get(memberName)
+	if err != nil {
+		return false, fmt.Errorf("error trying to get member service %s: %s", memberName, err.Error())
+	}
+	status.Member = memberName
+
+	switch memberService.Labels[constants.DrainLabel] {
+	case constants.LabelValueTrue:
+		logger.Infof("Member %s drained, restarting it with version %s", memberName, status.ToVersion)
+		updatedSts := sts.DeepCopy()
+		updatedSts.Spec.UpdateStrategy.RollingUpdate.Partition = &next
+		if err := util.PatchStatefulSet(sts, updatedSts, cc.kubeClient); err != nil {
+			return false, fmt.Errorf("error patching StatefulSet %s: %s", stsName, err.Error())
+		}
+		cc.recorder.Event(
+			c,
+			corev1.EventTypeNormal,
+			SuccessSynced,
+			fmt.Sprintf(MessageMemberUpgraded, memberName, status.ToVersion),
+		)
+	case constants.LabelValueFalse:
+		logger.Infof("Waiting for member %s to drain", memberName)
+	default:
+		logger.Infof("Member %s will drain", memberName)
+		if err := cc.requestMemberAction(memberService, constants.DrainLabel); err != nil {
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+// upgradeSSTables records the intent of the given member to upgrade its
+// sstables. It returns true once the member has upgraded its sstables.
+func (cc *ClusterController) upgradeSSTables(memberName string, c *cassandrav1alpha1.Cluster) (bool, error) {
+	memberService, err := cc.serviceLister.Services(c.Namespace).Get(memberName)
+	if err != nil {
+		return false, fmt.Errorf("error trying to get member service %s: %s", memberName, err.Error())
+	}
+	c.Status.Upgrade.Member = memberName
+
+	switch memberService.Labels[constants.UpgradeSSTablesLabel] {
+	case constants.LabelValueTrue:
+		return true, nil
+	case constants.LabelValueFalse:
+		logger.Infof("Waiting for member %s to upgrade its sstables", memberName)
+		return false, nil
+	default:
+		logger.Infof("Member %s will upgrade its sstables", memberName)
+		return false, cc.requestMemberAction(memberService, constants.UpgradeSSTablesLabel)
+	}
+}
+
+// requestSnapshot records the intent of the member to take a snapshot
+// with the given tag and clears the labels of a previous upgrade.
+func (cc *ClusterController) requestSnapshot(memberService *corev1.Service, tag string) error {
+	updated := memberService.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Labels[constants.SnapshotLabel] = constants.LabelValueFalse
+	updated.Annotations[constants.SnapshotTagAnnotation] = tag
+	delete(updated.Labels, constants.DrainLabel)
+	delete(updated.Labels, constants.UpgradeSSTablesLabel)
+	delete(updated.Labels, constants.ClearSnapshotLabel)
+	if err := util.PatchService(memberService, updated, cc.kubeClient); err != nil {
+		return fmt.Errorf("error patching member service %s: %s", memberService.Name, err.Error())
+	}
+	return nil
+}
+
+// requestMemberAction records the intent of the member to perform the
+// action expressed by the given label.
+func (cc *ClusterController) requestMemberAction(memberService *corev1.Service, label string) error {
+	updated := memberService.DeepCopy()
+	updated.Labels[label] = constants.LabelValueFalse
+	if err := util.PatchService(memberService, updated, cc.kubeClient); err != nil {
+		return fmt.Errorf("error patching member service %s: %s", memberService.Name, err.Error())
+	}
+	return nil
+}
+
+// membersReady returns true if all the members of the cluster are ready.
+func membersReady(c *cassandrav1alpha1.Cluster) bool {
+	for _, dc := range c.Spec.GetDatacenters() {
+		for _, rack := range dc.Racks {
+			rackStatus := c.Status.GetRackStatus(dc.Name, rack.Name)
+			if rackStatus != nil && rackStatus.ReadyMembers != rackStatus.Members {
+				return false
+			}
+		}
+	}
+	return true
+}
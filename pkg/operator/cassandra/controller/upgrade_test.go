@@ -0,0 +1,177 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
+	"github.com/rook/rook/pkg/operator/cassandra/constants"
+	"github.com/rook/rook/pkg/operator/cassandra/controller/util"
+	casstest "github.com/rook/rook/pkg/operator/cassandra/test"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestSyncUpgrade(t *testing.T) {
+
+	c := casstest.NewSimpleCluster(2)
+	dc := c.Spec.Datacenters[0]
+	r := dc.Racks[0]
+	c.Status.SetRackStatus(dc.Name, r.Name, &cassandrav1alpha1.RackStatus{Members: 2, ReadyMembers: 2})
+	sts := util.StatefulSetForRack(r, dc, c, "")
+	*sts.Spec.Replicas = 2
+	sts.Status.ReadyReplicas = 2
+	sts.Status.UpdatedReplicas = 2
+	c.Spec.Version = "4.0.0"
+	members := []string{"test-cluster-test-dc-test-rack-0", "test-cluster-test-dc-test-rack-1"}
+
+	objects := append(casstest.MemberServicesForCluster(c), sts)
+	// sync runs a sync of the upgrade with the objects of the previous sync
+	sync := func() bool {
+		cc := newFakeClusterController(objects, []runtime.Object{c})
+		upgrading, err := cc.syncUpgrade(c)
+		require.NoError(t, err)
+
+		objects = []runtime.Object{}
+		services, err := cc.kubeClient.CoreV1().Services(c.Namespace).List(metav1.ListOptions{})
+		require.NoError(t, err)
+		for i := range services.Items {
+			objects = append(objects, &services.Items[i])
+		}
+		updatedSts, err := cc.kubeClient.AppsV1().StatefulSets(c.Namespace).Get(sts.Name, metav1.GetOptions{})
+		require.NoError(t, err)
+		sts = updatedSts
+		objects = append(objects, sts)
+		return upgrading
+	}
+	member := func(name string) *corev1.Service {
+		for _, obj := range objects {
+			if svc, ok := obj.(*corev1.Service); ok && svc.Name == name {
+				return svc
+			}
+		}
+		t.Fatalf("member service %s not found", name)
+		return nil
+	}
+	partition := func() int32 {
+		return *sts.Spec.UpdateStrategy.RollingUpdate.Partition
+	}
+
+	// every member takes a snapshot first
+	require.True(t, sync())
+	require.NotNil(t, c.Status.Upgrade)
+	require.Equal(t, "3.1.11", c.Status.Upgrade.FromVersion)
+	require.Equal(t, "4.0.0", c.Status.Upgrade.ToVersion)
+	tag := c.Status.Upgrade.SnapshotTag
+	for _, name := range members {
+		require.Equal(t, constants.LabelValueFalse, member(name).Labels[constants.SnapshotLabel])
+		require.Equal(t, tag, member(name).Annotations[constants.SnapshotTagAnnotation])
+	}
+	require.True(t, sync())
+	require.Equal(t, "cassandra:3.1.11", util.ImageForStatefulSet(sts))
+
+	// the rack is updated without restarting any member
+	for _, name := range members {
+		member(name).Labels[constants.SnapshotLabel] = constants.LabelValueTrue
+	}
+	require.True(t, sync())
+	require.Equal(t, "cassandra:4.0.0", util.ImageForStatefulSet(sts))
+	require.Equal(t, int32(2), partition())
+	// no member runs the new revision yet
+	sts.Status.UpdatedReplicas = 0
+
+	// the member with the highest ordinal drains, then restarts
+	require.True(t, sync())
+	require.Equal(t, members[1], c.Status.Upgrade.Member)
+	require.Equal(t, constants.LabelValueFalse, member(members[1]).Labels[constants.DrainLabel])
+	member(members[1]).Labels[constants.DrainLabel] = constants.LabelValueTrue
+	require.True(t, sync())
+	require.Equal(t, int32(1), partition())
+
+	// the restarted member upgrades its sstables once it rejoined the cluster
+	require.True(t, sync())
+	_, ok := member(members[1]).Labels[constants.UpgradeSSTablesLabel]
+	require.False(t, ok)
+	sts.Status.UpdatedReplicas = 1
+	require.True(t, sync())
+	require.Equal(t, constants.LabelValueFalse, member(members[1]).Labels[constants.UpgradeSSTablesLabel])
+	member(members[1]).Labels[constants.UpgradeSSTablesLabel] = constants.LabelValueTrue
+
+	// the upgrade pauses while a member is down
+	c.Status.GetRackStatus(dc.Name, r.Name).ReadyMembers = 1
+	require.True(t, sync())
+	_, ok = member(members[0]).Labels[constants.DrainLabel]
+	require.False(t, ok)
+	c.Status.GetRackStatus(dc.Name, r.Name).ReadyMembers = 2
+
+	// then the next member
+	require.True(t, sync())
+	require.Equal(t, members[0], c.Status.Upgrade.Member)
+	member(members[0]).Labels[constants.DrainLabel] = constants.LabelValueTrue
+	require.True(t, sync())
+	require.Equal(t, int32(0), partition())
+	sts.Status.UpdatedReplicas = 2
+	require.True(t, sync())
+	member(members[0]).Labels[constants.UpgradeSSTablesLabel] = constants.LabelValueTrue
+
+	// every member clears the snapshot once all the members run the new version
+	require.True(t, sync())
+	for _, name := range members {
+		require.Equal(t, constants.LabelValueFalse, member(name).Labels[constants.ClearSnapshotLabel])
+		member(name).Labels[constants.ClearSnapshotLabel] = constants.LabelValueTrue
+	}
+
+	// the upgrade is finished once the snapshot is cleared
+	require.False(t, sync())
+	require.Nil(t, c.Status.Upgrade)
+	require.False(t, sync())
+}
+
+func TestSyncUpgradeVersionChanged(t *testing.T) {
+
+	c := casstest.NewSimpleCluster(1)
+	dc := c.Spec.Datacenters[0]
+	r := dc.Racks[0]
+	c.Status.SetRackStatus(dc.Name, r.Name, &cassandrav1alpha1.RackStatus{Members: 1, ReadyMembers: 1})
+	sts := util.StatefulSetForRack(r, dc, c, "")
+	c.Spec.Version = "4.0.0"
+	objects := append(casstest.MemberServicesForCluster(c), sts)
+	for _, obj := range objects {
+		if svc, ok := obj.(*corev1.Service); ok {
+			svc.Labels[constants.SnapshotLabel] = constants.LabelValueTrue
+			svc.Annotations = map[string]string{constants.SnapshotTagAnnotation: "upgrade-3.1.11-1557799200"}
+		}
+	}
+	c.Status.Upgrade = &cassandrav1alpha1.UpgradeStatus{
+		FromVersion: "3.1.11",
+		ToVersion:   "4.0.0",
+		SnapshotTag: "upgrade-3.1.11-1557799200",
+	}
+
+	// the upgrade in progress goes on with its version
+	c.Spec.Version = "4.0.1"
+	cc := newFakeClusterController(objects, []runtime.Object{c})
+	upgrading, err := cc.syncUpgrade(c)
+	require.NoError(t, err)
+	require.True(t, upgrading)
+	updatedSts, err := cc.kubeClient.AppsV1().StatefulSets(c.Namespace).Get(sts.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "cassandra:4.0.0", util.ImageForStatefulSet(updatedSts))
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
+	"github.com/rook/rook/pkg/util/s3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The backups of a cluster are stored in the bucket with the layout:
+// <namespace>/<cluster>/<backup>/<datacenter>/<rack>/<member index>/schema.cql
+// <namespace>/<cluster>/<backup>/<datacenter>/<rack>/<member index>/<keyspace>/<table>/<sstable file>
+
+// BackupPrefix returns the prefix of the keys of all the backups of the given cluster.
+func BackupPrefix(namespace, cluster string) string {
+	return fmt.Sprintf("%s/%s/", namespace, cluster)
+}
+
+// BackupKeyPrefix returns the prefix of the keys of the given backup.
+func BackupKeyPrefix(namespace, cluster, backup string) string {
+	return fmt.Sprintf("%s%s/", BackupPrefix(namespace, cluster), backup)
+}
+
+// MemberBackupKeyPrefix returns the prefix of the keys uploaded by a member
+// to the given backup. Members are identified by their index in their rack,
+// so that the backup can be restored to a cluster with a different name.
+func MemberBackupKeyPrefix(namespace, cluster, backup, dc, rack string, index int32) string {
+	return fmt.Sprintf("%s%s/%s/%d/", BackupKeyPrefix(namespace, cluster, backup), dc, rack, index)
+}
+
+// BackupNames returns the sorted names of the backups that the given keys
+// belong to. The names of the backups sort in the order they were taken.
+func BackupNames(keys []string, prefix string) []string {
+	found := map[string]bool{}
+	names := []string{}
+	for _, key := range keys {
+		name := strings.SplitN(strings.TrimPrefix(key, prefix), "/", 2)[0]
+		if name != "" && !found[name] {
+			found[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// S3ClientForSpec returns a client for the given bucket, with the credentials
+// of the secret of the spec in the given namespace.
+func S3ClientForSpec(spec cassandrav1alpha1.S3Spec, namespace string, kubeClient kubernetes.Interface) (*s3.Client, error) {
+	secret, err := kubeClient.CoreV1().Secrets(namespace).Get(spec.SecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting secret %s with the s3 credentials: %s", spec.SecretName, err.Error())
+	}
+	return s3.NewClient(s3.Config{
+		Endpoint:  spec.Endpoint,
+		Bucket:    spec.Bucket,
+		AccessKey: string(secret.Data[s3.AccessKeyName]),
+		SecretKey: string(secret.Data[s3.SecretKeyName]),
+	})
+}
@@ -33,19 +33,19 @@ func ClusterLabels(c *cassandrav1alpha1.Cluster) map[string]string {
 
 // DatacenterLabels returns a map of label keys and values
 // for the given Datacenter.
-func DatacenterLabels(c *cassandrav1alpha1.Cluster) map[string]string {
+func DatacenterLabels(dc cassandrav1alpha1.DatacenterSpec, c *cassandrav1alpha1.Cluster) map[string]string {
 	recLabels := recommendedLabels()
 	dcLabels := ClusterLabels(c)
-	dcLabels[constants.DatacenterNameLabel] = c.Spec.Datacenter.Name
+	dcLabels[constants.DatacenterNameLabel] = dc.Name
 
 	return mergeLabels(dcLabels, recLabels)
 }
 
 // RackLabels returns a map of label keys and values
 // for the given Rack.
-func RackLabels(r cassandrav1alpha1.RackSpec, c *cassandrav1alpha1.Cluster) map[string]string {
+func RackLabels(r cassandrav1alpha1.RackSpec, dc cassandrav1alpha1.DatacenterSpec, c *cassandrav1alpha1.Cluster) map[string]string {
 	recLabels := recommendedLabels()
-	rackLabels := DatacenterLabels(c)
+	rackLabels := DatacenterLabels(dc, c)
 	rackLabels[constants.RackNameLabel] = r.Name
 
 	return mergeLabels(rackLabels, recLabels)
@@ -60,14 +60,23 @@ func StatefulSetPodLabel(name string) map[string]string {
 }
 
 // RackSelector returns a LabelSelector for the given rack.
-func RackSelector(r cassandrav1alpha1.RackSpec, c *cassandrav1alpha1.Cluster) labels.Selector {
+func RackSelector(r cassandrav1alpha1.RackSpec, dc cassandrav1alpha1.DatacenterSpec, c *cassandrav1alpha1.Cluster) labels.Selector {
 
-	rackLabelsSet := labels.Set(RackLabels(r, c))
+	rackLabelsSet := labels.Set(RackLabels(r, dc, c))
 	sel := labels.SelectorFromSet(rackLabelsSet)
 
 	return sel
 }
 
+// DatacenterSelector returns a LabelSelector for the given datacenter.
+func DatacenterSelector(dc cassandrav1alpha1.DatacenterSpec, c *cassandrav1alpha1.Cluster) labels.Selector {
+
+	dcLabelsSet := labels.Set(DatacenterLabels(dc, c))
+	sel := labels.SelectorFromSet(dcLabelsSet)
+
+	return sel
+}
+
 func recommendedLabels() map[string]string {
 
 	return map[string]string{
@@ -26,10 +26,11 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"strings"
 )
 
-func StatefulSetNameForRack(r cassandrav1alpha1.RackSpec, c *cassandrav1alpha1.Cluster) string {
-	return fmt.Sprintf("%s-%s-%s", c.Name, c.Spec.Datacenter.Name, r.Name)
+func StatefulSetNameForRack(r cassandrav1alpha1.RackSpec, dc cassandrav1alpha1.DatacenterSpec, c *cassandrav1alpha1.Cluster) string {
+	return fmt.Sprintf("%s-%s-%s", c.Name, dc.Name, r.Name)
 }
 
 func ServiceAccountNameForMembers(c *cassandrav1alpha1.Cluster) string {
@@ -40,7 +41,27 @@ func HeadlessServiceNameForCluster(c *cassandrav1alpha1.Cluster) string {
 	return fmt.Sprintf("%s-client", c.Name)
 }
 
+func MetricsServiceNameForCluster(c *cassandrav1alpha1.Cluster) string {
+	return fmt.Sprintf("%s-metrics", c.Name)
+}
+
+// MetricsAnnotations returns the annotations that let Prometheus discover
+// the metrics endpoint of the sidecar of each member.
+func MetricsAnnotations() map[string]string {
+	return map[string]string{
+		"prometheus.io/scrape": "true",
+		"prometheus.io/port":   fmt.Sprintf("%d", constants.ProbePort),
+		"prometheus.io/path":   constants.MetricsPath,
+	}
+}
+
 func ImageForCluster(c *cassandrav1alpha1.Cluster) string {
+	return ImageForVersion(c, c.Spec.Version)
+}
+
+// ImageForVersion returns the database image of the given version for the
+// members of the given Cluster.
+func ImageForVersion(c *cassandrav1alpha1.Cluster, version string) string {
 
 	var repo string
 
@@ -54,13 +75,50 @@ func ImageForCluster(c *cassandrav1alpha1.Cluster) string {
 	if c.Spec.Repository != nil {
 		repo = *c.Spec.Repository
 	}
-	return fmt.Sprintf("%s:%s", repo, c.Spec.Version)
+	return fmt.Sprintf("%s:%s", repo, version)
+}
+
+// ImageForStatefulSet returns the database image of the members of the
+// given StatefulSet.
+func ImageForStatefulSet(sts *appsv1.StatefulSet) string {
+	for _, container := range sts.Spec.Template.Spec.Containers {
+		if container.Name == "cassandra" {
+			return container.Image
+		}
+	}
+	return ""
 }
 
-func StatefulSetForRack(r cassandrav1alpha1.RackSpec, c *cassandrav1alpha1.Cluster, rookImage string) *appsv1.StatefulSet {
+// SetImageForStatefulSet sets the database image of the members of the
+// given StatefulSet.
+func SetImageForStatefulSet(sts *appsv1.StatefulSet, image string) {
+	for i := range sts.Spec.Template.Spec.Containers {
+		if sts.Spec.Template.Spec.Containers[i].Name == "cassandra" {
+			sts.Spec.Template.Spec.Containers[i].Image = image
+		}
+	}
+}
+
+// VersionFromImage returns the version of the database from the tag of
+// its image.
+func VersionFromImage(image string) string {
+	// The registry of the repository may have a port
+	delimIndex := strings.LastIndex(image, ":")
+	if delimIndex == -1 || strings.Contains(image[delimIndex:], "/") {
+		return "latest"
+	}
+	return image[delimIndex+1:]
+}
 
-	rackLabels := RackLabels(r, c)
-	stsName := StatefulSetNameForRack(r, c)
+// MajorVersion returns the major version of the given version.
+func MajorVersion(version string) string {
+	return strings.SplitN(version, ".", 2)[0]
+}
+
+func StatefulSetForRack(r cassandrav1alpha1.RackSpec, dc cassandrav1alpha1.DatacenterSpec, c *cassandrav1alpha1.Cluster, rookImage string) *appsv1.StatefulSet {
+
+	rackLabels := RackLabels(r, dc, c)
+	stsName := StatefulSetNameForRack(r, dc, c)
 
 	return &appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -82,7 +140,8 @@ func StatefulSetForRack(r cassandrav1alpha1.RackSpec, c *cassandrav1alpha1.Clust
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: rackLabels,
+					Labels:      rackLabels,
+					Annotations: MetricsAnnotations(),
 				},
 				Spec: corev1.PodSpec{
 					Volumes: []corev1.Volume{
@@ -144,7 +203,11 @@ func StatefulSetForRack(r cassandrav1alpha1.RackSpec, c *cassandrav1alpha1.Clust
 								},
 								{
 									Name:          "prometheus",
-									ContainerPort: 9180,
+									ContainerPort: constants.ScyllaPrometheusPort,
+								},
+								{
+									Name:          "metrics",
+									ContainerPort: constants.ProbePort,
 								},
 							},
 							// TODO: unprivileged entrypoint
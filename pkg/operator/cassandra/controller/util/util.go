@@ -30,6 +30,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	appslisters "k8s.io/client-go/listers/apps/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -50,22 +51,57 @@ func GetPodsForCluster(cluster *cassandrav1alpha1.Cluster, podLister corelisters
 // GetMemberServicesForRack returns the member services for the given rack.
 func GerMemberServicesForRack(
 	r cassandrav1alpha1.RackSpec,
+	dc cassandrav1alpha1.DatacenterSpec,
 	c *cassandrav1alpha1.Cluster,
 	serviceLister corelisters.ServiceLister,
 ) ([]*corev1.Service, error) {
 
-	sel := RackSelector(r, c)
+	sel := RackSelector(r, dc, c)
 	return serviceLister.Services(c.Namespace).List(sel)
 }
 
+// GetMemberServicesForDatacenter returns the member services for the given datacenter.
+func GetMemberServicesForDatacenter(
+	dc cassandrav1alpha1.DatacenterSpec,
+	c *cassandrav1alpha1.Cluster,
+	serviceLister corelisters.ServiceLister,
+) ([]*corev1.Service, error) {
+
+	sel := DatacenterSelector(dc, c)
+	return serviceLister.Services(c.Namespace).List(sel)
+}
+
+// GetMemberServicesForCluster returns the member services of all the
+// datacenters of the given cluster, sorted by name.
+func GetMemberServicesForCluster(
+	c *cassandrav1alpha1.Cluster,
+	serviceLister corelisters.ServiceLister,
+) ([]*corev1.Service, error) {
+
+	// The headless service of the cluster has the cluster labels too,
+	// only the member services have a rack label
+	rackRequirement, err := labels.NewRequirement(constants.RackNameLabel, selection.Exists, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error trying to create rackRequirement: %s", err.Error())
+	}
+	sel := labels.SelectorFromSet(ClusterLabels(c)).Add(*rackRequirement)
+	services, err := serviceLister.Services(c.Namespace).List(sel)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services, nil
+}
+
 // GetPodsForRack returns the created Pods for the given rack.
 func GetPodsForRack(
 	r cassandrav1alpha1.RackSpec,
+	dc cassandrav1alpha1.DatacenterSpec,
 	c *cassandrav1alpha1.Cluster,
 	podLister corelisters.PodLister,
 ) ([]*corev1.Pod, error) {
 
-	sel := RackSelector(r, c)
+	sel := RackSelector(r, dc, c)
 	return podLister.Pods(c.Namespace).List(sel)
 
 }
@@ -183,7 +219,7 @@ func ScaleStatefulSet(sts *appsv1.StatefulSet, amount int32, kubeClient kubernet
 // and checks if it is true.
 func IsRackConditionTrue(rackStatus *cassandrav1alpha1.RackStatus, condType cassandrav1alpha1.RackConditionType) bool {
 	for _, cond := range rackStatus.Conditions {
-		if cond.Type == cassandrav1alpha1.RackConditionTypeMemberLeaving && cond.Status == cassandrav1alpha1.ConditionTrue {
+		if cond.Type == condType && cond.Status == cassandrav1alpha1.ConditionTrue {
 			return true
 		}
 	}
@@ -196,16 +232,18 @@ func IsRackConditionTrue(rackStatus *cassandrav1alpha1.RackStatus, condType cass
 // and process them later.
 func StatefulSetStatusesStale(c *cassandrav1alpha1.Cluster, statefulSetLister appslisters.StatefulSetLister) (bool, error) {
 	// Before proceeding, ensure all the Statefulset Statuses are valid
-	for _, r := range c.Spec.Datacenter.Racks {
-		if _, ok := c.Status.Racks[r.Name]; !ok {
-			continue
-		}
-		sts, err := statefulSetLister.StatefulSets(c.Namespace).Get(StatefulSetNameForRack(r, c))
-		if err != nil {
-			return true, fmt.Errorf("error getting statefulset: %s", err.Error())
-		}
-		if sts.Generation != sts.Status.ObservedGeneration {
-			return true, nil
+	for _, dc := range c.Spec.GetDatacenters() {
+		for _, r := range dc.Racks {
+			if c.Status.GetRackStatus(dc.Name, r.Name) == nil {
+				continue
+			}
+			sts, err := statefulSetLister.StatefulSets(c.Namespace).Get(StatefulSetNameForRack(r, dc, c))
+			if err != nil {
+				return true, fmt.Errorf("error getting statefulset: %s", err.Error())
+			}
+			if sts.Generation != sts.Status.ObservedGeneration {
+				return true, nil
+			}
 		}
 	}
 	return false, nil
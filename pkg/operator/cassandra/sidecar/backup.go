@@ -0,0 +1,329 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
+	"github.com/rook/rook/pkg/operator/cassandra/controller/util"
+)
+
+const schemaFileName = "schema.cql"
+
+// createStatement matches the statements of a schema that create an object
+var createStatement = regexp.MustCompile(`(?m)^CREATE (KEYSPACE|TABLE|TYPE|CUSTOM INDEX|INDEX|MATERIALIZED VIEW) (IF NOT EXISTS )?`)
+
+// snapshotFile is a file of a snapshot and the key it is uploaded to,
+// relative to the backup of the member.
+type snapshotFile struct {
+	path, key string
+}
+
+// backup takes a snapshot of the data of the member and uploads it, along
+// with the schema of the cluster, to the given backup.
+func (m *MemberController) backup(name string, c *cassandrav1alpha1.Cluster) error {
+	spec := c.Spec.Backup
+	client, err := util.S3ClientForSpec(spec.S3, c.Namespace, m.kubeClient)
+	if err != nil {
+		return err
+	}
+	index, err := util.IndexFromName(m.name)
+	if err != nil {
+		return err
+	}
+	prefix := util.MemberBackupKeyPrefix(c.Namespace, c.Name, name, m.datacenter, m.rack, index)
+
+	m.logger.Infof("Backing up member %s to backup %s", m.name, name)
+	if err := runNodetool(append([]string{"snapshot", "-t", name}, spec.Keyspaces...)...); err != nil {
+		return err
+	}
+	// The snapshot isn't needed anymore once it is uploaded
+	defer func() {
+		if err := runNodetool("clearsnapshot", "-t", name); err != nil {
+			m.logger.Warningf("Error clearing snapshot %s: %s", name, err.Error())
+		}
+	}()
+
+	schema, err := m.cqlsh("-e", "DESCRIBE SCHEMA").Output()
+	if err != nil {
+		return fmt.Errorf("error describing the schema: %s", err.Error())
+	}
+	if err := client.Put(prefix+schemaFileName, bytes.NewReader(schema)); err != nil {
+		return err
+	}
+
+	files, err := snapshotFiles(filepath.Join(m.dataDir(), "data"), name, spec.Keyspaces)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		f, err := os.Open(file.path)
+		if err != nil {
+			return fmt.Errorf("error opening %s: %s", file.path, err.Error())
+		}
+		err = client.Put(prefix+file.key, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	m.logger.Infof("Uploaded %d files of member %s to backup %s", len(files), m.name, name)
+	return nil
+}
+
+// restoreSchema creates the schema of the backup that seeds the cluster.
+func (m *MemberController) restoreSchema(c *cassandrav1alpha1.Cluster) error {
+	spec := c.Spec.RestoreFrom
+	client, err := util.S3ClientForSpec(spec.S3, c.Namespace, m.kubeClient)
+	if err != nil {
+		return err
+	}
+	prefix := util.BackupKeyPrefix(restoreNamespace(c), spec.Cluster, spec.Backup)
+	keys, err := client.List(prefix)
+	if err != nil {
+		return err
+	}
+
+	// Every member of the backup uploaded the schema of the cluster
+	var schema bytes.Buffer
+	for _, key := range keys {
+		if strings.HasSuffix(key, "/"+schemaFileName) {
+			if err := client.Get(key, &schema); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	if schema.Len() == 0 {
+		return fmt.Errorf("no schema found in backup %s", prefix)
+	}
+
+	schemaFile, err := ioutil.TempFile("", schemaFileName)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(schemaFile.Name())
+	_, err = schemaFile.WriteString(ifNotExists(schema.String()))
+	schemaFile.Close()
+	if err != nil {
+		return err
+	}
+
+	m.logger.Infof("Creating the schema of backup %s", prefix)
+	if output, err := m.cqlsh("-f", schemaFile.Name()).CombinedOutput(); err != nil {
+		return fmt.Errorf("error creating the schema: %s, output: %s", err.Error(), string(output))
+	}
+	return nil
+}
+
+// restoreData downloads the sstables uploaded to the backup that seeds the
+// cluster by the member with the same index in the same rack, and streams
+// them to the replicas of their data.
+func (m *MemberController) restoreData(c *cassandrav1alpha1.Cluster) error {
+	spec := c.Spec.RestoreFrom
+	client, err := util.S3ClientForSpec(spec.S3, c.Namespace, m.kubeClient)
+	if err != nil {
+		return err
+	}
+	index, err := util.IndexFromName(m.name)
+	if err != nil {
+		return err
+	}
+	prefix := util.MemberBackupKeyPrefix(restoreNamespace(c), spec.Cluster, spec.Backup, m.datacenter, m.rack, index)
+	keys, err := client.List(prefix)
+	if err != nil {
+		return err
+	}
+
+	restoreDir := filepath.Join(m.dataDir(), "restore", spec.Backup)
+	defer os.RemoveAll(restoreDir)
+
+	tables := map[string]bool{}
+	for _, key := range keys {
+		rel := strings.TrimPrefix(key, prefix)
+		if rel == schemaFileName {
+			continue
+		}
+		path := filepath.Join(restoreDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		err = client.Get(key, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		tables[filepath.Dir(path)] = true
+	}
+	if len(tables) == 0 {
+		m.logger.Infof("No data to restore for member %s in backup %s", m.name, prefix)
+		return nil
+	}
+
+	tableDirs := []string{}
+	for table := range tables {
+		tableDirs = append(tableDirs, table)
+	}
+	sort.Strings(tableDirs)
+	return m.loadSSTables(tableDirs)
+}
+
+// loadSSTables streams the sstables of the given table directories to the
+// replicas of their data. Cassandra loads them through jolokia and Scylla
+// with nodetool refresh, from the upload directory of their table. Neither
+// needs the credentials of the cluster.
+func (m *MemberController) loadSSTables(tableDirs []string) error {
+	for _, dir := range tableDirs {
+		m.logger.Infof("Loading %s", dir)
+		if m.mode != cassandrav1alpha1.ClusterModeScylla {
+			if _, err := jolokiaExec(m.jolokiaURL, storageServiceMBean, "bulkLoad(java.lang.String)", dir); err != nil {
+				return fmt.Errorf("error loading %s: %s", dir, err.Error())
+			}
+			continue
+		}
+
+		keyspace, table := filepath.Base(filepath.Dir(dir)), filepath.Base(dir)
+		uploadDir, err := tableUploadDir(m.dataDir(), keyspace, table)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(uploadDir, 0755); err != nil {
+			return err
+		}
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if err := os.Rename(filepath.Join(dir, f.Name()), filepath.Join(uploadDir, f.Name())); err != nil {
+				return err
+			}
+		}
+		if err := runNodetool("refresh", "--load-and-stream", keyspace, table); err != nil {
+			return fmt.Errorf("error loading %s: %s", dir, err.Error())
+		}
+	}
+	return nil
+}
+
+// tableUploadDir returns the directory that the sstables of the given table
+// are loaded from by nodetool refresh. The directory of the table is named
+// <table>-<id> in the data directory, and exists once its schema is created.
+func tableUploadDir(dataDir, keyspace, table string) (string, error) {
+	tableDirs, err := ioutil.ReadDir(filepath.Join(dataDir, keyspace))
+	if err != nil {
+		return "", fmt.Errorf("error reading directory of keyspace %s: %s", keyspace, err.Error())
+	}
+	for _, tableDir := range tableDirs {
+		name := tableDir.Name()
+		if i := strings.LastIndex(name, "-"); tableDir.IsDir() && i != -1 && name[:i] == table {
+			return filepath.Join(dataDir, keyspace, name, "upload"), nil
+		}
+	}
+	return "", fmt.Errorf("no directory for table %s.%s in %s", keyspace, table, dataDir)
+}
+
+// restoreNamespace returns the namespace of the cluster of the backup that
+// seeds the given cluster.
+func restoreNamespace(c *cassandrav1alpha1.Cluster) string {
+	if c.Spec.RestoreFrom.Namespace != "" {
+		return c.Spec.RestoreFrom.Namespace
+	}
+	return c.Namespace
+}
+
+// snapshotFiles returns the sstable files of the snapshot with the given tag
+// in the data directory, for the given keyspaces or for all the non-system
+// keyspaces. The files are stored in <keyspace>/<table>-<id>/snapshots/<tag>
+// and are uploaded to <keyspace>/<table>, as the id of the table changes when
+// its schema is created again.
+func snapshotFiles(dataDir, tag string, keyspaces []string) ([]snapshotFile, error) {
+	keyspaceDirs, err := ioutil.ReadDir(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading data directory %s: %s", dataDir, err.Error())
+	}
+
+	files := []snapshotFile{}
+	for _, keyspaceDir := range keyspaceDirs {
+		keyspace := keyspaceDir.Name()
+		if !keyspaceDir.IsDir() || !backedUpKeyspace(keyspace, keyspaces) {
+			continue
+		}
+		tableDirs, err := ioutil.ReadDir(filepath.Join(dataDir, keyspace))
+		if err != nil {
+			return nil, err
+		}
+		for _, tableDir := range tableDirs {
+			table := tableDir.Name()
+			if i := strings.LastIndex(table, "-"); i != -1 {
+				table = table[:i]
+			}
+			snapshotDir := filepath.Join(dataDir, keyspace, tableDir.Name(), "snapshots", tag)
+			snapshot, err := ioutil.ReadDir(snapshotDir)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range snapshot {
+				// Only the sstables are loaded back, the snapshot manifest and schema aren't
+				if f.IsDir() || f.Name() == "manifest.json" || f.Name() == schemaFileName {
+					continue
+				}
+				files = append(files, snapshotFile{
+					path: filepath.Join(snapshotDir, f.Name()),
+					key:  fmt.Sprintf("%s/%s/%s", keyspace, table, f.Name()),
+				})
+			}
+		}
+	}
+	return files, nil
+}
+
+// backedUpKeyspace returns true if the keyspace is in the given keyspaces,
+// or if it isn't a system keyspace when no keyspaces are given.
+func backedUpKeyspace(keyspace string, keyspaces []string) bool {
+	if len(keyspaces) == 0 {
+		return keyspace != "system" && !strings.HasPrefix(keyspace, "system_")
+	}
+	for _, k := range keyspaces {
+		if k == keyspace {
+			return true
+		}
+	}
+	return false
+}
+
+// ifNotExists makes the statements of a schema that create an object not
+// fail when the object exists, so that the schema can be created again.
+func ifNotExists(schema string) string {
+	return createStatement.ReplaceAllString(schema, "CREATE $1 IF NOT EXISTS ")
+}
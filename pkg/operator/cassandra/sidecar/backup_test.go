@@ -0,0 +1,103 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassandra-data")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeFile := func(path ...string) {
+		p := filepath.Join(append([]string{dir}, path...)...)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, ioutil.WriteFile(p, []byte("data"), 0644))
+	}
+	writeFile("ks1", "users-5a1c395e", "snapshots", "backup", "md-1-big-Data.db")
+	writeFile("ks1", "users-5a1c395e", "snapshots", "backup", "manifest.json")
+	writeFile("ks1", "users-5a1c395e", "snapshots", "other", "md-2-big-Data.db")
+	writeFile("ks1", "events-9f0e1d2c", "md-3-big-Data.db")
+	writeFile("ks2", "items-0c4d8e3a", "snapshots", "backup", "md-1-big-Data.db")
+	writeFile("system", "local-7ad54392", "snapshots", "backup", "md-1-big-Data.db")
+
+	// all the non-system keyspaces
+	files, err := snapshotFiles(dir, "backup", nil)
+	require.NoError(t, err)
+	require.Equal(t, []snapshotFile{
+		{path: filepath.Join(dir, "ks1", "users-5a1c395e", "snapshots", "backup", "md-1-big-Data.db"), key: "ks1/users/md-1-big-Data.db"},
+		{path: filepath.Join(dir, "ks2", "items-0c4d8e3a", "snapshots", "backup", "md-1-big-Data.db"), key: "ks2/items/md-1-big-Data.db"},
+	}, files)
+
+	// only the given keyspaces
+	files, err = snapshotFiles(dir, "backup", []string{"ks2"})
+	require.NoError(t, err)
+	require.Equal(t, []snapshotFile{
+		{path: filepath.Join(dir, "ks2", "items-0c4d8e3a", "snapshots", "backup", "md-1-big-Data.db"), key: "ks2/items/md-1-big-Data.db"},
+	}, files)
+}
+
+func TestTableUploadDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassandra-data")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "ks1", "users-5a1c395e"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "ks1", "users_by_name-9f0e1d2c"), 0755))
+
+	uploadDir, err := tableUploadDir(dir, "ks1", "users")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "ks1", "users-5a1c395e", "upload"), uploadDir)
+
+	// the schema of the table isn't created
+	_, err = tableUploadDir(dir, "ks1", "items")
+	require.Error(t, err)
+	_, err = tableUploadDir(dir, "ks2", "items")
+	require.Error(t, err)
+}
+
+func TestIfNotExists(t *testing.T) {
+	schema := `CREATE KEYSPACE ks1 WITH replication = {'class': 'NetworkTopologyStrategy', 'dc1': '3'};
+
+CREATE TYPE IF NOT EXISTS ks1.address (street text);
+
+CREATE TABLE ks1.users (
+    id uuid PRIMARY KEY,
+    name text
+);
+
+CREATE INDEX users_name ON ks1.users (name);
+`
+	expected := `CREATE KEYSPACE IF NOT EXISTS ks1 WITH replication = {'class': 'NetworkTopologyStrategy', 'dc1': '3'};
+
+CREATE TYPE IF NOT EXISTS ks1.address (street text);
+
+CREATE TABLE IF NOT EXISTS ks1.users (
+    id uuid PRIMARY KEY,
+    name text
+);
+
+CREATE INDEX IF NOT EXISTS users_name ON ks1.users (name);
+`
+	require.Equal(t, expected, ifNotExists(schema))
+}
@@ -17,17 +17,21 @@ limitations under the License.
 package sidecar
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/rook/rook/pkg/operator/cassandra/constants"
 	"github.com/yanniszark/go-nodetool/nodetool"
 	"net/http"
 )
 
-// setupHTTPChecks brings up the liveness and readiness probes
+// setupHTTPChecks brings up the liveness and readiness probes,
+// the metrics endpoint and the ring endpoint
 func (m *MemberController) setupHTTPChecks() error {
 
 	http.HandleFunc(constants.LivenessProbePath, livenessCheck(m))
 	http.HandleFunc(constants.ReadinessProbePath, readinessCheck(m))
+	http.HandleFunc(constants.MetricsPath, metricsHandler(m))
+	http.HandleFunc(constants.RingPath, ringHandler(m))
 
 	err := http.ListenAndServe(fmt.Sprintf("0.0.0.0:%d", constants.ProbePort), nil)
 	// If ListenAndServe returns, something went wrong
@@ -93,3 +97,22 @@ func readinessCheck(m *MemberController) func(http.ResponseWriter, *http.Request
 	}
 
 }
+
+// ringHandler serves the view of the ring of the member, for the members
+// that lost their data.
+func ringHandler(m *MemberController) func(http.ResponseWriter, *http.Request) {
+
+	return func(w http.ResponseWriter, req *http.Request) {
+
+		status, err := m.localRingStatus()
+		if err != nil {
+			m.logger.Errorf("Error getting the status of the ring: %s", err.Error())
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			m.logger.Errorf("Error writing the status of the ring: %s", err.Error())
+		}
+	}
+}
@@ -37,6 +37,7 @@ const (
 	cassandraYAMLPath             = configDirCassandra + "/" + "cassandra.yaml"
 	cassandraEnvPath              = configDirCassandra + "/" + "cassandra-env.sh"
 	cassandraRackDCPropertiesPath = configDirCassandra + "/" + "cassandra-rackdc.properties"
+	cassandraJVMOptionsPath       = configDirCassandra + "/" + "jvm.options"
 
 	// Scylla-Specific
 	configDirScylla            = "/etc/scylla"
@@ -125,18 +126,50 @@ func (m *MemberController) generateCassandraConfigFiles() error {
 		return fmt.Errorf("%s env variable not found", constants.ResourceLimitMemoryEnvVar)
 	}
 	memNumber, _ := strconv.ParseInt(mem, 10, 64)
-	maxHeapSize := util.Max(util.Min(memNumber/2, 1024), util.Min(memNumber/4, 8192))
-	heapNewSize := util.Min(maxHeapSize/4, 100*cpuNumber)
-	if err := os.Setenv("MAX_HEAP_SIZE", fmt.Sprintf("%dM", maxHeapSize)); err != nil {
+	c, err := m.rookClient.CassandraV1alpha1().Clusters(m.namespace).Get(m.cluster, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting cluster: %s", err.Error())
+	}
+	jvm := c.Spec.JVM
+	if jvm == nil {
+		jvm = &cassandrav1alpha1.JVMSpec{}
+	}
+	maxHeapSize, heapNewSize, err := heapSizes(memNumber, cpuNumber, jvm)
+	if err != nil {
+		return err
+	}
+	if err := os.Setenv("MAX_HEAP_SIZE", maxHeapSize); err != nil {
 		return fmt.Errorf("error setting MAX_HEAP_SIZE: %s", err.Error())
 	}
-	if err := os.Setenv("HEAP_NEWSIZE", fmt.Sprintf("%dM", heapNewSize)); err != nil {
+	if err := os.Setenv("HEAP_NEWSIZE", heapNewSize); err != nil {
 		return fmt.Errorf("error setting HEAP_NEWSIZE: %s", err.Error())
 	}
 
+	// Select the garbage collector in jvm.options, cassandra-env.sh follows it
+	gc, err := garbageCollector(maxHeapSize, jvm)
+	if err != nil {
+		return err
+	}
+	jvmOptions, err := ioutil.ReadFile(cassandraJVMOptionsPath)
+	if err != nil {
+		return fmt.Errorf("error trying to open jvm.options: %s", err.Error())
+	}
+	m.logger.Infof("Using heap size %s with the %s garbage collector", maxHeapSize, gc)
+	if err := ioutil.WriteFile(cassandraJVMOptionsPath, []byte(jvmOptionsForGC(string(jvmOptions), gc)), os.ModePerm); err != nil {
+		return fmt.Errorf("error trying to write jvm.options: %s", err.Error())
+	}
+
 	// Add jolokia javaagent
-	jolokiaConfig := []byte(fmt.Sprintf(`JVM_OPTS="$JVM_OPTS %s"`,
-		getJolokiaConfig()))
+	jvmOpts := getJolokiaConfig()
+	// Add the options of the spec after ours, so that they take precedence
+	for _, opt := range jvm.Options {
+		jvmOpts = fmt.Sprintf("%s %s", jvmOpts, opt)
+	}
+	// A member that lost its data replaces its previous self on its first boot
+	if m.replaceAddress != "" {
+		jvmOpts = fmt.Sprintf("%s -Dcassandra.replace_address_first_boot=%s", jvmOpts, m.replaceAddress)
+	}
+	jolokiaConfig := []byte(fmt.Sprintf(`JVM_OPTS="$JVM_OPTS %s"`, jvmOpts))
 
 	err = ioutil.WriteFile(cassandraEnvPath, append(cassandraEnv, jolokiaConfig...), os.ModePerm)
 	if err != nil {
@@ -331,6 +364,11 @@ func (m *MemberController) overrideConfigValues(configText []byte) ([]byte, erro
 		return nil, fmt.Errorf("POD_IP environment variable not set")
 	}
 
+	c, err := m.rookClient.CassandraV1alpha1().Clusters(m.namespace).Get(m.cluster, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting cluster: %s", err.Error())
+	}
+
 	seedProvider := []map[string]interface{}{
 		{
 			"class_name": "org.apache.cassandra.locator.SimpleSeedProvider",
@@ -350,12 +388,30 @@ func (m *MemberController) overrideConfigValues(configText []byte) ([]byte, erro
 	config["endpoint_snitch"] = "GossipingPropertyFileSnitch"
 	config["seed_provider"] = seedProvider
 
+	// The members of a datacenter added to a running cluster don't bootstrap,
+	// they get their data with a rebuild once the whole datacenter is up.
+	if m.skipBootstrap {
+		config["auto_bootstrap"] = false
+	}
+
+	// A Scylla member that lost its data replaces its previous self on its first boot.
+	// Cassandra gets the address as a system property instead.
+	if m.replaceAddress != "" && m.mode == cassandrav1alpha1.ClusterModeScylla {
+		config["replace_address_first_boot"] = m.replaceAddress
+	}
+
+	if err := m.generateSecurityConfig(config, c); err != nil {
+		return nil, fmt.Errorf("error generating security config: %s", err.Error())
+	}
+
 	return yaml.Marshal(config)
 }
 
 // getSeeds gets the IPs of the instances acting as Seeds
 // in the Cluster. It does that by getting all ClusterIP services
-// of the current Cluster with the cassandra.rook.io/seed label
+// of the current Cluster with the cassandra.rook.io/seed label.
+// The first members of every rack are seeds, so the seeds are
+// drawn from every datacenter of the Cluster.
 func (m *MemberController) getSeeds() (string, error) {
 
 	var services *corev1.ServiceList
@@ -413,6 +469,88 @@ func getJolokiaConfig() string {
 	return fmt.Sprintf("-javaagent:%s=%s", jolokiaPath, strings.Join(cmd, ","))
 }
 
+// heapSizes returns the size of the heap and of its young generation. Unless
+// they are set in the spec, they are derived from the memory and cpu limits,
+// in MB and cores, like cassandra-env.sh does from the memory and cpus of the
+// host.
+func heapSizes(mem, cpu int64, jvm *cassandrav1alpha1.JVMSpec) (string, string, error) {
+	maxHeapSize := util.Max(util.Min(mem/2, 1024), util.Min(mem/4, 8192))
+	if jvm.MaxHeapSize != "" {
+		var err error
+		if maxHeapSize, err = parseJVMSize(jvm.MaxHeapSize); err != nil {
+			return "", "", err
+		}
+	}
+	heapNewSize := fmt.Sprintf("%dM", util.Min(maxHeapSize/4, 100*cpu))
+	if jvm.HeapNewSize != "" {
+		if _, err := parseJVMSize(jvm.HeapNewSize); err != nil {
+			return "", "", err
+		}
+		heapNewSize = jvm.HeapNewSize
+	}
+	return fmt.Sprintf("%dM", maxHeapSize), heapNewSize, nil
+}
+
+// garbageCollector returns the garbage collector of the spec, or G1 for
+// heaps of 8G and more, as CMS doesn't scale well to large heaps.
+func garbageCollector(maxHeapSize string, jvm *cassandrav1alpha1.JVMSpec) (cassandrav1alpha1.GarbageCollector, error) {
+	if jvm.GC != "" {
+		return jvm.GC, nil
+	}
+	size, err := parseJVMSize(maxHeapSize)
+	if err != nil {
+		return "", err
+	}
+	if size >= 8192 {
+		return cassandrav1alpha1.GarbageCollectorG1, nil
+	}
+	return cassandrav1alpha1.GarbageCollectorCMS, nil
+}
+
+// parseJVMSize parses a size of the JVM options, e.g. 512M or 8G, in MB.
+func parseJVMSize(size string) (int64, error) {
+	multipliers := map[string]int64{"K": 1, "M": 1024, "G": 1024 * 1024}
+	unit := strings.ToUpper(size[len(size)-1:])
+	multiplier, ok := multipliers[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %s, the unit must be one of K, M or G", size)
+	}
+	value, err := strconv.ParseInt(size[:len(size)-1], 10, 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("invalid size %s", size)
+	}
+	return value * multiplier / 1024, nil
+}
+
+// jvmOptionsForGC edits the default jvm.options of Cassandra for the given
+// garbage collector. CMS is the default, so only G1 needs the options of CMS
+// commented out and the main options of G1 uncommented.
+func jvmOptionsForGC(jvmOptions string, gc cassandrav1alpha1.GarbageCollector) string {
+	if gc != cassandrav1alpha1.GarbageCollectorG1 {
+		return jvmOptions
+	}
+	cmsOptions := []string{"-XX:+UseParNewGC", "-XX:+UseConcMarkSweepGC", "-XX:SurvivorRatio", "-XX:MaxTenuringThreshold", "-XX:+UseCMS", "-XX:CMS", "-XX:+CMS"}
+	g1Options := []string{"-XX:+UseG1GC", "-XX:G1RSetUpdatingPauseTimePercent", "-XX:MaxGCPauseMillis"}
+	hasPrefix := func(line string, prefixes []string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(line, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	lines := strings.Split(jvmOptions, "\n")
+	for i, line := range lines {
+		if hasPrefix(line, cmsOptions) {
+			lines[i] = "#" + line
+		} else if strings.HasPrefix(line, "#") && hasPrefix(strings.TrimLeft(line, "#"), g1Options) {
+			lines[i] = strings.TrimLeft(line, "#")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 // Merge YAMLs merges two arbitrary YAML structures on the top level.
 func mergeYAMLs(initialYAML, overrideYAML []byte) ([]byte, error) {
 
@@ -19,6 +19,9 @@ package sidecar
 import (
 	"bytes"
 	"testing"
+
+	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMergeYAMLs(t *testing.T) {
@@ -68,3 +71,62 @@ func TestMergeYAMLs(t *testing.T) {
 		}
 	}
 }
+
+func TestHeapSizes(t *testing.T) {
+	tests := []struct {
+		mem, cpu             int64
+		jvm                  cassandrav1alpha1.JVMSpec
+		maxHeapSize, newSize string
+		gc                   cassandrav1alpha1.GarbageCollector
+	}{
+		// derived from the limits
+		{4096, 2, cassandrav1alpha1.JVMSpec{}, "1024M", "200M", cassandrav1alpha1.GarbageCollectorCMS},
+		{65536, 8, cassandrav1alpha1.JVMSpec{}, "8192M", "800M", cassandrav1alpha1.GarbageCollectorG1},
+		// set in the spec
+		{4096, 2, cassandrav1alpha1.JVMSpec{MaxHeapSize: "2G", HeapNewSize: "400m"}, "2048M", "400m", cassandrav1alpha1.GarbageCollectorCMS},
+		{65536, 8, cassandrav1alpha1.JVMSpec{GC: cassandrav1alpha1.GarbageCollectorCMS}, "8192M", "800M", cassandrav1alpha1.GarbageCollectorCMS},
+	}
+	for _, test := range tests {
+		maxHeapSize, newSize, err := heapSizes(test.mem, test.cpu, &test.jvm)
+		require.NoError(t, err)
+		require.Equal(t, test.maxHeapSize, maxHeapSize)
+		require.Equal(t, test.newSize, newSize)
+		gc, err := garbageCollector(maxHeapSize, &test.jvm)
+		require.NoError(t, err)
+		require.Equal(t, test.gc, gc)
+	}
+
+	_, _, err := heapSizes(4096, 2, &cassandrav1alpha1.JVMSpec{MaxHeapSize: "2GB"})
+	require.Error(t, err)
+}
+
+func TestJVMOptionsForGC(t *testing.T) {
+	jvmOptions := `### CMS Settings
+-XX:+UseParNewGC
+-XX:+UseConcMarkSweepGC
+-XX:SurvivorRatio=8
+-XX:CMSInitiatingOccupancyFraction=75
+-XX:+UseCMSInitiatingOccupancyOnly
+### G1 Settings
+## Use the Hotspot garbage-first collector.
+#-XX:+UseG1GC
+#-XX:G1RSetUpdatingPauseTimePercent=5
+#-XX:MaxGCPauseMillis=500
+#-XX:ParallelGCThreads=16
+-XX:+HeapDumpOnOutOfMemoryError`
+
+	require.Equal(t, jvmOptions, jvmOptionsForGC(jvmOptions, cassandrav1alpha1.GarbageCollectorCMS))
+	require.Equal(t, `### CMS Settings
+#-XX:+UseParNewGC
+#-XX:+UseConcMarkSweepGC
+#-XX:SurvivorRatio=8
+#-XX:CMSInitiatingOccupancyFraction=75
+#-XX:+UseCMSInitiatingOccupancyOnly
+### G1 Settings
+## Use the Hotspot garbage-first collector.
+-XX:+UseG1GC
+-XX:G1RSetUpdatingPauseTimePercent=5
+-XX:MaxGCPauseMillis=500
+#-XX:ParallelGCThreads=16
+-XX:+HeapDumpOnOutOfMemoryError`, jvmOptionsForGC(jvmOptions, cassandrav1alpha1.GarbageCollectorG1))
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const (
+	storageServiceMBean = "org.apache.cassandra.db:type=StorageService"
+)
+
+// jolokiaRequest is a request for the jolokia agent
+type jolokiaRequest struct {
+	Type      string        `json:"type"`
+	MBean     string        `json:"mbean"`
+	Attribute string        `json:"attribute,omitempty"`
+	Operation string        `json:"operation,omitempty"`
+	Arguments []interface{} `json:"arguments,omitempty"`
+}
+
+// jolokiaResponse is the response of the jolokia agent to a request
+type jolokiaResponse struct {
+	Status int             `json:"status"`
+	Value  json.RawMessage `json:"value"`
+	Error  string          `json:"error"`
+}
+
+// jolokiaExec executes an operation of an MBean through the jolokia agent of
+// the member and returns the value it returned. Operations that the nodetool
+// library doesn't support are executed this way. The call blocks until the
+// operation is finished.
+func jolokiaExec(jolokiaURL *url.URL, mbean, operation string, args ...interface{}) (json.RawMessage, error) {
+	return jolokiaDo(jolokiaURL, jolokiaRequest{Type: "exec", MBean: mbean, Operation: operation, Arguments: args})
+}
+
+// jolokiaRead reads an attribute of an MBean through the jolokia agent of
+// the member.
+func jolokiaRead(jolokiaURL *url.URL, mbean, attribute string) (json.RawMessage, error) {
+	return jolokiaDo(jolokiaURL, jolokiaRequest{Type: "read", MBean: mbean, Attribute: attribute})
+}
+
+// jolokiaDo sends the request to the jolokia agent and returns the value
+// of the response.
+func jolokiaDo(jolokiaURL *url.URL, request jolokiaRequest) (json.RawMessage, error) {
+	name := request.Operation
+	if request.Type == "read" {
+		name = request.Attribute
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.Post(jolokiaURL.String(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error executing %s: %s", name, err.Error())
+	}
+	defer resp.Body.Close()
+
+	var result jolokiaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error decoding response of %s: %s", name, err.Error())
+	}
+	if result.Status != http.StatusOK {
+		return nil, fmt.Errorf("error executing %s, status %d: %s", name, result.Status, result.Error)
+	}
+	return result.Value, nil
+}
+
+// rebuild streams the data of the member's token ranges from the given datacenter.
+func (m *MemberController) rebuild(sourceDC string) error {
+	m.logger.Infof("Rebuilding member %s from datacenter %s", m.name, sourceDC)
+	_, err := jolokiaExec(m.jolokiaURL, storageServiceMBean, "rebuild(java.lang.String)", sourceDC)
+	return err
+}
+
+// localHostID returns the host ID of the member in the ring.
+func (m *MemberController) localHostID() (string, error) {
+	value, err := jolokiaRead(m.jolokiaURL, storageServiceMBean, "LocalHostId")
+	if err != nil {
+		return "", err
+	}
+	var hostID string
+	if err := json.Unmarshal(value, &hostID); err != nil {
+		return "", fmt.Errorf("error decoding host id: %s", err.Error())
+	}
+	return hostID, nil
+}
+
+// ringStatus is the view of the ring of a member: the host ID of every
+// member of the ring by address, and the addresses of the members that
+// are down.
+type ringStatus struct {
+	HostIDs     map[string]string `json:"hostIDs"`
+	Unreachable []string          `json:"unreachable"`
+}
+
+// localRingStatus returns the view of the ring of the member.
+func (m *MemberController) localRingStatus() (*ringStatus, error) {
+	status := &ringStatus{}
+	value, err := jolokiaRead(m.jolokiaURL, storageServiceMBean, "HostIdMap")
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(value, &status.HostIDs); err != nil {
+		return nil, fmt.Errorf("error decoding host id map: %s", err.Error())
+	}
+	value, err = jolokiaRead(m.jolokiaURL, storageServiceMBean, "UnreachableNodes")
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(value, &status.Unreachable); err != nil {
+		return nil, fmt.Errorf("error decoding unreachable nodes: %s", err.Error())
+	}
+	return status, nil
+}
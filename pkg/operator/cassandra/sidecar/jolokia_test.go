@@ -0,0 +1,65 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJolokiaExec(t *testing.T) {
+	var request jolokiaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		if request.Type == "read" {
+			fmt.Fprint(w, `{"status":200,"value":"8b3a2a6e-4cd4-4c1b-9d5a-1fd7c7d2e2a4"}`)
+			return
+		}
+		if request.Arguments[0] == "unknown-dc" {
+			fmt.Fprint(w, `{"status":500,"error":"java.lang.IllegalArgumentException: unknown-dc"}`)
+			return
+		}
+		fmt.Fprint(w, `{"status":200,"value":null}`)
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	_, err = jolokiaExec(u, storageServiceMBean, "rebuild(java.lang.String)", "dc1")
+	require.NoError(t, err)
+	require.Equal(t, "exec", request.Type)
+	require.Equal(t, storageServiceMBean, request.MBean)
+	require.Equal(t, "rebuild(java.lang.String)", request.Operation)
+	require.Equal(t, []interface{}{"dc1"}, request.Arguments)
+
+	_, err = jolokiaExec(u, storageServiceMBean, "rebuild(java.lang.String)", "unknown-dc")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown-dc")
+
+	m := &MemberController{jolokiaURL: u}
+	hostID, err := m.localHostID()
+	require.NoError(t, err)
+	require.Equal(t, "read", request.Type)
+	require.Equal(t, "LocalHostId", request.Attribute)
+	require.Equal(t, "8b3a2a6e-4cd4-4c1b-9d5a-1fd7c7d2e2a4", hostID)
+}
@@ -0,0 +1,312 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
+	"github.com/rook/rook/pkg/operator/cassandra/constants"
+)
+
+const (
+	metricsNamespace = "cassandra"
+
+	// The latencies of Cassandra are in microseconds
+	microsecondsPerSecond = 1e6
+)
+
+// metric is a metric in the Prometheus text format
+type metric struct {
+	name, help, kind string
+	samples          []sample
+}
+
+// sample is a value of a metric with its labels. The suffix is
+// appended to the name of the metric, like the _sum and _count
+// of a summary.
+type sample struct {
+	suffix string
+	labels map[string]string
+	value  float64
+}
+
+// metricsHandler serves the metrics of the member in the Prometheus text
+// format. The metrics are read from the JMX MBeans of the member through
+// jolokia on every scrape. In Scylla mode, the metrics that Scylla exposes
+// on its own Prometheus port are served too.
+func metricsHandler(m *MemberController) func(http.ResponseWriter, *http.Request) {
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		var buf bytes.Buffer
+		writeMetrics(&buf, m.jmxMetrics())
+
+		if m.mode == cassandrav1alpha1.ClusterModeScylla {
+			if err := scyllaMetrics(&buf); err != nil {
+				m.logger.Errorf("Error getting scylla metrics: %s", err.Error())
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(buf.Bytes())
+	}
+}
+
+// jmxMetrics reads the metrics of the member through jolokia. The metrics
+// that can't be read are left out, the up metric reports if jolokia is
+// reachable at all.
+func (m *MemberController) jmxMetrics() []metric {
+
+	up := metric{
+		name: "up",
+		help: "Whether the JMX metrics of the member can be read.",
+		kind: "gauge",
+	}
+	heap, err := m.heapMetrics()
+	if err != nil {
+		m.logger.Errorf("Error reading jmx metrics: %s", err.Error())
+		up.samples = []sample{{value: 0}}
+		return []metric{up}
+	}
+	up.samples = []sample{{value: 1}}
+	metrics := []metric{up, heap}
+
+	for _, read := range []func() (metric, error){
+		m.latencyMetrics,
+		m.requestMetrics,
+		m.pendingCompactionMetrics,
+		m.droppedMessageMetrics,
+		m.hintMetrics,
+	} {
+		metric, err := read()
+		if err != nil {
+			m.logger.Debugf("Error reading jmx metric: %s", err.Error())
+			continue
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics
+}
+
+// heapMetrics reads the heap usage of the JVM.
+func (m *MemberController) heapMetrics() (metric, error) {
+	var usage map[string]float64
+	if err := m.readMBean("java.lang:type=Memory", "HeapMemoryUsage", &usage); err != nil {
+		return metric{}, err
+	}
+	heap := metric{
+		name: "jvm_heap_bytes",
+		help: "Heap memory of the JVM, by area.",
+		kind: "gauge",
+	}
+	for _, area := range []string{"used", "committed", "max"} {
+		heap.samples = append(heap.samples, sample{labels: map[string]string{"area": area}, value: usage[area]})
+	}
+	return heap, nil
+}
+
+// latencyMetrics reads the latencies of the reads and writes that the member
+// coordinated, as a summary of their quantiles, total and count.
+func (m *MemberController) latencyMetrics() (metric, error) {
+	latency := metric{
+		name: "client_request_latency_seconds",
+		help: "Latency of the client requests coordinated by the member, by operation.",
+		kind: "summary",
+	}
+	for _, operation := range []string{"Read", "Write"} {
+		var values map[string]float64
+		mbean := fmt.Sprintf("org.apache.cassandra.metrics:type=ClientRequest,scope=%s,name=Latency", operation)
+		if err := m.readMBean(mbean, "", &values); err != nil {
+			return metric{}, err
+		}
+		var total float64
+		mbean = fmt.Sprintf("org.apache.cassandra.metrics:type=ClientRequest,scope=%s,name=TotalLatency", operation)
+		if err := m.readMBean(mbean, "Count", &total); err != nil {
+			return metric{}, err
+		}
+
+		labels := map[string]string{"operation": strings.ToLower(operation)}
+		for _, quantile := range []struct{ quantile, attribute string }{
+			{"0.5", "50thPercentile"},
+			{"0.95", "95thPercentile"},
+			{"0.99", "99thPercentile"},
+		} {
+			latency.samples = append(latency.samples, sample{
+				labels: map[string]string{"operation": labels["operation"], "quantile": quantile.quantile},
+				value:  values[quantile.attribute] / microsecondsPerSecond,
+			})
+		}
+		latency.samples = append(latency.samples,
+			sample{suffix: "_sum", labels: labels, value: total / microsecondsPerSecond},
+			sample{suffix: "_count", labels: labels, value: values["Count"]},
+		)
+	}
+	return latency, nil
+}
+
+// requestMetrics reads the number of reads and writes that the member
+// coordinated.
+func (m *MemberController) requestMetrics() (metric, error) {
+	requests := metric{
+		name: "client_requests_total",
+		help: "Number of client requests coordinated by the member, by operation.",
+		kind: "counter",
+	}
+	for _, operation := range []string{"Read", "Write"} {
+		var count float64
+		mbean := fmt.Sprintf("org.apache.cassandra.metrics:type=ClientRequest,scope=%s,name=Latency", operation)
+		if err := m.readMBean(mbean, "Count", &count); err != nil {
+			return metric{}, err
+		}
+		requests.samples = append(requests.samples, sample{
+			labels: map[string]string{"operation": strings.ToLower(operation)},
+			value:  count,
+		})
+	}
+	return requests, nil
+}
+
+// pendingCompactionMetrics reads the number of compactions that the member
+// has yet to run.
+func (m *MemberController) pendingCompactionMetrics() (metric, error) {
+	var pending float64
+	if err := m.readMBean("org.apache.cassandra.metrics:type=Compaction,name=PendingTasks", "Value", &pending); err != nil {
+		return metric{}, err
+	}
+	return metric{
+		name:    "compaction_pending_tasks",
+		help:    "Number of compactions pending on the member.",
+		kind:    "gauge",
+		samples: []sample{{value: pending}},
+	}, nil
+}
+
+// droppedMessageMetrics reads the number of messages that the member dropped
+// because they timed out, for every type of message.
+func (m *MemberController) droppedMessageMetrics() (metric, error) {
+	// Reading a pattern returns the attributes of every matching MBean
+	var values map[string]map[string]float64
+	if err := m.readMBean("org.apache.cassandra.metrics:type=DroppedMessage,scope=*,name=Dropped", "Count", &values); err != nil {
+		return metric{}, err
+	}
+	dropped := metric{
+		name: "dropped_messages_total",
+		help: "Number of messages dropped by the member, by message type.",
+		kind: "counter",
+	}
+	for mbean, attributes := range values {
+		dropped.samples = append(dropped.samples, sample{
+			labels: map[string]string{"message_type": mbeanKey(mbean, "scope")},
+			value:  attributes["Count"],
+		})
+	}
+	sort.Slice(dropped.samples, func(i, j int) bool {
+		return dropped.samples[i].labels["message_type"] < dropped.samples[j].labels["message_type"]
+	})
+	return dropped, nil
+}
+
+// hintMetrics reads the number of hints that the member stored for
+// unavailable members.
+func (m *MemberController) hintMetrics() (metric, error) {
+	var hints float64
+	if err := m.readMBean("org.apache.cassandra.metrics:type=Storage,name=TotalHints", "Count", &hints); err != nil {
+		return metric{}, err
+	}
+	return metric{
+		name:    "hints_total",
+		help:    "Number of hints stored by the member.",
+		kind:    "counter",
+		samples: []sample{{value: hints}},
+	}, nil
+}
+
+// readMBean reads an attribute of an MBean, or all its attributes if the
+// attribute is empty, and decodes it into value.
+func (m *MemberController) readMBean(mbean, attribute string, value interface{}) error {
+	raw, err := jolokiaRead(m.jolokiaURL, mbean, attribute)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, value); err != nil {
+		return fmt.Errorf("error decoding %s of %s: %s", attribute, mbean, err.Error())
+	}
+	return nil
+}
+
+// mbeanKey returns the value of the given key of an MBean name.
+func mbeanKey(mbean, key string) string {
+	properties := mbean[strings.Index(mbean, ":")+1:]
+	for _, property := range strings.Split(properties, ",") {
+		kv := strings.SplitN(property, "=", 2)
+		if len(kv) == 2 && kv[0] == key {
+			return kv[1]
+		}
+	}
+	return ""
+}
+
+// writeMetrics writes the metrics in the Prometheus text format.
+func writeMetrics(w io.Writer, metrics []metric) {
+	for _, metric := range metrics {
+		name := fmt.Sprintf("%s_%s", metricsNamespace, metric.name)
+		fmt.Fprintf(w, "# HELP %s %s\n", name, metric.help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, metric.kind)
+		for _, s := range metric.samples {
+			fmt.Fprintf(w, "%s%s%s %g\n", name, s.suffix, formatLabels(s.labels), s.value)
+		}
+	}
+}
+
+// formatLabels returns the labels of a sample in the Prometheus text format.
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := []string{}
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := []string{}
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, labels[name]))
+	}
+	return fmt.Sprintf("{%s}", strings.Join(pairs, ","))
+}
+
+// scyllaMetrics copies the metrics that Scylla exposes on its Prometheus port.
+func scyllaMetrics(w io.Writer) error {
+	client := http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d%s", constants.ScyllaPrometheusPort, constants.MetricsPath))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	// values of the MBeans by name and attribute
+	latency := "org.apache.cassandra.metrics:type=ClientRequest,scope=%s,name=Latency"
+	totalLatency := "org.apache.cassandra.metrics:type=ClientRequest,scope=%s,name=TotalLatency"
+	values := map[string]string{
+		"java.lang:type=Memory/HeapMemoryUsage":                                `{"used":1048576,"committed":2097152,"max":4194304,"init":2097152}`,
+		fmt.Sprintf(latency, "Read") + "/":                                     `{"Count":100,"50thPercentile":500,"95thPercentile":1000,"99thPercentile":2000}`,
+		fmt.Sprintf(latency, "Read") + "/Count":                                `100`,
+		fmt.Sprintf(latency, "Write") + "/":                                    `{"Count":50,"50thPercentile":250,"95thPercentile":400,"99thPercentile":800}`,
+		fmt.Sprintf(latency, "Write") + "/Count":                               `50`,
+		fmt.Sprintf(totalLatency, "Read") + "/Count":                           `60000`,
+		fmt.Sprintf(totalLatency, "Write") + "/Count":                          `15000`,
+		"org.apache.cassandra.metrics:type=Compaction,name=PendingTasks/Value": `3`,
+		"org.apache.cassandra.metrics:type=DroppedMessage,scope=*,name=Dropped/Count": `{
+			"org.apache.cassandra.metrics:name=Dropped,scope=READ,type=DroppedMessage": {"Count": 2},
+			"org.apache.cassandra.metrics:name=Dropped,scope=MUTATION,type=DroppedMessage": {"Count": 5}
+		}`,
+		"org.apache.cassandra.metrics:type=Storage,name=TotalHints/Count": `7`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request jolokiaRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		value, ok := values[request.MBean+"/"+request.Attribute]
+		if !ok {
+			fmt.Fprint(w, `{"status":404,"error":"javax.management.InstanceNotFoundException"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"status":200,"value":%s}`, value)
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	m := &MemberController{
+		jolokiaURL: u,
+		logger:     capnslog.NewPackageLogger("github.com/rook/rook", "sidecar"),
+	}
+	recorder := httptest.NewRecorder()
+	metricsHandler(m)(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+
+	for _, line := range []string{
+		"# TYPE cassandra_up gauge\ncassandra_up 1\n",
+		`cassandra_jvm_heap_bytes{area="used"} 1.048576e+06`,
+		"# TYPE cassandra_client_request_latency_seconds summary\n",
+		`cassandra_client_request_latency_seconds{operation="read",quantile="0.99"} 0.002`,
+		`cassandra_client_request_latency_seconds{operation="write",quantile="0.5"} 0.00025`,
+		"cassandra_client_request_latency_seconds_sum{operation=\"read\"} 0.06\ncassandra_client_request_latency_seconds_count{operation=\"read\"} 100\n",
+		`cassandra_client_request_latency_seconds_sum{operation="write"} 0.015`,
+		`cassandra_client_requests_total{operation="read"} 100`,
+		`cassandra_compaction_pending_tasks 3`,
+		"cassandra_dropped_messages_total{message_type=\"MUTATION\"} 5\ncassandra_dropped_messages_total{message_type=\"READ\"} 2\n",
+		`cassandra_hints_total 7`,
+	} {
+		require.Contains(t, body, line)
+	}
+
+	// the member is down
+	server.Close()
+	recorder = httptest.NewRecorder()
+	metricsHandler(m)(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, "# HELP cassandra_up Whether the JMX metrics of the member can be read.\n# TYPE cassandra_up gauge\ncassandra_up 0\n", recorder.Body.String())
+}
+
+func TestMBeanKey(t *testing.T) {
+	mbean := "org.apache.cassandra.metrics:name=Dropped,scope=READ_REPAIR,type=DroppedMessage"
+	require.Equal(t, "READ_REPAIR", mbeanKey(mbean, "scope"))
+	require.Equal(t, "DroppedMessage", mbeanKey(mbean, "type"))
+	require.Equal(t, "", mbeanKey(mbean, "keyspace"))
+}
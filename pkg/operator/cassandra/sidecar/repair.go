@@ -0,0 +1,57 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
+)
+
+// repair repairs the ranges of the member for the given keyspace.
+// It blocks until the repair is finished, so the sync loop runs it
+// in the background.
+func (m *MemberController) repair(keyspace string, spec *cassandrav1alpha1.RepairSpec) error {
+	args := repairArgs(keyspace, spec)
+	m.logger.Infof("Repairing keyspace %s: nodetool %v", keyspace, args)
+	if err := runNodetool(args...); err != nil {
+		return err
+	}
+	m.logger.Infof("Repaired keyspace %s", keyspace)
+	return nil
+}
+
+// repairArgs returns the nodetool arguments to repair the ranges of the member
+// for the given keyspace.
+func repairArgs(keyspace string, spec *cassandrav1alpha1.RepairSpec) []string {
+	args := []string{"repair"}
+	// Repairs are incremental by default. Incremental repairs anticompact the
+	// repaired data, which breaks if they are limited to the primary ranges,
+	// so each member repairs all of its ranges.
+	if !spec.Incremental {
+		// Every member repairs its primary ranges, so that every range is repaired
+		// once when all the members have run the repair.
+		args = append(args, "-full", "-pr")
+	}
+
+	switch spec.Parallelism {
+	case cassandrav1alpha1.RepairParallelismSequential:
+		args = append(args, "-seq")
+	case cassandrav1alpha1.RepairParallelismDCParallel:
+		args = append(args, "-dcpar")
+	}
+
+	return append(args, "--", keyspace)
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"testing"
+
+	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepairArgs(t *testing.T) {
+	tests := []struct {
+		spec cassandrav1alpha1.RepairSpec
+		args []string
+	}{
+		{
+			cassandrav1alpha1.RepairSpec{},
+			[]string{"repair", "-full", "-pr", "--", "ks"},
+		},
+		{
+			cassandrav1alpha1.RepairSpec{Parallelism: cassandrav1alpha1.RepairParallelismSequential},
+			[]string{"repair", "-full", "-pr", "-seq", "--", "ks"},
+		},
+		{
+			cassandrav1alpha1.RepairSpec{Incremental: true, Parallelism: cassandrav1alpha1.RepairParallelismDCParallel},
+			[]string{"repair", "-dcpar", "--", "ks"},
+		},
+		{
+			cassandrav1alpha1.RepairSpec{Parallelism: cassandrav1alpha1.RepairParallelismParallel},
+			[]string{"repair", "-full", "-pr", "--", "ks"},
+		},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.args, repairArgs("ks", &test.spec))
+	}
+}
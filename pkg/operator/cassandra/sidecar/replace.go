@@ -0,0 +1,243 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
+	"github.com/rook/rook/pkg/operator/cassandra/constants"
+	"github.com/rook/rook/pkg/operator/cassandra/controller/util"
+	"github.com/yanniszark/go-nodetool/nodetool"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// hostIDCheckInterval is how often the member checks if it has joined
+// the ring, to record its host ID.
+const hostIDCheckInterval = 10 * time.Second
+
+// ringStatusTimeout is how long the member waits for another member to
+// return its view of the ring.
+const ringStatusTimeout = 10 * time.Second
+
+// checkReplace returns the address of the member to replace when the member
+// starts with an empty data directory, while the ring still has the host ID
+// that the member recorded when it joined the ring, as down. The member then
+// replaces its previous self, which has the same address, instead of
+// bootstrapping with an identity that the ring already knows. If the host ID
+// was removed from the ring, the member bootstraps as a new member.
+func (m *MemberController) checkReplace() (string, error) {
+	memberService, err := m.kubeClient.CoreV1().Services(m.namespace).Get(m.name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("error getting member service: %s", err.Error())
+	}
+
+	// The member restarted before finishing the replacement
+	if memberService.Labels[constants.ReplaceLabel] == constants.LabelValueFalse {
+		m.logger.Infof("Resuming the replacement of member %s", m.name)
+		return m.ip, nil
+	}
+
+	// The member never joined the ring
+	hostID, ok := memberService.Annotations[constants.HostIDAnnotation]
+	if !ok {
+		return "", nil
+	}
+
+	empty, err := dataDirEmpty(filepath.Join(m.dataDir(), "data"))
+	if err != nil {
+		return "", err
+	}
+	if !empty {
+		return "", nil
+	}
+
+	// Check the view of the ring of the other members, as the member is not running yet
+	status, err := m.peerRingStatus()
+	if err != nil {
+		return "", err
+	}
+	if status == nil {
+		m.logger.Infof("Member %s lost its data and is the only member of the cluster, bootstrapping it", m.name)
+		return "", nil
+	}
+	inRing, down := hostIDStatus(status, hostID)
+	if !inRing {
+		m.logger.Infof("Host ID %s of member %s is no longer in the ring, bootstrapping it as a new member", hostID, m.name)
+		return "", nil
+	}
+	if !down {
+		return "", fmt.Errorf("member %s lost its data, but its host ID %s is up in the ring", m.name, hostID)
+	}
+
+	m.logger.Infof("Member %s with host ID %s lost its data, replacing it", m.name, hostID)
+	old := memberService.DeepCopy()
+	memberService.Labels[constants.ReplaceLabel] = constants.LabelValueFalse
+	if err := util.PatchService(old, memberService, m.kubeClient); err != nil {
+		return "", fmt.Errorf("error patching MemberService, %s", err.Error())
+	}
+	return m.ip, nil
+}
+
+// peerRingStatus returns the view of the ring of the first other member of
+// the cluster that returns it, or nil if the cluster has no other member.
+func (m *MemberController) peerRingStatus() (*ringStatus, error) {
+	sel := fmt.Sprintf("%s=%s", constants.ClusterNameLabel, m.cluster)
+	services, err := m.kubeClient.CoreV1().Services(m.namespace).List(metav1.ListOptions{LabelSelector: sel})
+	if err != nil {
+		return nil, fmt.Errorf("error listing member services: %s", err.Error())
+	}
+
+	client := &http.Client{Timeout: ringStatusTimeout}
+	peers := 0
+	for _, svc := range services.Items {
+		if svc.Name == m.name {
+			continue
+		}
+		// The member services have the names of the pods of the members
+		pod, err := m.kubeClient.CoreV1().Pods(m.namespace).Get(svc.Name, metav1.GetOptions{})
+		if err != nil || pod.Status.PodIP == "" {
+			continue
+		}
+		peers++
+		status, err := getRingStatus(client, fmt.Sprintf("http://%s:%d%s", pod.Status.PodIP, constants.ProbePort, constants.RingPath))
+		if err != nil {
+			m.logger.Infof("Error getting the view of the ring of member %s: %s", svc.Name, err.Error())
+			continue
+		}
+		return status, nil
+	}
+	if peers == 0 {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("no member of cluster %s returned its view of the ring", m.cluster)
+}
+
+// getRingStatus returns the view of the ring served at the given url.
+func getRingStatus(client *http.Client, url string) (*ringStatus, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	status := &ringStatus{}
+	if err := json.NewDecoder(resp.Body).Decode(status); err != nil {
+		return nil, fmt.Errorf("error decoding the view of the ring: %s", err.Error())
+	}
+	return status, nil
+}
+
+// hostIDStatus returns if the given host ID is in the ring, and if its
+// member is down.
+func hostIDStatus(status *ringStatus, hostID string) (bool, bool) {
+	for address, id := range status.HostIDs {
+		if id != hostID {
+			continue
+		}
+		for _, unreachable := range status.Unreachable {
+			if unreachable == address {
+				return true, true
+			}
+		}
+		return true, false
+	}
+	return false, false
+}
+
+// recordHostID waits for the member to join the ring, then records its host
+// ID in the member service. It also marks a replacement of the member as done,
+// as the member has finished bootstrapping once it has joined the ring.
+func (m *MemberController) recordHostID(stopCh <-chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(hostIDCheckInterval):
+		}
+
+		if err := m.updateHostID(); err != nil {
+			m.logger.Infof("Member %s hasn't joined the ring yet: %s", m.name, err.Error())
+			continue
+		}
+		return
+	}
+}
+
+// updateHostID records the host ID of the member in the member service,
+// if the member has joined the ring.
+func (m *MemberController) updateHostID() error {
+	hostIDMap, err := m.nodetool.Status()
+	if err != nil {
+		return err
+	}
+	localNode, ok := hostIDMap[m.ip]
+	if !ok || localNode.Status != nodetool.NodeStatusUp || localNode.State != nodetool.NodeStateNormal {
+		return fmt.Errorf("member is not up and normal in the ring")
+	}
+	hostID, err := m.localHostID()
+	if err != nil {
+		return err
+	}
+
+	memberService, err := m.kubeClient.CoreV1().Services(m.namespace).Get(m.name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting member service: %s", err.Error())
+	}
+	old := memberService.DeepCopy()
+	if memberService.Annotations == nil {
+		memberService.Annotations = map[string]string{}
+	}
+	memberService.Annotations[constants.HostIDAnnotation] = hostID
+	if _, ok := memberService.Labels[constants.ReplaceLabel]; ok {
+		m.logger.Infof("Member %s finished replacing its previous self", m.name)
+		memberService.Labels[constants.ReplaceLabel] = constants.LabelValueTrue
+	}
+	if err := util.PatchService(old, memberService, m.kubeClient); err != nil {
+		return fmt.Errorf("error patching MemberService, %s", err.Error())
+	}
+	m.logger.Infof("Recorded host ID %s of member %s", hostID, m.name)
+	return nil
+}
+
+// dataDir returns the directory where the member stores its data.
+func (m *MemberController) dataDir() string {
+	if m.mode == cassandrav1alpha1.ClusterModeScylla {
+		return constants.DataDirScylla
+	}
+	return constants.DataDirCassandra
+}
+
+// dataDirEmpty returns true if the given directory doesn't exist or is empty.
+func dataDirEmpty(dir string) (bool, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error reading data directory %s: %s", dir, err.Error())
+	}
+	return len(files) == 0, nil
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/rook/rook/pkg/operator/cassandra/constants"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDataDirEmpty(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassandra-data")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	empty, err := dataDirEmpty(filepath.Join(dir, "data"))
+	require.NoError(t, err)
+	require.True(t, empty)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "data"), 0755))
+	empty, err = dataDirEmpty(filepath.Join(dir, "data"))
+	require.NoError(t, err)
+	require.True(t, empty)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "data", "system"), 0755))
+	empty, err = dataDirEmpty(filepath.Join(dir, "data"))
+	require.NoError(t, err)
+	require.False(t, empty)
+}
+
+func TestCheckReplace(t *testing.T) {
+	memberService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster-test-dc-test-rack-0",
+			Namespace: "test-ns",
+			Labels:    map[string]string{},
+		},
+	}
+	newMemberController := func(svc *corev1.Service) *MemberController {
+		return &MemberController{
+			name:       svc.Name,
+			namespace:  svc.Namespace,
+			ip:         "10.0.0.1",
+			kubeClient: kubefake.NewSimpleClientset(svc),
+			logger:     capnslog.NewPackageLogger("github.com/rook/rook", "sidecar"),
+		}
+	}
+
+	// the member never joined the ring
+	address, err := newMemberController(memberService).checkReplace()
+	require.NoError(t, err)
+	require.Equal(t, "", address)
+
+	// the member restarted before finishing the replacement
+	replacing := memberService.DeepCopy()
+	replacing.Labels[constants.ReplaceLabel] = constants.LabelValueFalse
+	replacing.Annotations = map[string]string{constants.HostIDAnnotation: "8b3a2a6e-4cd4-4c1b-9d5a-1fd7c7d2e2a4"}
+	address, err = newMemberController(replacing).checkReplace()
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1", address)
+
+	// the member lost its data, but no other member is left in the ring
+	lost := memberService.DeepCopy()
+	lost.Annotations = map[string]string{constants.HostIDAnnotation: "8b3a2a6e-4cd4-4c1b-9d5a-1fd7c7d2e2a4"}
+	m := newMemberController(lost)
+	address, err = m.checkReplace()
+	require.NoError(t, err)
+	require.Equal(t, "", address)
+	svc, err := m.kubeClient.CoreV1().Services(lost.Namespace).Get(lost.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	_, ok := svc.Labels[constants.ReplaceLabel]
+	require.False(t, ok)
+}
+
+func TestGetRingStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"hostIDs":{"10.0.0.1":"8b3a2a6e","10.0.0.2":"5c1e7f0a"},"unreachable":["10.0.0.1"]}`))
+	}))
+	defer server.Close()
+
+	status, err := getRingStatus(server.Client(), server.URL)
+	require.NoError(t, err)
+
+	// the member is down in the ring
+	inRing, down := hostIDStatus(status, "8b3a2a6e")
+	require.True(t, inRing)
+	require.True(t, down)
+
+	// the member is up in the ring
+	inRing, down = hostIDStatus(status, "5c1e7f0a")
+	require.True(t, inRing)
+	require.False(t, down)
+
+	// the member was removed from the ring
+	inRing, _ = hostIDStatus(status, "9d2f4b1c")
+	require.False(t, inRing)
+}
@@ -0,0 +1,374 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Keys of the secret with the superuser
+	usernameKey = "username"
+	passwordKey = "password"
+
+	// Keys of the secret with the certificates
+	certificateKey = "tls.crt"
+	privateKeyKey  = "tls.key"
+	caKey          = "ca.crt"
+
+	// Default superuser of a cluster with authentication
+	defaultSuperuser = "cassandra"
+
+	// Files of the certificates in the config directory
+	tlsDirName       = "tls"
+	keystoreName     = "keystore.p12"
+	truststoreName   = "truststore.p12"
+	cqlshrcName      = "cqlshrc"
+	defaultStoreType = "PKCS12"
+
+	// cqlshrc of the default superuser, to create the superuser with
+	defaultCqlshrcName = "cqlshrc-default"
+)
+
+// tlsFiles are the certificates of the member written in the config directory
+type tlsFiles struct {
+	certificate, privateKey, ca string
+	// Cassandra reads the certificates from stores instead
+	keystore, truststore, storePassword string
+}
+
+// configDir returns the directory of the configuration files of the member.
+func (m *MemberController) configDir() string {
+	if m.mode == cassandrav1alpha1.ClusterModeScylla {
+		return configDirScylla
+	}
+	return configDirCassandra
+}
+
+// cqlshrcPath returns the path of the cqlshrc that the sidecar runs cqlsh with.
+func (m *MemberController) cqlshrcPath() string {
+	return filepath.Join(m.configDir(), cqlshrcName)
+}
+
+// cqlsh returns the command that runs cqlsh on the member with the given
+// arguments, authenticated as the superuser and over TLS if the cluster
+// requires it.
+func (m *MemberController) cqlsh(args ...string) *exec.Cmd {
+	return exec.Command("cqlsh", append([]string{"--cqlshrc", m.cqlshrcPath()}, args...)...)
+}
+
+// defaultCqlsh returns the command that runs cqlsh on the member with the
+// given arguments, authenticated as the default superuser. The credentials
+// are read from a cqlshrc, so that they don't show in the arguments.
+func (m *MemberController) defaultCqlsh(args ...string) *exec.Cmd {
+	cqlshrc := filepath.Join(m.configDir(), defaultCqlshrcName)
+	return exec.Command("cqlsh", append([]string{"--cqlshrc", cqlshrc}, args...)...)
+}
+
+// superuser returns the username and password of the superuser of the
+// authentication settings.
+func (m *MemberController) superuser(auth *cassandrav1alpha1.AuthenticationSpec) (string, string, error) {
+	secret, err := m.kubeClient.CoreV1().Secrets(m.namespace).Get(auth.SuperuserSecretName, metav1.GetOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("error getting secret %s with the superuser: %s", auth.SuperuserSecretName, err.Error())
+	}
+	username, password := string(secret.Data[usernameKey]), string(secret.Data[passwordKey])
+	if username == "" || password == "" {
+		return "", "", fmt.Errorf("secret %s must have a %s and a %s", auth.SuperuserSecretName, usernameKey, passwordKey)
+	}
+	return username, password, nil
+}
+
+// generateSecurityConfig writes the certificates of the member and the
+// cqlshrc of the sidecar, and sets the security settings of the cluster in
+// the given configuration.
+func (m *MemberController) generateSecurityConfig(config map[string]interface{}, c *cassandrav1alpha1.Cluster) error {
+	security := c.Spec.Security
+	if security == nil {
+		security = &cassandrav1alpha1.SecuritySpec{}
+	}
+
+	var files *tlsFiles
+	if security.TLS != nil {
+		var err error
+		if files, err = m.writeTLSFiles(security.TLS); err != nil {
+			return err
+		}
+	}
+	for k, v := range securityConfig(security, files, m.mode) {
+		config[k] = v
+	}
+
+	username, password := "", ""
+	if security.Authentication != nil {
+		var err error
+		if username, password, err = m.superuser(security.Authentication); err != nil {
+			return err
+		}
+		defaultCqlshrc := cqlshrc(defaultSuperuser, defaultSuperuser, security.TLS, files)
+		if err := ioutil.WriteFile(filepath.Join(m.configDir(), defaultCqlshrcName), []byte(defaultCqlshrc), 0600); err != nil {
+			return fmt.Errorf("error writing cqlshrc of the default superuser: %s", err.Error())
+		}
+	}
+	cqlshrc := cqlshrc(username, password, security.TLS, files)
+	if err := ioutil.WriteFile(m.cqlshrcPath(), []byte(cqlshrc), 0600); err != nil {
+		return fmt.Errorf("error writing cqlshrc: %s", err.Error())
+	}
+	return nil
+}
+
+// writeTLSFiles writes the certificates of the secret of the TLS settings
+// in the config directory. In Cassandra mode, the certificates are imported
+// in a keystore and a truststore too.
+func (m *MemberController) writeTLSFiles(spec *cassandrav1alpha1.TLSSpec) (*tlsFiles, error) {
+	secret, err := m.kubeClient.CoreV1().Secrets(m.namespace).Get(spec.SecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting secret %s with the certificates: %s", spec.SecretName, err.Error())
+	}
+
+	dir := filepath.Join(m.configDir(), tlsDirName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	files := &tlsFiles{
+		certificate: filepath.Join(dir, certificateKey),
+		privateKey:  filepath.Join(dir, privateKeyKey),
+		ca:          filepath.Join(dir, caKey),
+	}
+	for key, path := range map[string]string{certificateKey: files.certificate, privateKeyKey: files.privateKey, caKey: files.ca} {
+		data, ok := secret.Data[key]
+		if !ok {
+			return nil, fmt.Errorf("secret %s must have a %s", spec.SecretName, key)
+		}
+		if err := ioutil.WriteFile(path, data, 0600); err != nil {
+			return nil, fmt.Errorf("error writing %s: %s", path, err.Error())
+		}
+	}
+	if m.mode == cassandrav1alpha1.ClusterModeScylla {
+		return files, nil
+	}
+
+	// The stores are only read by the member, so a new password is fine on every start
+	password := make([]byte, 16)
+	if _, err := rand.Read(password); err != nil {
+		return nil, err
+	}
+	files.storePassword = hex.EncodeToString(password)
+	files.keystore = filepath.Join(dir, keystoreName)
+	files.truststore = filepath.Join(dir, truststoreName)
+	os.Remove(files.keystore)
+	os.Remove(files.truststore)
+
+	if output, err := exec.Command("openssl", "pkcs12", "-export",
+		"-in", files.certificate, "-inkey", files.privateKey, "-name", m.name,
+		"-out", files.keystore, "-passout", "pass:"+files.storePassword).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("error creating keystore: %s, output: %s", err.Error(), string(output))
+	}
+	if output, err := exec.Command("keytool", "-importcert", "-noprompt",
+		"-alias", "ca", "-file", files.ca, "-storetype", defaultStoreType,
+		"-keystore", files.truststore, "-storepass", files.storePassword).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("error creating truststore: %s, output: %s", err.Error(), string(output))
+	}
+	return files, nil
+}
+
+// securityConfig returns the settings of the config file of the member for
+// the given security settings. Cassandra reads the certificates from stores,
+// while Scylla reads them from the PEM files.
+func securityConfig(security *cassandrav1alpha1.SecuritySpec, files *tlsFiles, mode cassandrav1alpha1.ClusterMode) map[string]interface{} {
+	config := map[string]interface{}{}
+
+	if security.Authentication != nil {
+		config["authenticator"] = "PasswordAuthenticator"
+		config["authorizer"] = "CassandraAuthorizer"
+	}
+
+	if security.TLS == nil || files == nil {
+		return config
+	}
+	options := func() map[string]interface{} {
+		if mode == cassandrav1alpha1.ClusterModeScylla {
+			return map[string]interface{}{
+				"certificate": files.certificate,
+				"keyfile":     files.privateKey,
+				"truststore":  files.ca,
+			}
+		}
+		return map[string]interface{}{
+			"keystore":            files.keystore,
+			"keystore_password":   files.storePassword,
+			"truststore":          files.truststore,
+			"truststore_password": files.storePassword,
+			"store_type":          defaultStoreType,
+		}
+	}
+
+	if security.TLS.Client {
+		client := options()
+		client["enabled"] = true
+		client["require_client_auth"] = security.TLS.RequireClientAuth
+		config["client_encryption_options"] = client
+	}
+	internode := security.TLS.Internode
+	if internode == "" {
+		internode = cassandrav1alpha1.InternodeEncryptionNone
+	}
+	server := options()
+	server["internode_encryption"] = string(internode)
+	if internode != cassandrav1alpha1.InternodeEncryptionNone {
+		// The members authenticate each other with their certificates
+		server["require_client_auth"] = true
+	}
+	config["server_encryption_options"] = server
+	return config
+}
+
+// cqlshrc returns the cqlshrc that authenticates cqlsh as the given user and
+// connects over TLS if the clients must.
+func cqlshrc(username, password string, tls *cassandrav1alpha1.TLSSpec, files *tlsFiles) string {
+	sections := []string{}
+	if username != "" {
+		sections = append(sections, fmt.Sprintf("[authentication]\nusername = %s\npassword = %s\n", username, password))
+	}
+	if tls != nil && tls.Client && files != nil {
+		sections = append(sections, "[connection]\nssl = true\n")
+		ssl := fmt.Sprintf("[ssl]\ncertfile = %s\nvalidate = false\n", files.ca)
+		if tls.RequireClientAuth {
+			ssl += fmt.Sprintf("userkey = %s\nusercert = %s\n", files.privateKey, files.certificate)
+		}
+		sections = append(sections, ssl)
+	}
+	return strings.Join(sections, "\n")
+}
+
+// createSuperuser creates the superuser of the authentication settings, with
+// the default superuser of the cluster, and disables the default superuser.
+// It replicates the system_auth keyspace to every datacenter first, so that
+// the members can authenticate the clients when other members are down.
+func (m *MemberController) createSuperuser(c *cassandrav1alpha1.Cluster) error {
+	username, password, err := m.superuser(c.Spec.Security.Authentication)
+	if err != nil {
+		return err
+	}
+
+	// The superuser exists if a previous attempt created it
+	if err := m.cqlsh("-e", "SELECT now() FROM system.local").Run(); err == nil {
+		m.logger.Infof("Superuser %s already exists", username)
+	} else {
+		statements := []string{
+			fmt.Sprintf("ALTER KEYSPACE system_auth WITH replication = %s;", authReplication(c)),
+		}
+		quotedPassword := strings.Replace(password, "'", "''", -1)
+		if username == defaultSuperuser {
+			// The default superuser only gets a new password
+			statements = append(statements, fmt.Sprintf("ALTER ROLE %s WITH PASSWORD = '%s';", defaultSuperuser, quotedPassword))
+		} else {
+			statements = append(statements,
+				fmt.Sprintf("CREATE ROLE IF NOT EXISTS %s WITH SUPERUSER = true AND LOGIN = true AND PASSWORD = '%s';", cqlIdentifier(username), quotedPassword),
+			)
+		}
+
+		m.logger.Infof("Creating superuser %s", username)
+		cmd := m.defaultCqlsh()
+		cmd.Stdin = strings.NewReader(strings.Join(statements, "\n"))
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("error creating superuser %s: %s, output: %s", username, err.Error(), string(output))
+		}
+	}
+
+	if username == defaultSuperuser {
+		return nil
+	}
+	return m.disableDefaultSuperuser()
+}
+
+// disableDefaultSuperuser disables the default superuser, as the superuser of
+// the authentication settings, since a role can't change its own superuser
+// status. It checks that the default superuser is disabled, so that a retry
+// disables it again otherwise.
+func (m *MemberController) disableDefaultSuperuser() error {
+	m.logger.Infof("Disabling default superuser %s", defaultSuperuser)
+	cmd := m.cqlsh()
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("ALTER ROLE %s WITH SUPERUSER = false AND LOGIN = false;", defaultSuperuser))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("error disabling default superuser %s: %s, output: %s", defaultSuperuser, err.Error(), string(output))
+	}
+
+	cmd = m.cqlsh()
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("CONSISTENCY QUORUM;\nSELECT is_superuser, can_login FROM system_auth.roles WHERE role = '%s';", defaultSuperuser))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error checking default superuser %s: %s, output: %s", defaultSuperuser, err.Error(), string(output))
+	}
+	if row := cqlshRow(string(output)); len(row) != 2 || row[0] != "False" || row[1] != "False" {
+		return fmt.Errorf("default superuser %s is still enabled, output: %s", defaultSuperuser, string(output))
+	}
+	return nil
+}
+
+// cqlshRow returns the values of the first row of the result of a query
+// printed by cqlsh, or nil if the result has no row.
+func cqlshRow(output string) []string {
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		// The header of the columns is followed by a separator line
+		if !strings.HasPrefix(strings.TrimSpace(line), "---") || i+1 == len(lines) {
+			continue
+		}
+		if strings.TrimSpace(lines[i+1]) == "" {
+			return nil
+		}
+		values := []string{}
+		for _, value := range strings.Split(lines[i+1], "|") {
+			values = append(values, strings.TrimSpace(value))
+		}
+		return values
+	}
+	return nil
+}
+
+// authReplication returns the replication of the system_auth keyspace,
+// with up to 3 replicas in every datacenter.
+func authReplication(c *cassandrav1alpha1.Cluster) string {
+	replicas := []string{"'class': 'NetworkTopologyStrategy'"}
+	for _, dc := range c.Spec.GetDatacenters() {
+		var members int32
+		for _, r := range dc.Racks {
+			members += r.Members
+		}
+		if members > 3 {
+			members = 3
+		}
+		replicas = append(replicas, fmt.Sprintf("'%s': %d", dc.Name, members))
+	}
+	return fmt.Sprintf("{%s}", strings.Join(replicas, ", "))
+}
+
+// cqlIdentifier quotes the given name, so that it is case sensitive.
+func cqlIdentifier(name string) string {
+	return fmt.Sprintf(`"%s"`, strings.Replace(name, `"`, `""`, -1))
+}
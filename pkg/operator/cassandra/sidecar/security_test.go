@@ -0,0 +1,111 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"testing"
+
+	cassandrav1alpha1 "github.com/rook/rook/pkg/apis/cassandra.rook.io/v1alpha1"
+	casstest "github.com/rook/rook/pkg/operator/cassandra/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityConfig(t *testing.T) {
+	files := &tlsFiles{
+		certificate:   "/etc/cassandra/tls/tls.crt",
+		privateKey:    "/etc/cassandra/tls/tls.key",
+		ca:            "/etc/cassandra/tls/ca.crt",
+		keystore:      "/etc/cassandra/tls/keystore.p12",
+		truststore:    "/etc/cassandra/tls/truststore.p12",
+		storePassword: "secret",
+	}
+
+	// no security
+	require.Empty(t, securityConfig(&cassandrav1alpha1.SecuritySpec{}, nil, cassandrav1alpha1.ClusterModeCassandra))
+
+	// authentication and encryption of the clients
+	security := &cassandrav1alpha1.SecuritySpec{
+		Authentication: &cassandrav1alpha1.AuthenticationSpec{SuperuserSecretName: "superuser"},
+		TLS:            &cassandrav1alpha1.TLSSpec{SecretName: "certs", Client: true},
+	}
+	config := securityConfig(security, files, cassandrav1alpha1.ClusterModeCassandra)
+	require.Equal(t, "PasswordAuthenticator", config["authenticator"])
+	require.Equal(t, "CassandraAuthorizer", config["authorizer"])
+	require.Equal(t, map[string]interface{}{
+		"enabled":             true,
+		"require_client_auth": false,
+		"keystore":            files.keystore,
+		"keystore_password":   "secret",
+		"truststore":          files.truststore,
+		"truststore_password": "secret",
+		"store_type":          "PKCS12",
+	}, config["client_encryption_options"])
+	require.Equal(t, "none", config["server_encryption_options"].(map[string]interface{})["internode_encryption"])
+
+	// scylla reads the pem files and the members authenticate each other
+	security.TLS.Internode = cassandrav1alpha1.InternodeEncryptionAll
+	config = securityConfig(security, files, cassandrav1alpha1.ClusterModeScylla)
+	require.Equal(t, map[string]interface{}{
+		"internode_encryption": "all",
+		"require_client_auth":  true,
+		"certificate":          files.certificate,
+		"keyfile":              files.privateKey,
+		"truststore":           files.ca,
+	}, config["server_encryption_options"])
+}
+
+func TestCqlshrc(t *testing.T) {
+	files := &tlsFiles{certificate: "/tls/tls.crt", privateKey: "/tls/tls.key", ca: "/tls/ca.crt"}
+
+	require.Equal(t, "", cqlshrc("", "", nil, nil))
+	require.Equal(t, "[authentication]\nusername = admin\npassword = secret\n", cqlshrc("admin", "secret", nil, nil))
+	// internode encryption doesn't change the connection of cqlsh
+	require.Equal(t, "", cqlshrc("", "", &cassandrav1alpha1.TLSSpec{Internode: cassandrav1alpha1.InternodeEncryptionAll}, files))
+	require.Equal(t,
+		"[authentication]\nusername = admin\npassword = secret\n\n"+
+			"[connection]\nssl = true\n\n"+
+			"[ssl]\ncertfile = /tls/ca.crt\nvalidate = false\nuserkey = /tls/tls.key\nusercert = /tls/tls.crt\n",
+		cqlshrc("admin", "secret", &cassandrav1alpha1.TLSSpec{Client: true, RequireClientAuth: true}, files))
+}
+
+func TestAuthReplication(t *testing.T) {
+	c := casstest.NewSimpleCluster(5)
+	c.Spec.Datacenters = append(c.Spec.Datacenters, cassandrav1alpha1.DatacenterSpec{
+		Name:  "other-dc",
+		Racks: []cassandrav1alpha1.RackSpec{{Name: "rack-a", Members: 1}, {Name: "rack-b", Members: 1}},
+	})
+	require.Equal(t, "{'class': 'NetworkTopologyStrategy', 'test-dc': 3, 'other-dc': 2}", authReplication(c))
+}
+
+func TestCqlIdentifier(t *testing.T) {
+	require.Equal(t, `"Admin"`, cqlIdentifier("Admin"))
+	require.Equal(t, `"a""b"`, cqlIdentifier(`a"b`))
+}
+
+func TestCqlshRow(t *testing.T) {
+	output := `Consistency level set to QUORUM.
+
+ is_superuser | can_login
+--------------+-----------
+        False |     False
+
+(1 rows)
+`
+	require.Equal(t, []string{"False", "False"}, cqlshRow(output))
+	require.Nil(t, cqlshRow(" is_superuser | can_login\n--------------+-----------\n\n(0 rows)\n"))
+	require.Nil(t, cqlshRow(""))
+}
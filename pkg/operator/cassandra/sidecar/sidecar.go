@@ -38,6 +38,7 @@ import (
 	"os"
 	"os/exec"
 	"reflect"
+	"sync"
 	"time"
 )
 
@@ -55,9 +56,23 @@ type MemberController struct {
 	serviceLister       corelisters.ServiceLister
 	serviceListerSynced cache.InformerSynced
 
-	nodetool *nodetool.Nodetool
-	queue    workqueue.RateLimitingInterface
-	logger   *capnslog.PackageLogger
+	nodetool   *nodetool.Nodetool
+	jolokiaURL *url.URL
+	queue      workqueue.RateLimitingInterface
+	logger     *capnslog.PackageLogger
+
+	// replaceAddress is the address of the member to replace,
+	// when the member lost its data
+	replaceAddress string
+
+	// skipBootstrap is true if the member joins the ring without
+	// bootstrapping, to be rebuilt from another datacenter
+	skipBootstrap bool
+
+	// operations are the long running operations in progress,
+	// by the label of the member service that requested them
+	operations     map[string]bool
+	operationsLock sync.Mutex
 }
 
 // New return a new MemberController
@@ -117,9 +132,12 @@ func New(
 		serviceLister:       serviceInformer.Lister(),
 		serviceListerSynced: serviceInformer.Informer().HasSynced,
 		nodetool:            nodetool,
+		jolokiaURL:          url,
 		queue:               workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
 		logger:              logger,
+		operations:          map[string]bool{},
 	}
+	_, m.skipBootstrap = memberService.Labels[constants.SkipBootstrapLabel]
 
 	serviceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
@@ -162,7 +180,7 @@ func (m *MemberController) Run(threadiness int, stopCh <-chan struct{}) error {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
-	if err := m.onStartup(); err != nil {
+	if err := m.onStartup(stopCh); err != nil {
 		return fmt.Errorf("error on startup: %s", err.Error())
 	}
 
@@ -238,7 +256,7 @@ func (m *MemberController) syncHandler(key string) error {
 
 // onStartup is executed before the MemberController starts
 // its sync loop.
-func (m *MemberController) onStartup() error {
+func (m *MemberController) onStartup(stopCh <-chan struct{}) error {
 
 	// Setup HTTP checks
 	m.logger.Info("Setting up HTTP Checks...")
@@ -248,6 +266,13 @@ func (m *MemberController) onStartup() error {
 		panic("Something went wrong with the HTTP Checks")
 	}()
 
+	// Check if the member lost its data and must replace its previous self
+	replaceAddress, err := m.checkReplace()
+	if err != nil {
+		return fmt.Errorf("error checking if member must be replaced: %s", err.Error())
+	}
+	m.replaceAddress = replaceAddress
+
 	// Prepare config files for Cassandra
 	m.logger.Infof("Generating cassandra config files...")
 	if err := m.generateConfigFiles(); err != nil {
@@ -264,6 +289,9 @@ func (m *MemberController) onStartup() error {
 		return err
 	}
 
+	// Record the host ID of the member once it joins the ring
+	go m.recordHostID(stopCh)
+
 	return nil
 }
 
@@ -22,8 +22,15 @@ import (
 	"github.com/rook/rook/pkg/operator/cassandra/controller/util"
 	"github.com/yanniszark/go-nodetool/nodetool"
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"time"
 )
 
+// operationRetryInterval is the time to wait before retrying
+// a failed long running operation of the member
+const operationRetryInterval = time.Minute
+
 func (m *MemberController) Sync(memberService *v1.Service) error {
 
 	// Check if member must decommission
@@ -49,5 +56,214 @@ func (m *MemberController) Sync(memberService *v1.Service) error {
 
 	}
 
+	// Check if member must rebuild its data from another datacenter
+	// If the value is true, the member has already rebuilt
+	if rebuild, ok := memberService.Labels[constants.RebuildLabel]; ok && rebuild == constants.LabelValueFalse {
+		// Rebuild member from the datacenter of the spec
+		c, err := m.rookClient.CassandraV1alpha1().Clusters(m.namespace).Get(m.cluster, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("error getting cluster: %s", err.Error())
+		}
+		dc := c.Spec.GetDatacenter(m.datacenter)
+		if dc == nil || dc.RebuildFrom == "" {
+			return fmt.Errorf("no datacenter to rebuild datacenter %s from", m.datacenter)
+		}
+		// Streaming the data takes hours, so the label is updated when it's done
+		m.runInBackground(memberService, constants.RebuildLabel, func() error {
+			return m.rebuild(dc.RebuildFrom)
+		})
+	}
+
+	// Check if member must take a snapshot before an upgrade
+	// If the value is true, the member has already taken the snapshot
+	if snapshot, ok := memberService.Labels[constants.SnapshotLabel]; ok && snapshot == constants.LabelValueFalse {
+		tag := memberService.Annotations[constants.SnapshotTagAnnotation]
+		m.runInBackground(memberService, constants.SnapshotLabel, func() error {
+			if err := m.snapshot(tag); err != nil {
+				return fmt.Errorf("error taking snapshot %s: %s", tag, err.Error())
+			}
+			return nil
+		})
+	}
+
+	// Check if member must clear the snapshot once the upgrade is finished
+	// If the value is true, the member has already cleared the snapshot
+	if clear, ok := memberService.Labels[constants.ClearSnapshotLabel]; ok && clear == constants.LabelValueFalse {
+		tag := memberService.Annotations[constants.SnapshotTagAnnotation]
+		m.runInBackground(memberService, constants.ClearSnapshotLabel, func() error {
+			if err := m.clearSnapshot(tag); err != nil {
+				return fmt.Errorf("error clearing snapshot %s: %s", tag, err.Error())
+			}
+			return nil
+		})
+	}
+
+	// Check if member must drain before it is restarted with a new version
+	// If the value is true, the member has already drained
+	if drain, ok := memberService.Labels[constants.DrainLabel]; ok && drain == constants.LabelValueFalse {
+		// Flushing the memtables of a large member takes a while
+		m.runInBackground(memberService, constants.DrainLabel, func() error {
+			if err := m.drain(); err != nil {
+				return fmt.Errorf("error during drain: %s", err.Error())
+			}
+			return nil
+		})
+		// The member can't do anything else until it restarts
+		return nil
+	}
+
+	// Check if member must upgrade its sstables after an upgrade
+	// If the value is true, the member has already upgraded its sstables
+	if upgrade, ok := memberService.Labels[constants.UpgradeSSTablesLabel]; ok && upgrade == constants.LabelValueFalse {
+		// Rewriting the sstables can take hours, so the label is updated when it's done
+		m.runInBackground(memberService, constants.UpgradeSSTablesLabel, func() error {
+			if err := m.upgradeSSTables(); err != nil {
+				return fmt.Errorf("error upgrading sstables: %s", err.Error())
+			}
+			return nil
+		})
+	}
+
+	// Check if member must upload its data to a backup
+	// If the value is true, the member has already uploaded its data
+	if backup, ok := memberService.Labels[constants.BackupLabel]; ok && backup == constants.LabelValueFalse {
+		name := memberService.Annotations[constants.BackupNameAnnotation]
+		c, err := m.rookClient.CassandraV1alpha1().Clusters(m.namespace).Get(m.cluster, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("error getting cluster: %s", err.Error())
+		}
+		// Backups were disabled while the member was waiting to back up
+		if c.Spec.Backup != nil {
+			// Uploading the data can take hours, so the label is updated when it's done
+			m.runInBackground(memberService, constants.BackupLabel, func() error {
+				if err := m.backup(name, c); err != nil {
+					return fmt.Errorf("error during backup %s: %s", name, err.Error())
+				}
+				return nil
+			})
+		}
+	}
+
+	// Check if member must create the superuser of the cluster
+	// If the value is true, the member has already created the superuser
+	if superuser, ok := memberService.Labels[constants.SuperuserLabel]; ok && superuser == constants.LabelValueFalse {
+		c, err := m.rookClient.CassandraV1alpha1().Clusters(m.namespace).Get(m.cluster, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("error getting cluster: %s", err.Error())
+		}
+		if c.Spec.Security == nil || c.Spec.Security.Authentication == nil {
+			return fmt.Errorf("no superuser to create for cluster %s", m.cluster)
+		}
+		if err := m.createSuperuser(c); err != nil {
+			return fmt.Errorf("error creating superuser: %s", err.Error())
+		}
+		// Update Label
+		old := memberService.DeepCopy()
+		memberService.Labels[constants.SuperuserLabel] = constants.LabelValueTrue
+		if err := util.PatchService(old, memberService, m.kubeClient); err != nil {
+			return fmt.Errorf("error patching MemberService, %s", err.Error())
+		}
+	}
+
+	// Check if member must create the schema of the backup the cluster is restored from
+	// If the value is true, the member has already created the schema
+	if restore, ok := memberService.Labels[constants.RestoreSchemaLabel]; ok && restore == constants.LabelValueFalse {
+		c, err := m.rookClient.CassandraV1alpha1().Clusters(m.namespace).Get(m.cluster, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("error getting cluster: %s", err.Error())
+		}
+		if c.Spec.RestoreFrom == nil {
+			return fmt.Errorf("no backup to restore cluster %s from", m.cluster)
+		}
+		if err := m.restoreSchema(c); err != nil {
+			return fmt.Errorf("error restoring schema: %s", err.Error())
+		}
+		// Update Label
+		old := memberService.DeepCopy()
+		memberService.Labels[constants.RestoreSchemaLabel] = constants.LabelValueTrue
+		if err := util.PatchService(old, memberService, m.kubeClient); err != nil {
+			return fmt.Errorf("error patching MemberService, %s", err.Error())
+		}
+	}
+
+	// Check if member must load its data from the backup the cluster is restored from
+	// If the value is true, the member has already loaded its data
+	if restore, ok := memberService.Labels[constants.RestoreLabel]; ok && restore == constants.LabelValueFalse {
+		c, err := m.rookClient.CassandraV1alpha1().Clusters(m.namespace).Get(m.cluster, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("error getting cluster: %s", err.Error())
+		}
+		if c.Spec.RestoreFrom == nil {
+			return fmt.Errorf("no backup to restore cluster %s from", m.cluster)
+		}
+		// Downloading and loading the data can take hours, so the label is updated when it's done
+		m.runInBackground(memberService, constants.RestoreLabel, func() error {
+			if err := m.restoreData(c); err != nil {
+				return fmt.Errorf("error restoring data: %s", err.Error())
+			}
+			return nil
+		})
+	}
+
+	// Check if member must repair a keyspace
+	// If the value is true, the member has already repaired the keyspace
+	if repair, ok := memberService.Labels[constants.RepairLabel]; ok && repair == constants.LabelValueFalse {
+		keyspace := memberService.Annotations[constants.RepairKeyspaceAnnotation]
+		c, err := m.rookClient.CassandraV1alpha1().Clusters(m.namespace).Get(m.cluster, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("error getting cluster: %s", err.Error())
+		}
+		// Repairs were disabled while the member was waiting to repair
+		if c.Spec.Repair == nil {
+			return nil
+		}
+		// A repair can take hours, so the label is updated when it's done
+		spec := c.Spec.Repair
+		m.runInBackground(memberService, constants.RepairLabel, func() error {
+			if err := m.repair(keyspace, spec); err != nil {
+				return fmt.Errorf("error during repair of keyspace %s: %s", keyspace, err.Error())
+			}
+			return nil
+		})
+	}
+
 	return nil
 }
+
+// runInBackground runs a long running operation of the member without
+// blocking the sync loop, and sets the label of the member service that
+// requested it to true once it's done. A failed operation is retried on
+// the next sync after operationRetryInterval.
+func (m *MemberController) runInBackground(memberService *v1.Service, label string, operation func() error) {
+	m.operationsLock.Lock()
+	defer m.operationsLock.Unlock()
+	if m.operations[label] {
+		m.logger.Infof("Operation %s of member %s already in progress", label, m.name)
+		return
+	}
+	m.operations[label] = true
+
+	old := memberService.DeepCopy()
+	done := memberService.DeepCopy()
+	go func() {
+		err := operation()
+		if err == nil {
+			// Update Label
+			done.Labels[label] = constants.LabelValueTrue
+			if err = util.PatchService(old, done, m.kubeClient); err != nil {
+				err = fmt.Errorf("error patching MemberService, %s", err.Error())
+			}
+		}
+
+		m.operationsLock.Lock()
+		delete(m.operations, label)
+		m.operationsLock.Unlock()
+
+		if err != nil {
+			m.logger.Errorf("Error during operation %s: %s", label, err.Error())
+			if key, err := cache.MetaNamespaceKeyFunc(old); err == nil {
+				m.queue.AddAfter(key, operationRetryInterval)
+			}
+		}
+	}()
+}
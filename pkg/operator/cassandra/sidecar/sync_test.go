@@ -0,0 +1,89 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/rook/rook/pkg/operator/cassandra/constants"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestRunInBackground(t *testing.T) {
+	memberService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster-dc1-rack1-0",
+			Namespace: "test-ns",
+			Labels:    map[string]string{constants.RebuildLabel: constants.LabelValueFalse},
+		},
+	}
+	m := &MemberController{
+		name:       memberService.Name,
+		kubeClient: kubefake.NewSimpleClientset(memberService),
+		queue:      workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		logger:     capnslog.NewPackageLogger("github.com/rook/rook", "sidecar"),
+		operations: map[string]bool{},
+	}
+	defer m.queue.ShutDown()
+
+	getLabel := func() string {
+		svc, err := m.kubeClient.CoreV1().Services(memberService.Namespace).Get(memberService.Name, metav1.GetOptions{})
+		require.NoError(t, err)
+		return svc.Labels[constants.RebuildLabel]
+	}
+	waitDone := func() {
+		for i := 0; i < 100; i++ {
+			m.operationsLock.Lock()
+			running := m.operations[constants.RebuildLabel]
+			m.operationsLock.Unlock()
+			if !running {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("operation still running")
+	}
+
+	// the sync doesn't wait for the operation, nor start it twice
+	release := make(chan struct{})
+	runs := 0
+	operation := func() error {
+		runs++
+		<-release
+		return nil
+	}
+	m.runInBackground(memberService, constants.RebuildLabel, operation)
+	m.runInBackground(memberService, constants.RebuildLabel, operation)
+	require.Equal(t, constants.LabelValueFalse, getLabel())
+	close(release)
+	waitDone()
+	require.Equal(t, 1, runs)
+	require.Equal(t, constants.LabelValueTrue, getLabel())
+
+	// a failed operation leaves the label and can run again
+	m.kubeClient = kubefake.NewSimpleClientset(memberService)
+	m.runInBackground(memberService, constants.RebuildLabel, func() error { return fmt.Errorf("mock failure") })
+	waitDone()
+	require.Equal(t, constants.LabelValueFalse, getLabel())
+}
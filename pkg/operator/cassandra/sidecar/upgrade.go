@@ -0,0 +1,61 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// snapshot takes a snapshot of the data of all the keyspaces of the member
+// with the given tag.
+func (m *MemberController) snapshot(tag string) error {
+	m.logger.Infof("Taking snapshot %s", tag)
+	return runNodetool("snapshot", "-t", tag)
+}
+
+// clearSnapshot removes the snapshot with the given tag from the data of
+// all the keyspaces of the member.
+func (m *MemberController) clearSnapshot(tag string) error {
+	m.logger.Infof("Clearing snapshot %s", tag)
+	return runNodetool("clearsnapshot", "-t", tag)
+}
+
+// drain flushes the memtables of the member and stops it from accepting
+// connections, so that it can be restarted without replaying its commitlog.
+func (m *MemberController) drain() error {
+	m.logger.Infof("Draining member %s", m.name)
+	return runNodetool("drain")
+}
+
+// upgradeSSTables rewrites the sstables of the member that are not in the
+// format of its current version.
+func (m *MemberController) upgradeSSTables() error {
+	m.logger.Infof("Upgrading the sstables of member %s", m.name)
+	return runNodetool("upgradesstables")
+}
+
+// runNodetool runs nodetool with the given arguments against the local
+// member. It blocks until the command returns.
+func runNodetool(args ...string) error {
+	output, err := exec.Command("nodetool", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error running nodetool %s: %s, output: %s", strings.Join(args, " "), err.Error(), string(output))
+	}
+	return nil
+}
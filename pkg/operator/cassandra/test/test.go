@@ -38,12 +38,14 @@ func NewSimpleCluster(members int32) *cassandrav1alpha1.Cluster {
 		Spec: cassandrav1alpha1.ClusterSpec{
 			Version: "3.1.11",
 			Mode:    cassandrav1alpha1.ClusterModeCassandra,
-			Datacenter: cassandrav1alpha1.DatacenterSpec{
-				Name: "test-dc",
-				Racks: []cassandrav1alpha1.RackSpec{
-					{
-						Name:    "test-rack",
-						Members: members,
+			Datacenters: []cassandrav1alpha1.DatacenterSpec{
+				{
+					Name: "test-dc",
+					Racks: []cassandrav1alpha1.RackSpec{
+						{
+							Name:    "test-rack",
+							Members: members,
+						},
 					},
 				},
 			},
@@ -55,16 +57,22 @@ func NewSimpleCluster(members int32) *cassandrav1alpha1.Cluster {
 func MemberServicesForCluster(c *cassandrav1alpha1.Cluster) []runtime.Object {
 
 	services := []runtime.Object{}
-	for _, r := range c.Spec.Datacenter.Racks {
-		for i := int32(0); i < c.Status.Racks[r.Name].Members; i++ {
-			svc := &corev1.Service{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      fmt.Sprintf("%s-%s-%s-%d", c.Name, c.Spec.Datacenter.Name, r.Name, i),
-					Namespace: c.Namespace,
-					Labels:    util.RackLabels(r, c),
-				},
+	for _, dc := range c.Spec.GetDatacenters() {
+		for _, r := range dc.Racks {
+			rackStatus := c.Status.GetRackStatus(dc.Name, r.Name)
+			if rackStatus == nil {
+				continue
+			}
+			for i := int32(0); i < rackStatus.Members; i++ {
+				svc := &corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("%s-%s-%s-%d", c.Name, dc.Name, r.Name, i),
+						Namespace: c.Namespace,
+						Labels:    util.RackLabels(r, dc, c),
+					},
+				}
+				services = append(services, svc)
 			}
-			services = append(services, svc)
 		}
 	}
 	return services
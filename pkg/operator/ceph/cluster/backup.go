@@ -0,0 +1,273 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/backup"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/cron"
+	"github.com/rook/rook/pkg/util/s3"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	backupAppName           = "rook-ceph-backup"
+	backupCheckInterval     = time.Minute
+	backupJobTimeout        = 15 * time.Minute
+	backupMonStoreMountPath = "/var/lib/rook/backup/mon-store"
+	backupMetadataMountPath = "/etc/rook/backup"
+	backupVolumeMountPath   = "/var/lib/rook/backups"
+)
+
+// backupScheduler runs the backups of the mon store and the cluster metadata on the schedule of the cluster
+type backupScheduler struct {
+	context      *clusterd.Context
+	namespace    string
+	resourceName string
+	rookImage    string
+	mons         *mon.Cluster
+	ownerRef     metav1.OwnerReference
+	started      time.Time
+}
+
+func newBackupScheduler(context *clusterd.Context, namespace, resourceName, rookImage string, mons *mon.Cluster, ownerRef metav1.OwnerReference) *backupScheduler {
+	return &backupScheduler{
+		context:      context,
+		namespace:    namespace,
+		resourceName: resourceName,
+		rookImage:    rookImage,
+		mons:         mons,
+		ownerRef:     ownerRef,
+	}
+}
+
+// run periodically checks whether a backup is due
+func (b *backupScheduler) run(stopCh chan struct{}) {
+	b.started = time.Now()
+	for {
+		select {
+		case <-stopCh:
+			logger.Infof("Stopping backup scheduler of cluster %s", b.namespace)
+			return
+
+		case <-time.After(backupCheckInterval):
+			b.checkSchedule()
+		}
+	}
+}
+
+func (b *backupScheduler) checkSchedule() {
+	// get the latest spec since the schedule may have been updated
+	cluster, err := b.context.RookClientset.CephV1().CephClusters(b.namespace).Get(b.resourceName, metav1.GetOptions{})
+	if err != nil {
+		logger.Errorf("failed to get cluster %s to check the backup schedule. %+v", b.namespace, err)
+		return
+	}
+	if !cluster.Spec.Backup.Enabled() {
+		return
+	}
+
+	schedule, err := cron.Parse(cluster.Spec.Backup.Schedule)
+	if err != nil {
+		logger.Errorf("invalid backup schedule for cluster %s. %+v", b.namespace, err)
+		return
+	}
+
+	// continue from the last backup if the operator was restarted
+	lastRun := b.started
+	if cluster.Status.Backup != nil {
+		if t, err := time.Parse(time.RFC3339, cluster.Status.Backup.LastAttempted); err == nil {
+			lastRun = t
+		}
+	}
+	now := time.Now().UTC()
+	if !schedule.Due(lastRun, now) {
+		return
+	}
+
+	logger.Infof("starting backup of cluster %s", b.namespace)
+	err = b.runBackup(cluster)
+	if err != nil {
+		logger.Errorf("backup of cluster %s failed. %+v", b.namespace, err)
+	} else {
+		logger.Infof("backup of cluster %s completed", b.namespace)
+	}
+	if err := b.updateBackupStatus(now, err); err != nil {
+		logger.Errorf("failed to update backup status of cluster %s. %+v", b.namespace, err)
+	}
+}
+
+func (b *backupScheduler) runBackup(cluster *cephv1.CephCluster) error {
+	spec := cluster.Spec.Backup
+	if spec.PersistentVolumeClaim == "" && spec.S3 == nil {
+		return fmt.Errorf("either a persistentVolumeClaim or an s3 bucket is required for the backups")
+	}
+
+	metadata, err := backup.ExportMetadata(b.context.Clientset, b.namespace, cluster.UID)
+	if err != nil {
+		return fmt.Errorf("failed to export metadata. %+v", err)
+	}
+	if err := b.saveMetadata(metadata); err != nil {
+		return err
+	}
+
+	return b.mons.WithMonStopped(func(m mon.StoppedMon) error {
+		job := b.makeBackupJob(cluster, m)
+		if err := k8sutil.RunReplaceableJob(b.context.Clientset, job, true); err != nil {
+			return fmt.Errorf("failed to start backup job. %+v", err)
+		}
+		if err := k8sutil.WaitForJobCompletion(b.context.Clientset, job, backupJobTimeout); err != nil {
+			return fmt.Errorf("failed to complete backup job. %+v", err)
+		}
+		return nil
+	})
+}
+
+// saveMetadata stores the exported metadata in a secret that is mounted by the backup job
+func (b *backupScheduler) saveMetadata(metadata []byte) error {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backup.MetadataSecretName,
+			Namespace: b.namespace,
+		},
+		Data: map[string][]byte{backup.MetadataKey: metadata},
+		Type: k8sutil.RookType,
+	}
+	k8sutil.SetOwnerRef(b.context.Clientset, b.namespace, &secret.ObjectMeta, &b.ownerRef)
+
+	_, err := b.context.Clientset.CoreV1().Secrets(b.namespace).Create(secret)
+	if errors.IsAlreadyExists(err) {
+		_, err = b.context.Clientset.CoreV1().Secrets(b.namespace).Update(secret)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save metadata secret. %+v", err)
+	}
+	return nil
+}
+
+func (b *backupScheduler) makeBackupJob(cluster *cephv1.CephCluster, m mon.StoppedMon) *batch.Job {
+	spec := cluster.Spec.Backup
+	volumes := []v1.Volume{
+		{Name: "mon-store", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: m.HostDataDir}}},
+		{Name: "metadata", VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: backup.MetadataSecretName}}},
+	}
+	mounts := []v1.VolumeMount{
+		{Name: "mon-store", MountPath: backupMonStoreMountPath, ReadOnly: true},
+		{Name: "metadata", MountPath: backupMetadataMountPath, ReadOnly: true},
+	}
+	env := []v1.EnvVar{
+		{Name: "ROOK_PREFIX", Value: b.namespace},
+		{Name: "ROOK_MON_STORE_DIR", Value: backupMonStoreMountPath},
+		{Name: "ROOK_METADATA_FILE", Value: path.Join(backupMetadataMountPath, backup.MetadataKey)},
+		{Name: "ROOK_RETENTION", Value: strconv.Itoa(spec.Retention)},
+	}
+
+	if spec.S3 != nil {
+		env = append(env,
+			v1.EnvVar{Name: "ROOK_S3_ENDPOINT", Value: spec.S3.Endpoint},
+			v1.EnvVar{Name: "ROOK_S3_BUCKET", Value: spec.S3.Bucket},
+			secretEnvVar(s3.AccessKeyName, spec.S3.SecretName),
+			secretEnvVar(s3.SecretKeyName, spec.S3.SecretName),
+		)
+	} else {
+		volumes = append(volumes, v1.Volume{Name: "backups", VolumeSource: v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: spec.PersistentVolumeClaim}}})
+		mounts = append(mounts, v1.VolumeMount{Name: "backups", MountPath: backupVolumeMountPath})
+		env = append(env, v1.EnvVar{Name: "ROOK_BACKUP_DIR", Value: backupVolumeMountPath})
+	}
+
+	podSpec := v1.PodSpec{
+		Containers: []v1.Container{
+			{
+				Name:         "backup",
+				Image:        b.rookImage,
+				Args:         []string{"ceph", "backup", "create"},
+				Env:          env,
+				VolumeMounts: mounts,
+			},
+		},
+		RestartPolicy: v1.RestartPolicyOnFailure,
+		Volumes:       volumes,
+	}
+	// the job must run next to the stopped mon to read its store
+	cephv1.GetMonPlacement(cluster.Spec.Placement).ApplyToPodSpec(&podSpec)
+	podSpec.Affinity = nil
+	podSpec.NodeName = m.NodeName
+
+	labels := map[string]string{
+		k8sutil.AppAttr:     backupAppName,
+		k8sutil.ClusterAttr: b.namespace,
+	}
+	job := &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backupAppName,
+			Namespace: b.namespace,
+			Labels:    labels,
+		},
+		Spec: batch.JobSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       podSpec,
+			},
+		},
+	}
+	k8sutil.AddRookVersionLabelToJob(job)
+	k8sutil.SetOwnerRef(b.context.Clientset, b.namespace, &job.ObjectMeta, &b.ownerRef)
+	return job
+}
+
+func secretEnvVar(key, secretName string) v1.EnvVar {
+	return v1.EnvVar{Name: key, ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{
+		LocalObjectReference: v1.LocalObjectReference{Name: secretName},
+		Key:                  key,
+	}}}
+}
+
+func (b *backupScheduler) updateBackupStatus(attempted time.Time, backupErr error) error {
+	cluster, err := b.context.RookClientset.CephV1().CephClusters(b.namespace).Get(b.resourceName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster. %+v", err)
+	}
+
+	status := &cephv1.BackupStatus{}
+	if cluster.Status.Backup != nil {
+		status.LastSucceeded = cluster.Status.Backup.LastSucceeded
+	}
+	status.LastAttempted = formatTime(attempted)
+	if backupErr != nil {
+		status.Message = backupErr.Error()
+	} else {
+		status.LastSucceeded = status.LastAttempted
+	}
+	cluster.Status.Backup = status
+
+	if _, err := b.context.RookClientset.CephV1().CephClusters(b.namespace).Update(cluster); err != nil {
+		return fmt.Errorf("failed to update cluster status. %+v", err)
+	}
+	return nil
+}
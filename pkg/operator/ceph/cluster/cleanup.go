@@ -0,0 +1,280 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	osdconfig "github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	cleanupAppName          = "rook-ceph-cleanup"
+	cleanupAppNameFmt       = "rook-ceph-cleanup-%s"
+	cleanupServiceAccount   = "rook-ceph-osd"
+	cleanupDataDirMountPath = "/var/lib/rook"
+	cleanupPodsGoneInterval = 5 * time.Second
+	cleanupPodsGoneTimeout  = 5 * time.Minute
+	cleanupJobTimeout       = 30 * time.Minute
+)
+
+// startHostCleanup starts the cleanup of the hosts of a deleted cluster in the background if the cleanup policy was
+// confirmed. The hosts and the devices of the OSDs are read before the finalizer of the cluster is removed, since the
+// OSD config stores of the cluster are garbage collected with it.
+func (c *ClusterController) startHostCleanup(cluster *cephv1.CephCluster) {
+	if !cluster.Spec.CleanupPolicy.HasDataDirCleanPolicy() {
+		logger.Infof("cleanup policy is not confirmed for cluster %s. the hosts will not be cleaned up", cluster.Namespace)
+		return
+	}
+
+	c.cleanupLock.Lock()
+	defer c.cleanupLock.Unlock()
+	if c.cleanups[cluster.Namespace] {
+		logger.Infof("cleanup of cluster %s is already in progress", cluster.Namespace)
+		return
+	}
+
+	hosts, err := c.getClusterHosts(cluster.Namespace)
+	if err != nil {
+		logger.Errorf("failed to find the hosts of cluster %s. the hosts will need to be cleaned up manually. %+v", cluster.Namespace, err)
+		return
+	}
+	if len(hosts) == 0 {
+		logger.Infof("no hosts to clean up for cluster %s", cluster.Namespace)
+		return
+	}
+	hostDevices := c.getHostDevices(cluster.Namespace, hosts)
+
+	c.cleanups[cluster.Namespace] = true
+	go func() {
+		// a failure does not block the deletion of the cluster, but the hosts will need to be cleaned up manually
+		if err := c.cleanupHosts(cluster, hostDevices); err != nil {
+			logger.Errorf("failed to clean up the hosts of cluster %s. %+v", cluster.Namespace, err)
+		}
+		c.cleanupLock.Lock()
+		delete(c.cleanups, cluster.Namespace)
+		c.cleanupLock.Unlock()
+	}()
+}
+
+// cleanupHosts removes the data left on the hosts by the cluster and zaps the devices of its OSDs.
+// The daemons are stopped first so that nothing is using the data dir or the devices while they are wiped.
+func (c *ClusterController) cleanupHosts(cluster *cephv1.CephCluster, hostDevices map[string][]string) error {
+	if err := c.stopClusterDaemons(cluster.Namespace); err != nil {
+		return fmt.Errorf("failed to stop the daemons of cluster %s. %+v", cluster.Namespace, err)
+	}
+
+	logger.Infof("starting cleanup of cluster %s on hosts %v", cluster.Namespace, hostDevices)
+	var wg sync.WaitGroup
+	var errMux sync.Mutex
+	var errorMessages []string
+	for host, devices := range hostDevices {
+		wg.Add(1)
+		go func(host string, devices []string) {
+			defer wg.Done()
+			if err := c.runCleanupJob(cluster, host, devices); err != nil {
+				errMux.Lock()
+				errorMessages = append(errorMessages, err.Error())
+				errMux.Unlock()
+			}
+		}(host, devices)
+	}
+	wg.Wait()
+
+	if len(errorMessages) > 0 {
+		return fmt.Errorf("%d hosts failed to be cleaned up: %v", len(errorMessages), errorMessages)
+	}
+	logger.Infof("completed cleanup of cluster %s on %d hosts", cluster.Namespace, len(hostDevices))
+	return nil
+}
+
+// getHostDevices returns the devices of the OSDs of the cluster on each host, as recorded in the OSD config store of
+// the host. The devices of other clusters on the same hosts are not in the stores of this cluster.
+func (c *ClusterController) getHostDevices(namespace string, hosts []string) map[string][]string {
+	kv := k8sutil.NewConfigMapKVStore(namespace, c.context.Clientset, metav1.OwnerReference{})
+	hostDevices := map[string][]string{}
+	for _, host := range hosts {
+		hostDevices[host] = []string{}
+		scheme, err := osdconfig.LoadScheme(kv, osdconfig.GetConfigStoreName(host))
+		if err != nil {
+			logger.Warningf("failed to load the osd config of host %s. its devices will not be zapped. %+v", host, err)
+			continue
+		}
+
+		devices := map[string]bool{}
+		if scheme.Metadata != nil && scheme.Metadata.Device != "" {
+			devices[scheme.Metadata.Device] = true
+		}
+		for _, entry := range scheme.Entries {
+			for _, partition := range entry.Partitions {
+				if partition.Device != "" {
+					devices[partition.Device] = true
+				}
+			}
+		}
+		for device := range devices {
+			hostDevices[host] = append(hostDevices[host], device)
+		}
+		sort.Strings(hostDevices[host])
+	}
+	return hostDevices
+}
+
+// getClusterHosts returns the names of the nodes where daemons of the cluster are running
+func (c *ClusterController) getClusterHosts(namespace string) ([]string, error) {
+	selector := fmt.Sprintf("%s=%s", k8sutil.ClusterAttr, namespace)
+	pods, err := c.context.Clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	hostMap := map[string]bool{}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != "" {
+			hostMap[pod.Spec.NodeName] = true
+		}
+	}
+
+	hosts := []string{}
+	for host := range hostMap {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts, nil
+}
+
+// stopClusterDaemons deletes the deployments of the cluster and waits for their pods to terminate
+func (c *ClusterController) stopClusterDaemons(namespace string) error {
+	selector := fmt.Sprintf("%s=%s", k8sutil.ClusterAttr, namespace)
+	deployments, err := k8sutil.GetDeployments(c.context.Clientset, namespace, selector)
+	if err != nil {
+		return err
+	}
+	for _, d := range deployments.Items {
+		if err := k8sutil.DeleteDeployment(c.context.Clientset, namespace, d.Name); err != nil {
+			return err
+		}
+	}
+
+	return wait.Poll(cleanupPodsGoneInterval, cleanupPodsGoneTimeout, func() (bool, error) {
+		pods, err := c.context.Clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return false, err
+		}
+		running := 0
+		for _, pod := range pods.Items {
+			// pods of completed jobs such as the osd prepare jobs will not go away
+			if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+				running++
+			}
+		}
+		if running > 0 {
+			logger.Infof("waiting for %d pods of cluster %s to terminate", running, namespace)
+			return false, nil
+		}
+		return true, nil
+	})
+}
+
+func (c *ClusterController) runCleanupJob(cluster *cephv1.CephCluster, host string, devices []string) error {
+	job := c.makeCleanupJob(cluster, host, devices)
+
+	if err := k8sutil.RunReplaceableJob(c.context.Clientset, job, true); err != nil {
+		return fmt.Errorf("failed to start cleanup job on host %s. %+v", host, err)
+	}
+	if err := k8sutil.WaitForJobCompletion(c.context.Clientset, job, cleanupJobTimeout); err != nil {
+		return fmt.Errorf("failed to complete cleanup job on host %s. %+v", host, err)
+	}
+
+	logger.Infof("cleanup completed on host %s", host)
+	return nil
+}
+
+func (c *ClusterController) makeCleanupJob(cluster *cephv1.CephCluster, host string, devices []string) *batch.Job {
+	privileged := true
+	volumes := []v1.Volume{
+		{Name: "devices", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/dev"}}},
+		{Name: "udev", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/run/udev"}}},
+	}
+	mounts := []v1.VolumeMount{
+		{Name: "devices", MountPath: "/dev"},
+		{Name: "udev", MountPath: "/run/udev"},
+	}
+	dataDir := ""
+	if cluster.Spec.DataDirHostPath != "" {
+		volumes = append(volumes, v1.Volume{Name: k8sutil.DataDirVolume, VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: cluster.Spec.DataDirHostPath}}})
+		mounts = append(mounts, v1.VolumeMount{Name: k8sutil.DataDirVolume, MountPath: cleanupDataDirMountPath})
+		dataDir = cleanupDataDirMountPath
+	}
+
+	podSpec := v1.PodSpec{
+		ServiceAccountName: cleanupServiceAccount,
+		Containers: []v1.Container{
+			{
+				Name:  "cleanup",
+				Image: c.rookImage,
+				Args:  []string{"ceph", "clean"},
+				Env: []v1.EnvVar{
+					{Name: "ROOK_DATA_DIR", Value: dataDir},
+					{Name: "ROOK_DEVICES", Value: strings.Join(devices, ",")},
+					{Name: "ROOK_SANITIZE_DEVICES", Value: strconv.FormatBool(cluster.Spec.CleanupPolicy.SanitizeDevices)},
+				},
+				VolumeMounts:    mounts,
+				SecurityContext: &v1.SecurityContext{Privileged: &privileged},
+			},
+		},
+		RestartPolicy: v1.RestartPolicyOnFailure,
+		Volumes:       volumes,
+	}
+	// the osd tolerations are needed since the job must run on every node where the osds were allowed to run
+	cephv1.GetOSDPlacement(cluster.Spec.Placement).ApplyToPodSpec(&podSpec)
+	podSpec.Affinity = nil
+	podSpec.NodeName = host
+
+	labels := map[string]string{
+		k8sutil.AppAttr: cleanupAppName,
+		// the cluster label is not set since the job must not be mistaken for a cluster daemon
+		"cleanup-cluster": cluster.Namespace,
+	}
+	job := &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      k8sutil.TruncateNodeName(cleanupAppNameFmt, host),
+			Namespace: cluster.Namespace,
+			Labels:    labels,
+		},
+		Spec: batch.JobSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       podSpec,
+			},
+		},
+	}
+	// no owner reference is set on the job since the cluster is being deleted
+	k8sutil.AddRookVersionLabelToJob(job)
+	return job
+}
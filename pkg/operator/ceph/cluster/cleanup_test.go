@@ -0,0 +1,60 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	osdconfig "github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetHostDevices(t *testing.T) {
+	clientset := testop.New(2)
+	c := &ClusterController{context: &clusterd.Context{Clientset: clientset}}
+
+	saveScheme := func(namespace, host string, metadataDevice string, devices ...string) {
+		scheme := osdconfig.NewPerfScheme()
+		if metadataDevice != "" {
+			scheme.Metadata = osdconfig.NewMetadataDeviceInfo(metadataDevice)
+		}
+		for i, device := range devices {
+			entry := osdconfig.NewPerfSchemeEntry(osdconfig.Bluestore)
+			entry.ID = i
+			entry.Partitions[osdconfig.BlockPartitionType] = &osdconfig.PerfSchemePartitionDetails{Device: device}
+			scheme.Entries = append(scheme.Entries, entry)
+		}
+		kv := k8sutil.NewConfigMapKVStore(namespace, clientset, metav1.OwnerReference{})
+		assert.Nil(t, scheme.SaveScheme(kv, osdconfig.GetConfigStoreName(host)))
+	}
+	saveScheme("rook-ceph", "node0", "nvme0n1", "sdb", "sdc")
+	// another cluster with osds on the same host
+	saveScheme("rook-ceph-2", "node0", "", "sdd")
+
+	hostDevices := c.getHostDevices("rook-ceph", []string{"node0", "node1"})
+	assert.Equal(t, map[string][]string{
+		"node0": {"nvme0n1", "sdb", "sdc"},
+		"node1": {},
+	}, hostDevices)
+
+	hostDevices = c.getHostDevices("rook-ceph-2", []string{"node0"})
+	assert.Equal(t, []string{"sdd"}, hostDevices["node0"])
+}
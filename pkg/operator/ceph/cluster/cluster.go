@@ -34,6 +34,7 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/rbd"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	batch "k8s.io/api/batch/v1"
@@ -57,6 +58,7 @@ type cluster struct {
 	orchestrationRunning bool
 	orchestrationNeeded  bool
 	orchMux              sync.Mutex
+	quorumRestoreRunning bool
 	childControllers     []childController
 }
 
@@ -201,6 +203,12 @@ func (c *cluster) doOrchestration(rookImage string, cephVersion cephver.CephVers
 		return fmt.Errorf("failed to create initial crushmap: %+v", err)
 	}
 
+	// Create the ceph users of the csi drivers
+	err = csi.CreateCSISecrets(c.context, c.Namespace, &c.ownerRef)
+	if err != nil {
+		return fmt.Errorf("failed to create the csi secrets. %+v", err)
+	}
+
 	mgrs := mgr.New(c.Info, c.context, c.Namespace, rookImage,
 		spec.CephVersion, cephv1.GetMgrPlacement(spec.Placement), cephv1.GetMgrAnnotations(c.Spec.Annotations),
 		spec.Network.HostNetwork, spec.Dashboard, cephv1.GetMgrResources(spec.Resources), c.ownerRef, c.Spec.DataDirHostPath)
@@ -325,6 +333,24 @@ func (c *cluster) unsetOrchestrationStatus() {
 	c.orchestrationRunning = false
 }
 
+// checkSetQuorumRestoreStatus returns true and sets the quorumRestoreRunning-flag if no mon quorum restore is running
+func (c *cluster) checkSetQuorumRestoreStatus() bool {
+	c.orchMux.Lock()
+	defer c.orchMux.Unlock()
+	if c.quorumRestoreRunning {
+		return false
+	}
+	c.quorumRestoreRunning = true
+	return true
+}
+
+// unsetQuorumRestoreStatus resets the quorumRestoreRunning-flag
+func (c *cluster) unsetQuorumRestoreStatus() {
+	c.orchMux.Lock()
+	defer c.orchMux.Unlock()
+	c.quorumRestoreRunning = false
+}
+
 // checkSetOrchestrationStatus is responsible to do orchestration as long as there is a request needed
 func (c *cluster) checkSetOrchestrationStatus() bool {
 	c.orchMux.Lock()
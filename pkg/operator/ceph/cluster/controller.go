@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/coreos/pkg/capnslog"
@@ -32,6 +33,8 @@ import (
 	discoverDaemon "github.com/rook/rook/pkg/daemon/discover"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/ceph/disruption"
 	"github.com/rook/rook/pkg/operator/ceph/file"
 	"github.com/rook/rook/pkg/operator/ceph/nfs"
 	"github.com/rook/rook/pkg/operator/ceph/object"
@@ -96,6 +99,9 @@ type ClusterController struct {
 	devicesInUse     bool
 	rookImage        string
 	clusterMap       map[string]*cluster
+	// cleanups are the namespaces of the deleted clusters whose hosts are being cleaned up
+	cleanups    map[string]bool
+	cleanupLock sync.Mutex
 }
 
 // NewClusterController create controller for watching cluster custom resources created
@@ -105,6 +111,7 @@ func NewClusterController(context *clusterd.Context, rookImage string, volumeAtt
 		volumeAttachment: volumeAttachment,
 		rookImage:        rookImage,
 		clusterMap:       make(map[string]*cluster),
+		cleanups:         make(map[string]bool),
 	}
 }
 
@@ -315,7 +322,7 @@ func (c *ClusterController) onAdd(obj interface{}) {
 	}
 
 	// Start pool CRD watcher
-	poolController := pool.NewPoolController(c.context, cluster.Namespace)
+	poolController := pool.NewPoolController(c.context, cluster.Namespace, cluster.Spec.CSI)
 	poolController.StartWatch(cluster.stopCh)
 
 	// Start object store CRD watcher
@@ -327,7 +334,7 @@ func (c *ClusterController) onAdd(obj interface{}) {
 	objectStoreUserController.StartWatch(cluster.stopCh)
 
 	// Start file system CRD watcher
-	fileController := file.NewFilesystemController(cluster.Info, c.context, cluster.Namespace, c.rookImage, cluster.Spec.CephVersion, cluster.Spec.Network.HostNetwork, cluster.ownerRef, cluster.Spec.DataDirHostPath)
+	fileController := file.NewFilesystemController(cluster.Info, c.context, cluster.Namespace, c.rookImage, cluster.Spec.CephVersion, cluster.Spec.Network.HostNetwork, cluster.ownerRef, cluster.Spec.DataDirHostPath, cluster.Spec.CSI)
 	fileController.StartWatch(cluster.stopCh)
 
 	// Start nfs ganesha CRD watcher
@@ -350,6 +357,14 @@ func (c *ClusterController) onAdd(obj interface{}) {
 	cephChecker := newCephStatusChecker(c.context, cluster.Namespace, clusterObj.Name)
 	go cephChecker.checkCephStatus(cluster.stopCh)
 
+	// Start the scheduler of the mon store and metadata backups
+	backupScheduler := newBackupScheduler(c.context, cluster.Namespace, clusterObj.Name, c.rookImage, cluster.mons, cluster.ownerRef)
+	go backupScheduler.run(cluster.stopCh)
+
+	// Start the management of the pod disruption budgets
+	disruptionController := disruption.New(c.context, cluster.Namespace, clusterObj.Name, cluster.ownerRef)
+	go disruptionController.Run(cluster.stopCh)
+
 	// add the finalizer to the crd
 	err = c.addFinalizer(clusterObj)
 	if err != nil {
@@ -456,6 +471,8 @@ func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
 			logger.Errorf("failed finalizer for cluster. %+v", err)
 			return
 		}
+		// wipe the hosts if requested by the cleanup policy, without blocking the deletion of the cluster
+		c.startHostCleanup(newClust)
 		// remove the finalizer from the crd, which indicates to k8s that the resource can safely be deleted
 		c.removeFinalizer(newClust)
 		return
@@ -466,6 +483,17 @@ func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
 		return
 	}
 
+	// Check if the user requested to restore the mon quorum from a surviving mon.
+	// The restore takes minutes, so it runs in the background and reports its progress in the cluster status.
+	if survivor, ok := newClust.Annotations[mon.RestoreQuorumAnnotation]; ok {
+		if !cluster.checkSetQuorumRestoreStatus() {
+			logger.Infof("the mon quorum of cluster %s is already being restored", newClust.Namespace)
+			return
+		}
+		go c.restoreMonQuorum(newClust, cluster, survivor)
+		return
+	}
+
 	changed, _ := clusterChanged(oldClust.Spec, newClust.Spec, cluster)
 	if !changed {
 		logger.Debugf("update event for cluster %s is not supported", newClust.Namespace)
@@ -532,6 +560,41 @@ func (c *ClusterController) handleUpdate(crdName string, cluster *cluster) (bool
 	return true, nil
 }
 
+// restoreMonQuorum restores the mon quorum from a surviving mon, then removes the annotation that requested it
+func (c *ClusterController) restoreMonQuorum(clust *cephv1.CephCluster, cluster *cluster, survivor string) {
+	defer cluster.unsetQuorumRestoreStatus()
+	if cluster.Info == nil {
+		logger.Errorf("cannot restore the mon quorum of cluster %s that is not initialized", cluster.Namespace)
+		return
+	}
+
+	logger.Warningf("restoring the mon quorum of cluster %s from mon %s", cluster.Namespace, survivor)
+	if err := c.updateClusterStatus(clust.Namespace, clust.Name, cephv1.ClusterStateUpdating, fmt.Sprintf("restoring mon quorum from mon %s", survivor)); err != nil {
+		logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.Namespace, err)
+	}
+
+	state := cephv1.ClusterStateCreated
+	message := ""
+	if err := cluster.mons.RestoreQuorum(survivor); err != nil {
+		state = cephv1.ClusterStateError
+		message = fmt.Sprintf("failed to restore mon quorum from mon %s. %+v", survivor, err)
+		logger.Error(message)
+	}
+
+	// remove the annotation so the quorum is not restored again on the next update
+	latest, err := c.context.RookClientset.CephV1().CephClusters(clust.Namespace).Get(clust.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Errorf("failed to get cluster %s to remove the mon quorum restore annotation. %+v", clust.Namespace, err)
+		return
+	}
+	delete(latest.Annotations, mon.RestoreQuorumAnnotation)
+	latest.Status.State = state
+	latest.Status.Message = message
+	if _, err := c.context.RookClientset.CephV1().CephClusters(clust.Namespace).Update(latest); err != nil {
+		logger.Errorf("failed to remove the mon quorum restore annotation from cluster %s. %+v", clust.Namespace, err)
+	}
+}
+
 func (c *ClusterController) onDeviceCMUpdate(oldObj, newObj interface{}) {
 	oldCm, ok := oldObj.(*v1.ConfigMap)
 	if !ok {
@@ -607,6 +670,9 @@ func (c *ClusterController) onDelete(obj interface{}) {
 	if err != nil {
 		logger.Errorf("failed to delete cluster. %+v", err)
 	}
+	if err := csi.RemoveCluster(c.context.Clientset, clust.Namespace); err != nil {
+		logger.Errorf("failed to remove cluster %s from the csi config. %+v", clust.Namespace, err)
+	}
 	if cluster, ok := c.clusterMap[clust.Namespace]; ok {
 		close(cluster.stopCh)
 		delete(c.clusterMap, clust.Namespace)
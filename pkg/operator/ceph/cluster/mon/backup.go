@@ -0,0 +1,138 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+var (
+	monStoppedInterval = 5 * time.Second
+	monStoppedTimeout  = 5 * time.Minute
+)
+
+// StoppedMon is the location of the store of a mon that was stopped for a backup
+type StoppedMon struct {
+	// Name of the mon daemon, e.g. "a"
+	Name string
+	// NodeName is the node where the mon store is found
+	NodeName string
+	// HostDataDir is the path of the mon store on the node
+	HostDataDir string
+}
+
+// WithMonStopped stops a mon whose store can be safely copied and calls the function before starting the mon again.
+// A mon is only stopped if the remaining mons keep the quorum. The mon health checks are paused in the meantime so
+// that the stopped mon is not failed over.
+func (c *Cluster) WithMonStopped(fn func(mon StoppedMon) error) error {
+	c.acquireOrchestrationLock()
+	defer c.releaseOrchestrationLock()
+
+	if c.clusterInfo == nil {
+		return fmt.Errorf("the mons have not been initialized")
+	}
+
+	status, err := client.GetMonStatus(c.context, c.clusterInfo.Name, false)
+	if err != nil {
+		return fmt.Errorf("failed to get mon status. %+v", err)
+	}
+	if len(status.Quorum)-1 <= len(status.MonMap.Mons)/2 {
+		return fmt.Errorf("the quorum would be lost if a mon is stopped. %d of %d mons are in quorum", len(status.Quorum), len(status.MonMap.Mons))
+	}
+
+	name, node, err := c.chooseMonToStop(status)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("stopping mon %s", name)
+	if err := c.scaleMon(name, 0); err != nil {
+		return err
+	}
+	defer func() {
+		logger.Infof("starting mon %s", name)
+		if err := c.scaleMon(name, 1); err != nil {
+			logger.Errorf("failed to start mon %s. %+v", name, err)
+			return
+		}
+		if err := c.waitForMonsToJoin([]*monConfig{{DaemonName: name}}, true); err != nil {
+			logger.Errorf("mon %s did not rejoin the quorum. %+v", name, err)
+		}
+	}()
+
+	if err := c.waitForMonStopped(name); err != nil {
+		return err
+	}
+
+	return fn(StoppedMon{
+		Name:        name,
+		NodeName:    node.Name,
+		HostDataDir: path.Join(c.dataDirHostPath, dataDirRelativeHostPath(name)),
+	})
+}
+
+// chooseMonToStop returns the first mon in quorum that is assigned to a node
+func (c *Cluster) chooseMonToStop(status client.MonStatusResponse) (string, *NodeInfo, error) {
+	names := []string{}
+	for _, m := range status.MonMap.Mons {
+		if monInQuorum(m, status.Quorum) {
+			names = append(names, m.Name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if node, ok := c.mapping.Node[name]; ok && node != nil {
+			return name, node, nil
+		}
+	}
+	return "", nil, fmt.Errorf("none of the mons in quorum %v are assigned to a node", names)
+}
+
+func (c *Cluster) scaleMon(name string, replicas int32) error {
+	d, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(resourceName(name), metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get mon deployment %s. %+v", name, err)
+	}
+	d.Spec.Replicas = &replicas
+	if _, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Update(d); err != nil {
+		return fmt.Errorf("failed to scale mon deployment %s to %d. %+v", name, replicas, err)
+	}
+	return nil
+}
+
+func (c *Cluster) waitForMonStopped(name string) error {
+	selector := fmt.Sprintf("app=%s,mon=%s", AppName, name)
+	return wait.Poll(monStoppedInterval, monStoppedTimeout, func() (bool, error) {
+		pods, err := c.context.Clientset.CoreV1().Pods(c.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return false, fmt.Errorf("failed to list pods of mon %s. %+v", name, err)
+		}
+		if len(pods.Items) > 0 {
+			logger.Infof("waiting for mon %s to stop", name)
+			return false, nil
+		}
+		return true, nil
+	})
+}
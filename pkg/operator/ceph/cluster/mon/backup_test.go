@@ -0,0 +1,87 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	clienttest "github.com/rook/rook/pkg/daemon/ceph/client/test"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWithMonStopped(t *testing.T) {
+	monStoppedInterval = time.Millisecond
+	monResponse := clienttest.MonInQuorumResponse()
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			return monResponse, nil
+		},
+	}
+	clientset := test.New(1)
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{
+		Clientset: clientset,
+		ConfigDir: configDir,
+		Executor:  executor,
+	}
+	c := New(context, "ns", "/var/lib/rook", false, metav1.OwnerReference{})
+	setCommonMonProperties(c, 3, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, "myversion")
+	c.waitForStart = false
+	for i := 0; i < 3; i++ {
+		m := c.newMonConfig(i)
+		node := "node-" + m.DaemonName
+		c.mapping.Node[m.DaemonName] = &NodeInfo{Name: node, Hostname: node}
+		clientset.AppsV1().Deployments("ns").Create(c.makeDeployment(m, node))
+	}
+
+	called := false
+	backup := func(m StoppedMon) error {
+		called = true
+		assert.Equal(t, "a", m.Name)
+		assert.Equal(t, "node-a", m.NodeName)
+		assert.Equal(t, "/var/lib/rook/mon-a/data", m.HostDataDir)
+		d, _ := clientset.AppsV1().Deployments("ns").Get("rook-ceph-mon-a", metav1.GetOptions{})
+		assert.Equal(t, int32(0), *d.Spec.Replicas)
+		return nil
+	}
+
+	// a single mon cannot be stopped without losing quorum
+	err := c.WithMonStopped(backup)
+	assert.NotNil(t, err)
+	assert.False(t, called)
+
+	monResponse = clienttest.MonInQuorumResponseFromMons(c.clusterInfo.Monitors)
+	err = c.WithMonStopped(backup)
+	assert.Nil(t, err)
+	assert.True(t, called)
+
+	// the mon is started again
+	var d *apps.Deployment
+	d, err = clientset.AppsV1().Deployments("ns").Get("rook-ceph-mon-a", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), *d.Spec.Replicas)
+}
@@ -299,24 +299,34 @@ func (c *Cluster) failoverMon(name string) error {
 func (c *Cluster) removeMon(daemonName string) error {
 	logger.Infof("ensuring removal of unhealthy monitor %s", daemonName)
 
-	resourceName := resourceName(daemonName)
-
 	// Remove the mon pod if it is still there
-	var gracePeriod int64
-	propagation := metav1.DeletePropagationForeground
-	options := &metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod, PropagationPolicy: &propagation}
-	if err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Delete(resourceName, options); err != nil {
+	if err := c.removeMonDeployment(daemonName); err != nil {
+		return err
+	}
+
+	// Remove the bad monitor from quorum
+	if err := removeMonitorFromQuorum(c.context, c.clusterInfo.Name, daemonName); err != nil {
+		return fmt.Errorf("failed to remove mon %s from quorum. %+v", daemonName, err)
+	}
+
+	return c.removeMonResources(daemonName)
+}
+
+func (c *Cluster) removeMonDeployment(daemonName string) error {
+	resourceName := resourceName(daemonName)
+	if err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Delete(resourceName, deleteOptions()); err != nil {
 		if errors.IsNotFound(err) {
 			logger.Infof("dead mon %s was already gone", resourceName)
 		} else {
 			return fmt.Errorf("failed to remove dead mon deployment %s. %+v", resourceName, err)
 		}
 	}
+	return nil
+}
 
-	// Remove the bad monitor from quorum
-	if err := removeMonitorFromQuorum(c.context, c.clusterInfo.Name, daemonName); err != nil {
-		return fmt.Errorf("failed to remove mon %s from quorum. %+v", daemonName, err)
-	}
+// removeMonResources forgets about a mon that is no longer in the mon map and removes its service
+func (c *Cluster) removeMonResources(daemonName string) error {
+	resourceName := resourceName(daemonName)
 	delete(c.clusterInfo.Monitors, daemonName)
 	// check if a mapping exists for the mon
 	if _, ok := c.mapping.Node[daemonName]; ok {
@@ -336,7 +346,7 @@ func (c *Cluster) removeMon(daemonName string) error {
 	}
 
 	// Remove the service endpoint
-	if err := c.context.Clientset.CoreV1().Services(c.Namespace).Delete(resourceName, options); err != nil {
+	if err := c.context.Clientset.CoreV1().Services(c.Namespace).Delete(resourceName, deleteOptions()); err != nil {
 		if errors.IsNotFound(err) {
 			logger.Infof("dead mon service %s was already gone", resourceName)
 		} else {
@@ -356,6 +366,13 @@ func (c *Cluster) removeMon(daemonName string) error {
 	return nil
 }
 
+// deleteOptions removes the mon resources immediately, including their dependents
+func deleteOptions() *metav1.DeleteOptions {
+	var gracePeriod int64
+	propagation := metav1.DeletePropagationForeground
+	return &metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod, PropagationPolicy: &propagation}
+}
+
 func removeMonitorFromQuorum(context *clusterd.Context, clusterName, name string) error {
 	logger.Debugf("removing monitor %s", name)
 	args := []string{"mon", "remove", name}
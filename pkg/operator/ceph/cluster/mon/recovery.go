@@ -0,0 +1,147 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	cephutil "github.com/rook/rook/pkg/daemon/ceph/util"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// RestoreQuorumAnnotation is set on the CephCluster with the name of a surviving mon (e.g. "a")
+	// to restore the mon quorum from that mon after the quorum was lost.
+	RestoreQuorumAnnotation = "ceph.rook.io/restore-mon-quorum"
+)
+
+// RestoreQuorum recovers from the loss of the mon quorum. All the mons except the survivor are stopped and removed
+// from the monmap of the survivor, which is then started alone to form a quorum of one. The quorum is then grown back
+// to the desired mon count with new mons.
+func (c *Cluster) RestoreQuorum(survivor string) error {
+	c.acquireOrchestrationLock()
+	defer c.releaseOrchestrationLock()
+
+	if c.clusterInfo == nil {
+		return fmt.Errorf("the mons have not been initialized")
+	}
+	monInfo, ok := c.clusterInfo.Monitors[survivor]
+	if !ok {
+		return fmt.Errorf("mon %s is not a known mon. mons: %s", survivor, FlattenMonEndpoints(c.clusterInfo.Monitors))
+	}
+	node, ok := c.mapping.Node[survivor]
+	if !ok {
+		return fmt.Errorf("mon %s is not assigned to a node", survivor)
+	}
+
+	deadMons := []string{}
+	for name := range c.clusterInfo.Monitors {
+		if name != survivor {
+			deadMons = append(deadMons, name)
+		}
+	}
+	sort.Strings(deadMons)
+	logger.Warningf("restoring mon quorum from mon %s. removing mons %v", survivor, deadMons)
+
+	// stop the dead mons so they cannot rejoin with the old monmap if they come back
+	for _, name := range deadMons {
+		if err := c.removeMonDeployment(name); err != nil {
+			return err
+		}
+	}
+
+	m := &monConfig{
+		ResourceName: resourceName(survivor),
+		DaemonName:   survivor,
+		PublicIP:     cephutil.GetIPFromEndpoint(monInfo.Endpoint),
+		Port:         cephutil.GetPortFromEndpoint(monInfo.Endpoint),
+		DataPathMap: config.NewStatefulDaemonDataPathMap(
+			c.dataDirHostPath, dataDirRelativeHostPath(survivor), config.MonType, survivor, c.Namespace),
+	}
+
+	// restart the survivor with an edited monmap that only contains itself
+	d := c.makeMonmapRestoreDeployment(m, node.Hostname, deadMons)
+	if _, err := updateDeploymentAndWait(c.context, d, c.Namespace); err != nil {
+		return fmt.Errorf("failed to restart mon %s with the edited monmap. %+v", survivor, err)
+	}
+
+	// forget about the dead mons so the endpoints and the config only point to the survivor
+	for _, name := range deadMons {
+		if err := c.removeMonResources(name); err != nil {
+			return err
+		}
+	}
+
+	if err := c.waitForMonsToJoin([]*monConfig{m}, true); err != nil {
+		return fmt.Errorf("mon %s did not form a quorum. %+v", survivor, err)
+	}
+	logger.Infof("mon %s formed a quorum alone", survivor)
+
+	// run the survivor again with its usual spec now that the monmap is fixed
+	if err := c.startMon(m, node.Hostname); err != nil {
+		return err
+	}
+
+	targetCount, msg, err := c.getTargetMonCount()
+	if err != nil {
+		return fmt.Errorf("failed to get target mon count. %+v", err)
+	}
+	logger.Infof(msg)
+
+	if err := c.startMons(targetCount); err != nil {
+		return fmt.Errorf("failed to grow the mon quorum back to %d mons. %+v", targetCount, err)
+	}
+
+	logger.Infof("mon quorum restored from mon %s", survivor)
+	return nil
+}
+
+// makeMonmapRestoreDeployment returns the mon deployment with init containers that remove the dead mons from the
+// monmap stored in the mon's data dir before the mon starts.
+func (c *Cluster) makeMonmapRestoreDeployment(m *monConfig, hostname string, deadMons []string) *apps.Deployment {
+	d := c.makeDeployment(m, hostname)
+	monmapPath := path.Join(m.DataPathMap.ContainerDataDir, monmapFile)
+
+	extract := c.makeMonmapContainer(m, "extract-monmap", cephMonCommand,
+		append(opspec.DaemonFlags(c.clusterInfo, m.DaemonName), config.NewFlag("extract-monmap", monmapPath)))
+
+	removeArgs := []string{monmapPath}
+	for _, name := range deadMons {
+		removeArgs = append(removeArgs, "--rm", name)
+	}
+	remove := c.makeMonmapContainer(m, "remove-dead-mons", monmaptoolCommand, removeArgs)
+
+	inject := c.makeMonmapContainer(m, "inject-monmap", cephMonCommand,
+		append(opspec.DaemonFlags(c.clusterInfo, m.DaemonName), config.NewFlag("inject-monmap", monmapPath)))
+
+	initContainers := &d.Spec.Template.Spec.InitContainers
+	*initContainers = append(*initContainers, extract, remove, inject)
+	return d
+}
+
+func (c *Cluster) makeMonmapContainer(m *monConfig, name, command string, args []string) v1.Container {
+	container := c.makeMonFSInitContainer(m)
+	container.Name = name
+	container.Command = []string{command}
+	container.Args = args
+	return container
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	clienttest "github.com/rook/rook/pkg/daemon/ceph/client/test"
+	testopk8s "github.com/rook/rook/pkg/operator/k8sutil/test"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRestoreQuorum(t *testing.T) {
+	var deploymentsUpdated *[]*apps.Deployment
+	updateDeploymentAndWait, deploymentsUpdated = testopk8s.UpdateDeploymentAndWaitStub()
+
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			return clienttest.MonInQuorumResponse(), nil
+		},
+	}
+	clientset := test.New(1)
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{
+		Clientset: clientset,
+		ConfigDir: configDir,
+		Executor:  executor,
+	}
+	c := New(context, "ns", "", false, metav1.OwnerReference{})
+	setCommonMonProperties(c, 3, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, "myversion")
+	c.waitForStart = false
+	c.maxMonID = 2
+	for _, name := range []string{"a", "b", "c"} {
+		c.mapping.Node[name] = &NodeInfo{Name: "node0", Hostname: "node0"}
+	}
+
+	// an unknown mon cannot be the survivor
+	err := c.RestoreQuorum("z")
+	assert.NotNil(t, err)
+
+	err = c.RestoreQuorum("a")
+	assert.Nil(t, err)
+
+	// the survivor is restarted with the edited monmap, then with its usual spec
+	assert.Equal(t, []string{"rook-ceph-mon-a", "rook-ceph-mon-a"}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
+	restore := (*deploymentsUpdated)[0].Spec.Template.Spec.InitContainers
+	assert.Equal(t, 4, len(restore))
+	assert.Equal(t, "extract-monmap", restore[1].Name)
+	assert.Equal(t, []string{monmaptoolCommand}, restore[2].Command)
+	assert.Equal(t, []string{"/var/lib/ceph/mon/ceph-a/monmap", "--rm", "b", "--rm", "c"}, restore[2].Args)
+	assert.Equal(t, "inject-monmap", restore[3].Name)
+	assert.Equal(t, 1, len((*deploymentsUpdated)[1].Spec.Template.Spec.InitContainers))
+
+	// the dead mons are replaced with new mons
+	assert.Equal(t, 3, len(c.clusterInfo.Monitors))
+	for _, name := range []string{"a", "d", "e"} {
+		_, ok := c.clusterInfo.Monitors[name]
+		assert.True(t, ok, name)
+	}
+	for _, name := range []string{"b", "c"} {
+		_, ok := c.clusterInfo.Monitors[name]
+		assert.False(t, ok, name)
+		_, ok = c.mapping.Node[name]
+		assert.False(t, ok, name)
+	}
+
+	cm, err := clientset.CoreV1().ConfigMaps("ns").Get(EndpointConfigMapName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.NotContains(t, cm.Data[EndpointDataKey], "b=")
+	assert.NotContains(t, cm.Data[EndpointDataKey], "c=")
+}
@@ -42,12 +42,14 @@ import (
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-osd")
 
 const (
-	appName                             = "rook-ceph-osd"
-	prepareAppName                      = "rook-ceph-osd-prepare"
-	prepareAppNameFmt                   = "rook-ceph-osd-prepare-%s"
-	legacyAppNameFmt                    = "rook-ceph-osd-id-%d"
-	osdAppNameFmt                       = "rook-ceph-osd-%d"
-	osdLabelKey                         = "ceph-osd-id"
+	// AppName is the app label of the osd pods
+	AppName           = "rook-ceph-osd"
+	prepareAppName    = "rook-ceph-osd-prepare"
+	prepareAppNameFmt = "rook-ceph-osd-prepare-%s"
+	legacyAppNameFmt  = "rook-ceph-osd-id-%d"
+	osdAppNameFmt     = "rook-ceph-osd-%d"
+	// OsdIDLabelKey is the label of the osd pods with the id of their osd
+	OsdIDLabelKey                       = "ceph-osd-id"
 	clusterAvailableSpaceReserve        = 0.05
 	serviceAccountName                  = "rook-ceph-osd"
 	unknownID                           = -1
@@ -401,7 +403,7 @@ func (c *Cluster) cleanupRemovedNode(config *provisionConfig, nodeName, crushNam
 // node names -> a list of osd deployments on the node
 func (c *Cluster) discoverStorageNodes() (map[string][]*apps.Deployment, error) {
 
-	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s", appName)}
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s", AppName)}
 	osdDeployments, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).List(listOpts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list osd deployment: %+v", err)
@@ -486,7 +488,7 @@ func (c *Cluster) isSafeToRemoveNode(nodeName string, osdDeployments []*apps.Dep
 }
 
 func getIDFromDeployment(deployment *apps.Deployment) int {
-	if idstr, ok := deployment.Labels[osdLabelKey]; ok {
+	if idstr, ok := deployment.Labels[OsdIDLabelKey]; ok {
 		id, err := strconv.Atoi(idstr)
 		if err != nil {
 			logger.Errorf("unknown osd id from label %s", idstr)
@@ -260,17 +260,17 @@ func (c *Cluster) makeDeployment(nodeName string, selection rookalpha.Selection,
 			Name:      fmt.Sprintf(osdAppNameFmt, osd.ID),
 			Namespace: c.Namespace,
 			Labels: map[string]string{
-				k8sutil.AppAttr:     appName,
+				k8sutil.AppAttr:     AppName,
 				k8sutil.ClusterAttr: c.Namespace,
-				osdLabelKey:         fmt.Sprintf("%d", osd.ID),
+				OsdIDLabelKey:       fmt.Sprintf("%d", osd.ID),
 			},
 		},
 		Spec: apps.DeploymentSpec{
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{
-					k8sutil.AppAttr:     appName,
+					k8sutil.AppAttr:     AppName,
 					k8sutil.ClusterAttr: c.Namespace,
-					osdLabelKey:         fmt.Sprintf("%d", osd.ID),
+					OsdIDLabelKey:       fmt.Sprintf("%d", osd.ID),
 				},
 			},
 			Strategy: apps.DeploymentStrategy{
@@ -278,11 +278,11 @@ func (c *Cluster) makeDeployment(nodeName string, selection rookalpha.Selection,
 			},
 			Template: v1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Name: appName,
+					Name: AppName,
 					Labels: map[string]string{
-						k8sutil.AppAttr:     appName,
+						k8sutil.AppAttr:     AppName,
 						k8sutil.ClusterAttr: c.Namespace,
-						osdLabelKey:         fmt.Sprintf("%d", osd.ID),
+						OsdIDLabelKey:       fmt.Sprintf("%d", osd.ID),
 					},
 				},
 				Spec: v1.PodSpec{
@@ -397,7 +397,7 @@ func (c *Cluster) provisionPodTemplateSpec(devices []rookalpha.Device, selection
 	c.placement.ApplyToPodSpec(&podSpec)
 
 	podMeta := metav1.ObjectMeta{
-		Name: appName,
+		Name: AppName,
 		Labels: map[string]string{
 			k8sutil.AppAttr:     prepareAppName,
 			k8sutil.ClusterAttr: c.Namespace,
@@ -0,0 +1,155 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/coreos/pkg/capnslog"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-csi")
+
+const (
+	// ConfigName is the name of the config map with the monitors of each cluster, mounted in the csi drivers
+	ConfigName = "rook-ceph-csi-config"
+	// ConfigKey is the key of the config map with the json config of the clusters
+	ConfigKey = "csi-cluster-config-json"
+)
+
+// clusterConfigEntry is the config of a cluster in the format expected by the csi drivers. The id of the cluster
+// is its namespace, which the storage classes refer to with the clusterID parameter.
+type clusterConfigEntry struct {
+	ClusterID string   `json:"clusterID"`
+	Monitors  []string `json:"monitors"`
+}
+
+// the config map is shared by all the clusters managed by the operator
+var configMutex sync.Mutex
+
+// driversStarted returns whether the operator deployed the csi drivers
+func driversStarted() bool {
+	return CSIEnabled() && CSIParam.Namespace != ""
+}
+
+// createConfigMap creates the empty config map of the clusters if it does not exist yet. The drivers cannot start
+// until the config map exists.
+func createConfigMap(namespace string, clientset kubernetes.Interface) error {
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigName,
+			Namespace: namespace,
+		},
+		Data: map[string]string{
+			ConfigKey: "[]",
+		},
+	}
+	_, err := clientset.CoreV1().ConfigMaps(namespace).Create(configMap)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create csi config map. %+v", err)
+	}
+	return nil
+}
+
+// SaveClusterConfig updates the monitors of the cluster in the csi config map. It must be called every time the
+// mon endpoints change so that the drivers can still reach the cluster after a mon failover.
+func SaveClusterConfig(clientset kubernetes.Interface, clusterNamespace string, clusterInfo *cephconfig.ClusterInfo) error {
+	if !driversStarted() {
+		return nil
+	}
+
+	monitors := []string{}
+	for _, mon := range clusterInfo.Monitors {
+		monitors = append(monitors, mon.Endpoint)
+	}
+	sort.Strings(monitors)
+	if err := updateClusterConfig(clientset, clusterNamespace, monitors); err != nil {
+		return err
+	}
+	logger.Infof("saved the monitors of cluster %s in the csi config: %v", clusterNamespace, monitors)
+	return nil
+}
+
+// RemoveCluster removes the cluster from the csi config map and deletes the storage classes created for the cluster
+func RemoveCluster(clientset kubernetes.Interface, clusterNamespace string) error {
+	if !driversStarted() {
+		return nil
+	}
+
+	if err := updateClusterConfig(clientset, clusterNamespace, nil); err != nil {
+		return err
+	}
+	return deleteStorageClasses(clientset, clusterNamespace)
+}
+
+// updateClusterConfig replaces the monitors of the cluster in the config map, or removes the cluster if there are
+// no monitors
+func updateClusterConfig(clientset kubernetes.Interface, clusterID string, monitors []string) error {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
+	configMap, err := clientset.CoreV1().ConfigMaps(CSIParam.Namespace).Get(ConfigName, metav1.GetOptions{})
+	if err != nil && errors.IsNotFound(err) {
+		// the drivers may have failed to start before the config map was created
+		if err := createConfigMap(CSIParam.Namespace, clientset); err != nil {
+			return err
+		}
+		configMap, err = clientset.CoreV1().ConfigMaps(CSIParam.Namespace).Get(ConfigName, metav1.GetOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get csi config map. %+v", err)
+	}
+
+	entries := []clusterConfigEntry{}
+	if data := configMap.Data[ConfigKey]; data != "" {
+		if err := json.Unmarshal([]byte(data), &entries); err != nil {
+			return fmt.Errorf("failed to parse csi config. %+v", err)
+		}
+	}
+
+	updated := []clusterConfigEntry{}
+	for _, entry := range entries {
+		if entry.ClusterID != clusterID {
+			updated = append(updated, entry)
+		}
+	}
+	if len(monitors) > 0 {
+		updated = append(updated, clusterConfigEntry{ClusterID: clusterID, Monitors: monitors})
+	}
+	sort.Slice(updated, func(i, j int) bool { return updated[i].ClusterID < updated[j].ClusterID })
+
+	config, err := json.Marshal(updated)
+	if err != nil {
+		return fmt.Errorf("failed to marshal csi config. %+v", err)
+	}
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[ConfigKey] = string(config)
+	if _, err := clientset.CoreV1().ConfigMaps(CSIParam.Namespace).Update(configMap); err != nil {
+		return fmt.Errorf("failed to update csi config map. %+v", err)
+	}
+	return nil
+}
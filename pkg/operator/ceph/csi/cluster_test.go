@@ -0,0 +1,124 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"testing"
+
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterConfig(t *testing.T) {
+	clientset := test.New(1)
+	CSIParam.Namespace = "operator"
+	defer func() { CSIParam.Namespace = "" }()
+	assert.Nil(t, createConfigMap("operator", clientset))
+
+	getConfig := func() string {
+		cm, err := clientset.CoreV1().ConfigMaps("operator").Get(ConfigName, metav1.GetOptions{})
+		assert.Nil(t, err)
+		return cm.Data[ConfigKey]
+	}
+	assert.Equal(t, "[]", getConfig())
+
+	info := &cephconfig.ClusterInfo{Monitors: map[string]*cephconfig.MonInfo{
+		"b": {Name: "b", Endpoint: "1.2.3.5:6789"},
+		"a": {Name: "a", Endpoint: "1.2.3.4:6789"},
+	}}
+	assert.Nil(t, SaveClusterConfig(clientset, "ns1", info))
+	assert.Nil(t, SaveClusterConfig(clientset, "ns2", &cephconfig.ClusterInfo{Monitors: map[string]*cephconfig.MonInfo{
+		"a": {Name: "a", Endpoint: "10.0.0.1:6789"},
+	}}))
+	assert.Equal(t, `[{"clusterID":"ns1","monitors":["1.2.3.4:6789","1.2.3.5:6789"]},{"clusterID":"ns2","monitors":["10.0.0.1:6789"]}]`, getConfig())
+
+	// a mon failover replaces the monitors of the cluster
+	delete(info.Monitors, "b")
+	info.Monitors["c"] = &cephconfig.MonInfo{Name: "c", Endpoint: "1.2.3.6:6789"}
+	assert.Nil(t, SaveClusterConfig(clientset, "ns1", info))
+	assert.Equal(t, `[{"clusterID":"ns1","monitors":["1.2.3.4:6789","1.2.3.6:6789"]},{"clusterID":"ns2","monitors":["10.0.0.1:6789"]}]`, getConfig())
+
+	// the config map is not reset when the operator restarts
+	assert.Nil(t, createConfigMap("operator", clientset))
+	assert.Contains(t, getConfig(), "ns1")
+
+	assert.Nil(t, RemoveCluster(clientset, "ns1"))
+	assert.Equal(t, `[{"clusterID":"ns2","monitors":["10.0.0.1:6789"]}]`, getConfig())
+
+	// the config map is created if the drivers failed to start before creating it
+	assert.Nil(t, clientset.CoreV1().ConfigMaps("operator").Delete(ConfigName, &metav1.DeleteOptions{}))
+	assert.Nil(t, SaveClusterConfig(clientset, "ns1", info))
+	assert.Equal(t, `[{"clusterID":"ns1","monitors":["1.2.3.4:6789","1.2.3.6:6789"]}]`, getConfig())
+}
+
+func TestStorageClasses(t *testing.T) {
+	clientset := test.New(1)
+
+	// nothing is created when the drivers are not deployed
+	assert.Nil(t, CreateRBDStorageClass(clientset, "ns1", "replicapool"))
+	classes, _ := clientset.StorageV1().StorageClasses().List(metav1.ListOptions{})
+	assert.Equal(t, 0, len(classes.Items))
+
+	CSIParam.Namespace = "operator"
+	defer func() { CSIParam.Namespace = "" }()
+	assert.Nil(t, createConfigMap("operator", clientset))
+	assert.Nil(t, CreateRBDStorageClass(clientset, "ns1", "replicapool"))
+	assert.Nil(t, CreateCephFSStorageClass(clientset, "ns1", "myfs", "myfs-data0"))
+	assert.Nil(t, CreateRBDStorageClass(clientset, "ns2", "replicapool"))
+	// creating a storage class again is not an error
+	assert.Nil(t, CreateRBDStorageClass(clientset, "ns1", "replicapool"))
+
+	rbd, err := clientset.StorageV1().StorageClasses().Get("ns1-rbd-replicapool", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, RBDDriverName, rbd.Provisioner)
+	assert.Equal(t, "ns1", rbd.Parameters["clusterID"])
+	assert.Equal(t, "replicapool", rbd.Parameters["pool"])
+	assert.Equal(t, RBDNodeSecretName, rbd.Parameters[nodeStageSecretNameParam])
+	assert.Equal(t, "ns1", rbd.Parameters[nodeStageSecretNamespaceParam])
+
+	cephfs, err := clientset.StorageV1().StorageClasses().Get("ns1-cephfs-myfs", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, CephFSDriverName, cephfs.Provisioner)
+	assert.Equal(t, "myfs", cephfs.Parameters["fsName"])
+	assert.Equal(t, "myfs-data0", cephfs.Parameters["pool"])
+	assert.Equal(t, CephFSProvisionerSecretName, cephfs.Parameters[provisionerSecretNameParam])
+
+	// only the storage classes of the removed cluster are deleted
+	assert.Nil(t, RemoveCluster(clientset, "ns1"))
+	classes, _ = clientset.StorageV1().StorageClasses().List(metav1.ListOptions{})
+	assert.Equal(t, 1, len(classes.Items))
+	assert.Equal(t, "ns2-rbd-replicapool", classes.Items[0].Name)
+
+	// a storage class of another cluster or that was not created by the operator is not deleted
+	assert.Nil(t, DeleteStorageClass(clientset, "ns1", "ns2-rbd-replicapool"))
+	_, err = clientset.StorageV1().StorageClasses().Create(&storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: "ns1-rbd-mypool"},
+		Provisioner: "ceph.rook.io/block",
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, DeleteStorageClass(clientset, "ns1", "ns1-rbd-mypool"))
+	classes, _ = clientset.StorageV1().StorageClasses().List(metav1.ListOptions{})
+	assert.Equal(t, 2, len(classes.Items))
+
+	assert.Nil(t, DeleteStorageClass(clientset, "ns2", "ns2-rbd-replicapool"))
+	assert.Nil(t, DeleteStorageClass(clientset, "ns2", "ns2-rbd-replicapool"))
+	classes, _ = clientset.StorageV1().StorageClasses().List(metav1.ListOptions{})
+	assert.Equal(t, 1, len(classes.Items))
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// RBDProvisionerSecretName is the secret with the ceph user of the rbd provisioner
+	RBDProvisionerSecretName = "rook-csi-rbd-provisioner"
+	// RBDNodeSecretName is the secret with the ceph user of the rbd node plugin
+	RBDNodeSecretName = "rook-csi-rbd-node"
+	// CephFSProvisionerSecretName is the secret with the ceph user of the cephfs provisioner
+	CephFSProvisionerSecretName = "rook-csi-cephfs-provisioner"
+	// CephFSNodeSecretName is the secret with the ceph user of the cephfs node plugin
+	CephFSNodeSecretName = "rook-csi-cephfs-node"
+)
+
+// csiUser is a ceph user of a csi driver and the keys of the secret in which the driver expects it
+type csiUser struct {
+	name       string
+	secretName string
+	idKey      string
+	keyKey     string
+	access     []string
+}
+
+// csiUsers returns the users of the enabled drivers. Their access is restricted to what the provisioners and
+// the node plugins need instead of using the admin key.
+func csiUsers() []csiUser {
+	users := []csiUser{}
+	if EnableRBD {
+		users = append(users,
+			csiUser{
+				name:       "client.csi-rbd-provisioner",
+				secretName: RBDProvisionerSecretName,
+				idKey:      "userID",
+				keyKey:     "userKey",
+				access:     []string{"mon", "profile rbd", "mgr", "allow rw", "osd", "profile rbd"},
+			},
+			csiUser{
+				name:       "client.csi-rbd-node",
+				secretName: RBDNodeSecretName,
+				idKey:      "userID",
+				keyKey:     "userKey",
+				access:     []string{"mon", "profile rbd", "osd", "profile rbd"},
+			})
+	}
+	if EnableCephFS {
+		users = append(users,
+			csiUser{
+				name:       "client.csi-cephfs-provisioner",
+				secretName: CephFSProvisionerSecretName,
+				idKey:      "adminID",
+				keyKey:     "adminKey",
+				access:     []string{"mon", "allow r", "mgr", "allow rw", "osd", "allow rw tag cephfs metadata=*"},
+			},
+			csiUser{
+				name:       "client.csi-cephfs-node",
+				secretName: CephFSNodeSecretName,
+				idKey:      "adminID",
+				keyKey:     "adminKey",
+				access:     []string{"mon", "allow r", "mgr", "allow rw", "osd", "allow rw tag cephfs *=*", "mds", "allow rw"},
+			})
+	}
+	return users
+}
+
+// CreateCSISecrets creates the ceph users of the csi drivers in the cluster and stores their keys in secrets in
+// the cluster namespace, where the storage classes of the cluster refer to them.
+func CreateCSISecrets(context *clusterd.Context, namespace string, ownerRef *metav1.OwnerReference) error {
+	if !driversStarted() {
+		return nil
+	}
+
+	store := keyring.GetSecretStore(context, namespace, ownerRef)
+	for _, user := range csiUsers() {
+		key, err := store.GenerateKey(user.secretName, user.name, user.access)
+		if err != nil {
+			return fmt.Errorf("failed to generate the key of %s. %+v", user.name, err)
+		}
+
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      user.secretName,
+				Namespace: namespace,
+			},
+			StringData: map[string]string{
+				user.idKey:  strings.TrimPrefix(user.name, "client."),
+				user.keyKey: key,
+			},
+			Type: k8sutil.RookType,
+		}
+		k8sutil.SetOwnerRef(context.Clientset, namespace, &secret.ObjectMeta, ownerRef)
+
+		_, err = context.Clientset.CoreV1().Secrets(namespace).Create(secret)
+		if errors.IsAlreadyExists(err) {
+			_, err = context.Clientset.CoreV1().Secrets(namespace).Update(secret)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save secret %s. %+v", user.secretName, err)
+		}
+	}
+	logger.Infof("created the csi secrets of cluster %s", namespace)
+	return nil
+}
@@ -56,8 +56,8 @@ const (
 	KubeMinMinor = "13"
 
 	// image names
-	DefaultRBDPluginImage    = "quay.io/cephcsi/rbdplugin:v1.0.0"
-	DefaultCephFSPluginImage = "quay.io/cephcsi/cephfsplugin:v1.0.0"
+	DefaultRBDPluginImage    = "quay.io/cephcsi/cephcsi:v1.1.0"
+	DefaultCephFSPluginImage = "quay.io/cephcsi/cephcsi:v1.1.0"
 	DefaultRegistrarImage    = "quay.io/k8scsi/csi-node-driver-registrar:v1.0.2"
 	DefaultProvisionerImage  = "quay.io/k8scsi/csi-provisioner:v1.0.1"
 	DefaultAttacherImage     = "quay.io/k8scsi/csi-attacher:v1.0.1"
@@ -150,6 +150,11 @@ func StartCSIDrivers(namespace string, clientset kubernetes.Interface) error {
 		}
 	}
 
+	// the drivers mount the config of the clusters
+	if err = createConfigMap(namespace, clientset); err != nil {
+		return err
+	}
+
 	if rbdPlugin != nil {
 		err = k8sutil.CreateDaemonSet("csi rbd plugin", namespace, clientset, rbdPlugin)
 		if err != nil {
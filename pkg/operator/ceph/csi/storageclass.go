@@ -0,0 +1,164 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"fmt"
+
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// RBDDriverName is the name of the rbd driver, which is the provisioner of the rbd storage classes
+	RBDDriverName = "rbd.csi.ceph.com"
+	// CephFSDriverName is the name of the cephfs driver, which is the provisioner of the cephfs storage classes
+	CephFSDriverName = "cephfs.csi.ceph.com"
+
+	provisionerSecretNameParam      = "csi.storage.k8s.io/provisioner-secret-name"
+	provisionerSecretNamespaceParam = "csi.storage.k8s.io/provisioner-secret-namespace"
+	nodeStageSecretNameParam        = "csi.storage.k8s.io/node-stage-secret-name"
+	nodeStageSecretNamespaceParam   = "csi.storage.k8s.io/node-stage-secret-namespace"
+)
+
+// RBDStorageClassName returns the name of the storage class created for a pool
+func RBDStorageClassName(clusterNamespace, pool string) string {
+	return fmt.Sprintf("%s-rbd-%s", clusterNamespace, pool)
+}
+
+// CephFSStorageClassName returns the name of the storage class created for a filesystem
+func CephFSStorageClassName(clusterNamespace, filesystem string) string {
+	return fmt.Sprintf("%s-cephfs-%s", clusterNamespace, filesystem)
+}
+
+// CreateRBDStorageClass creates the storage class of the images in the pool if the rbd driver is deployed
+func CreateRBDStorageClass(clientset kubernetes.Interface, clusterNamespace, pool string) error {
+	if !driversStarted() || !EnableRBD {
+		return nil
+	}
+
+	return createStorageClass(clientset, clusterNamespace, RBDStorageClassName(clusterNamespace, pool), RBDDriverName, map[string]string{
+		"clusterID":                     clusterNamespace,
+		"pool":                          pool,
+		"imageFormat":                   "2",
+		"imageFeatures":                 "layering",
+		provisionerSecretNameParam:      RBDProvisionerSecretName,
+		provisionerSecretNamespaceParam: clusterNamespace,
+		nodeStageSecretNameParam:        RBDNodeSecretName,
+		nodeStageSecretNamespaceParam:   clusterNamespace,
+	})
+}
+
+// CreateCephFSStorageClass creates the storage class of the volumes in the filesystem if the cephfs driver is deployed
+func CreateCephFSStorageClass(clientset kubernetes.Interface, clusterNamespace, filesystem, dataPool string) error {
+	if !driversStarted() || !EnableCephFS {
+		return nil
+	}
+
+	return createStorageClass(clientset, clusterNamespace, CephFSStorageClassName(clusterNamespace, filesystem), CephFSDriverName, map[string]string{
+		"clusterID":                     clusterNamespace,
+		"fsName":                        filesystem,
+		"pool":                          dataPool,
+		provisionerSecretNameParam:      CephFSProvisionerSecretName,
+		provisionerSecretNamespaceParam: clusterNamespace,
+		nodeStageSecretNameParam:        CephFSNodeSecretName,
+		nodeStageSecretNamespaceParam:   clusterNamespace,
+	})
+}
+
+// DeleteStorageClass deletes a storage class created by the operator for the cluster. A storage class of the same name
+// that was not created by the operator for the cluster is left as it is.
+func DeleteStorageClass(clientset kubernetes.Interface, clusterNamespace, name string) error {
+	storageClass, err := clientset.StorageV1().StorageClasses().Get(name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get storage class %s. %+v", name, err)
+	}
+	if !ownedStorageClass(storageClass, clusterNamespace) {
+		logger.Infof("storage class %s was not created for cluster %s, not deleting it", name, clusterNamespace)
+		return nil
+	}
+	return deleteStorageClass(clientset, name)
+}
+
+func deleteStorageClass(clientset kubernetes.Interface, name string) error {
+	err := clientset.StorageV1().StorageClasses().Delete(name, &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete storage class %s. %+v", name, err)
+	}
+	return nil
+}
+
+// ownedStorageClass returns true if the storage class was created by the operator for the cluster
+func ownedStorageClass(storageClass *storagev1.StorageClass, clusterNamespace string) bool {
+	if storageClass.Labels[k8sutil.ClusterAttr] != clusterNamespace {
+		return false
+	}
+	return storageClass.Provisioner == RBDDriverName || storageClass.Provisioner == CephFSDriverName
+}
+
+func createStorageClass(clientset kubernetes.Interface, clusterNamespace, name, provisioner string, parameters map[string]string) error {
+	reclaimPolicy := v1.PersistentVolumeReclaimDelete
+	storageClass := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				k8sutil.ClusterAttr: clusterNamespace,
+			},
+		},
+		Provisioner:   provisioner,
+		Parameters:    parameters,
+		ReclaimPolicy: &reclaimPolicy,
+	}
+
+	// the parameters of a storage class cannot be updated, an existing storage class is left as it is
+	_, err := clientset.StorageV1().StorageClasses().Create(storageClass)
+	if err != nil {
+		if errors.IsAlreadyExists(err) {
+			logger.Debugf("storage class %s already exists", name)
+			return nil
+		}
+		return fmt.Errorf("failed to create storage class %s. %+v", name, err)
+	}
+	logger.Infof("created storage class %s", name)
+	return nil
+}
+
+// deleteStorageClasses deletes the storage classes of a cluster
+func deleteStorageClasses(clientset kubernetes.Interface, clusterNamespace string) error {
+	selector := fmt.Sprintf("%s=%s", k8sutil.ClusterAttr, clusterNamespace)
+	storageClasses, err := clientset.StorageV1().StorageClasses().List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list the storage classes of cluster %s. %+v", clusterNamespace, err)
+	}
+	for i := range storageClasses.Items {
+		storageClass := &storageClasses.Items[i]
+		if !ownedStorageClass(storageClass, clusterNamespace) {
+			continue
+		}
+		if err := deleteStorageClass(clientset, storageClass.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package disruption manages the PodDisruptionBudgets of the ceph daemons so that node drains cannot make
+// the cluster unavailable.
+package disruption
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/file/mds"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-disruption")
+
+const (
+	budgetAppName      = "rook-ceph-pdb"
+	monBudgetName      = "rook-ceph-mon-pdb"
+	mdsBudgetNameFmt   = "rook-ceph-mds-%s-pdb"
+	rgwBudgetNameFmt   = "rook-ceph-rgw-%s-pdb"
+	fileSystemLabelKey = "rook_file_system"
+	objectStoreLabel   = "rook_object_store"
+	checkInterval      = 30 * time.Second
+)
+
+// Controller maintains the PodDisruptionBudgets of the daemons of a cluster
+type Controller struct {
+	context      *clusterd.Context
+	namespace    string
+	resourceName string
+	ownerRef     metav1.OwnerReference
+}
+
+// New creates a controller for the cluster in the namespace
+func New(context *clusterd.Context, namespace, resourceName string, ownerRef metav1.OwnerReference) *Controller {
+	return &Controller{
+		context:      context,
+		namespace:    namespace,
+		resourceName: resourceName,
+		ownerRef:     ownerRef,
+	}
+}
+
+// Run periodically updates the budgets until the stop channel is closed
+func (c *Controller) Run(stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			logger.Infof("Stopping disruption management of cluster %s", c.namespace)
+			return
+
+		case <-time.After(checkInterval):
+			if err := c.reconcile(); err != nil {
+				logger.Errorf("failed to manage the pod disruption budgets of cluster %s. %+v", c.namespace, err)
+			}
+		}
+	}
+}
+
+func (c *Controller) reconcile() error {
+	// get the latest spec since disruption management may have been enabled or disabled
+	cluster, err := c.context.RookClientset.CephV1().CephClusters(c.namespace).Get(c.resourceName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster. %+v", err)
+	}
+	spec := cluster.Spec.DisruptionManagement
+
+	if !spec.ManagePodBudgets {
+		if err := c.clearOSDMaintenance(); err != nil {
+			return err
+		}
+		return c.syncBudgets(nil)
+	}
+
+	budgets := []*policyv1beta1.PodDisruptionBudget{
+		c.makeBudget(monBudgetName, &metav1.LabelSelector{MatchLabels: map[string]string{
+			k8sutil.AppAttr:     mon.AppName,
+			k8sutil.ClusterAttr: c.namespace,
+		}}, 1),
+	}
+
+	filesystems, err := c.context.RookClientset.CephV1().CephFilesystems(c.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list filesystems. %+v", err)
+	}
+	for _, fs := range filesystems.Items {
+		budgets = append(budgets, c.makeBudget(fmt.Sprintf(mdsBudgetNameFmt, fs.Name), &metav1.LabelSelector{MatchLabels: map[string]string{
+			k8sutil.AppAttr:    mds.AppName,
+			fileSystemLabelKey: fs.Name,
+		}}, 1))
+	}
+
+	stores, err := c.context.RookClientset.CephV1().CephObjectStores(c.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list object stores. %+v", err)
+	}
+	for _, store := range stores.Items {
+		budgets = append(budgets, c.makeBudget(fmt.Sprintf(rgwBudgetNameFmt, store.Name), &metav1.LabelSelector{MatchLabels: map[string]string{
+			k8sutil.AppAttr:  object.AppName,
+			objectStoreLabel: store.Name,
+		}}, 1))
+	}
+
+	osdBudgets, err := c.reconcileOSDs(spec)
+	if err != nil {
+		return err
+	}
+	budgets = append(budgets, osdBudgets...)
+
+	return c.syncBudgets(budgets)
+}
+
+func (c *Controller) makeBudget(name string, selector *metav1.LabelSelector, maxUnavailable int) *policyv1beta1.PodDisruptionBudget {
+	max := intstr.FromInt(maxUnavailable)
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.namespace,
+			Labels: map[string]string{
+				k8sutil.AppAttr:     budgetAppName,
+				k8sutil.ClusterAttr: c.namespace,
+			},
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector:       selector,
+			MaxUnavailable: &max,
+		},
+	}
+	k8sutil.SetOwnerRef(c.context.Clientset, c.namespace, &pdb.ObjectMeta, &c.ownerRef)
+	return pdb
+}
+
+// syncBudgets creates the desired budgets and removes the budgets created by the operator that are not desired anymore.
+// A budget whose spec changed is replaced since the spec of a budget cannot be updated.
+func (c *Controller) syncBudgets(desired []*policyv1beta1.PodDisruptionBudget) error {
+	client := c.context.Clientset.PolicyV1beta1().PodDisruptionBudgets(c.namespace)
+	selector := fmt.Sprintf("%s=%s,%s=%s", k8sutil.AppAttr, budgetAppName, k8sutil.ClusterAttr, c.namespace)
+	existing, err := client.List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list pod disruption budgets. %+v", err)
+	}
+	current := map[string]policyv1beta1.PodDisruptionBudget{}
+	for _, pdb := range existing.Items {
+		current[pdb.Name] = pdb
+	}
+
+	// create the new budgets first so that the daemons are never left unprotected
+	desiredNames := map[string]bool{}
+	for _, pdb := range desired {
+		desiredNames[pdb.Name] = true
+		old, ok := current[pdb.Name]
+		if ok && reflect.DeepEqual(old.Spec, pdb.Spec) {
+			continue
+		}
+		if ok {
+			if err := client.Delete(pdb.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete pod disruption budget %s. %+v", pdb.Name, err)
+			}
+		}
+		if _, err := client.Create(pdb); err != nil {
+			return fmt.Errorf("failed to create pod disruption budget %s. %+v", pdb.Name, err)
+		}
+		logger.Infof("pod disruption budget %s allows %s unavailable pods", pdb.Name, pdb.Spec.MaxUnavailable.String())
+	}
+
+	for name := range current {
+		if desiredNames[name] {
+			continue
+		}
+		if err := client.Delete(name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete pod disruption budget %s. %+v", name, err)
+		}
+		logger.Infof("removed pod disruption budget %s", name)
+	}
+	return nil
+}
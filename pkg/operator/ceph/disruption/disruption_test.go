@@ -0,0 +1,153 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const osdTree = `{"nodes":[
+{"id":-1,"name":"default","type":"root","children":[-2,-3]},
+{"id":-2,"name":"node-a","type":"host","children":[0,1]},
+{"id":-3,"name":"node-b","type":"host","children":[2]},
+{"id":0,"name":"osd.0","type":"osd"},
+{"id":1,"name":"osd.1","type":"osd"},
+{"id":2,"name":"osd.2","type":"osd"}]}`
+
+func addOSD(t *testing.T, clientset *fake.Clientset, id int, nodeName string) {
+	labels := map[string]string{
+		k8sutil.AppAttr:     osd.AppName,
+		k8sutil.ClusterAttr: "ns",
+		osd.OsdIDLabelKey:   fmt.Sprintf("%d", id),
+	}
+	d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("rook-ceph-osd-%d", id), Namespace: "ns", Labels: labels}}
+	_, err := clientset.AppsV1().Deployments("ns").Create(d)
+	assert.Nil(t, err)
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("rook-ceph-osd-%d-pod", id), Namespace: "ns", Labels: labels},
+		Spec:       v1.PodSpec{NodeName: nodeName},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	_, err = clientset.CoreV1().Pods("ns").Create(pod)
+	assert.Nil(t, err)
+}
+
+func budgetNames(t *testing.T, clientset *fake.Clientset) []string {
+	pdbs, err := clientset.PolicyV1beta1().PodDisruptionBudgets("ns").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	names := []string{}
+	for _, pdb := range pdbs.Items {
+		names = append(names, pdb.Name)
+	}
+	return names
+}
+
+func TestReconcile(t *testing.T) {
+	noout := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			switch {
+			case args[0] == "osd" && args[1] == "tree":
+				return osdTree, nil
+			case args[0] == "osd" && strings.HasSuffix(args[1], "-noout"):
+				call := []string{args[1]}
+				for _, arg := range args[2:] {
+					if strings.HasPrefix(arg, "osd.") {
+						call = append(call, arg)
+					}
+				}
+				noout = append(noout, strings.Join(call, " "))
+				return "", nil
+			case args[0] == "status":
+				return `{"pgmap":{"num_pgs":0}}`, nil
+			}
+			return "", fmt.Errorf("unexpected command %v", args)
+		},
+	}
+
+	cluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "ns"},
+		Spec: cephv1.ClusterSpec{
+			DisruptionManagement: cephv1.DisruptionManagementSpec{ManagePodBudgets: true},
+		},
+	}
+	filesystem := &cephv1.CephFilesystem{ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"}}
+	clientset := fake.NewSimpleClientset()
+	rookClientset := rookfake.NewSimpleClientset(cluster, filesystem)
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookClientset, Executor: executor}
+	for _, name := range []string{"node-a", "node-b"} {
+		_, err := clientset.CoreV1().Nodes().Create(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}})
+		assert.Nil(t, err)
+	}
+	addOSD(t, clientset, 0, "node-a")
+	addOSD(t, clientset, 1, "node-a")
+	addOSD(t, clientset, 2, "node-b")
+	c := New(context, "ns", "rook-ceph", metav1.OwnerReference{})
+
+	// a single osd may be disrupted while the cluster is healthy
+	assert.Nil(t, c.reconcile())
+	assert.ElementsMatch(t, []string{"rook-ceph-mon-pdb", "rook-ceph-mds-myfs-pdb", "rook-ceph-osd-pdb"}, budgetNames(t, clientset))
+	assert.Equal(t, 0, len(noout))
+
+	// draining node-a only allows disruptions on node-a
+	node, _ := clientset.CoreV1().Nodes().Get("node-a", metav1.GetOptions{})
+	node.Spec.Unschedulable = true
+	clientset.CoreV1().Nodes().Update(node)
+	assert.Nil(t, c.reconcile())
+	assert.ElementsMatch(t, []string{"rook-ceph-mon-pdb", "rook-ceph-mds-myfs-pdb", "rook-ceph-osd-host-node-b-pdb"}, budgetNames(t, clientset))
+	assert.Equal(t, []string{"add-noout osd.0 osd.1"}, noout)
+	pdb, err := clientset.PolicyV1beta1().PodDisruptionBudgets("ns").Get("rook-ceph-osd-host-node-b-pdb", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, pdb.Spec.MaxUnavailable.IntValue())
+	assert.Equal(t, []string{"2"}, pdb.Spec.Selector.MatchExpressions[0].Values)
+
+	// the maintenance continues while the node is cordoned
+	assert.Nil(t, c.reconcile())
+	assert.Equal(t, 1, len(noout))
+
+	// uncordoning the node ends the maintenance
+	node.Spec.Unschedulable = false
+	clientset.CoreV1().Nodes().Update(node)
+	assert.Nil(t, c.reconcile())
+	assert.ElementsMatch(t, []string{"rook-ceph-mon-pdb", "rook-ceph-mds-myfs-pdb", "rook-ceph-osd-pdb"}, budgetNames(t, clientset))
+	assert.Equal(t, []string{"add-noout osd.0 osd.1", "rm-noout osd.0 osd.1"}, noout)
+
+	// the budgets are removed when disruption management is disabled
+	cluster.Spec.DisruptionManagement.ManagePodBudgets = false
+	rookClientset.CephV1().CephClusters("ns").Update(cluster)
+	assert.Nil(t, c.reconcile())
+	assert.Equal(t, 0, len(budgetNames(t, clientset)))
+}
+
+func TestBudgetNameSuffix(t *testing.T) {
+	assert.Equal(t, "node-a", budgetNameSuffix("node-a"))
+	assert.Equal(t, "rack1-row-2", budgetNameSuffix("Rack1_Row.2"))
+}
@@ -0,0 +1,329 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	osdBudgetName                = "rook-ceph-osd-pdb"
+	osdDomainBudgetNameFmt       = "rook-ceph-osd-%s-%%s-pdb"
+	stateConfigMapName           = "rook-ceph-disruption-state"
+	drainingDomainKey            = "draining-failure-domain"
+	drainingSinceKey             = "draining-since"
+	drainingOSDsKey              = "draining-osds"
+	nooutExpiredKey              = "noout-expired"
+	defaultOSDFailureDomain      = "host"
+	defaultOSDMaintenanceTimeout = 30 * time.Minute
+)
+
+var invalidNameChars = regexp.MustCompile("[^a-z0-9-]+")
+
+// osdDomains is the state of the osds in each failure domain
+type osdDomains struct {
+	osds      map[string][]int
+	disrupted map[string]bool
+}
+
+// maintenanceState is the failure domain whose osds are allowed to be disrupted
+type maintenanceState struct {
+	drainingDomain string
+	// osds on which noout was set
+	osds         []int
+	since        time.Time
+	nooutExpired bool
+}
+
+// reconcileOSDs returns the budgets of the osds. While no failure domain is disrupted, a single osd may be disrupted
+// at a time. As soon as an osd is down or a node is cordoned, the disruptions are only allowed in the failure domain of
+// that osd or node until its osds are back and the placement groups are clean again. noout is set on the osds of that
+// failure domain so that their data is not recovered elsewhere during a short maintenance.
+func (c *Controller) reconcileOSDs(spec cephv1.DisruptionManagementSpec) ([]*policyv1beta1.PodDisruptionBudget, error) {
+	domainType := spec.OSDFailureDomain
+	if domainType == "" {
+		domainType = defaultOSDFailureDomain
+	}
+	timeout := defaultOSDMaintenanceTimeout
+	if spec.OSDMaintenanceTimeout > 0 {
+		timeout = time.Duration(spec.OSDMaintenanceTimeout) * time.Minute
+	}
+
+	domains, err := c.getOSDDomains(domainType)
+	if err != nil {
+		return nil, err
+	}
+	state, err := c.loadState()
+	if err != nil {
+		return nil, err
+	}
+	changed := false
+
+	if state.drainingDomain != "" {
+		_, ok := domains.osds[state.drainingDomain]
+		switch {
+		case !ok || (!domains.disrupted[state.drainingDomain] && client.IsClusterClean(c.context, c.namespace) == nil):
+			logger.Infof("maintenance of %s %s is completed", domainType, state.drainingDomain)
+			c.endMaintenance(state)
+			state = &maintenanceState{}
+			changed = true
+		case !state.nooutExpired && time.Since(state.since) > timeout:
+			logger.Warningf("maintenance of %s %s exceeded %s. its data will be recovered on other osds", domainType, state.drainingDomain, timeout)
+			c.endMaintenance(state)
+			state.nooutExpired = true
+			changed = true
+		}
+	}
+
+	if state.drainingDomain == "" {
+		if domain := domains.firstDisrupted(); domain != "" {
+			logger.Infof("%s %s is disrupted. osds may only be disrupted in this %s until it is healthy again", domainType, domain, domainType)
+			state = &maintenanceState{drainingDomain: domain, osds: domains.osds[domain], since: time.Now()}
+			if err := client.SetNoOut(c.context, c.namespace, state.osds); err != nil {
+				logger.Warningf("failed to set noout on %s %s. %+v", domainType, domain, err)
+			}
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := c.saveState(state); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.makeOSDBudgets(domainType, domains, state.drainingDomain), nil
+}
+
+func (c *Controller) makeOSDBudgets(domainType string, domains *osdDomains, drainingDomain string) []*policyv1beta1.PodDisruptionBudget {
+	if drainingDomain == "" {
+		return []*policyv1beta1.PodDisruptionBudget{
+			c.makeBudget(osdBudgetName, &metav1.LabelSelector{MatchLabels: map[string]string{
+				k8sutil.AppAttr:     osd.AppName,
+				k8sutil.ClusterAttr: c.namespace,
+			}}, 1),
+		}
+	}
+
+	// no budget is needed for the draining failure domain since all its osds may be disrupted
+	budgets := []*policyv1beta1.PodDisruptionBudget{}
+	nameFmt := fmt.Sprintf(osdDomainBudgetNameFmt, domainType)
+	for _, domain := range domains.names() {
+		if domain == drainingDomain {
+			continue
+		}
+		ids := []string{}
+		for _, id := range domains.osds[domain] {
+			ids = append(ids, strconv.Itoa(id))
+		}
+		selector := &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				k8sutil.AppAttr:     osd.AppName,
+				k8sutil.ClusterAttr: c.namespace,
+			},
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: osd.OsdIDLabelKey, Operator: metav1.LabelSelectorOpIn, Values: ids},
+			},
+		}
+		budgets = append(budgets, c.makeBudget(k8sutil.TruncateNodeName(nameFmt, budgetNameSuffix(domain)), selector, 0))
+	}
+	return budgets
+}
+
+// getOSDDomains finds the failure domain of each osd in the crush map and whether the osds are disrupted
+func (c *Controller) getOSDDomains(domainType string) (*osdDomains, error) {
+	tree, err := client.GetOSDTree(c.context, c.namespace)
+	if err != nil {
+		return nil, err
+	}
+	osdDomain := tree.FailureDomains(domainType)
+
+	selector := fmt.Sprintf("%s=%s,%s=%s", k8sutil.AppAttr, osd.AppName, k8sutil.ClusterAttr, c.namespace)
+	deployments, err := k8sutil.GetDeployments(c.context.Clientset, c.namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := c.context.Clientset.CoreV1().Pods(c.namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list osd pods. %+v", err)
+	}
+
+	runningOnNode := map[int]string{}
+	for _, pod := range pods.Items {
+		id, err := strconv.Atoi(pod.Labels[osd.OsdIDLabelKey])
+		if err != nil {
+			continue
+		}
+		if pod.Status.Phase == v1.PodRunning && pod.DeletionTimestamp == nil {
+			runningOnNode[id] = pod.Spec.NodeName
+		}
+	}
+
+	domains := &osdDomains{osds: map[string][]int{}, disrupted: map[string]bool{}}
+	cordoned := map[string]bool{}
+	for _, d := range deployments.Items {
+		id, err := strconv.Atoi(d.Labels[osd.OsdIDLabelKey])
+		if err != nil {
+			continue
+		}
+		domain, ok := osdDomain[id]
+		if !ok {
+			logger.Debugf("osd.%d is not in a %s in the crush map", id, domainType)
+			continue
+		}
+		domains.osds[domain] = append(domains.osds[domain], id)
+
+		nodeName, running := runningOnNode[id]
+		if !running {
+			logger.Debugf("osd.%d in %s %s is not running", id, domainType, domain)
+			domains.disrupted[domain] = true
+			continue
+		}
+		if _, ok := cordoned[nodeName]; !ok {
+			cordoned[nodeName] = c.nodeCordoned(nodeName)
+		}
+		if cordoned[nodeName] {
+			logger.Debugf("node %s of osd.%d in %s %s is cordoned", nodeName, id, domainType, domain)
+			domains.disrupted[domain] = true
+		}
+	}
+	for domain := range domains.osds {
+		sort.Ints(domains.osds[domain])
+	}
+	return domains, nil
+}
+
+func (c *Controller) nodeCordoned(nodeName string) bool {
+	node, err := c.context.Clientset.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get node %s. %+v", nodeName, err)
+		return false
+	}
+	return node.Spec.Unschedulable
+}
+
+func (d *osdDomains) names() []string {
+	names := []string{}
+	for name := range d.osds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (d *osdDomains) firstDisrupted() string {
+	for _, name := range d.names() {
+		if d.disrupted[name] {
+			return name
+		}
+	}
+	return ""
+}
+
+// endMaintenance removes noout from the osds of the draining failure domain
+func (c *Controller) endMaintenance(state *maintenanceState) {
+	if state.nooutExpired {
+		return
+	}
+	if err := client.UnsetNoOut(c.context, c.namespace, state.osds); err != nil {
+		logger.Warningf("failed to unset noout on osds %v. %+v", state.osds, err)
+	}
+}
+
+// clearOSDMaintenance ends the maintenance of the draining failure domain when disruption management is disabled
+func (c *Controller) clearOSDMaintenance() error {
+	state, err := c.loadState()
+	if err != nil || state.drainingDomain == "" {
+		return err
+	}
+
+	logger.Infof("disruption management is disabled. ending maintenance of %s", state.drainingDomain)
+	c.endMaintenance(state)
+	return c.saveState(&maintenanceState{})
+}
+
+func (c *Controller) loadState() (*maintenanceState, error) {
+	cm, err := c.context.Clientset.CoreV1().ConfigMaps(c.namespace).Get(stateConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return &maintenanceState{}, nil
+		}
+		return nil, fmt.Errorf("failed to load disruption state. %+v", err)
+	}
+
+	state := &maintenanceState{drainingDomain: cm.Data[drainingDomainKey]}
+	for _, id := range strings.Fields(cm.Data[drainingOSDsKey]) {
+		if i, err := strconv.Atoi(id); err == nil {
+			state.osds = append(state.osds, i)
+		}
+	}
+	if since, err := time.Parse(time.RFC3339, cm.Data[drainingSinceKey]); err == nil {
+		state.since = since
+	}
+	state.nooutExpired, _ = strconv.ParseBool(cm.Data[nooutExpiredKey])
+	return state, nil
+}
+
+func (c *Controller) saveState(state *maintenanceState) error {
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      stateConfigMapName,
+			Namespace: c.namespace,
+		},
+		Data: map[string]string{
+			drainingDomainKey: state.drainingDomain,
+			nooutExpiredKey:   strconv.FormatBool(state.nooutExpired),
+		},
+	}
+	ids := []string{}
+	for _, id := range state.osds {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	cm.Data[drainingOSDsKey] = strings.Join(ids, " ")
+	if !state.since.IsZero() {
+		cm.Data[drainingSinceKey] = state.since.UTC().Format(time.RFC3339)
+	}
+	k8sutil.SetOwnerRef(c.context.Clientset, c.namespace, &cm.ObjectMeta, &c.ownerRef)
+
+	_, err := c.context.Clientset.CoreV1().ConfigMaps(c.namespace).Create(cm)
+	if errors.IsAlreadyExists(err) {
+		_, err = c.context.Clientset.CoreV1().ConfigMaps(c.namespace).Update(cm)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save disruption state. %+v", err)
+	}
+	return nil
+}
+
+// budgetNameSuffix converts the name of a crush bucket to a valid part of a resource name
+func budgetNameSuffix(domain string) string {
+	return strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(domain), "-"), "-")
+}
@@ -28,6 +28,7 @@ import (
 	cephbeta "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
 	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -66,6 +67,7 @@ type FilesystemController struct {
 	hostNetwork        bool
 	ownerRef           metav1.OwnerReference
 	dataDirHostPath    string
+	csiSpec            cephv1.CSISpec
 	orchestrationMutex sync.Mutex
 }
 
@@ -79,6 +81,7 @@ func NewFilesystemController(
 	hostNetwork bool,
 	ownerRef metav1.OwnerReference,
 	dataDirHostPath string,
+	csiSpec cephv1.CSISpec,
 ) *FilesystemController {
 	return &FilesystemController{
 		clusterInfo:     clusterInfo,
@@ -89,6 +92,7 @@ func NewFilesystemController(
 		hostNetwork:     hostNetwork,
 		ownerRef:        ownerRef,
 		dataDirHostPath: dataDirHostPath,
+		csiSpec:         csiSpec,
 	}
 }
 
@@ -131,7 +135,10 @@ func (c *FilesystemController) onAdd(obj interface{}) {
 	err = createFilesystem(c.clusterInfo, c.context, *filesystem, c.rookVersion, c.cephVersion, c.hostNetwork, c.filesystemOwners(filesystem), c.dataDirHostPath)
 	if err != nil {
 		logger.Errorf("failed to create filesystem %s: %+v", filesystem.Name, err)
+		return
 	}
+
+	c.createStorageClass(filesystem)
 }
 
 func (c *FilesystemController) onUpdate(oldObj, newObj interface{}) {
@@ -171,6 +178,10 @@ func (c *FilesystemController) onUpdate(oldObj, newObj interface{}) {
 
 func (c *FilesystemController) ParentClusterChanged(cluster cephv1.ClusterSpec, clusterInfo *cephconfig.ClusterInfo) {
 	c.clusterInfo = clusterInfo
+	if cluster.CSI.CreateStorageClasses != c.csiSpec.CreateStorageClasses {
+		c.csiSpec = cluster.CSI
+		c.createStorageClasses()
+	}
 	if cluster.CephVersion.Image == c.cephVersion.Image {
 		logger.Debugf("No need to update the file system after the parent cluster changed")
 		return
@@ -215,6 +226,41 @@ func (c *FilesystemController) onDelete(obj interface{}) {
 	if err != nil {
 		logger.Errorf("failed to delete filesystem %s: %+v", filesystem.Name, err)
 	}
+
+	if err := csi.DeleteStorageClass(c.context.Clientset, filesystem.Namespace, csi.CephFSStorageClassName(filesystem.Namespace, filesystem.Name)); err != nil {
+		logger.Errorf("failed to delete the storage class of filesystem %s. %+v", filesystem.Name, err)
+	}
+}
+
+// createStorageClasses creates the storage classes of the existing filesystems when they are enabled. The storage
+// classes are not removed when they are disabled since volumes may still refer to them.
+func (c *FilesystemController) createStorageClasses() {
+	if !c.csiSpec.CreateStorageClasses {
+		return
+	}
+	filesystems, err := c.context.RookClientset.CephV1().CephFilesystems(c.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		logger.Errorf("failed to list filesystems to create their storage classes. %+v", err)
+		return
+	}
+	for i := range filesystems.Items {
+		c.createStorageClass(&filesystems.Items[i])
+	}
+}
+
+// createStorageClass creates the csi storage class of the filesystem if enabled in the cluster. The volumes are
+// created in the first data pool.
+func (c *FilesystemController) createStorageClass(fs *cephv1.CephFilesystem) {
+	if !c.csiSpec.CreateStorageClasses {
+		return
+	}
+	if len(fs.Spec.DataPools) == 0 {
+		logger.Infof("not creating a storage class for filesystem %s since its data pools are not known", fs.Name)
+		return
+	}
+	if err := csi.CreateCephFSStorageClass(c.context.Clientset, fs.Namespace, fs.Name, dataPoolName(fs.Name, 0)); err != nil {
+		logger.Errorf("failed to create the storage class of filesystem %s. %+v", fs.Name, err)
+	}
 }
 
 func (c *FilesystemController) filesystemOwners(fs *cephv1.CephFilesystem) []metav1.OwnerReference {
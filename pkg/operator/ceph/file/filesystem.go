@@ -149,7 +149,7 @@ func newFS(name string, metadataPool *model.Pool, dataPools []*model.Pool, activ
 
 	metadataPool.Name = fmt.Sprintf("%s-%s", name, metadataPoolSuffix)
 	for i, pool := range dataPools {
-		pool.Name = fmt.Sprintf("%s-%s%d", name, dataPoolSuffix, i)
+		pool.Name = dataPoolName(name, i)
 	}
 
 	return &Filesystem{
@@ -252,3 +252,8 @@ func downFilesystem(context *clusterd.Context, cephVersion cephver.CephVersion,
 	logger.Infof("Downed filesystem %s", filesystemName)
 	return nil
 }
+
+// dataPoolName returns the name of the data pool of the filesystem at the given index
+func dataPoolName(filesystem string, index int) string {
+	return fmt.Sprintf("%s-%s%d", filesystem, dataPoolSuffix, index)
+}
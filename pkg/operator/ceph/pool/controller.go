@@ -29,6 +29,7 @@ import (
 	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	"github.com/rook/rook/pkg/daemon/ceph/model"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -66,13 +67,15 @@ var PoolResourceRookLegacy = opkit.CustomResource{
 type PoolController struct {
 	context   *clusterd.Context
 	namespace string
+	csiSpec   cephv1.CSISpec
 }
 
 // NewPoolController create controller for watching pool custom resources created
-func NewPoolController(context *clusterd.Context, namespace string) *PoolController {
+func NewPoolController(context *clusterd.Context, namespace string, csiSpec cephv1.CSISpec) *PoolController {
 	return &PoolController{
 		context:   context,
 		namespace: namespace,
+		csiSpec:   csiSpec,
 	}
 }
 
@@ -112,7 +115,10 @@ func (c *PoolController) onAdd(obj interface{}) {
 	err = createPool(c.context, pool)
 	if err != nil {
 		logger.Errorf("failed to create pool %s. %+v", pool.ObjectMeta.Name, err)
+		return
 	}
+
+	c.createStorageClass(pool)
 }
 
 func (c *PoolController) onUpdate(oldObj, newObj interface{}) {
@@ -155,7 +161,35 @@ func (c *PoolController) onUpdate(oldObj, newObj interface{}) {
 }
 
 func (c *PoolController) ParentClusterChanged(cluster cephv1.ClusterSpec, clusterInfo *cephconfig.ClusterInfo) {
-	logger.Debugf("No need to update the pool after the parent cluster changed")
+	if cluster.CSI.CreateStorageClasses == c.csiSpec.CreateStorageClasses {
+		logger.Debugf("No need to update the pool after the parent cluster changed")
+		return
+	}
+
+	// create the storage classes of the existing pools when they are enabled. the storage classes are not removed
+	// when they are disabled since volumes may still refer to them.
+	c.csiSpec = cluster.CSI
+	if !c.csiSpec.CreateStorageClasses {
+		return
+	}
+	pools, err := c.context.RookClientset.CephV1().CephBlockPools(c.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		logger.Errorf("failed to list pools to create their storage classes. %+v", err)
+		return
+	}
+	for i := range pools.Items {
+		c.createStorageClass(&pools.Items[i])
+	}
+}
+
+// createStorageClass creates the csi storage class of the pool if enabled in the cluster
+func (c *PoolController) createStorageClass(p *cephv1.CephBlockPool) {
+	if !c.csiSpec.CreateStorageClasses {
+		return
+	}
+	if err := csi.CreateRBDStorageClass(c.context.Clientset, p.Namespace, p.Name); err != nil {
+		logger.Errorf("failed to create the storage class of pool %s. %+v", p.Name, err)
+	}
 }
 
 func poolChanged(old, new cephv1.PoolSpec) bool {
@@ -181,6 +215,10 @@ func (c *PoolController) onDelete(obj interface{}) {
 	if err := deletePool(c.context, pool); err != nil {
 		logger.Errorf("failed to delete pool %s. %+v", pool.ObjectMeta.Name, err)
 	}
+
+	if err := csi.DeleteStorageClass(c.context.Clientset, pool.Namespace, csi.RBDStorageClassName(pool.Namespace, pool.Name)); err != nil {
+		logger.Errorf("failed to delete the storage class of pool %s. %+v", pool.Name, err)
+	}
 }
 
 // Create the pool
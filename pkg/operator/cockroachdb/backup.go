@@ -0,0 +1,262 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cockroachdb
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	cockroachdbv1alpha1 "github.com/rook/rook/pkg/apis/cockroachdb.rook.io/v1alpha1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/cron"
+	"github.com/rook/rook/pkg/util/s3"
+	batch "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	backupAppName        = appName + "-backup"
+	backupNameAnnotation = "cockroachdb.rook.io/backup"
+	backupTimeFormat     = "20060102-150405"
+	// the certificate of the root user is mounted in the backup and restore jobs of secure clusters
+	backupCertsDir = "/etc/cockroach-certs"
+)
+
+// syncBackup starts a backup of the cluster when its schedule is due, and records the result of the running backup
+// in the status of the cluster
+func (c *ClusterController) syncBackup(clusterObj *cockroachdbv1alpha1.Cluster, now time.Time) error {
+	spec := clusterObj.Spec.Backup
+	if spec == nil {
+		return nil
+	}
+
+	oldStatus := cockroachdbv1alpha1.BackupStatus{}
+	if clusterObj.Status.Backup != nil {
+		oldStatus = *clusterObj.Status.Backup
+	}
+	status := *oldStatus.DeepCopy()
+	cluster := newCluster(clusterObj, c.context)
+
+	if status.Running != "" {
+		finished, err := c.checkBackupJob(cluster, status.Running)
+		if finished {
+			if err != nil {
+				logger.Warningf("backup %s of cluster %s in namespace %s failed: %+v", status.Running, cluster.name, cluster.namespace, err)
+				status.LastError = err.Error()
+			} else {
+				logger.Infof("backup %s of cluster %s in namespace %s succeeded", status.Running, cluster.name, cluster.namespace)
+				status.LastSuccessfulBackup = status.Running
+				status.LastSuccessfulTime = &metav1.Time{Time: now}
+				status.LastError = ""
+			}
+			status.Running = ""
+		}
+	}
+
+	if status.Running == "" {
+		schedule, err := cron.Parse(spec.Schedule)
+		if err != nil {
+			return fmt.Errorf("invalid backup schedule: %+v", err)
+		}
+		lastRun := clusterObj.CreationTimestamp.Time
+		if status.LastScheduleTime != nil {
+			lastRun = status.LastScheduleTime.Time
+		}
+		if schedule.Due(lastRun, now) {
+			name := now.UTC().Format(backupTimeFormat)
+			if err := c.startBackupJob(cluster, name); err != nil {
+				return fmt.Errorf("failed to start backup %s: %+v", name, err)
+			}
+			logger.Infof("started backup %s of cluster %s in namespace %s", name, cluster.name, cluster.namespace)
+			status.Running = name
+			status.LastScheduleTime = &metav1.Time{Time: now}
+		}
+	}
+
+	if reflect.DeepEqual(oldStatus, status) {
+		return nil
+	}
+	return c.updateBackupStatus(cluster.namespace, cluster.name, status)
+}
+
+// checkBackupJob returns whether the job of the given backup finished, and the error if it failed
+func (c *ClusterController) checkBackupJob(cluster *cluster, name string) (bool, error) {
+	job, err := c.context.Clientset.BatchV1().Jobs(cluster.namespace).Get(backupAppName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return true, fmt.Errorf("backup job %s not found", backupAppName)
+		}
+		logger.Warningf("failed to get backup job %s: %+v", backupAppName, err)
+		return false, nil
+	}
+	if job.Annotations[backupNameAnnotation] != name {
+		return true, fmt.Errorf("backup job %s was replaced", backupAppName)
+	}
+	return isJobFinished(job)
+}
+
+// startBackupJob runs a job that backs up the databases of the cluster, replacing the job of the previous backup
+func (c *ClusterController) startBackupJob(cluster *cluster, name string) error {
+	spec := cluster.spec.Backup
+	mode := spec.Mode
+	if mode == "" {
+		mode = cockroachdbv1alpha1.BackupModeBackup
+	}
+
+	env := []v1.EnvVar{
+		{Name: "ROOK_NAME", Value: name},
+		{Name: "ROOK_MODE", Value: string(mode)},
+		{Name: "ROOK_DATABASES", Value: strings.Join(spec.Databases, ",")},
+		{Name: "ROOK_RETENTION", Value: strconv.Itoa(spec.Retention)},
+	}
+	job, err := createBackupJob(cluster, c.containerImage, backupAppName, []string{"cockroachdb", "backup", "create"},
+		env, backupPrefix(cluster.namespace, cluster.name), spec.S3)
+	if err != nil {
+		return err
+	}
+	job.Annotations = map[string]string{backupNameAnnotation: name}
+
+	return k8sutil.RunReplaceableJob(c.context.Clientset, job, true)
+}
+
+// createBackupJob creates a job that runs the given backup command of the rook image against the cluster, with the
+// credentials of the bucket and the certificate of the root user of a secure cluster
+func createBackupJob(cluster *cluster, rookImage, name string, args []string, env []v1.EnvVar, prefix string,
+	s3Spec cockroachdbv1alpha1.S3Spec) (*batch.Job, error) {
+
+	_, grpcPort, err := getPortsFromSpec(cluster.spec.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	env = append(env,
+		v1.EnvVar{Name: "ROOK_HOST", Value: fmt.Sprintf("%s.%s", clientServiceName, cluster.namespace)},
+		v1.EnvVar{Name: "ROOK_PORT", Value: strconv.Itoa(int(grpcPort))},
+		v1.EnvVar{Name: "ROOK_PREFIX", Value: prefix},
+		v1.EnvVar{Name: "ROOK_S3_ENDPOINT", Value: s3Spec.Endpoint},
+		v1.EnvVar{Name: "ROOK_S3_BUCKET", Value: s3Spec.Bucket},
+		secretEnvVar(s3.AccessKeyName, s3Spec.SecretName),
+		secretEnvVar(s3.SecretKeyName, s3Spec.SecretName),
+	)
+	volumes := []v1.Volume{}
+	mounts := []v1.VolumeMount{}
+	if cluster.spec.Secure {
+		env = append(env, v1.EnvVar{Name: "ROOK_CERTS_DIR", Value: backupCertsDir})
+		volumes = append(volumes, createCertsVolume(rootClientSecretName))
+		mounts = append(mounts, v1.VolumeMount{Name: volumeCertsName, MountPath: backupCertsDir, ReadOnly: true})
+	}
+
+	labels := map[string]string{k8sutil.AppAttr: name}
+	job := &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cluster.namespace,
+			Labels:    labels,
+		},
+		Spec: batch.JobSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Name:         name,
+							Image:        rookImage,
+							Args:         args,
+							Env:          env,
+							VolumeMounts: mounts,
+						},
+					},
+					RestartPolicy: v1.RestartPolicyOnFailure,
+					Volumes:       volumes,
+				},
+			},
+		},
+	}
+	k8sutil.AddRookVersionLabelToJob(job)
+	k8sutil.SetOwnerRef(cluster.context.Clientset, cluster.namespace, &job.ObjectMeta, &cluster.ownerRef)
+	return job, nil
+}
+
+// isJobFinished returns whether the job finished, and an error if it failed after retrying
+func isJobFinished(job *batch.Job) (bool, error) {
+	if job.Status.Succeeded > 0 {
+		return true, nil
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batch.JobFailed && condition.Status == v1.ConditionTrue {
+			return true, fmt.Errorf("job %s failed: %s", job.Name, condition.Message)
+		}
+	}
+	return false, nil
+}
+
+func (c *ClusterController) updateBackupStatus(namespace, name string, status cockroachdbv1alpha1.BackupStatus) error {
+	cluster, err := c.context.RookClientset.CockroachdbV1alpha1().Clusters(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster %s prior to updating its backup status: %+v", name, err)
+	}
+	cluster.Status.Backup = &status
+	if _, err := c.context.RookClientset.CockroachdbV1alpha1().Clusters(namespace).Update(cluster); err != nil {
+		return fmt.Errorf("failed to update cluster %s backup status: %+v", name, err)
+	}
+	return nil
+}
+
+// backupPrefix returns the prefix of the keys of the backups of a cluster in the bucket
+func backupPrefix(namespace, clusterName string) string {
+	return fmt.Sprintf("%s/%s", namespace, clusterName)
+}
+
+func validateBackupSpec(spec *cockroachdbv1alpha1.BackupSpec) error {
+	if spec == nil {
+		return nil
+	}
+	if _, err := cron.Parse(spec.Schedule); err != nil {
+		return fmt.Errorf("invalid backup schedule %s: %+v", spec.Schedule, err)
+	}
+	if len(spec.Databases) == 0 {
+		return fmt.Errorf("no databases to back up")
+	}
+	switch spec.Mode {
+	case "", cockroachdbv1alpha1.BackupModeBackup, cockroachdbv1alpha1.BackupModeDump:
+	default:
+		return fmt.Errorf("invalid backup mode %s. Must be %s or %s", spec.Mode, cockroachdbv1alpha1.BackupModeBackup, cockroachdbv1alpha1.BackupModeDump)
+	}
+	if spec.Retention < 0 {
+		return fmt.Errorf("invalid backup retention %d. Must be at least 0", spec.Retention)
+	}
+	return validateS3Spec(spec.S3)
+}
+
+func validateS3Spec(spec cockroachdbv1alpha1.S3Spec) error {
+	if spec.Endpoint == "" || spec.Bucket == "" || spec.SecretName == "" {
+		return fmt.Errorf("the s3 endpoint, bucket and secretName are required")
+	}
+	return nil
+}
+
+func secretEnvVar(key, secretName string) v1.EnvVar {
+	return v1.EnvVar{Name: key, ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{
+		LocalObjectReference: v1.LocalObjectReference{Name: secretName},
+		Key:                  key,
+	}}}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cockroachdb
+
+import (
+	"testing"
+	"time"
+
+	cockroachdbv1alpha1 "github.com/rook/rook/pkg/apis/cockroachdb.rook.io/v1alpha1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	batch "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newBackupSpecForTest() *cockroachdbv1alpha1.BackupSpec {
+	return &cockroachdbv1alpha1.BackupSpec{
+		Schedule:  "0 2 * * *",
+		Databases: []string{"bank", "shop"},
+		Retention: 7,
+		S3:        cockroachdbv1alpha1.S3Spec{Endpoint: "http://minio:9000", Bucket: "backups", SecretName: "s3-credentials"},
+	}
+}
+
+func getBackupStatus(t *testing.T, context *clusterd.Context, namespace, name string) *cockroachdbv1alpha1.BackupStatus {
+	clusterObj, err := context.RookClientset.CockroachdbV1alpha1().Clusters(namespace).Get(name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	return clusterObj.Status.Backup
+}
+
+func TestSyncBackup(t *testing.T) {
+	namespace := "rook-cockroachdb-324"
+	created := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+	clusterObj := &cockroachdbv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-831", Namespace: namespace, CreationTimestamp: metav1.Time{Time: created}},
+		Spec: cockroachdbv1alpha1.ClusterSpec{
+			Storage: rookalpha.StorageScopeSpec{NodeCount: 3},
+			Secure:  true,
+			Backup:  newBackupSpecForTest(),
+		},
+	}
+	context := &clusterd.Context{Clientset: testop.New(3), RookClientset: rookfake.NewSimpleClientset(clusterObj)}
+	controller := NewClusterController(context, "rook/cockroachdb:mockTag")
+
+	// the backup is not due yet
+	assert.Nil(t, controller.syncBackup(clusterObj, created.Add(time.Hour)))
+	assert.Nil(t, getBackupStatus(t, context, namespace, clusterObj.Name))
+
+	// the backup job is started when the schedule is due
+	now := created.Add(2*time.Hour + time.Minute)
+	assert.Nil(t, controller.syncBackup(clusterObj, now))
+	status := getBackupStatus(t, context, namespace, clusterObj.Name)
+	assert.Equal(t, "20190102-020100", status.Running)
+	assert.Equal(t, now, status.LastScheduleTime.Time)
+
+	job, err := context.Clientset.BatchV1().Jobs(namespace).Get(backupAppName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "20190102-020100", job.Annotations[backupNameAnnotation])
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "rook/cockroachdb:mockTag", container.Image)
+	assert.Equal(t, []string{"cockroachdb", "backup", "create"}, container.Args)
+	env := map[string]v1.EnvVar{}
+	for _, e := range container.Env {
+		env[e.Name] = e
+	}
+	assert.Equal(t, "backup", env["ROOK_MODE"].Value)
+	assert.Equal(t, "bank,shop", env["ROOK_DATABASES"].Value)
+	assert.Equal(t, "7", env["ROOK_RETENTION"].Value)
+	assert.Equal(t, "cockroachdb-public.rook-cockroachdb-324", env["ROOK_HOST"].Value)
+	assert.Equal(t, "26257", env["ROOK_PORT"].Value)
+	assert.Equal(t, "rook-cockroachdb-324/cluster-831", env["ROOK_PREFIX"].Value)
+	assert.Equal(t, backupCertsDir, env["ROOK_CERTS_DIR"].Value)
+	assert.Equal(t, "s3-credentials", env["AWS_SECRET_ACCESS_KEY"].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, rootClientSecretName, job.Spec.Template.Spec.Volumes[0].Secret.SecretName)
+
+	// the running backup is tracked until the job finishes
+	clusterObj.Status.Backup = status
+	assert.Nil(t, controller.syncBackup(clusterObj, now.Add(time.Minute)))
+	assert.Equal(t, status, getBackupStatus(t, context, namespace, clusterObj.Name))
+
+	job.Status.Succeeded = 1
+	_, err = context.Clientset.BatchV1().Jobs(namespace).Update(job)
+	assert.Nil(t, err)
+	assert.Nil(t, controller.syncBackup(clusterObj, now.Add(2*time.Minute)))
+	status = getBackupStatus(t, context, namespace, clusterObj.Name)
+	assert.Equal(t, "", status.Running)
+	assert.Equal(t, "20190102-020100", status.LastSuccessfulBackup)
+	assert.Equal(t, now.Add(2*time.Minute), status.LastSuccessfulTime.Time)
+
+	// the next backup fails
+	clusterObj.Status.Backup = status
+	now = now.Add(24 * time.Hour)
+	assert.Nil(t, controller.syncBackup(clusterObj, now))
+	clusterObj.Status.Backup = getBackupStatus(t, context, namespace, clusterObj.Name)
+	assert.Equal(t, "20190103-020100", clusterObj.Status.Backup.Running)
+
+	job, err = context.Clientset.BatchV1().Jobs(namespace).Get(backupAppName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	job.Status.Conditions = []batch.JobCondition{{Type: batch.JobFailed, Status: v1.ConditionTrue, Message: "BackoffLimitExceeded"}}
+	_, err = context.Clientset.BatchV1().Jobs(namespace).Update(job)
+	assert.Nil(t, err)
+	assert.Nil(t, controller.syncBackup(clusterObj, now.Add(time.Minute)))
+	status = getBackupStatus(t, context, namespace, clusterObj.Name)
+	assert.Equal(t, "", status.Running)
+	assert.Equal(t, "20190102-020100", status.LastSuccessfulBackup)
+	assert.Contains(t, status.LastError, "BackoffLimitExceeded")
+}
+
+func TestValidateBackupSpec(t *testing.T) {
+	assert.Nil(t, validateBackupSpec(nil))
+	assert.Nil(t, validateBackupSpec(newBackupSpecForTest()))
+
+	spec := newBackupSpecForTest()
+	spec.Mode = cockroachdbv1alpha1.BackupModeDump
+	assert.Nil(t, validateBackupSpec(spec))
+
+	spec = newBackupSpecForTest()
+	spec.Mode = "copy"
+	assert.NotNil(t, validateBackupSpec(spec))
+
+	spec = newBackupSpecForTest()
+	spec.Schedule = "every night"
+	assert.NotNil(t, validateBackupSpec(spec))
+
+	spec = newBackupSpecForTest()
+	spec.Databases = nil
+	assert.NotNil(t, validateBackupSpec(spec))
+
+	spec = newBackupSpecForTest()
+	spec.Retention = -1
+	assert.NotNil(t, validateBackupSpec(spec))
+
+	spec = newBackupSpecForTest()
+	spec.S3.SecretName = ""
+	assert.NotNil(t, validateBackupSpec(spec))
+}
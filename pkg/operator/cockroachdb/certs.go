@@ -0,0 +1,386 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cockroachdb
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	certsDir             = "/cockroach/cockroach-certs"
+	volumeCertsName      = "certs"
+	caSecretName         = "rook-cockroachdb-ca"
+	nodeSecretName       = "rook-cockroachdb-node"
+	rootClientSecretName = "rook-cockroachdb-client-root"
+	caCertFileName       = "ca.crt"
+	caKeyFileName        = "ca.key"
+	nodeCertFileName     = "node.crt"
+	nodeKeyFileName      = "node.key"
+	rootCertFileName     = "client.root.crt"
+	rootKeyFileName      = "client.root.key"
+	clusterDomain        = "cluster.local"
+	certKeySize          = 2048
+	caValidity           = 10 * 365 * 24 * time.Hour
+	certValidity         = 365 * 24 * time.Hour
+	// certificates are renewed when they expire within this duration
+	certRenewBefore = 30 * 24 * time.Hour
+	// annotation of the pod template with the serial number of the node certificate, so that renewing the
+	// certificate rolls the pods
+	nodeCertSerialAnnotation = "cockroachdb.rook.io/node-cert-serial"
+)
+
+// syncCertificates creates the CA of a secure cluster and issues the node and root client certificates from it. The
+// certificates are issued again when they are about to expire or when the node certificate is missing the name of
+// a replica. Returns true if the node certificate was issued again, as the pods only load it when they start.
+func (c *ClusterController) syncCertificates(cluster *cluster) (bool, error) {
+	ca, caKey, err := c.getOrCreateCA(cluster)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the CA: %+v", err)
+	}
+
+	now := time.Now()
+	nodeRenewed, err := c.syncCertificate(cluster, nodeSecretName, nodeCertFileName, nodeKeyFileName,
+		createNodeCertTemplate(cluster, now), ca, caKey, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to sync the node certificate: %+v", err)
+	}
+
+	if _, err := c.syncCertificate(cluster, rootClientSecretName, rootCertFileName, rootKeyFileName,
+		createClientCertTemplate("root", now), ca, caKey, now); err != nil {
+		return false, fmt.Errorf("failed to sync the root client certificate: %+v", err)
+	}
+
+	return nodeRenewed, nil
+}
+
+// getOrCreateCA returns the CA of the cluster, which is created the first time
+func (c *ClusterController) getOrCreateCA(cluster *cluster) (*x509.Certificate, *rsa.PrivateKey, error) {
+	secret, err := c.context.Clientset.CoreV1().Secrets(cluster.namespace).Get(caSecretName, metav1.GetOptions{})
+	if err == nil {
+		ca, err := parseCertificate(secret.Data[caCertFileName])
+		if err != nil {
+			return nil, nil, err
+		}
+		caKey, err := parsePrivateKey(secret.Data[caKeyFileName])
+		if err != nil {
+			return nil, nil, err
+		}
+		return ca, caKey, nil
+	}
+	if !errors.IsNotFound(err) {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		Subject:               pkix.Name{Organization: []string{"Cockroach"}, CommonName: "Cockroach CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certPEM, keyPEM, err := issueCertificate(template, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	secret = createCertSecret(cluster, caSecretName, map[string][]byte{caCertFileName: certPEM, caKeyFileName: keyPEM})
+	k8sutil.SetOwnerRef(c.context.Clientset, cluster.namespace, &secret.ObjectMeta, &cluster.ownerRef)
+	if _, err := c.context.Clientset.CoreV1().Secrets(cluster.namespace).Create(secret); err != nil {
+		// the certificates issued by the existing CA must stay valid
+		if errors.IsAlreadyExists(err) {
+			return c.getOrCreateCA(cluster)
+		}
+		return nil, nil, err
+	}
+	logger.Infof("created the CA of cluster %s in namespace %s", cluster.name, cluster.namespace)
+
+	ca, _ := parseCertificate(certPEM)
+	caKey, _ := parsePrivateKey(keyPEM)
+	return ca, caKey, nil
+}
+
+// syncCertificate issues the certificate of the given secret from the template if it doesn't exist, expires soon,
+// was issued by another CA or is missing a name of the template. Returns true if the certificate was issued.
+func (c *ClusterController) syncCertificate(cluster *cluster, secretName, certFileName, keyFileName string,
+	template *x509.Certificate, ca *x509.Certificate, caKey *rsa.PrivateKey, now time.Time) (bool, error) {
+
+	secret, err := c.context.Clientset.CoreV1().Secrets(cluster.namespace).Get(secretName, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	if err == nil {
+		cert, err := parseCertificate(secret.Data[certFileName])
+		if err == nil && !certNeedsRenewal(cert, template, ca, now) {
+			return false, nil
+		}
+		logger.Infof("renewing certificate %s of cluster %s in namespace %s", secretName, cluster.name, cluster.namespace)
+	}
+
+	certPEM, keyPEM, err := issueCertificate(template, ca, caKey)
+	if err != nil {
+		return false, err
+	}
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})
+	data := map[string][]byte{caCertFileName: caPEM, certFileName: certPEM, keyFileName: keyPEM}
+	if err := c.saveCertSecret(cluster, secretName, data); err != nil {
+		return false, err
+	}
+
+	logger.Infof("issued certificate %s of cluster %s in namespace %s", secretName, cluster.name, cluster.namespace)
+	return true, nil
+}
+
+func createCertSecret(cluster *cluster, name string, data map[string][]byte) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cluster.namespace,
+			Labels:    createAppLabels(),
+		},
+		Data: data,
+		Type: v1.SecretTypeOpaque,
+	}
+}
+
+func (c *ClusterController) saveCertSecret(cluster *cluster, name string, data map[string][]byte) error {
+	secret := createCertSecret(cluster, name, data)
+	k8sutil.SetOwnerRef(c.context.Clientset, cluster.namespace, &secret.ObjectMeta, &cluster.ownerRef)
+
+	if _, err := c.context.Clientset.CoreV1().Secrets(cluster.namespace).Create(secret); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
+		if _, err := c.context.Clientset.CoreV1().Secrets(cluster.namespace).Update(secret); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// certNeedsRenewal returns true if the certificate expires soon, wasn't issued by the CA or is missing a name of the
+// template
+func certNeedsRenewal(cert, template, ca *x509.Certificate, now time.Time) bool {
+	if cert.NotAfter.Sub(now) < certRenewBefore {
+		return true
+	}
+	if err := cert.CheckSignatureFrom(ca); err != nil {
+		return true
+	}
+
+	names := map[string]bool{}
+	for _, name := range cert.DNSNames {
+		names[name] = true
+	}
+	for _, name := range template.DNSNames {
+		if !names[name] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// creates the template of the node certificate, valid for all the names the nodes are reached at
+func createNodeCertTemplate(cluster *cluster, now time.Time) *x509.Certificate {
+	names := []string{
+		"localhost",
+		clientServiceName,
+		fmt.Sprintf("%s.%s", clientServiceName, cluster.namespace),
+		fmt.Sprintf("%s.%s.svc.%s", clientServiceName, cluster.namespace, clusterDomain),
+	}
+	for i := 0; i < cluster.spec.Storage.NodeCount; i++ {
+		name := createQualifiedReplicaServiceName(i, cluster.namespace)
+		// the nodes advertise their fully qualified hostname
+		names = append(names, name, fmt.Sprintf("%s.svc.%s", name, clusterDomain))
+	}
+
+	return &x509.Certificate{
+		// the nodes authenticate to each other as the node user
+		Subject:     pkix.Name{Organization: []string{"Cockroach"}, CommonName: "node"},
+		DNSNames:    names,
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:   now.Add(-time.Hour),
+		NotAfter:    now.Add(certValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+}
+
+// creates the template of the certificate of a SQL user
+func createClientCertTemplate(user string, now time.Time) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:     pkix.Name{Organization: []string{"Cockroach"}, CommonName: user},
+		NotBefore:   now.Add(-time.Hour),
+		NotAfter:    now.Add(certValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+}
+
+// issueCertificate generates a key and issues a certificate for it from the template, signed by the CA or self-signed
+// if the CA is nil. Returns the certificate and the key in PEM format.
+func issueCertificate(template, ca *x509.Certificate, caKey *rsa.PrivateKey) ([]byte, []byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, certKeySize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate key: %+v", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate serial number: %+v", err)
+	}
+	template.SerialNumber = serial
+
+	parent, parentKey := ca, caKey
+	if ca == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create certificate %s: %+v", template.Subject.CommonName, err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return certPEM, keyPEM, nil
+}
+
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode private key")
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+// getNodeCertSerial returns the serial number of the node certificate of the cluster
+func (c *ClusterController) getNodeCertSerial(cluster *cluster) (string, error) {
+	secret, err := c.context.Clientset.CoreV1().Secrets(cluster.namespace).Get(nodeSecretName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	cert, err := parseCertificate(secret.Data[nodeCertFileName])
+	if err != nil {
+		return "", err
+	}
+	return cert.SerialNumber.Text(16), nil
+}
+
+// applyNodeCertAnnotation annotates the pod template of a secure cluster with the serial number of its node
+// certificate
+func (c *ClusterController) applyNodeCertAnnotation(cluster *cluster, template *v1.PodTemplateSpec) error {
+	if !cluster.spec.Secure {
+		return nil
+	}
+	serial, err := c.getNodeCertSerial(cluster)
+	if err != nil {
+		return fmt.Errorf("failed to get the node certificate: %+v", err)
+	}
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[nodeCertSerialAnnotation] = serial
+	return nil
+}
+
+// renewCertificates renews the certificates of a secure cluster that expire soon, and rolls the pods to load the
+// node certificate when it is renewed
+func (c *ClusterController) renewCertificates(cluster *cluster) error {
+	if !cluster.spec.Secure {
+		return nil
+	}
+	// the certificates of a new cluster are created when it is added
+	if _, err := c.context.Clientset.CoreV1().Secrets(cluster.namespace).Get(nodeSecretName, metav1.GetOptions{}); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	nodeRenewed, err := c.syncCertificates(cluster)
+	if err != nil {
+		return err
+	}
+	if nodeRenewed {
+		logger.Infof("restarting the pods of cluster %s in namespace %s with the renewed node certificate", cluster.name, cluster.namespace)
+		return c.updateStatefulSet(cluster)
+	}
+	return nil
+}
+
+// getClientFlags returns the flags of the cockroach commands that the operator runs against the cluster. The
+// certificates of the root user of a secure cluster are written to the config dir of the operator.
+func (c *ClusterController) getClientFlags(cluster *cluster) ([]string, error) {
+	if !cluster.spec.Secure {
+		return []string{"--insecure"}, nil
+	}
+
+	secret, err := c.context.Clientset.CoreV1().Secrets(cluster.namespace).Get(rootClientSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the root client certificate: %+v", err)
+	}
+	dir := filepath.Join(c.context.ConfigDir, cluster.namespace, "cockroach-certs")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	// cockroach refuses keys that are readable by others
+	for _, name := range []string{caCertFileName, rootCertFileName, rootKeyFileName} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), secret.Data[name], 0600); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %+v", name, err)
+		}
+	}
+
+	return []string{fmt.Sprintf("--certs-dir=%s", dir)}, nil
+}
+
+func createCertsVolume(secretName string) v1.Volume {
+	// cockroach refuses keys that are readable by others
+	defaultMode := int32(0400)
+	return v1.Volume{
+		Name: volumeCertsName,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{
+				SecretName:  secretName,
+				DefaultMode: &defaultMode,
+			},
+		},
+	}
+}
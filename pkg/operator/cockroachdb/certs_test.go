@@ -0,0 +1,196 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cockroachdb
+
+import (
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cockroachdbv1alpha1 "github.com/rook/rook/pkg/apis/cockroachdb.rook.io/v1alpha1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newSecureClusterForTest(namespace string, nodeCount int, context *clusterd.Context) *cluster {
+	return newCluster(&cockroachdbv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-830", Namespace: namespace},
+		Spec: cockroachdbv1alpha1.ClusterSpec{
+			Storage: rookalpha.StorageScopeSpec{NodeCount: nodeCount},
+			Secure:  true,
+		},
+	}, context)
+}
+
+func getSecretCert(t *testing.T, context *clusterd.Context, namespace, secretName, certFileName string) *x509.Certificate {
+	secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	cert, err := parseCertificate(secret.Data[certFileName])
+	assert.Nil(t, err)
+	return cert
+}
+
+func TestSyncCertificates(t *testing.T) {
+	namespace := "rook-cockroachdb-320"
+	context := &clusterd.Context{Clientset: testop.New(3)}
+	controller := NewClusterController(context, "rook/cockroachdb:mockTag")
+	cluster := newSecureClusterForTest(namespace, 3, context)
+
+	// the CA, node and root client certificates are created
+	renewed, err := controller.syncCertificates(cluster)
+	assert.Nil(t, err)
+	assert.True(t, renewed)
+
+	ca := getSecretCert(t, context, namespace, caSecretName, caCertFileName)
+	assert.True(t, ca.IsCA)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	// the node certificate is valid for the replicas of the stateful set
+	node := getSecretCert(t, context, namespace, nodeSecretName, nodeCertFileName)
+	assert.Equal(t, "node", node.Subject.CommonName)
+	for _, name := range []string{
+		"rook-cockroachdb-2.rook-cockroachdb.rook-cockroachdb-320",
+		"rook-cockroachdb-2.rook-cockroachdb.rook-cockroachdb-320.svc.cluster.local",
+		"cockroachdb-public.rook-cockroachdb-320",
+		"localhost",
+	} {
+		_, err = node.Verify(x509.VerifyOptions{DNSName: name, Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+		assert.Nil(t, err)
+	}
+	_, err = node.Verify(x509.VerifyOptions{DNSName: "rook-cockroachdb-3.rook-cockroachdb.rook-cockroachdb-320", Roots: roots})
+	assert.NotNil(t, err)
+
+	root := getSecretCert(t, context, namespace, rootClientSecretName, rootCertFileName)
+	assert.Equal(t, "root", root.Subject.CommonName)
+	_, err = root.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	assert.Nil(t, err)
+
+	// the valid certificates are kept
+	renewed, err = controller.syncCertificates(cluster)
+	assert.Nil(t, err)
+	assert.False(t, renewed)
+	assert.Equal(t, node.SerialNumber, getSecretCert(t, context, namespace, nodeSecretName, nodeCertFileName).SerialNumber)
+	assert.Equal(t, ca.SerialNumber, getSecretCert(t, context, namespace, caSecretName, caCertFileName).SerialNumber)
+
+	// the node certificate is issued again for the names of the added replicas
+	cluster.spec.Storage.NodeCount = 4
+	renewed, err = controller.syncCertificates(cluster)
+	assert.Nil(t, err)
+	assert.True(t, renewed)
+	node = getSecretCert(t, context, namespace, nodeSecretName, nodeCertFileName)
+	_, err = node.Verify(x509.VerifyOptions{DNSName: "rook-cockroachdb-3.rook-cockroachdb.rook-cockroachdb-320", Roots: roots})
+	assert.Nil(t, err)
+}
+
+func TestRenewCertificates(t *testing.T) {
+	namespace := "rook-cockroachdb-321"
+	context := &clusterd.Context{Clientset: testop.New(3)}
+	controller := NewClusterController(context, "rook/cockroachdb:mockTag")
+	cluster := newSecureClusterForTest(namespace, 3, context)
+
+	// nothing is created for a cluster that is not added yet
+	assert.Nil(t, controller.renewCertificates(cluster))
+	_, err := context.Clientset.CoreV1().Secrets(namespace).Get(caSecretName, metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	_, err = controller.syncCertificates(cluster)
+	assert.Nil(t, err)
+	assert.Nil(t, controller.createStatefulSet(cluster))
+	ss, err := context.Clientset.AppsV1().StatefulSets(namespace).Get(appName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	serial := ss.Spec.Template.Annotations[nodeCertSerialAnnotation]
+	assert.Equal(t, getSecretCert(t, context, namespace, nodeSecretName, nodeCertFileName).SerialNumber.Text(16), serial)
+
+	// the certificates are valid for a year
+	assert.Nil(t, controller.renewCertificates(cluster))
+	ss, err = context.Clientset.AppsV1().StatefulSets(namespace).Get(appName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, serial, ss.Spec.Template.Annotations[nodeCertSerialAnnotation])
+
+	// a node certificate that expires soon is renewed and the pods are rolled
+	ca, caKey, err := controller.getOrCreateCA(cluster)
+	assert.Nil(t, err)
+	template := createNodeCertTemplate(cluster, time.Now())
+	template.NotAfter = time.Now().Add(24 * time.Hour)
+	certPEM, keyPEM, err := issueCertificate(template, ca, caKey)
+	assert.Nil(t, err)
+	assert.Nil(t, controller.saveCertSecret(cluster, nodeSecretName, map[string][]byte{nodeCertFileName: certPEM, nodeKeyFileName: keyPEM}))
+
+	assert.Nil(t, controller.renewCertificates(cluster))
+	node := getSecretCert(t, context, namespace, nodeSecretName, nodeCertFileName)
+	assert.True(t, node.NotAfter.After(time.Now().Add(certRenewBefore)))
+	ss, err = context.Clientset.AppsV1().StatefulSets(namespace).Get(appName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, node.SerialNumber.Text(16), ss.Spec.Template.Annotations[nodeCertSerialAnnotation])
+	assert.NotEqual(t, serial, ss.Spec.Template.Annotations[nodeCertSerialAnnotation])
+}
+
+func TestGetClientFlags(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "cockroachdb")
+	assert.Nil(t, err)
+	defer os.RemoveAll(configDir)
+
+	namespace := "rook-cockroachdb-322"
+	context := &clusterd.Context{Clientset: testop.New(3), ConfigDir: configDir}
+	controller := NewClusterController(context, "rook/cockroachdb:mockTag")
+	cluster := newSecureClusterForTest(namespace, 3, context)
+
+	// insecure clusters
+	cluster.spec.Secure = false
+	flags, err := controller.getClientFlags(cluster)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"--insecure"}, flags)
+
+	// the root client certificate of secure clusters is written to the config dir
+	cluster.spec.Secure = true
+	_, err = controller.getClientFlags(cluster)
+	assert.NotNil(t, err)
+	_, err = controller.syncCertificates(cluster)
+	assert.Nil(t, err)
+	flags, err = controller.getClientFlags(cluster)
+	assert.Nil(t, err)
+	dir := filepath.Join(configDir, namespace, "cockroach-certs")
+	assert.Equal(t, []string{"--certs-dir=" + dir}, flags)
+	for _, name := range []string{caCertFileName, rootCertFileName, rootKeyFileName} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		assert.Nil(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+}
+
+func TestCreatePodSpecSecure(t *testing.T) {
+	cluster := newSecureClusterForTest("rook-cockroachdb-323", 3, &clusterd.Context{})
+	podSpec := createPodSpec(cluster, "rook/cockroachdb:mockTag", 8080, 26257)
+
+	assert.Equal(t, 2, len(podSpec.Volumes))
+	assert.Equal(t, nodeSecretName, podSpec.Volumes[1].Secret.SecretName)
+	assert.Equal(t, int32(0400), *podSpec.Volumes[1].Secret.DefaultMode)
+
+	container := podSpec.Containers[0]
+	assert.Equal(t, v1.VolumeMount{Name: volumeCertsName, MountPath: certsDir, ReadOnly: true}, container.VolumeMounts[1])
+	assert.Equal(t, v1.URISchemeHTTPS, container.LivenessProbe.HTTPGet.Scheme)
+	assert.Equal(t, v1.URISchemeHTTPS, container.ReadinessProbe.HTTPGet.Scheme)
+	assert.Contains(t, container.Command[2], "start --logtostderr --certs-dir=/cockroach/cockroach-certs --advertise-host")
+	assert.Equal(t, []v1.EnvVar{{Name: "COCKROACH_CHANNEL", Value: "kubernetes-secure"}}, container.Env)
+}
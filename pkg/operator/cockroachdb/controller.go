@@ -24,6 +24,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	opkit "github.com/rook/operator-kit"
@@ -56,6 +57,7 @@ const (
 	grpcPortDefault                = int32(26257)
 	grpcPortName                   = "grpc"
 	volumeDataName                 = "datadir"
+	minReplicatedNodeCount         = 3
 	envVarChannel                  = "COCKROACH_CHANNEL"
 	envVarValChannelSecure         = "kubernetes-secure"
 	envVarValChannelInsecure       = "kubernetes-insecure"
@@ -74,6 +76,13 @@ type ClusterController struct {
 	context                 *clusterd.Context
 	containerImage          string
 	createInitRetryInterval time.Duration
+	updateRetryInterval     time.Duration
+	adminAddress            func(namespace string, httpPort int32) string
+	// updates counts the updates received by cluster, an update stops once a later update of its cluster is received
+	updates map[string]int
+	// updateLocks serialize the updates of every cluster
+	updateLocks map[string]*sync.Mutex
+	lock        sync.Mutex
 }
 
 func NewClusterController(context *clusterd.Context, containerImage string) *ClusterController {
@@ -81,11 +90,16 @@ func NewClusterController(context *clusterd.Context, containerImage string) *Clu
 		context:                 context,
 		containerImage:          containerImage,
 		createInitRetryInterval: createInitRetryIntervalDefault,
+		updateRetryInterval:     updateClusterInterval,
+		adminAddress:            createAdminAddress,
+		updates:                 map[string]int{},
+		updateLocks:             map[string]*sync.Mutex{},
 	}
 }
 
 type cluster struct {
 	context     *clusterd.Context
+	name        string
 	namespace   string
 	spec        cockroachdbv1alpha1.ClusterSpec
 	annotations rookv1alpha2.Annotations
@@ -95,6 +109,7 @@ type cluster struct {
 func newCluster(c *cockroachdbv1alpha1.Cluster, context *clusterd.Context) *cluster {
 	return &cluster{
 		context:     context,
+		name:        c.Name,
 		namespace:   c.Namespace,
 		spec:        c.Spec,
 		annotations: c.Spec.Annotations,
@@ -124,6 +139,9 @@ func (c *ClusterController) StartWatch(namespace string, stopCh chan struct{}) e
 	watcher := opkit.NewWatcher(ClusterResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CockroachdbV1alpha1().RESTClient())
 	go watcher.Watch(&cockroachdbv1alpha1.Cluster{}, stopCh)
 
+	// report the liveness of the nodes of the clusters and renew their certificates
+	go c.monitorClusters(namespace, stopCh)
+
 	return nil
 }
 
@@ -134,27 +152,38 @@ func (c *ClusterController) onAdd(obj interface{}) {
 	cluster := newCluster(clusterObj, c.context)
 
 	if err := validateClusterSpec(cluster.spec); err != nil {
-		logger.Errorf("invalid cluster spec: %+v", err)
+		c.setClusterError(cluster, fmt.Sprintf("invalid cluster spec: %+v", err))
 		return
 	}
 
+	if err := c.updateClusterStatus(cluster.namespace, cluster.name, cockroachdbv1alpha1.ClusterStateCreating, ""); err != nil {
+		logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.namespace, err)
+	}
+
 	if err := c.createClientService(cluster); err != nil {
-		logger.Errorf("failed to create client service: %+v", err)
+		c.setClusterError(cluster, fmt.Sprintf("failed to create client service: %+v", err))
 		return
 	}
 
 	if err := c.createReplicaService(cluster); err != nil {
-		logger.Errorf("failed to create replica service: %+v", err)
+		c.setClusterError(cluster, fmt.Sprintf("failed to create replica service: %+v", err))
 		return
 	}
 
 	if err := c.createPodDisruptionBudget(cluster); err != nil {
-		logger.Errorf("failed to create pod disruption budget: %+v", err)
+		c.setClusterError(cluster, fmt.Sprintf("failed to create pod disruption budget: %+v", err))
 		return
 	}
 
+	if cluster.spec.Secure {
+		if _, err := c.syncCertificates(cluster); err != nil {
+			c.setClusterError(cluster, fmt.Sprintf("failed to create certificates: %+v", err))
+			return
+		}
+	}
+
 	if err := c.createStatefulSet(cluster); err != nil {
-		logger.Errorf("failed to create stateful set: %+v", err)
+		c.setClusterError(cluster, fmt.Sprintf("failed to create stateful set: %+v", err))
 		return
 	}
 
@@ -173,22 +202,160 @@ func (c *ClusterController) onAdd(obj interface{}) {
 		return true, nil
 	})
 	if err != nil {
-		logger.Errorf("failed to initialize cluster in namespace %s: %+v", cluster.namespace, err)
+		c.setClusterError(cluster, fmt.Sprintf("failed to initialize cluster in namespace %s: %+v", cluster.namespace, err))
 		return
 	}
 
+	if err := c.updateClusterStatus(cluster.namespace, cluster.name, cockroachdbv1alpha1.ClusterStateCreated, ""); err != nil {
+		logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.namespace, err)
+	}
 	logger.Infof("succeeded creating and initializing cluster in namespace %s", cluster.namespace)
 }
 
 func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
-	_ = oldObj.(*cockroachdbv1alpha1.Cluster).DeepCopy()
-	newCluster := newObj.(*cockroachdbv1alpha1.Cluster).DeepCopy()
-	logger.Infof("cluster %s updated in namespace %s", newCluster.Name, newCluster.Namespace)
+	oldClusterObj := oldObj.(*cockroachdbv1alpha1.Cluster).DeepCopy()
+	newClusterObj := newObj.(*cockroachdbv1alpha1.Cluster).DeepCopy()
+
+	// the status updates of the operator are watched too
+	if reflect.DeepEqual(oldClusterObj.Spec, newClusterObj.Spec) {
+		logger.Debugf("spec of cluster %s in namespace %s did not change", newClusterObj.Name, newClusterObj.Namespace)
+		return
+	}
+	logger.Infof("cluster %s updated in namespace %s", newClusterObj.Name, newClusterObj.Namespace)
+
+	cluster := newCluster(newClusterObj, c.context)
+	if err := validateClusterSpec(cluster.spec); err != nil {
+		c.setClusterError(cluster, fmt.Sprintf("invalid cluster spec: %+v", err))
+		return
+	}
+	if err := validateClusterUpdate(oldClusterObj.Spec, cluster.spec); err != nil {
+		c.setClusterError(cluster, fmt.Sprintf("unsupported cluster update: %+v", err))
+		return
+	}
+
+	// the decommission of the removed nodes and the rolling of the pods take up to an hour each, the other clusters
+	// are updated meanwhile
+	update, lock := c.nextUpdate(cluster)
+	go c.updateCluster(cluster, update, lock)
+}
+
+// nextUpdate records an update of a cluster, and returns its number and the lock serializing the updates of the
+// cluster
+func (c *ClusterController) nextUpdate(cluster *cluster) (int, *sync.Mutex) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := clusterKey(cluster)
+	c.updates[key]++
+	if _, ok := c.updateLocks[key]; !ok {
+		c.updateLocks[key] = &sync.Mutex{}
+	}
+	return c.updates[key], c.updateLocks[key]
+}
+
+// isLatestUpdate returns false once a later update of the cluster was received, or the cluster was deleted
+func (c *ClusterController) isLatestUpdate(cluster *cluster, update int) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.updates[clusterKey(cluster)] == update
+}
+
+func clusterKey(cluster *cluster) string {
+	return cluster.namespace + "/" + cluster.name
+}
+
+// updateCluster applies an update of a cluster, unless a later update of the cluster replaced it
+func (c *ClusterController) updateCluster(cluster *cluster, update int, lock *sync.Mutex) {
+	lock.Lock()
+	defer lock.Unlock()
+	if !c.isLatestUpdate(cluster, update) {
+		logger.Infof("skipping update of cluster %s superseded by a later update", cluster.name)
+		return
+	}
+
+	if err := c.updateClusterStatus(cluster.namespace, cluster.name, cockroachdbv1alpha1.ClusterStateUpdating, ""); err != nil {
+		logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.namespace, err)
+	}
+
+	// the nodes that are removed must move their data to the remaining nodes before their pods are deleted. The
+	// replicas are counted from the stateful set, as the updates replaced by a later update were not applied.
+	replicas, err := c.getStatefulSetReplicas(cluster)
+	if err != nil {
+		c.setClusterError(cluster, fmt.Sprintf("failed to get stateful set: %+v", err))
+		return
+	}
+	if cluster.spec.Storage.NodeCount < replicas {
+		if err := c.decommissionNodes(cluster, cluster.spec.Storage.NodeCount, replicas); err != nil {
+			c.setClusterError(cluster, fmt.Sprintf("failed to decommission nodes: %+v", err))
+			return
+		}
+	}
+	if !c.isLatestUpdate(cluster, update) {
+		logger.Infof("update of cluster %s superseded by a later update", cluster.name)
+		return
+	}
+
+	// the node certificate is issued again for the names of the added replicas
+	if cluster.spec.Secure {
+		if _, err := c.syncCertificates(cluster); err != nil {
+			c.setClusterError(cluster, fmt.Sprintf("failed to update certificates: %+v", err))
+			return
+		}
+	}
+
+	if err := c.updateStatefulSet(cluster); err != nil {
+		c.setClusterError(cluster, fmt.Sprintf("failed to update stateful set: %+v", err))
+		return
+	}
+
+	// wait for the stateful set to roll all the pods to the new spec, or for a later update
+	err = wait.Poll(c.updateRetryInterval, updateClusterTimeout, func() (bool, error) {
+		if !c.isLatestUpdate(cluster, update) {
+			return true, nil
+		}
+		if err := c.isStatefulSetUpdated(cluster); err != nil {
+			logger.Infof("stateful set is not yet updated: %+v", err)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		c.setClusterError(cluster, fmt.Sprintf("giving up waiting for cluster %s to update after %s", cluster.name, updateClusterTimeout))
+		return
+	}
+	if !c.isLatestUpdate(cluster, update) {
+		logger.Infof("update of cluster %s superseded by a later update", cluster.name)
+		return
+	}
+
+	if err := c.updateClusterStatus(cluster.namespace, cluster.name, cockroachdbv1alpha1.ClusterStateCreated, ""); err != nil {
+		logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.namespace, err)
+	}
+	logger.Infof("succeeded updating cluster %s in namespace %s", cluster.name, cluster.namespace)
 }
 
 func (c *ClusterController) onDelete(obj interface{}) {
-	cluster := obj.(*cockroachdbv1alpha1.Cluster).DeepCopy()
-	logger.Infof("cluster %s deleted from namespace %s", cluster.Name, cluster.Namespace)
+	clusterObj := obj.(*cockroachdbv1alpha1.Cluster).DeepCopy()
+	logger.Infof("cluster %s deleted from namespace %s", clusterObj.Name, clusterObj.Namespace)
+
+	// the running update of the cluster stops
+	c.lock.Lock()
+	key := clusterKey(newCluster(clusterObj, c.context))
+	delete(c.updates, key)
+	delete(c.updateLocks, key)
+	c.lock.Unlock()
+
+	// The resources of the cluster are garbage collected through their owner reference, but it can't be set on some
+	// versions of OpenShift. The persistent volume claims of the nodes are kept either way so that no data is lost.
+	if err := c.deleteClusterResources(newCluster(clusterObj, c.context)); err != nil {
+		logger.Errorf("failed to delete the resources of cluster %s in namespace %s: %+v", clusterObj.Name, clusterObj.Namespace, err)
+	}
+}
+
+func (c *ClusterController) setClusterError(cluster *cluster, message string) {
+	logger.Error(message)
+	if err := c.updateClusterStatus(cluster.namespace, cluster.name, cockroachdbv1alpha1.ClusterStateError, message); err != nil {
+		logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.namespace, err)
+	}
 }
 
 func (c *ClusterController) createClientService(cluster *cluster) error {
@@ -201,7 +368,7 @@ func (c *ClusterController) createClientService(cluster *cluster) error {
 	// automatically load balance connections to the different database pods.
 	clientService := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "cockroachdb-public",
+			Name:      clientServiceName,
 			Namespace: cluster.namespace,
 			Labels:    createAppLabels(),
 		},
@@ -329,7 +496,7 @@ func (c *ClusterController) createStatefulSet(cluster *cluster) error {
 					Namespace: cluster.namespace,
 					Labels:    createAppLabels(),
 				},
-				Spec: createPodSpec(cluster, c.containerImage, httpPort, grpcPort),
+				Spec: createPodSpec(cluster, c.clusterImage(cluster), httpPort, grpcPort),
 			},
 			PodManagementPolicy: appsv1.ParallelPodManagement,
 			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
@@ -340,6 +507,9 @@ func (c *ClusterController) createStatefulSet(cluster *cluster) error {
 	}
 	cluster.annotations.ApplyToObjectMeta(&statefulSet.Spec.Template.ObjectMeta)
 	cluster.annotations.ApplyToObjectMeta(&statefulSet.ObjectMeta)
+	if err := c.applyNodeCertAnnotation(cluster, &statefulSet.Spec.Template); err != nil {
+		return err
+	}
 	k8sutil.SetOwnerRef(c.context.Clientset, cluster.namespace, &statefulSet.ObjectMeta, &cluster.ownerRef)
 
 	if _, err := c.context.Clientset.AppsV1().StatefulSets(cluster.namespace).Create(statefulSet); err != nil {
@@ -354,6 +524,143 @@ func (c *ClusterController) createStatefulSet(cluster *cluster) error {
 	return nil
 }
 
+// updateStatefulSet applies the node count and the pod template of the spec to the stateful set. The rolling update
+// strategy of the stateful set restarts the pods one at a time when the template changes, waiting for each pod to be
+// ready before the next one.
+func (c *ClusterController) updateStatefulSet(cluster *cluster) error {
+	replicas := int32(cluster.spec.Storage.NodeCount)
+
+	httpPort, grpcPort, err := getPortsFromSpec(cluster.spec.Network)
+	if err != nil {
+		return err
+	}
+
+	statefulSet, err := c.context.Clientset.AppsV1().StatefulSets(cluster.namespace).Get(appName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	statefulSet.Spec.Replicas = &replicas
+	statefulSet.Spec.Template.Spec = createPodSpec(cluster, c.clusterImage(cluster), httpPort, grpcPort)
+	cluster.annotations.ApplyToObjectMeta(&statefulSet.Spec.Template.ObjectMeta)
+	cluster.annotations.ApplyToObjectMeta(&statefulSet.ObjectMeta)
+	if err := c.applyNodeCertAnnotation(cluster, &statefulSet.Spec.Template); err != nil {
+		return err
+	}
+
+	if _, err := c.context.Clientset.AppsV1().StatefulSets(cluster.namespace).Update(statefulSet); err != nil {
+		return err
+	}
+	logger.Infof("stateful set %s updated in namespace %s", statefulSet.Name, statefulSet.Namespace)
+
+	return nil
+}
+
+func (c *ClusterController) getStatefulSetReplicas(cluster *cluster) (int, error) {
+	statefulSet, err := c.context.Clientset.AppsV1().StatefulSets(cluster.namespace).Get(appName, metav1.GetOptions{})
+	if err != nil {
+		return 0, err
+	}
+	if statefulSet.Spec.Replicas == nil {
+		return 1, nil
+	}
+	return int(*statefulSet.Spec.Replicas), nil
+}
+
+func (c *ClusterController) isStatefulSetUpdated(cluster *cluster) error {
+	statefulSet, err := c.context.Clientset.AppsV1().StatefulSets(cluster.namespace).Get(appName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	replicas := int32(cluster.spec.Storage.NodeCount)
+	status := statefulSet.Status
+	if status.ObservedGeneration < statefulSet.Generation {
+		return fmt.Errorf("stateful set generation %d is not yet observed", statefulSet.Generation)
+	}
+	if status.Replicas != replicas || status.UpdatedReplicas != replicas || status.ReadyReplicas != replicas {
+		return fmt.Errorf("%d updated and %d ready replicas out of %d, expected %d",
+			status.UpdatedReplicas, status.ReadyReplicas, status.Replicas, replicas)
+	}
+
+	return nil
+}
+
+// decommissionNodes moves the data of the nodes of the given replicas of the stateful set to the other nodes, so that
+// the replicas can be removed
+func (c *ClusterController) decommissionNodes(cluster *cluster, fromReplica, toReplica int) error {
+	nodes, err := c.getNodeStatuses(cluster)
+	if err != nil {
+		return err
+	}
+
+	nodeIDs := []string{}
+	for i := fromReplica; i < toReplica; i++ {
+		nodeID := findNodeID(nodes, i, cluster.namespace)
+		if nodeID == 0 {
+			logger.Infof("replica %d never joined cluster %s, nothing to decommission", i, cluster.name)
+			continue
+		}
+		nodeIDs = append(nodeIDs, strconv.Itoa(nodeID))
+	}
+	if len(nodeIDs) == 0 {
+		return nil
+	}
+
+	logger.Infof("decommissioning nodes %s of cluster %s", strings.Join(nodeIDs, ","), cluster.name)
+	clientFlags, err := c.getClientFlags(cluster)
+	if err != nil {
+		return err
+	}
+	hostFlag := fmt.Sprintf("--host=%s", createQualifiedReplicaServiceName(0, cluster.namespace))
+	args := append([]string{"node", "decommission"}, nodeIDs...)
+	args = append(args, clientFlags...)
+	args = append(args, hostFlag)
+	// the command waits until all the data of the nodes is moved
+	out, err := c.context.Executor.ExecuteCommandWithTimeout(false, updateClusterTimeout, "cockroachdb decommission",
+		"/cockroach/cockroach", args...)
+	if err != nil {
+		return fmt.Errorf("failed to decommission nodes %s: %+v. %s", strings.Join(nodeIDs, ","), err, out)
+	}
+
+	logger.Infof("decommissioned nodes %s of cluster %s", strings.Join(nodeIDs, ","), cluster.name)
+	return nil
+}
+
+func (c *ClusterController) deleteClusterResources(cluster *cluster) error {
+	if err := c.context.Clientset.AppsV1().StatefulSets(cluster.namespace).Delete(appName, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err := c.context.Clientset.PolicyV1beta1().PodDisruptionBudgets(cluster.namespace).Delete("cockroachdb-budget", &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	for _, name := range []string{clientServiceName, appName} {
+		if err := c.context.Clientset.CoreV1().Services(cluster.namespace).Delete(name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	propagation := metav1.DeletePropagationBackground
+	if err := c.context.Clientset.BatchV1().Jobs(cluster.namespace).Delete(backupAppName, &metav1.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	for _, name := range []string{caSecretName, nodeSecretName, rootClientSecretName} {
+		if err := c.context.Clientset.CoreV1().Secrets(cluster.namespace).Delete(name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	logger.Infof("deleted the resources of cluster %s in namespace %s", cluster.name, cluster.namespace)
+	return nil
+}
+
+// returns the image of cockroachdb of the cluster, which defaults to the image of the operator
+func (c *ClusterController) clusterImage(cluster *cluster) string {
+	if cluster.spec.Image != "" {
+		return cluster.spec.Image
+	}
+	return c.containerImage
+}
+
 func createPodSpec(cluster *cluster, containerImage string, httpPort, grpcPort int32) v1.PodSpec {
 	terminationGracePeriodSeconds := int64(60)
 
@@ -366,6 +673,9 @@ func createPodSpec(cluster *cluster, containerImage string, httpPort, grpcPort i
 			},
 		})
 	}
+	if cluster.spec.Secure {
+		volumes = append(volumes, createCertsVolume(nodeSecretName))
+	}
 
 	return v1.PodSpec{
 		Affinity: &v1.Affinity{
@@ -398,8 +708,11 @@ func createPodSpec(cluster *cluster, containerImage string, httpPort, grpcPort i
 
 func createContainer(cluster *cluster, containerImage string, httpPort, grpcPort int32) v1.Container {
 	var envVarChannelVal string
+	// the http port serves https in secure clusters
+	probeScheme := v1.URISchemeHTTP
 	if cluster.spec.Secure {
 		envVarChannelVal = envVarValChannelSecure
+		probeScheme = v1.URISchemeHTTPS
 	} else {
 		envVarChannelVal = envVarValChannelInsecure
 	}
@@ -409,6 +722,20 @@ func createContainer(cluster *cluster, containerImage string, httpPort, grpcPort
 		cockroachDataVolumeName = cluster.spec.Storage.VolumeClaimTemplates[0].GetName()
 	}
 
+	volumeMounts := []v1.VolumeMount{
+		{
+			Name:      cockroachDataVolumeName,
+			MountPath: "/cockroach/cockroach-data",
+		},
+	}
+	if cluster.spec.Secure {
+		volumeMounts = append(volumeMounts, v1.VolumeMount{
+			Name:      volumeCertsName,
+			MountPath: certsDir,
+			ReadOnly:  true,
+		})
+	}
+
 	return v1.Container{
 		Name:            appName,
 		Image:           containerImage,
@@ -426,8 +753,9 @@ func createContainer(cluster *cluster, containerImage string, httpPort, grpcPort
 		LivenessProbe: &v1.Probe{
 			Handler: v1.Handler{
 				HTTPGet: &v1.HTTPGetAction{
-					Path: "/health",
-					Port: intstr.FromString(httpPortName),
+					Path:   "/health",
+					Port:   intstr.FromString(httpPortName),
+					Scheme: probeScheme,
 				},
 			},
 			InitialDelaySeconds: int32(30),
@@ -436,20 +764,16 @@ func createContainer(cluster *cluster, containerImage string, httpPort, grpcPort
 		ReadinessProbe: &v1.Probe{
 			Handler: v1.Handler{
 				HTTPGet: &v1.HTTPGetAction{
-					Path: "/health?ready=1",
-					Port: intstr.FromString(httpPortName),
+					Path:   "/health?ready=1",
+					Port:   intstr.FromString(httpPortName),
+					Scheme: probeScheme,
 				},
 			},
 			InitialDelaySeconds: int32(10),
 			PeriodSeconds:       int32(5),
 			FailureThreshold:    int32(2),
 		},
-		VolumeMounts: []v1.VolumeMount{
-			{
-				Name:      cockroachDataVolumeName,
-				MountPath: "/cockroach/cockroach-data",
-			},
-		},
+		VolumeMounts: volumeMounts,
 		Env: []v1.EnvVar{
 			{
 				Name:  envVarChannel,
@@ -486,9 +810,14 @@ func (c *ClusterController) initCluster(cluster *cluster) error {
 		return nil
 	}
 
+	clientFlags, err := c.getClientFlags(cluster)
+	if err != nil {
+		return err
+	}
 	hostFlag := fmt.Sprintf("--host=%s", createQualifiedReplicaServiceName(0, cluster.namespace))
-	out, err := c.context.Executor.ExecuteCommandWithCombinedOutput(false, "cockroachdb init",
-		"/cockroach/cockroach", "init", "--insecure", hostFlag)
+	args := append([]string{"init"}, clientFlags...)
+	args = append(args, hostFlag)
+	out, err := c.context.Executor.ExecuteCommandWithCombinedOutput(false, "cockroachdb init", "/cockroach/cockroach", args...)
 	if err != nil {
 		return fmt.Errorf("cluster init failed for namespace %s: %+v. %s", cluster.namespace, err, out)
 	}
@@ -513,6 +842,29 @@ func validateClusterSpec(spec cockroachdbv1alpha1.ClusterSpec) error {
 		return err
 	}
 
+	if err := validateBackupSpec(spec.Backup); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateClusterUpdate returns an error if the changes to the spec of a running cluster are not supported
+func validateClusterUpdate(oldSpec, newSpec cockroachdbv1alpha1.ClusterSpec) error {
+	if !reflect.DeepEqual(oldSpec.Network, newSpec.Network) {
+		return fmt.Errorf("the network of a running cluster cannot be changed")
+	}
+	if oldSpec.Secure != newSpec.Secure {
+		return fmt.Errorf("the secure setting of a running cluster cannot be changed")
+	}
+	if !reflect.DeepEqual(oldSpec.Storage.VolumeClaimTemplates, newSpec.Storage.VolumeClaimTemplates) {
+		return fmt.Errorf("the volume claim templates of a running cluster cannot be changed")
+	}
+	// decommissioning would never finish, as the ranges could not be replicated 3 times
+	if oldSpec.Storage.NodeCount >= minReplicatedNodeCount && newSpec.Storage.NodeCount < minReplicatedNodeCount {
+		return fmt.Errorf("invalid node count: %d. Cannot scale down below %d nodes", newSpec.Storage.NodeCount, minReplicatedNodeCount)
+	}
+
 	return nil
 }
 
@@ -576,9 +928,9 @@ func createQualifiedReplicaServiceName(replicaNum int, namespace string) string
 }
 
 func createCommand(cluster *cluster, httpPort, grpcPort int32) string {
-	var insecureFlag string
-	if !cluster.spec.Secure {
-		insecureFlag = "--insecure"
+	securityFlag := "--insecure"
+	if cluster.spec.Secure {
+		securityFlag = fmt.Sprintf("--certs-dir=%s", certsDir)
 	}
 
 	var joinFlag string
@@ -595,5 +947,5 @@ func createCommand(cluster *cluster, httpPort, grpcPort int32) string {
 
 	// The use of qualified `hostname -f` is crucial: Other nodes aren't able to look up the unqualified hostname.
 	return fmt.Sprintf("exec /cockroach/cockroach start --logtostderr %s --advertise-host $(hostname -f) --http-host 0.0.0.0 --port %d --http-port %d %s --cache %s%% --max-sql-memory %s%%",
-		insecureFlag, grpcPort, httpPort, joinFlag, strconv.Itoa(cluster.spec.CachePercent), strconv.Itoa(cluster.spec.MaxSQLMemoryPercent))
+		securityFlag, grpcPort, httpPort, joinFlag, strconv.Itoa(cluster.spec.CachePercent), strconv.Itoa(cluster.spec.MaxSQLMemoryPercent))
 }
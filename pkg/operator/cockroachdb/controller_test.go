@@ -23,15 +23,18 @@ import (
 
 	cockroachdbv1alpha1 "github.com/rook/rook/pkg/apis/cockroachdb.rook.io/v1alpha1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	testop "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/fake"
 )
 
@@ -162,7 +165,8 @@ func TestOnAdd(t *testing.T) {
 
 	// initialize the controller and its dependencies
 	clientset := testop.New(3)
-	context := &clusterd.Context{Clientset: clientset, Executor: executor}
+	rookClientset := rookfake.NewSimpleClientset(cluster)
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookClientset, Executor: executor}
 	controller := NewClusterController(context, "rook/cockroachdb:mockTag")
 	controller.createInitRetryInterval = 1 * time.Millisecond
 
@@ -228,6 +232,198 @@ func TestOnAdd(t *testing.T) {
 
 	// cockroachdb init should have been called
 	assert.True(t, initCalled)
+
+	// the cluster should be reported as created
+	clusterObj, err := rookClientset.CockroachdbV1alpha1().Clusters(namespace).Get(cluster.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cockroachdbv1alpha1.ClusterStateCreated, clusterObj.Status.State)
+}
+
+func TestOnUpdate(t *testing.T) {
+	namespace := "rook-cockroachdb-316"
+	oldCluster := &cockroachdbv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-825",
+			Namespace: namespace,
+		},
+		Spec: cockroachdbv1alpha1.ClusterSpec{
+			Storage:             rookalpha.StorageScopeSpec{NodeCount: 5},
+			CachePercent:        30,
+			MaxSQLMemoryPercent: 40,
+		},
+	}
+	updatedCluster := oldCluster.DeepCopy()
+	updatedCluster.Spec.Storage.NodeCount = 3
+	updatedCluster.Spec.CachePercent = 25
+	updatedCluster.Spec.Image = "cockroachdb/cockroach:v2.1.6"
+
+	// the admin endpoint reports the nodes of the 5 replicas
+	server := newFakeAdminServer(namespace, 5)
+	defer server.Close()
+
+	// keep track of the nodes that are decommissioned
+	var decommissionArgs []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithTimeout: func(debug bool, timeout time.Duration, actionName string, command string, arg ...string) (string, error) {
+			if strings.Contains(command, "cockroach") && arg[0] == "node" && arg[1] == "decommission" {
+				decommissionArgs = arg
+			}
+			return "", nil
+		},
+	}
+
+	clientset := testop.New(3)
+	rookClientset := rookfake.NewSimpleClientset(updatedCluster)
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookClientset, Executor: executor}
+	controller := NewClusterController(context, "rook/cockroachdb:mockTag")
+	controller.updateRetryInterval = 1 * time.Millisecond
+	controller.adminAddress = func(namespace string, httpPort int32) string { return server.URL }
+	assert.Nil(t, controller.createStatefulSet(newCluster(oldCluster, context)))
+
+	// in a background thread, simulate the stateful set rolling its pods
+	go simulateStatefulSetUpdated(clientset, namespace, updatedCluster.Spec.Storage.NodeCount)
+
+	// the update runs in the background
+	controller.onUpdate(oldCluster, updatedCluster)
+	err := wait.Poll(time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		clusterObj, err := rookClientset.CockroachdbV1alpha1().Clusters(namespace).Get(updatedCluster.Name, metav1.GetOptions{})
+		return err == nil && clusterObj.Status.State == cockroachdbv1alpha1.ClusterStateCreated, nil
+	})
+	assert.Nil(t, err)
+
+	// the nodes of the removed replicas should have been decommissioned first
+	assert.Equal(t, []string{"node", "decommission", "4", "5", "--insecure",
+		"--host=rook-cockroachdb-0.rook-cockroachdb.rook-cockroachdb-316"}, decommissionArgs)
+
+	ss, err := clientset.AppsV1().StatefulSets(namespace).Get(appName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), *ss.Spec.Replicas)
+	container := ss.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "cockroachdb/cockroach:v2.1.6", container.Image)
+	assert.True(t, strings.HasSuffix(container.Command[2], "--cache 25% --max-sql-memory 40%"))
+
+	clusterObj, err := rookClientset.CockroachdbV1alpha1().Clusters(namespace).Get(updatedCluster.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cockroachdbv1alpha1.ClusterStateCreated, clusterObj.Status.State)
+
+	// scaling below 3 nodes is not supported
+	decommissionArgs = nil
+	smallCluster := updatedCluster.DeepCopy()
+	smallCluster.Spec.Storage.NodeCount = 1
+	controller.onUpdate(updatedCluster, smallCluster)
+	assert.Nil(t, decommissionArgs)
+	clusterObj, err = rookClientset.CockroachdbV1alpha1().Clusters(namespace).Get(updatedCluster.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cockroachdbv1alpha1.ClusterStateError, clusterObj.Status.State)
+}
+
+func TestUpdateSuperseded(t *testing.T) {
+	namespace := "rook-cockroachdb-317"
+	clusterObj := &cockroachdbv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-826", Namespace: namespace},
+		Spec:       cockroachdbv1alpha1.ClusterSpec{Storage: rookalpha.StorageScopeSpec{NodeCount: 3}},
+	}
+	clientset := testop.New(3)
+	rookClientset := rookfake.NewSimpleClientset(clusterObj)
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookClientset}
+	controller := NewClusterController(context, "rook/cockroachdb:mockTag")
+	cluster := newCluster(clusterObj, context)
+	assert.Nil(t, controller.createStatefulSet(cluster))
+
+	// an update replaced by a later update of the cluster is not applied
+	update, lock := controller.nextUpdate(cluster)
+	latest, latestLock := controller.nextUpdate(cluster)
+	assert.Equal(t, lock, latestLock)
+	assert.True(t, controller.isLatestUpdate(cluster, latest))
+	cluster.spec.Storage.NodeCount = 5
+	controller.updateCluster(cluster, update, lock)
+	replicas, err := controller.getStatefulSetReplicas(cluster)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, replicas)
+	current, err := rookClientset.CockroachdbV1alpha1().Clusters(namespace).Get(clusterObj.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cockroachdbv1alpha1.ClusterState(""), current.Status.State)
+
+	// the updates of a deleted cluster stop
+	controller.onDelete(clusterObj)
+	assert.False(t, controller.isLatestUpdate(cluster, latest))
+}
+
+func TestOnDelete(t *testing.T) {
+	namespace := "rook-cockroachdb-317"
+	cluster := &cockroachdbv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-826",
+			Namespace: namespace,
+		},
+		Spec: cockroachdbv1alpha1.ClusterSpec{
+			Storage: rookalpha.StorageScopeSpec{NodeCount: 1},
+		},
+	}
+
+	clientset := testop.New(3)
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookfake.NewSimpleClientset()}
+	controller := NewClusterController(context, "rook/cockroachdb:mockTag")
+	c := newCluster(cluster, context)
+	assert.Nil(t, controller.createClientService(c))
+	assert.Nil(t, controller.createReplicaService(c))
+	assert.Nil(t, controller.createPodDisruptionBudget(c))
+	assert.Nil(t, controller.createStatefulSet(c))
+
+	controller.onDelete(cluster)
+
+	_, err := clientset.AppsV1().StatefulSets(namespace).Get(appName, metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+	_, err = clientset.PolicyV1beta1().PodDisruptionBudgets(namespace).Get("cockroachdb-budget", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+	services, err := clientset.CoreV1().Services(namespace).List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(services.Items))
+
+	// deleting again is a no-op
+	controller.onDelete(cluster)
+}
+
+func TestValidateClusterUpdate(t *testing.T) {
+	spec := cockroachdbv1alpha1.ClusterSpec{Storage: rookalpha.StorageScopeSpec{NodeCount: 3}}
+
+	// scaling, tuning the memory and changing the image are supported
+	newSpec := *spec.DeepCopy()
+	newSpec.Storage.NodeCount = 5
+	newSpec.CachePercent = 30
+	newSpec.Image = "cockroachdb/cockroach:v2.1.6"
+	assert.Nil(t, validateClusterUpdate(spec, newSpec))
+
+	// scaling below 3 nodes
+	newSpec = *spec.DeepCopy()
+	newSpec.Storage.NodeCount = 2
+	err := validateClusterUpdate(spec, newSpec)
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "Cannot scale down below 3 nodes"))
+
+	// changing the ports
+	newSpec = *spec.DeepCopy()
+	newSpec.Network.Ports = []rookalpha.PortSpec{{Name: "http", Port: 123}}
+	assert.NotNil(t, validateClusterUpdate(spec, newSpec))
+
+	// changing the secure setting
+	newSpec = *spec.DeepCopy()
+	newSpec.Secure = true
+	assert.NotNil(t, validateClusterUpdate(spec, newSpec))
+}
+
+func simulateStatefulSetUpdated(clientset *fake.Clientset, namespace string, replicas int) {
+	for {
+		ss, err := clientset.AppsV1().StatefulSets(namespace).Get(appName, metav1.GetOptions{})
+		if err == nil && *ss.Spec.Replicas == int32(replicas) {
+			ss.Status.Replicas = int32(replicas)
+			ss.Status.UpdatedReplicas = int32(replicas)
+			ss.Status.ReadyReplicas = int32(replicas)
+			clientset.AppsV1().StatefulSets(namespace).Update(ss)
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
 }
 
 func simulatePodsRunning(clientset *fake.Clientset, namespace string, podCount int) {
@@ -35,16 +35,19 @@ type Operator struct {
 	resources         []opkit.CustomResource
 	rookImage         string
 	clusterController *ClusterController
+	restoreController *RestoreController
 }
 
 // New creates an operator instance
 func New(context *clusterd.Context, rookImage string) *Operator {
 	clusterController := NewClusterController(context, rookImage)
+	restoreController := NewRestoreController(context, rookImage)
 
-	schemes := []opkit.CustomResource{ClusterResource}
+	schemes := []opkit.CustomResource{ClusterResource, RestoreResource}
 	return &Operator{
 		context:           context,
 		clusterController: clusterController,
+		restoreController: restoreController,
 		resources:         schemes,
 		rookImage:         rookImage,
 	}
@@ -60,6 +63,9 @@ func (o *Operator) Run() error {
 	// watch for changes to the cockroachdb clusters
 	o.clusterController.StartWatch(v1.NamespaceAll, stopChan)
 
+	// watch for the restores of cockroachdb clusters from their backups
+	o.restoreController.StartWatch(v1.NamespaceAll, stopChan)
+
 	for {
 		select {
 		case <-signalChan:
@@ -0,0 +1,275 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cockroachdb
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	opkit "github.com/rook/operator-kit"
+	cockroachdbv1alpha1 "github.com/rook/rook/pkg/apis/cockroachdb.rook.io/v1alpha1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/cockroachdb/backup"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	RestoreResourceName       = "restore"
+	RestoreResourceNamePlural = "restores"
+	restoreAppName            = appName + "-restore"
+	restoreRetryInterval      = 10 * time.Second
+	restoreTimeout            = 1 * time.Hour
+)
+
+var RestoreResource = opkit.CustomResource{
+	Name:    RestoreResourceName,
+	Plural:  RestoreResourceNamePlural,
+	Group:   cockroachdbv1alpha1.CustomResourceGroup,
+	Version: cockroachdbv1alpha1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cockroachdbv1alpha1.Restore{}).Name(),
+}
+
+// RestoreController creates a cluster for every restore and restores the databases of a backup into it
+type RestoreController struct {
+	context        *clusterd.Context
+	containerImage string
+	retryInterval  time.Duration
+}
+
+func NewRestoreController(context *clusterd.Context, containerImage string) *RestoreController {
+	return &RestoreController{
+		context:        context,
+		containerImage: containerImage,
+		retryInterval:  restoreRetryInterval,
+	}
+}
+
+func (c *RestoreController) StartWatch(namespace string, stopCh chan struct{}) error {
+	// a restore is only run when it is added, the updates of its spec are ignored
+	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+		AddFunc: c.onAdd,
+	}
+
+	logger.Infof("start watching cockroachdb restores in all namespaces")
+	watcher := opkit.NewWatcher(RestoreResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CockroachdbV1alpha1().RESTClient())
+	go watcher.Watch(&cockroachdbv1alpha1.Restore{}, stopCh)
+
+	return nil
+}
+
+func (c *RestoreController) onAdd(obj interface{}) {
+	restore := obj.(*cockroachdbv1alpha1.Restore).DeepCopy()
+
+	// the restores that finished are listed again when the operator restarts
+	if restore.Status.State == cockroachdbv1alpha1.RestoreStateCompleted || restore.Status.State == cockroachdbv1alpha1.RestoreStateError {
+		logger.Debugf("restore %s in namespace %s already finished", restore.Name, restore.Namespace)
+		return
+	}
+	logger.Infof("new restore %s added to namespace %s", restore.Name, restore.Namespace)
+
+	if err := validateRestoreSpec(restore.Spec); err != nil {
+		c.setRestoreError(restore, fmt.Sprintf("invalid restore spec: %+v", err))
+		return
+	}
+
+	// the restore takes up to an hour, the other restores are handled meanwhile
+	go c.runRestore(restore)
+}
+
+// runRestore creates the cluster of the restore and restores the backup into it once it is initialized, reporting the
+// progress in the restore status
+func (c *RestoreController) runRestore(restore *cockroachdbv1alpha1.Restore) {
+	if err := c.updateRestoreStatus(restore, cockroachdbv1alpha1.RestoreStateCreating, fmt.Sprintf("waiting for cluster %s to be initialized", restore.Spec.ClusterName)); err != nil {
+		logger.Errorf("failed to update restore status in namespace %s: %+v", restore.Namespace, err)
+	}
+
+	clusterObj, err := c.createCluster(restore)
+	if err != nil {
+		c.setRestoreError(restore, fmt.Sprintf("failed to create cluster %s: %+v", restore.Spec.ClusterName, err))
+		return
+	}
+
+	// the databases are restored once the cluster is initialized
+	err = wait.Poll(c.retryInterval, createInitTimeout+restoreRetryInterval, func() (bool, error) {
+		current, err := c.context.RookClientset.CockroachdbV1alpha1().Clusters(restore.Namespace).Get(restore.Spec.ClusterName, metav1.GetOptions{})
+		if err != nil {
+			logger.Warningf("failed to get cluster %s: %+v", restore.Spec.ClusterName, err)
+			return false, nil
+		}
+		switch current.Status.State {
+		case cockroachdbv1alpha1.ClusterStateCreated:
+			return true, nil
+		case cockroachdbv1alpha1.ClusterStateError:
+			return false, fmt.Errorf("cluster failed to start: %s", current.Status.Message)
+		}
+		logger.Infof("cluster %s is not yet created", restore.Spec.ClusterName)
+		return false, nil
+	})
+	if err != nil {
+		c.setRestoreError(restore, fmt.Sprintf("failed to create cluster %s: %+v", restore.Spec.ClusterName, err))
+		return
+	}
+
+	if err := c.updateRestoreStatus(restore, cockroachdbv1alpha1.RestoreStateRestoring, fmt.Sprintf("restoring backup of cluster %s", restore.Spec.Source.Cluster)); err != nil {
+		logger.Errorf("failed to update restore status in namespace %s: %+v", restore.Namespace, err)
+	}
+
+	cluster := newCluster(clusterObj, c.context)
+	if err := c.runRestoreJob(cluster, restore); err != nil {
+		c.setRestoreError(restore, fmt.Sprintf("failed to restore backup: %+v", err))
+		return
+	}
+
+	if err := c.updateRestoreStatus(restore, cockroachdbv1alpha1.RestoreStateCompleted, ""); err != nil {
+		logger.Errorf("failed to update restore status in namespace %s: %+v", restore.Namespace, err)
+	}
+	logger.Infof("succeeded restoring cluster %s in namespace %s", restore.Spec.ClusterName, restore.Namespace)
+}
+
+// createCluster creates the cluster of the restore, or returns the cluster that was created before the operator
+// restarted
+func (c *RestoreController) createCluster(restore *cockroachdbv1alpha1.Restore) (*cockroachdbv1alpha1.Cluster, error) {
+	if err := c.checkNamespaceAvailable(restore); err != nil {
+		return nil, err
+	}
+
+	clusterObj := &cockroachdbv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restore.Spec.ClusterName,
+			Namespace: restore.Namespace,
+		},
+		Spec: restore.Spec.Cluster,
+	}
+
+	created, err := c.context.RookClientset.CockroachdbV1alpha1().Clusters(restore.Namespace).Create(clusterObj)
+	if err == nil {
+		logger.Infof("cluster %s created in namespace %s", created.Name, created.Namespace)
+		return created, nil
+	}
+	if !errors.IsAlreadyExists(err) {
+		return nil, err
+	}
+	// a backup is never restored into a cluster that was not created by the restore
+	if restore.Status.State == "" {
+		return nil, fmt.Errorf("cluster %s already exists", restore.Spec.ClusterName)
+	}
+	return c.context.RookClientset.CockroachdbV1alpha1().Clusters(restore.Namespace).Get(restore.Spec.ClusterName, metav1.GetOptions{})
+}
+
+// checkNamespaceAvailable returns an error if the namespace of the restore already runs a cluster. The resources of a
+// cluster have fixed names in its namespace, a second cluster would take over the resources of the running cluster
+// and the backup would be restored into it.
+func (c *RestoreController) checkNamespaceAvailable(restore *cockroachdbv1alpha1.Restore) error {
+	// the cluster created by the restore before the operator restarted is the only one allowed
+	resuming := restore.Status.State != ""
+
+	clusters, err := c.context.RookClientset.CockroachdbV1alpha1().Clusters(restore.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list the clusters of namespace %s: %+v", restore.Namespace, err)
+	}
+	for _, clusterObj := range clusters.Items {
+		if !resuming || clusterObj.Name != restore.Spec.ClusterName {
+			return fmt.Errorf("namespace %s already has cluster %s", restore.Namespace, clusterObj.Name)
+		}
+	}
+
+	if !resuming {
+		_, err := c.context.Clientset.AppsV1().StatefulSets(restore.Namespace).Get(appName, metav1.GetOptions{})
+		if err == nil {
+			return fmt.Errorf("namespace %s already runs statefulset %s", restore.Namespace, appName)
+		}
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get statefulset %s: %+v", appName, err)
+		}
+	}
+	return nil
+}
+
+// runRestoreJob runs a job that restores the databases of the backup into the cluster and waits for it to finish
+func (c *RestoreController) runRestoreJob(cluster *cluster, restore *cockroachdbv1alpha1.Restore) error {
+	source := restore.Spec.Source
+	namespace := source.Namespace
+	if namespace == "" {
+		namespace = restore.Namespace
+	}
+	name := source.Backup
+	if name == "" {
+		name = backup.LatestBackup
+	}
+
+	env := []v1.EnvVar{{Name: "ROOK_NAME", Value: name}}
+	job, err := createBackupJob(cluster, c.containerImage, restoreAppName, []string{"cockroachdb", "backup", "restore"},
+		env, backupPrefix(namespace, source.Cluster), source.S3)
+	if err != nil {
+		return err
+	}
+	// the job of a restore that was interrupted by a restart of the operator keeps running
+	if err := k8sutil.RunReplaceableJob(c.context.Clientset, job, false); err != nil {
+		return err
+	}
+
+	return wait.Poll(c.retryInterval, restoreTimeout, func() (bool, error) {
+		job, err := c.context.Clientset.BatchV1().Jobs(cluster.namespace).Get(restoreAppName, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("failed to get restore job %s: %+v", restoreAppName, err)
+		}
+		return isJobFinished(job)
+	})
+}
+
+func (c *RestoreController) setRestoreError(restore *cockroachdbv1alpha1.Restore, message string) {
+	logger.Error(message)
+	if err := c.updateRestoreStatus(restore, cockroachdbv1alpha1.RestoreStateError, message); err != nil {
+		logger.Errorf("failed to update restore status in namespace %s: %+v", restore.Namespace, err)
+	}
+}
+
+func (c *RestoreController) updateRestoreStatus(restore *cockroachdbv1alpha1.Restore, state cockroachdbv1alpha1.RestoreState, message string) error {
+	// get the most recent restore CRD object
+	restoreObj, err := c.context.RookClientset.CockroachdbV1alpha1().Restores(restore.Namespace).Get(restore.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get restore %s prior to updating its status: %+v", restore.Name, err)
+	}
+
+	restoreObj.Status.State = state
+	restoreObj.Status.Message = message
+	if _, err := c.context.RookClientset.CockroachdbV1alpha1().Restores(restore.Namespace).Update(restoreObj); err != nil {
+		return fmt.Errorf("failed to update restore %s status: %+v", restore.Name, err)
+	}
+	return nil
+}
+
+func validateRestoreSpec(spec cockroachdbv1alpha1.RestoreSpec) error {
+	if spec.ClusterName == "" {
+		return fmt.Errorf("the name of the cluster is required")
+	}
+	if spec.Source.Cluster == "" {
+		return fmt.Errorf("the cluster the backup was taken from is required")
+	}
+	if err := validateS3Spec(spec.Source.S3); err != nil {
+		return err
+	}
+	return validateClusterSpec(spec.Cluster)
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cockroachdb
+
+import (
+	"testing"
+	"time"
+
+	cockroachdbv1alpha1 "github.com/rook/rook/pkg/apis/cockroachdb.rook.io/v1alpha1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func newRestoreForTest(namespace string) *cockroachdbv1alpha1.Restore {
+	return &cockroachdbv1alpha1.Restore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore-832", Namespace: namespace},
+		Spec: cockroachdbv1alpha1.RestoreSpec{
+			ClusterName: "cluster-832",
+			Cluster:     cockroachdbv1alpha1.ClusterSpec{Storage: rookalpha.StorageScopeSpec{NodeCount: 3}},
+			Source: cockroachdbv1alpha1.BackupSource{
+				Cluster:   "cluster-831",
+				Namespace: "rook-cockroachdb-324",
+				Backup:    "20190102-020100",
+				S3:        cockroachdbv1alpha1.S3Spec{Endpoint: "http://minio:9000", Bucket: "backups", SecretName: "s3-credentials"},
+			},
+		},
+	}
+}
+
+func getRestoreState(t *testing.T, context *clusterd.Context, namespace, name string) cockroachdbv1alpha1.RestoreState {
+	restore, err := context.RookClientset.CockroachdbV1alpha1().Restores(namespace).Get(name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	return restore.Status.State
+}
+
+// waitRestoreState waits for the restore, which runs in the background, to reach a state
+func waitRestoreState(t *testing.T, context *clusterd.Context, namespace, name string, state cockroachdbv1alpha1.RestoreState) {
+	err := wait.Poll(time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		restore, err := context.RookClientset.CockroachdbV1alpha1().Restores(namespace).Get(name, metav1.GetOptions{})
+		return err == nil && restore.Status.State == state, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, state, getRestoreState(t, context, namespace, name))
+}
+
+func TestRestoreOnAdd(t *testing.T) {
+	namespace := "rook-cockroachdb-325"
+	restore := newRestoreForTest(namespace)
+	context := &clusterd.Context{Clientset: testop.New(3), RookClientset: rookfake.NewSimpleClientset(restore)}
+	controller := NewRestoreController(context, "rook/cockroachdb:mockTag")
+	controller.retryInterval = time.Millisecond
+
+	// simulate the cluster controller and the restore job
+	go func() {
+		wait.Poll(time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+			clusterObj, err := context.RookClientset.CockroachdbV1alpha1().Clusters(namespace).Get("cluster-832", metav1.GetOptions{})
+			if err != nil {
+				return false, nil
+			}
+			clusterObj.Status.State = cockroachdbv1alpha1.ClusterStateCreated
+			_, err = context.RookClientset.CockroachdbV1alpha1().Clusters(namespace).Update(clusterObj)
+			return err == nil, nil
+		})
+		wait.Poll(time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+			job, err := context.Clientset.BatchV1().Jobs(namespace).Get(restoreAppName, metav1.GetOptions{})
+			if err != nil {
+				return false, nil
+			}
+			job.Status.Succeeded = 1
+			_, err = context.Clientset.BatchV1().Jobs(namespace).Update(job)
+			return err == nil, nil
+		})
+	}()
+
+	controller.onAdd(restore)
+	waitRestoreState(t, context, namespace, restore.Name, cockroachdbv1alpha1.RestoreStateCompleted)
+
+	// the cluster is created with the spec of the restore
+	clusterObj, err := context.RookClientset.CockroachdbV1alpha1().Clusters(namespace).Get("cluster-832", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, clusterObj.Spec.Storage.NodeCount)
+
+	// the backup of the source cluster is restored
+	job, err := context.Clientset.BatchV1().Jobs(namespace).Get(restoreAppName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, []string{"cockroachdb", "backup", "restore"}, container.Args)
+	env := map[string]v1.EnvVar{}
+	for _, e := range container.Env {
+		env[e.Name] = e
+	}
+	assert.Equal(t, "20190102-020100", env["ROOK_NAME"].Value)
+	assert.Equal(t, "rook-cockroachdb-324/cluster-831", env["ROOK_PREFIX"].Value)
+	assert.Equal(t, "cockroachdb-public.rook-cockroachdb-325", env["ROOK_HOST"].Value)
+	assert.Equal(t, "", env["ROOK_CERTS_DIR"].Value)
+}
+
+func TestRestoreOnAddExistingCluster(t *testing.T) {
+	namespace := "rook-cockroachdb-326"
+	restore := newRestoreForTest(namespace)
+	existing := &cockroachdbv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster-832", Namespace: namespace}}
+	context := &clusterd.Context{Clientset: testop.New(3), RookClientset: rookfake.NewSimpleClientset(restore, existing)}
+	controller := NewRestoreController(context, "rook/cockroachdb:mockTag")
+
+	// a backup is never restored into a cluster that already exists
+	controller.onAdd(restore)
+	waitRestoreState(t, context, namespace, restore.Name, cockroachdbv1alpha1.RestoreStateError)
+	_, err := context.Clientset.BatchV1().Jobs(namespace).Get(restoreAppName, metav1.GetOptions{})
+	assert.NotNil(t, err)
+}
+
+func TestRestoreOnAddNamespaceInUse(t *testing.T) {
+	namespace := "rook-cockroachdb-327"
+
+	// the namespace has another cluster
+	restore := newRestoreForTest(namespace)
+	other := &cockroachdbv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster-831", Namespace: namespace}}
+	context := &clusterd.Context{Clientset: testop.New(3), RookClientset: rookfake.NewSimpleClientset(restore, other)}
+	controller := NewRestoreController(context, "rook/cockroachdb:mockTag")
+	controller.onAdd(restore)
+	waitRestoreState(t, context, namespace, restore.Name, cockroachdbv1alpha1.RestoreStateError)
+	_, err := context.RookClientset.CockroachdbV1alpha1().Clusters(namespace).Get("cluster-832", metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	// the namespace runs the statefulset of a cluster
+	restore = newRestoreForTest(namespace)
+	context = &clusterd.Context{Clientset: testop.New(3), RookClientset: rookfake.NewSimpleClientset(restore)}
+	_, err = context.Clientset.AppsV1().StatefulSets(namespace).Create(&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: appName, Namespace: namespace}})
+	assert.Nil(t, err)
+	controller = NewRestoreController(context, "rook/cockroachdb:mockTag")
+	controller.onAdd(restore)
+	waitRestoreState(t, context, namespace, restore.Name, cockroachdbv1alpha1.RestoreStateError)
+	_, err = context.RookClientset.CockroachdbV1alpha1().Clusters(namespace).Get("cluster-832", metav1.GetOptions{})
+	assert.NotNil(t, err)
+	_, err = context.Clientset.BatchV1().Jobs(namespace).Get(restoreAppName, metav1.GetOptions{})
+	assert.NotNil(t, err)
+}
+
+func TestValidateRestoreSpec(t *testing.T) {
+	assert.Nil(t, validateRestoreSpec(newRestoreForTest("ns").Spec))
+
+	spec := newRestoreForTest("ns").Spec
+	spec.ClusterName = ""
+	assert.NotNil(t, validateRestoreSpec(spec))
+
+	spec = newRestoreForTest("ns").Spec
+	spec.Source.Cluster = ""
+	assert.NotNil(t, validateRestoreSpec(spec))
+
+	spec = newRestoreForTest("ns").Spec
+	spec.Source.S3.Bucket = ""
+	assert.NotNil(t, validateRestoreSpec(spec))
+
+	spec = newRestoreForTest("ns").Spec
+	spec.Cluster.Storage.NodeCount = 0
+	assert.NotNil(t, validateRestoreSpec(spec))
+}
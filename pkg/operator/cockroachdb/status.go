@@ -0,0 +1,265 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cockroachdb
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cockroachdbv1alpha1 "github.com/rook/rook/pkg/apis/cockroachdb.rook.io/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	statusInterval    = 1 * time.Minute
+	adminHTTPTimeout  = 10 * time.Second
+	adminNodesPath    = "/_status/nodes"
+	adminLivenessPath = "/_admin/v1/liveness"
+	clientServiceName = "cockroachdb-public"
+	// the nodes of a secure cluster are read through SQL, as its admin endpoints require a login
+	nodeStatusQuery = "SELECT n.node_id, n.address, n.is_live, l.draining, l.decommissioning " +
+		"FROM crdb_internal.gossip_nodes n JOIN crdb_internal.gossip_liveness l ON n.node_id = l.node_id"
+)
+
+// nodesResponse is the part of the response of the nodes endpoint of the admin UI that the operator uses
+type nodesResponse struct {
+	Nodes []struct {
+		Desc struct {
+			NodeID  int `json:"node_id"`
+			Address struct {
+				AddressField string `json:"address_field"`
+			} `json:"address"`
+		} `json:"desc"`
+	} `json:"nodes"`
+}
+
+// livenessResponse is the part of the response of the liveness endpoint of the admin UI that the operator uses
+type livenessResponse struct {
+	Livenesses []struct {
+		NodeID     int `json:"node_id"`
+		Expiration struct {
+			WallTime int64 `json:"wall_time,string"`
+		} `json:"expiration"`
+		Draining        bool `json:"draining"`
+		Decommissioning bool `json:"decommissioning"`
+	} `json:"livenesses"`
+}
+
+// creates the address of the admin UI of a cluster, served by any node behind the client service
+func createAdminAddress(namespace string, httpPort int32) string {
+	return fmt.Sprintf("http://%s.%s:%d", clientServiceName, namespace, httpPort)
+}
+
+// getNodeStatuses returns the nodes of the cluster and their liveness, sorted by node ID
+func (c *ClusterController) getNodeStatuses(cluster *cluster) ([]cockroachdbv1alpha1.NodeStatus, error) {
+	if cluster.spec.Secure {
+		return c.getSecureNodeStatuses(cluster)
+	}
+
+	httpPort, _, err := getPortsFromSpec(cluster.spec.Network)
+	if err != nil {
+		return nil, err
+	}
+	address := c.adminAddress(cluster.namespace, httpPort)
+
+	var nodes nodesResponse
+	if err := c.getAdminJSON(address+adminNodesPath, &nodes); err != nil {
+		return nil, err
+	}
+	var liveness livenessResponse
+	if err := c.getAdminJSON(address+adminLivenessPath, &liveness); err != nil {
+		return nil, err
+	}
+
+	statuses := map[int]*cockroachdbv1alpha1.NodeStatus{}
+	for _, node := range nodes.Nodes {
+		statuses[node.Desc.NodeID] = &cockroachdbv1alpha1.NodeStatus{
+			NodeID:  node.Desc.NodeID,
+			Address: node.Desc.Address.AddressField,
+		}
+	}
+	now := time.Now().UnixNano()
+	for _, l := range liveness.Livenesses {
+		status, ok := statuses[l.NodeID]
+		if !ok {
+			continue
+		}
+		// a node is live until its last heartbeat expires
+		status.Live = l.Expiration.WallTime > now
+		status.Draining = l.Draining
+		status.Decommissioning = l.Decommissioning
+	}
+
+	result := []cockroachdbv1alpha1.NodeStatus{}
+	for _, status := range statuses {
+		result = append(result, *status)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].NodeID < result[j].NodeID })
+	return result, nil
+}
+
+// getSecureNodeStatuses returns the nodes of a secure cluster and their liveness, sorted by node ID
+func (c *ClusterController) getSecureNodeStatuses(cluster *cluster) ([]cockroachdbv1alpha1.NodeStatus, error) {
+	clientFlags, err := c.getClientFlags(cluster)
+	if err != nil {
+		return nil, err
+	}
+	args := append([]string{"sql", "--format=csv", "-e", nodeStatusQuery + " ORDER BY n.node_id"}, clientFlags...)
+	args = append(args, fmt.Sprintf("--host=%s", createQualifiedReplicaServiceName(0, cluster.namespace)))
+	out, err := c.context.Executor.ExecuteCommandWithOutput(false, "cockroachdb node status", "/cockroach/cockroach", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query node status: %+v. %s", err, out)
+	}
+
+	return parseNodeStatuses(out)
+}
+
+// parseNodeStatuses parses the csv output of the node status query, which starts with a header
+func parseNodeStatuses(out string) ([]cockroachdbv1alpha1.NodeStatus, error) {
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse node status: %+v", err)
+	}
+
+	nodes := []cockroachdbv1alpha1.NodeStatus{}
+	for i, record := range records {
+		if i == 0 {
+			continue
+		}
+		if len(record) != 5 {
+			return nil, fmt.Errorf("unexpected node status %v", record)
+		}
+		nodeID, err := strconv.Atoi(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid node id %s", record[0])
+		}
+		node := cockroachdbv1alpha1.NodeStatus{NodeID: nodeID, Address: record[1]}
+		for j, field := range []*bool{&node.Live, &node.Draining, &node.Decommissioning} {
+			if *field, err = strconv.ParseBool(record[j+2]); err != nil {
+				return nil, fmt.Errorf("invalid node status %v", record)
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func (c *ClusterController) getAdminJSON(url string, v interface{}) error {
+	client := http.Client{Timeout: adminHTTPTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %+v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get %s: status %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %+v", url, err)
+	}
+	return nil
+}
+
+// findNodeID returns the ID of the node of the given replica of the stateful set, or 0 if the replica
+// never joined the cluster
+func findNodeID(nodes []cockroachdbv1alpha1.NodeStatus, replicaNum int, namespace string) int {
+	host := createQualifiedReplicaServiceName(replicaNum, namespace)
+	for _, node := range nodes {
+		if strings.Split(node.Address, ":")[0] == host {
+			return node.NodeID
+		}
+	}
+	return 0
+}
+
+// monitorClusters periodically reports the liveness of the nodes of every cluster in their status, renews the
+// certificates of the secure clusters that expire soon and runs the scheduled backups
+func (c *ClusterController) monitorClusters(namespace string, stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			logger.Infof("stopping monitoring of cockroachdb clusters")
+			return
+		case <-time.After(statusInterval):
+			clusters, err := c.context.RookClientset.CockroachdbV1alpha1().Clusters(namespace).List(metav1.ListOptions{})
+			if err != nil {
+				logger.Warningf("failed to list cockroachdb clusters: %+v", err)
+				continue
+			}
+			for i := range clusters.Items {
+				cluster := newCluster(&clusters.Items[i], c.context)
+				if err := c.renewCertificates(cluster); err != nil {
+					logger.Warningf("failed to renew the certificates of cluster %s in namespace %s: %+v", cluster.name, cluster.namespace, err)
+				}
+				if err := c.updateNodeStatus(cluster); err != nil {
+					logger.Warningf("failed to update the node status of cluster %s in namespace %s: %+v", cluster.name, cluster.namespace, err)
+				}
+				if err := c.syncBackup(&clusters.Items[i], time.Now()); err != nil {
+					logger.Warningf("failed to back up cluster %s in namespace %s: %+v", cluster.name, cluster.namespace, err)
+				}
+			}
+		}
+	}
+}
+
+// updateNodeStatus reports the liveness of the nodes of the cluster in its status
+func (c *ClusterController) updateNodeStatus(cluster *cluster) error {
+	name := cluster.name
+	nodes, err := c.getNodeStatuses(cluster)
+	if err != nil {
+		return err
+	}
+
+	clusterObj, err := c.context.RookClientset.CockroachdbV1alpha1().Clusters(cluster.namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster %s prior to updating its status: %+v", name, err)
+	}
+	// the status is only written when it changed, as every update of the cluster object is watched
+	if reflect.DeepEqual(clusterObj.Status.Nodes, nodes) {
+		return nil
+	}
+	clusterObj.Status.Nodes = nodes
+	if _, err := c.context.RookClientset.CockroachdbV1alpha1().Clusters(cluster.namespace).Update(clusterObj); err != nil {
+		return fmt.Errorf("failed to update cluster %s status: %+v", name, err)
+	}
+	return nil
+}
+
+func (c *ClusterController) updateClusterStatus(namespace, name string, state cockroachdbv1alpha1.ClusterState, message string) error {
+	// get the most recent cluster CRD object
+	cluster, err := c.context.RookClientset.CockroachdbV1alpha1().Clusters(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster from namespace %s prior to updating its status: %+v", namespace, err)
+	}
+
+	// update the state on the retrieved cluster object, the nodes are reported by the status monitor
+	cluster.Status.State = state
+	cluster.Status.Message = message
+	if _, err := c.context.RookClientset.CockroachdbV1alpha1().Clusters(cluster.namespace).Update(cluster); err != nil {
+		return fmt.Errorf("failed to update cluster %s status: %+v", cluster.Name, err)
+	}
+
+	return nil
+}
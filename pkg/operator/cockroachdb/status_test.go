@@ -0,0 +1,136 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cockroachdb
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cockroachdbv1alpha1 "github.com/rook/rook/pkg/apis/cockroachdb.rook.io/v1alpha1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newFakeAdminServer serves the admin endpoints of a cluster with a node for each replica of the stateful set. The
+// node ID of a replica is its index plus one, and the last node is not live anymore.
+func newFakeAdminServer(namespace string, nodeCount int) *httptest.Server {
+	nodes := []string{}
+	livenesses := []string{}
+	for i := 0; i < nodeCount; i++ {
+		nodes = append(nodes, fmt.Sprintf(`{"desc": {"node_id": %d, "address": {"network_field": "tcp", "address_field": "%s:26257"}}}`,
+			i+1, createQualifiedReplicaServiceName(i, namespace)))
+		expiration := time.Now().Add(time.Hour)
+		if i == nodeCount-1 {
+			expiration = time.Now().Add(-time.Hour)
+		}
+		livenesses = append(livenesses, fmt.Sprintf(`{"node_id": %d, "epoch": "1", "expiration": {"wall_time": "%d", "logical": 0}, "draining": false, "decommissioning": %t}`,
+			i+1, expiration.UnixNano(), i == 0))
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case adminNodesPath:
+			fmt.Fprintf(w, `{"nodes": [%s]}`, strings.Join(nodes, ","))
+		case adminLivenessPath:
+			fmt.Fprintf(w, `{"livenesses": [%s]}`, strings.Join(livenesses, ","))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestGetNodeStatuses(t *testing.T) {
+	namespace := "rook-cockroachdb-318"
+	server := newFakeAdminServer(namespace, 3)
+	defer server.Close()
+
+	context := &clusterd.Context{}
+	controller := NewClusterController(context, "rook/cockroachdb:mockTag")
+	controller.adminAddress = func(namespace string, httpPort int32) string { return server.URL }
+	cluster := newCluster(&cockroachdbv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-827", Namespace: namespace},
+		Spec:       cockroachdbv1alpha1.ClusterSpec{Storage: rookalpha.StorageScopeSpec{NodeCount: 3}},
+	}, context)
+
+	nodes, err := controller.getNodeStatuses(cluster)
+	assert.Nil(t, err)
+	expectedNodes := []cockroachdbv1alpha1.NodeStatus{
+		{NodeID: 1, Address: "rook-cockroachdb-0.rook-cockroachdb.rook-cockroachdb-318:26257", Live: true, Decommissioning: true},
+		{NodeID: 2, Address: "rook-cockroachdb-1.rook-cockroachdb.rook-cockroachdb-318:26257", Live: true},
+		{NodeID: 3, Address: "rook-cockroachdb-2.rook-cockroachdb.rook-cockroachdb-318:26257", Live: false},
+	}
+	assert.Equal(t, expectedNodes, nodes)
+
+	// the nodes are found by the replica of the stateful set they run in
+	assert.Equal(t, 2, findNodeID(nodes, 1, namespace))
+	assert.Equal(t, 0, findNodeID(nodes, 3, namespace))
+
+	// the admin endpoint is not reachable
+	server.Close()
+	_, err = controller.getNodeStatuses(cluster)
+	assert.NotNil(t, err)
+}
+
+func TestUpdateNodeStatus(t *testing.T) {
+	namespace := "rook-cockroachdb-319"
+	server := newFakeAdminServer(namespace, 3)
+	defer server.Close()
+
+	clusterObj := &cockroachdbv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-828", Namespace: namespace},
+		Spec:       cockroachdbv1alpha1.ClusterSpec{Storage: rookalpha.StorageScopeSpec{NodeCount: 3}},
+		Status:     cockroachdbv1alpha1.ClusterStatus{State: cockroachdbv1alpha1.ClusterStateCreated},
+	}
+	rookClientset := rookfake.NewSimpleClientset(clusterObj)
+	context := &clusterd.Context{RookClientset: rookClientset}
+	controller := NewClusterController(context, "rook/cockroachdb:mockTag")
+	controller.adminAddress = func(namespace string, httpPort int32) string { return server.URL }
+
+	assert.Nil(t, controller.updateNodeStatus(newCluster(clusterObj, context)))
+
+	// the nodes are reported without changing the state of the cluster
+	clusterObj, err := rookClientset.CockroachdbV1alpha1().Clusters(namespace).Get(clusterObj.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cockroachdbv1alpha1.ClusterStateCreated, clusterObj.Status.State)
+	assert.Equal(t, 3, len(clusterObj.Status.Nodes))
+	assert.True(t, clusterObj.Status.Nodes[1].Live)
+	assert.False(t, clusterObj.Status.Nodes[2].Live)
+}
+
+func TestParseNodeStatuses(t *testing.T) {
+	out := `node_id,address,is_live,draining,decommissioning
+1,rook-cockroachdb-0.rook-cockroachdb.rook-cockroachdb:26257,true,false,false
+2,rook-cockroachdb-1.rook-cockroachdb.rook-cockroachdb:26257,false,true,true
+`
+	nodes, err := parseNodeStatuses(out)
+	assert.Nil(t, err)
+	expectedNodes := []cockroachdbv1alpha1.NodeStatus{
+		{NodeID: 1, Address: "rook-cockroachdb-0.rook-cockroachdb.rook-cockroachdb:26257", Live: true},
+		{NodeID: 2, Address: "rook-cockroachdb-1.rook-cockroachdb.rook-cockroachdb:26257", Live: false, Draining: true, Decommissioning: true},
+	}
+	assert.Equal(t, expectedNodes, nodes)
+
+	// unexpected output
+	_, err = parseNodeStatuses("node_id,address\n1,foo\n")
+	assert.NotNil(t, err)
+}
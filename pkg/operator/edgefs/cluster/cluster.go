@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"sync"
 
 	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
 	rookv1alpha2 "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
@@ -27,6 +28,7 @@ import (
 	"github.com/rook/rook/pkg/operator/edgefs/cluster/mgr"
 	"github.com/rook/rook/pkg/operator/edgefs/cluster/prepare"
 	"github.com/rook/rook/pkg/operator/edgefs/cluster/target"
+	"github.com/rook/rook/pkg/operator/edgefs/cluster/validate"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -50,23 +52,51 @@ const (
 
 type cluster struct {
 	context   *clusterd.Context
+	Name      string
 	Namespace string
 	Spec      edgefsv1beta1.ClusterSpec
 	ownerRef  metav1.OwnerReference
 	targets   *target.Cluster
 	mgrs      *mgr.Cluster
-	stopCh    chan struct{}
+	// stopCh stops the watchers of the services of the cluster
+	stopCh chan struct{}
+	// image is the EdgeFS image the cluster runs, the previous image until an upgrade is complete
+	image string
+	// servicesImage is the EdgeFS image the services created by the watchers run
+	servicesImage string
+	restapi       *mgr.RestAPIClient
+	// lock serializes the creation and the updates of the cluster
+	lock sync.Mutex
+	// updates counts the updates of the cluster received, only the latest one is applied
+	updates int
+	// evacuateDevice moves the data off a device of a node removed from the cluster
+	evacuateDevice func(rookImage, nodeName string, containerIndex int, deviceName string) error
+	// evacuating is true while the removed nodes are evacuated in the background
+	evacuating     bool
+	evacuationLock sync.Mutex
+	// onEvacuated updates the cluster once the removed nodes are evacuated
+	onEvacuated func()
+	// deleteCh stops the background steps of the cluster when it is deleted
+	deleteCh chan struct{}
+	// validateNode returns the checks failed by the host of a node prior to the deployment of its target
+	validateNode func(rookImage, nodeName string, requirements validate.Requirements) ([]string, error)
 }
 
 func newCluster(c *edgefsv1beta1.Cluster, context *clusterd.Context) *cluster {
 
-	return &cluster{
+	clust := &cluster{
 		context:   context,
+		Name:      c.Name,
 		Namespace: c.Namespace,
 		Spec:      c.Spec,
 		stopCh:    make(chan struct{}),
+		deleteCh:  make(chan struct{}),
 		ownerRef:  ClusterOwnerRef(c.Namespace, string(c.UID)),
+		restapi:   mgr.NewRestAPIClient(mgr.RestAPIAddress(c.Namespace)),
 	}
+	clust.evacuateDevice = clust.runEvacuation
+	clust.validateNode = clust.runValidation
+	return clust
 }
 
 func (c *cluster) createInstance(rookImage string) error {
@@ -96,32 +126,58 @@ func (c *cluster) createInstance(rookImage string) error {
 		return fmt.Errorf("failed to create override configmap %s. %+v", c.Namespace, err)
 	}
 
+	dro := ParseDevicesResurrectMode(c.Spec.DevicesResurrectMode)
+	logger.Infof("DevicesResurrect mode: %s options %+v", c.Spec.DevicesResurrectMode, dro)
+
+	// Devices layouts of the nodes of a previous deployment, if any
+	var layouts map[string]edgefsv1beta1.SetupNode
+	if !dro.NeedToResurrect {
+		layouts, err = c.getNodeLayouts()
+		if err != nil {
+			return err
+		}
+	}
+	// Nodes removed from the spec, before the nodes are narrowed down to the valid ones
+	removedNodes := getRemovedNodes(layouts, c.Spec.Storage)
+
 	clusterNodes, err := c.getClusterNodes()
 	if err != nil {
 		return fmt.Errorf("failed to get nodes for cluster %s. %s", c.Namespace, err)
 	}
 
-	dro := ParseDevicesResurrectMode(c.Spec.DevicesResurrectMode)
-	logger.Infof("DevicesResurrect mode: %s options %+v", c.Spec.DevicesResurrectMode, dro)
-
-	deploymentConfig, err := c.createDeploymentConfig(clusterNodes, dro.NeedToResurrect)
+	deploymentConfig, err := c.createDeploymentConfig(clusterNodes, layouts, dro.NeedToResurrect)
 	if err != nil {
 		logger.Errorf("Failed to create deploymentConfig %+v", err)
 		return err
 	}
 	logger.Debugf("DeploymentConfig: %+v ", deploymentConfig)
 
-	if err := c.createClusterConfigMap(clusterNodes, deploymentConfig, dro.NeedToResurrect); err != nil {
-		logger.Errorf("Failed to create/update Edgefs cluster configuration: %+v", err)
-		return err
+	//
+	// Evacuate the nodes removed from the cluster before they are configured out of it. The evacuation runs in the
+	// background and the cluster is configured once it completes.
+	//
+	if len(removedNodes) > 0 {
+		logger.Infof("removing nodes %v from cluster %s", removedNodes, c.Namespace)
+		if err := c.validateNodeRemoval(layouts, removedNodes); err != nil {
+			return err
+		}
+		evacuated, err := c.startEvacuation(rookImage, layouts, removedNodes)
+		if err != nil {
+			return fmt.Errorf("failed to evacuate nodes %v. %+v", removedNodes, err)
+		}
+		if !evacuated {
+			logger.Infof("cluster %s is configured once nodes %v are evacuated", c.Namespace, removedNodes)
+			return nil
+		}
 	}
+
 	//
 	// Create and start EdgeFS prepare job (set some networking parameters that we cannot set via InitContainers)
 	// Skip preparation job in case of resurrect option is on
 	//
 
 	if c.Spec.SkipHostPrepare == false && dro.NeedToResurrect == false {
-		err = c.prepareHostNodes(rookImage, deploymentConfig)
+		err = c.prepareHostNodes(rookImage, deploymentConfig, layouts)
 		if err != nil {
 			logger.Errorf("Failed to create preparation jobs. %+v", err)
 		}
@@ -129,6 +185,21 @@ func (c *cluster) createInstance(rookImage string) error {
 		logger.Infof("EdgeFS node preparation will be skipped due skipHostPrepare=true or resurrect cluster option")
 	}
 
+	//
+	// Validate the hosts of the new nodes before their targets are deployed. The nodes are only recorded in the
+	// cluster configuration once they pass, as the nodes of the configuration are not validated again.
+	//
+	if !c.Spec.SkipHostValidation && !dro.NeedToResurrect {
+		if err := c.validateHostNodes(rookImage, deploymentConfig, layouts); err != nil {
+			return err
+		}
+	}
+
+	if err := c.createClusterConfigMap(clusterNodes, deploymentConfig, dro.NeedToResurrect); err != nil {
+		logger.Errorf("Failed to create/update Edgefs cluster configuration: %+v", err)
+		return err
+	}
+
 	//
 	// Create and start EdgeFS Targets StatefulSet
 	//
@@ -142,12 +213,18 @@ func (c *cluster) createInstance(rookImage string) error {
 		return fmt.Errorf("failed to start the targets. %+v", err)
 	}
 
+	if len(removedNodes) > 0 {
+		if err := c.removeNodes(removedNodes); err != nil {
+			return fmt.Errorf("failed to remove nodes %v. %+v", removedNodes, err)
+		}
+	}
+
 	//
 	// Create and start EdgeFS manager Deployment (gRPC proxy, Prometheus metrics)
 	//
 	c.mgrs = mgr.New(c.context, c.Namespace, "latest", c.Spec.ServiceAccount, c.Spec.DataDirHostPath, c.Spec.DataVolumeSize,
 		edgefsv1beta1.GetMgrAnnotations(c.Spec.Annotations), edgefsv1beta1.GetMgrPlacement(c.Spec.Placement), c.Spec.Network, c.Spec.Dashboard,
-		v1.ResourceRequirements{}, c.Spec.ResourceProfile, c.ownerRef)
+		c.Spec.Monitoring, v1.ResourceRequirements{}, c.Spec.ResourceProfile, c.ownerRef)
 	err = c.mgrs.Start(rookImage)
 	if err != nil {
 		return fmt.Errorf("failed to start the edgefs mgr. %+v", err)
@@ -157,12 +234,16 @@ func (c *cluster) createInstance(rookImage string) error {
 	return nil
 }
 
-func (c *cluster) prepareHostNodes(rookImage string, deploymentConfig edgefsv1beta1.ClusterDeploymentConfig) error {
+// prepareHostNodes prepares the nodes not prepared yet by a previous deployment
+func (c *cluster) prepareHostNodes(rookImage string, deploymentConfig edgefsv1beta1.ClusterDeploymentConfig, layouts map[string]edgefsv1beta1.SetupNode) error {
 
 	prep := prepare.New(c.context, c.Namespace, "latest", c.Spec.ServiceAccount,
 		edgefsv1beta1.GetPrepareAnnotations(c.Spec.Annotations), edgefsv1beta1.GetPreparePlacement(c.Spec.Placement), v1.ResourceRequirements{}, c.ownerRef)
 
 	for nodeName, devicesConfig := range deploymentConfig.DevConfig {
+		if _, ok := layouts[nodeName]; ok {
+			continue
+		}
 
 		logger.Debugf("HostNodePreparation %s devConfig: %+v", nodeName, devicesConfig)
 		err := prep.Start(rookImage, nodeName)
@@ -246,5 +327,20 @@ func clusterChanged(oldCluster, newCluster edgefsv1beta1.ClusterSpec) bool {
 		changeFound = true
 	}
 
+	if oldCluster.EdgefsImageName != newCluster.EdgefsImageName {
+		logger.Infof("The EdgeFS image has changed from %s to %s", oldCluster.EdgefsImageName, newCluster.EdgefsImageName)
+		changeFound = true
+	}
+
+	if oldCluster.Upgrade != newCluster.Upgrade {
+		logger.Infof("The upgrade settings have changed")
+		changeFound = true
+	}
+
+	if !reflect.DeepEqual(oldCluster.Monitoring, newCluster.Monitoring) {
+		logger.Infof("The monitoring settings have changed")
+		changeFound = true
+	}
+
 	return changeFound
 }
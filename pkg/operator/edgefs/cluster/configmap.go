@@ -63,6 +63,9 @@ func (c *cluster) createClusterConfigMap(nodes []rookalpha.Node, deploymentConfi
 		}
 	}
 
+	// The replicas are placed across the zones, or the hosts, of the cluster
+	failureDomain := getFailureDomain(getReplicaPlacement(&deploymentConfig, c.Spec.Zoning))
+
 	// Fully resolve the storage config and resources for all nodes
 	for _, node := range nodes {
 		devConfig := deploymentConfig.DevConfig[node.Name]
@@ -91,19 +94,14 @@ func (c *cluster) createClusterConfigMap(nodes []rookalpha.Node, deploymentConfi
 			rtSlaveDevices = make([]edgefsv1beta1.RTDevices, 0)
 			rtlfsDevices = make([]edgefsv1beta1.RtlfsDevice, 0)
 		}
-		// Set failureDomain to 2 if current node's zone > 0
-		failureDomain := 1
-		if devConfig.Zone > 0 {
-			failureDomain = 2
-		}
-
 		nodeConfig := edgefsv1beta1.SetupNode{
 			Ccow: edgefsv1beta1.CcowConf{
 				Trlog: edgefsv1beta1.CcowTrlog{
 					Interval: defaultTrlogProcessingInterval,
 				},
 				Tenant: edgefsv1beta1.CcowTenant{
-					FailureDomain: failureDomain,
+					FailureDomain:    failureDomain,
+					ReplicationCount: c.Spec.Zoning.ReplicationCount,
 				},
 				Network: edgefsv1beta1.CcowNetwork{
 					BrokerInterfaces: brokerIfName,
@@ -22,6 +22,7 @@ package cluster
 import (
 	"fmt"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/coreos/pkg/capnslog"
@@ -73,6 +74,8 @@ type ClusterController struct {
 	containerImage string
 	devicesInUse   bool
 	clusterMap     map[string]*cluster
+	// lock protects the clusters of the namespaces, which are created and updated concurrently
+	lock sync.Mutex
 }
 
 func NewClusterController(context *clusterd.Context, containerImage string) *ClusterController {
@@ -111,8 +114,11 @@ func (c *ClusterController) StartWatch(namespace string, stopCh chan struct{}) e
 }
 
 func (c *ClusterController) StopWatch() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	for _, cluster := range c.clusterMap {
 		close(cluster.stopCh)
+		close(cluster.deleteCh)
 	}
 	c.clusterMap = make(map[string]*cluster)
 }
@@ -122,28 +128,56 @@ func (c *ClusterController) onAdd(obj interface{}) {
 	logger.Infof("new cluster %s added to namespace %s", clusterObj.Name, clusterObj.Namespace)
 
 	cluster := newCluster(clusterObj, c.context)
-	c.clusterMap[cluster.Namespace] = cluster
-
-	//Override rook containerImage value
-	c.containerImage = defaultEdgefsImageName
-	if cluster.Spec.EdgefsImageName != "" {
-		c.containerImage = cluster.Spec.EdgefsImageName
+	cluster.onEvacuated = func() { c.onEvacuated(cluster) }
+	if err := c.addCluster(cluster); err != nil {
+		logger.Error(err.Error())
+		if err := c.updateClusterStatus(clusterObj.Namespace, clusterObj.Name, edgefsv1beta1.ClusterStateError, err.Error()); err != nil {
+			logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.Namespace, err)
+		}
+		return
 	}
 
-	logger.Infof("starting cluster in namespace %s", cluster.Namespace)
+	// the clusters of the namespaces are created concurrently, the creation of a cluster takes minutes
+	go c.startCluster(clusterObj, cluster)
+}
+
+// addCluster adds the cluster of a namespace to the clusters of the operator. A cluster shares the nodes with the
+// clusters of the other namespaces, but not their devices nor their data directories.
+func (c *ClusterController) addCluster(cluster *cluster) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 
 	if c.devicesInUse && cluster.Spec.Storage.AnyUseAllDevices() {
-		message := "using all devices in more than one namespace not supported"
-		logger.Error(message)
-		if err := c.updateClusterStatus(clusterObj.Namespace, clusterObj.Name, edgefsv1beta1.ClusterStateError, message); err != nil {
-			logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.Namespace, err)
+		return fmt.Errorf("using all devices in more than one namespace not supported")
+	}
+	for namespace, other := range c.clusterMap {
+		if namespace != cluster.Namespace && cluster.Spec.DataDirHostPath != "" && other.Spec.DataDirHostPath == cluster.Spec.DataDirHostPath {
+			return fmt.Errorf("dataDirHostPath %s is already used by the cluster in namespace %s", cluster.Spec.DataDirHostPath, namespace)
 		}
-		return
 	}
 
+	c.clusterMap[cluster.Namespace] = cluster
 	if cluster.Spec.Storage.AnyUseAllDevices() {
 		c.devicesInUse = true
 	}
+	return nil
+}
+
+// getCluster returns the cluster of a namespace
+func (c *ClusterController) getCluster(namespace string) (*cluster, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	cluster, ok := c.clusterMap[namespace]
+	return cluster, ok
+}
+
+// startCluster creates the cluster of a namespace and starts watching its services
+func (c *ClusterController) startCluster(clusterObj *edgefsv1beta1.Cluster, cluster *cluster) {
+	cluster.lock.Lock()
+	defer cluster.lock.Unlock()
+
+	containerImage := getEdgefsImage(cluster.Spec)
+	logger.Infof("starting cluster in namespace %s", cluster.Namespace)
 
 	// Start the Rook cluster components. Retry several times in case of failure.
 	err := wait.Poll(clusterCreateInterval, clusterCreateTimeout, func() (bool, error) {
@@ -152,12 +186,27 @@ func (c *ClusterController) onAdd(obj interface{}) {
 			return false, nil
 		}
 
-		err := cluster.createInstance(c.containerImage)
+		err := cluster.createInstance(containerImage)
 		if err != nil {
 			logger.Errorf("failed to create cluster in namespace %s. %+v", cluster.Namespace, err)
 			return false, nil
 		}
 
+		// the cluster is updated again once the evacuation of its removed nodes resumed by the creation completes
+		if cluster.isEvacuating() {
+			if err := c.updateClusterStatus(clusterObj.Namespace, clusterObj.Name, edgefsv1beta1.ClusterStateUpdating, evacuatingMessage); err != nil {
+				logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.Namespace, err)
+				return false, nil
+			}
+			return true, nil
+		}
+
+		// resume the upgrade interrupted by a restart of the operator
+		if _, err := cluster.upgrade(); err != nil {
+			logger.Errorf("failed to upgrade cluster in namespace %s. %+v", cluster.Namespace, err)
+			return false, nil
+		}
+
 		// cluster is created, update the cluster CRD status now
 		if err := c.updateClusterStatus(clusterObj.Namespace, clusterObj.Name, edgefsv1beta1.ClusterStateCreated, ""); err != nil {
 			logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.Namespace, err)
@@ -177,71 +226,102 @@ func (c *ClusterController) onAdd(obj interface{}) {
 
 	logger.Infof("succeeded creating and initializing EdgeFS cluster in namespace %s", cluster.Namespace)
 
+	c.startServices(cluster)
+
+	// add the finalizer to the crd
+	err = c.addFinalizer(clusterObj)
+	if err != nil {
+		logger.Errorf("failed to add finalizer to cluster crd. %+v", err)
+	}
+}
+
+// startServices starts watching the services of the cluster, which are deployed with the EdgeFS image the cluster
+// runs. The watchers of the image the cluster ran before an upgrade are stopped.
+func (c *ClusterController) startServices(cluster *cluster) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if current, ok := c.clusterMap[cluster.Namespace]; !ok || current != cluster {
+		logger.Infof("not watching the services of deleted cluster %s", cluster.Namespace)
+		return
+	}
+	image := cluster.image
+	if image == cluster.servicesImage {
+		return
+	}
+	if cluster.servicesImage != "" {
+		logger.Infof("restarting the watchers of the services of cluster %s with image %s", cluster.Namespace, image)
+		close(cluster.stopCh)
+		cluster.stopCh = make(chan struct{})
+	}
+	cluster.servicesImage = image
+	stopCh := cluster.stopCh
+
 	// Start NFS service CRD watcher
-	NFSController := nfs.NewNFSController(c.context, c.containerImage,
+	NFSController := nfs.NewNFSController(c.context, image,
 		isHostNetworkDefined(cluster.Spec.Network),
 		cluster.Spec.DataDirHostPath, cluster.Spec.DataVolumeSize,
 		edgefsv1beta1.GetTargetPlacement(cluster.Spec.Placement),
 		cluster.Spec.Resources,
 		cluster.Spec.ResourceProfile,
+		cluster.Spec.ServiceAccount,
 		cluster.ownerRef)
-	NFSController.StartWatch(cluster.Namespace, cluster.stopCh)
+	NFSController.StartWatch(cluster.Namespace, stopCh)
 
 	// Start S3 service CRD watcher
-	S3Controller := s3.NewS3Controller(c.context, c.containerImage,
+	S3Controller := s3.NewS3Controller(c.context, image,
 		isHostNetworkDefined(cluster.Spec.Network),
 		cluster.Spec.DataDirHostPath, cluster.Spec.DataVolumeSize,
 		edgefsv1beta1.GetTargetPlacement(cluster.Spec.Placement),
 		cluster.Spec.Resources,
 		cluster.Spec.ResourceProfile,
+		cluster.Spec.ServiceAccount,
 		cluster.ownerRef)
-	S3Controller.StartWatch(cluster.Namespace, cluster.stopCh)
+	S3Controller.StartWatch(cluster.Namespace, stopCh)
 
 	// Start SWIFT service CRD watcher
-	SWIFTController := swift.NewSWIFTController(c.context, c.containerImage,
+	SWIFTController := swift.NewSWIFTController(c.context, image,
 		isHostNetworkDefined(cluster.Spec.Network),
 		cluster.Spec.DataDirHostPath, cluster.Spec.DataVolumeSize,
 		edgefsv1beta1.GetTargetPlacement(cluster.Spec.Placement),
 		cluster.Spec.Resources,
 		cluster.Spec.ResourceProfile,
+		cluster.Spec.ServiceAccount,
 		cluster.ownerRef)
-	SWIFTController.StartWatch(cluster.Namespace, cluster.stopCh)
+	SWIFTController.StartWatch(cluster.Namespace, stopCh)
 
 	// Start S3X service CRD watcher
-	S3XController := s3x.NewS3XController(c.context, c.containerImage,
+	S3XController := s3x.NewS3XController(c.context, image,
 		isHostNetworkDefined(cluster.Spec.Network),
 		cluster.Spec.DataDirHostPath, cluster.Spec.DataVolumeSize,
 		edgefsv1beta1.GetTargetPlacement(cluster.Spec.Placement),
 		cluster.Spec.Resources,
 		cluster.Spec.ResourceProfile,
+		cluster.Spec.ServiceAccount,
 		cluster.ownerRef)
-	S3XController.StartWatch(cluster.Namespace, cluster.stopCh)
+	S3XController.StartWatch(cluster.Namespace, stopCh)
 
 	// Start ISCSI service CRD watcher
-	ISCSIController := iscsi.NewISCSIController(c.context, c.containerImage,
+	ISCSIController := iscsi.NewISCSIController(c.context, image,
 		isHostNetworkDefined(cluster.Spec.Network),
 		cluster.Spec.DataDirHostPath, cluster.Spec.DataVolumeSize,
 		edgefsv1beta1.GetTargetPlacement(cluster.Spec.Placement),
 		cluster.Spec.Resources,
 		cluster.Spec.ResourceProfile,
+		cluster.Spec.ServiceAccount,
 		cluster.ownerRef)
-	ISCSIController.StartWatch(cluster.Namespace, cluster.stopCh)
+	ISCSIController.StartWatch(cluster.Namespace, stopCh)
 
 	// Start ISGW service CRD watcher
-	ISGWController := isgw.NewISGWController(c.context, c.containerImage,
+	ISGWController := isgw.NewISGWController(c.context, image,
 		isHostNetworkDefined(cluster.Spec.Network),
 		cluster.Spec.DataDirHostPath, cluster.Spec.DataVolumeSize,
 		edgefsv1beta1.GetTargetPlacement(cluster.Spec.Placement),
 		cluster.Spec.Resources,
 		cluster.Spec.ResourceProfile,
+		cluster.Spec.ServiceAccount,
 		cluster.ownerRef)
-	ISGWController.StartWatch(cluster.Namespace, cluster.stopCh)
-
-	// add the finalizer to the crd
-	err = c.addFinalizer(clusterObj)
-	if err != nil {
-		logger.Errorf("failed to add finalizer to cluster crd. %+v", err)
-	}
+	ISGWController.StartWatch(cluster.Namespace, stopCh)
 }
 
 func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
@@ -273,11 +353,33 @@ func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
 	logger.Debugf("old cluster: %+v", oldCluster.Spec)
 	logger.Debugf("new cluster: %+v", newCluster.Spec)
 
+	c.lock.Lock()
 	cluster, ok := c.clusterMap[newCluster.Namespace]
 	if !ok {
+		c.lock.Unlock()
 		logger.Errorf("Cannot update cluster %s that does not exist", newCluster.Namespace)
 		return
 	}
+	cluster.updates++
+	update := cluster.updates
+	c.lock.Unlock()
+
+	// the clusters of the namespaces are updated concurrently
+	go c.updateCluster(newCluster, cluster, update)
+}
+
+// updateCluster updates the cluster of a namespace to the spec of an update, unless a later update was received since
+func (c *ClusterController) updateCluster(newCluster *edgefsv1beta1.Cluster, cluster *cluster, update int) {
+	cluster.lock.Lock()
+	defer cluster.lock.Unlock()
+
+	c.lock.Lock()
+	latest := update == cluster.updates
+	c.lock.Unlock()
+	if !latest {
+		logger.Infof("skipping update of cluster %s superseded by a later update", newCluster.Namespace)
+		return
+	}
 	cluster.Spec = newCluster.Spec
 
 	// attempt to update the cluster.  note this is done outside of wait.Poll because that function
@@ -307,10 +409,33 @@ func (c *ClusterController) handleUpdate(newClust *edgefsv1beta1.Cluster, cluste
 		return false, nil
 	}
 
-	if err := cluster.createInstance(c.containerImage); err != nil {
+	if err := cluster.createInstance(getEdgefsImage(cluster.Spec)); err != nil {
 		logger.Errorf("failed to update cluster in namespace %s. %+v", newClust.Namespace, err)
 		return false, nil
 	}
+	if cluster.isEvacuating() {
+		if err := c.updateClusterStatus(newClust.Namespace, newClust.Name, edgefsv1beta1.ClusterStateUpdating, evacuatingMessage); err != nil {
+			logger.Errorf("failed to update cluster status in namespace %s: %+v", newClust.Namespace, err)
+			return false, nil
+		}
+		logger.Infof("update of cluster in namespace %s resumes once its removed nodes are evacuated", newClust.Namespace)
+		return true, nil
+	}
+
+	done, err := cluster.upgrade()
+	if err != nil {
+		logger.Errorf("failed to upgrade cluster in namespace %s. %+v", newClust.Namespace, err)
+		return false, nil
+	}
+	c.startServices(cluster)
+	if !done {
+		// the upgrade resumes with the update of the cluster unpausing it
+		if err := c.updateClusterStatus(newClust.Namespace, newClust.Name, edgefsv1beta1.ClusterStateUpdating, "upgrade paused"); err != nil {
+			logger.Errorf("failed to update cluster status in namespace %s: %+v", newClust.Namespace, err)
+		}
+		logger.Infof("upgrade of cluster in namespace %s is paused", newClust.Namespace)
+		return true, nil
+	}
 
 	if err := c.updateClusterStatus(newClust.Namespace, newClust.Name, edgefsv1beta1.ClusterStateCreated, ""); err != nil {
 		logger.Errorf("failed to update cluster status in namespace %s: %+v", newClust.Namespace, err)
@@ -321,6 +446,27 @@ func (c *ClusterController) handleUpdate(newClust *edgefsv1beta1.Cluster, cluste
 	return true, nil
 }
 
+// onEvacuated updates the cluster of a namespace once the nodes removed from it are evacuated, to configure the nodes
+// out of it
+func (c *ClusterController) onEvacuated(cluster *cluster) {
+	clusterObj, err := c.context.RookClientset.EdgefsV1beta1().Clusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Errorf("failed to get cluster %s after the evacuation of its nodes. %+v", cluster.Namespace, err)
+		return
+	}
+
+	c.lock.Lock()
+	if current, ok := c.clusterMap[cluster.Namespace]; !ok || current != cluster {
+		c.lock.Unlock()
+		return
+	}
+	cluster.updates++
+	update := cluster.updates
+	c.lock.Unlock()
+
+	c.updateCluster(clusterObj, cluster, update)
+}
+
 func (c *ClusterController) onDelete(obj interface{}) {
 	clust := obj.(*edgefsv1beta1.Cluster).DeepCopy()
 
@@ -330,18 +476,21 @@ func (c *ClusterController) onDelete(obj interface{}) {
 	if err != nil {
 		logger.Errorf("failed to delete cluster. %+v", err)
 	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	if cluster, ok := c.clusterMap[clust.Namespace]; ok {
 		close(cluster.stopCh)
+		close(cluster.deleteCh)
 		delete(c.clusterMap, clust.Namespace)
-	}
-	if clust.Spec.Storage.AnyUseAllDevices() {
-		c.devicesInUse = false
+		if cluster.Spec.Storage.AnyUseAllDevices() {
+			c.devicesInUse = false
+		}
 	}
 }
 
 func (c *ClusterController) handleDelete(clust *edgefsv1beta1.Cluster, retryInterval time.Duration) error {
 
-	cluster, ok := c.clusterMap[clust.Namespace]
+	cluster, ok := c.getCluster(clust.Namespace)
 	if !ok {
 		return fmt.Errorf("Cannot delete cluster %s that does not exist", clust.Namespace)
 	}
@@ -368,8 +517,9 @@ func (c *ClusterController) updateClusterStatus(namespace, name string, state ed
 		return fmt.Errorf("failed to get cluster from namespace %s prior to updating its status: %+v", namespace, err)
 	}
 
-	// update the status on the retrieved cluster object
-	cluster.Status = edgefsv1beta1.ClusterStatus{State: state, Message: message}
+	// update the status on the retrieved cluster object, keeping the progress of the node evacuations
+	cluster.Status.State = state
+	cluster.Status.Message = message
 	if _, err := c.context.RookClientset.EdgefsV1beta1().Clusters(cluster.Namespace).Update(cluster); err != nil {
 		return fmt.Errorf("failed to update cluster %s status: %+v", cluster.Namespace, err)
 	}
@@ -377,6 +527,14 @@ func (c *ClusterController) updateClusterStatus(namespace, name string, state ed
 	return nil
 }
 
+// getEdgefsImage returns the EdgeFS image of the cluster of a namespace
+func getEdgefsImage(spec edgefsv1beta1.ClusterSpec) string {
+	if spec.EdgefsImageName != "" {
+		return spec.EdgefsImageName
+	}
+	return defaultEdgefsImageName
+}
+
 func isHostNetworkDefined(hostNetworkSpec edgefsv1beta1.NetworkSpec) bool {
 	if len(hostNetworkSpec.ServerIfName) > 0 || len(hostNetworkSpec.ServerIfName) > 0 {
 		return true
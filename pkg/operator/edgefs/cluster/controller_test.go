@@ -89,6 +89,10 @@ func TestClusterChanged(t *testing.T) {
 		{Name: "node1", Selection: rookalpha.Selection{Devices: []rookalpha.Device{{Name: "sda"}}}},
 	}
 	assert.False(t, clusterChanged(old, new))
+
+	// the service monitor is synced with the monitoring settings
+	new.Monitoring = edgefsv1beta1.MonitoringSpec{ServiceMonitor: true}
+	assert.True(t, clusterChanged(old, new))
 }
 
 func TestRemoveFinalizer(t *testing.T) {
@@ -124,3 +128,44 @@ func TestRemoveFinalizer(t *testing.T) {
 	assert.NotNil(t, cluster)
 	assert.Len(t, cluster.Finalizers, 0)
 }
+
+func TestAddCluster(t *testing.T) {
+	controller := NewClusterController(&clusterd.Context{Clientset: testop.New(3)}, "")
+	useAllDevices := true
+	newClusterObj := func(namespace, dataDirHostPath string, allDevices bool) *cluster {
+		obj := &edgefsv1beta1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: namespace, Namespace: namespace},
+			Spec:       edgefsv1beta1.ClusterSpec{DataDirHostPath: dataDirHostPath},
+		}
+		if allDevices {
+			obj.Spec.Storage.Selection.UseAllDevices = &useAllDevices
+		}
+		return newCluster(obj, controller.context)
+	}
+
+	// the clusters of several namespaces share the nodes
+	assert.Nil(t, controller.addCluster(newClusterObj("tenant1", "/var/lib/edgefs-tenant1", true)))
+	assert.Nil(t, controller.addCluster(newClusterObj("tenant2", "/var/lib/edgefs-tenant2", false)))
+	assert.Equal(t, 2, len(controller.clusterMap))
+
+	// but not their devices nor their data directories
+	assert.NotNil(t, controller.addCluster(newClusterObj("tenant3", "/var/lib/edgefs-tenant3", true)))
+	assert.NotNil(t, controller.addCluster(newClusterObj("tenant3", "/var/lib/edgefs-tenant1", false)))
+	assert.Nil(t, controller.addCluster(newClusterObj("tenant3", "", false)))
+	assert.Nil(t, controller.addCluster(newClusterObj("tenant4", "", false)))
+
+	cluster, ok := controller.getCluster("tenant2")
+	assert.True(t, ok)
+	assert.Equal(t, "tenant2", cluster.Namespace)
+
+	// the devices are released with their cluster
+	controller.onDelete(&edgefsv1beta1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "tenant1", Namespace: "tenant1"}})
+	_, ok = controller.getCluster("tenant1")
+	assert.False(t, ok)
+	assert.Nil(t, controller.addCluster(newClusterObj("tenant5", "/var/lib/edgefs-tenant5", true)))
+}
+
+func TestGetEdgefsImage(t *testing.T) {
+	assert.Equal(t, "edgefs/edgefs:latest", getEdgefsImage(edgefsv1beta1.ClusterSpec{}))
+	assert.Equal(t, "edgefs/edgefs:1.1.0", getEdgefsImage(edgefsv1beta1.ClusterSpec{EdgefsImageName: "edgefs/edgefs:1.1.0"}))
+}
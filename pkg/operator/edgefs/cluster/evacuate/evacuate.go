@@ -0,0 +1,280 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package evacuate to move the data off the devices of the nodes removed from an Edgefs cluster.
+package evacuate
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	batch "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "edgefs-op-evacuate")
+
+const (
+	appName                   = "device-evacuate"
+	defaultServiceAccountName = "rook-edgefs-cluster"
+	jobNameFmt                = "%s-%d-%s"
+	devicesVolumeName         = "devices"
+	dataVolumeName            = "edgefs-datadir"
+	stateVolumeFolder         = ".state"
+	etcVolumeFolder           = ".etc"
+	// the toolbox command moving the data off a device to the other devices of the cluster
+	evacuateCmdFmt = "efscli device evacuate -y %s"
+	// the evacuation of a device lasts as long as its data takes to be copied
+	evacuateTimeout = 12 * time.Hour
+)
+
+// Cluster is the edgefs device evacuation manager
+type Cluster struct {
+	Namespace       string
+	Version         string
+	serviceAccount  string
+	dataDirHostPath string
+	hostNetwork     bool
+	annotations     rookalpha.Annotations
+	placement       rookalpha.Placement
+	context         *clusterd.Context
+	ownerRef        metav1.OwnerReference
+}
+
+// New creates an instance of the device evacuation
+func New(
+	context *clusterd.Context, namespace, version string,
+	serviceAccount string,
+	dataDirHostPath string,
+	hostNetwork bool,
+	annotations rookalpha.Annotations,
+	placement rookalpha.Placement,
+	ownerRef metav1.OwnerReference,
+) *Cluster {
+
+	if serviceAccount == "" {
+		// if the service account was not set, make a best effort with the example service account name since the default is unlikely to be sufficient.
+		serviceAccount = defaultServiceAccountName
+		logger.Infof("setting the evacuate pods to use the service account name: %s", serviceAccount)
+	}
+
+	return &Cluster{
+		context:         context,
+		Namespace:       namespace,
+		serviceAccount:  serviceAccount,
+		dataDirHostPath: dataDirHostPath,
+		hostNetwork:     hostNetwork,
+		annotations:     annotations,
+		placement:       placement,
+		Version:         version,
+		ownerRef:        ownerRef,
+	}
+}
+
+// Start the evacuation of a device of the target container containerIndex of a node, and wait for its completion
+func (c *Cluster) Start(rookImage, nodeName string, containerIndex int, deviceName string) error {
+	logger.Infof("start evacuating device %s of node %s", deviceName, nodeName)
+
+	job := c.makeJob(rookImage, nodeName, containerIndex, deviceName)
+	if err := k8sutil.RunReplaceableJob(c.context.Clientset, job, true); err != nil {
+		return fmt.Errorf("failed to create %s job. %+v", job.Name, err)
+	}
+
+	if err := k8sutil.WaitForJobCompletion(c.context.Clientset, job, evacuateTimeout); err != nil {
+		return fmt.Errorf("failed to evacuate device %s of node %s. %+v", deviceName, nodeName, err)
+	}
+
+	if err := k8sutil.DeleteBatchJob(c.context.Clientset, c.Namespace, job.Name, false); err != nil {
+		logger.Warningf("Failed to delete job %s. %+v", job.Name, err)
+	}
+	logger.Infof("device %s of node %s evacuated", deviceName, nodeName)
+	return nil
+}
+
+func (c *Cluster) makeJob(rookImage, nodeName string, containerIndex int, deviceName string) *batch.Job {
+	hostPathDirectoryOrCreate := v1.HostPathDirectoryOrCreate
+	volumes := []v1.Volume{
+		{
+			Name: devicesVolumeName,
+			VolumeSource: v1.VolumeSource{
+				HostPath: &v1.HostPathVolumeSource{
+					Path: "/dev",
+				},
+			},
+		},
+		{
+			Name: dataVolumeName,
+			VolumeSource: v1.VolumeSource{
+				HostPath: &v1.HostPathVolumeSource{
+					Path: c.dataDirHostPath,
+					Type: &hostPathDirectoryOrCreate,
+				},
+			},
+		},
+	}
+
+	gracePeriod := int64(0)
+	backoffLimit := int32(0)
+	DNSPolicy := v1.DNSClusterFirst
+	if c.hostNetwork {
+		DNSPolicy = v1.DNSClusterFirstWithHostNet
+	}
+	podSpec := v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   appName,
+			Labels: c.getLabels(),
+		},
+		Spec: v1.PodSpec{
+			ServiceAccountName:            c.serviceAccount,
+			Containers:                    []v1.Container{c.evacuateContainer(rookImage, containerIndex, deviceName)},
+			RestartPolicy:                 v1.RestartPolicyNever,
+			HostIPC:                       true,
+			HostNetwork:                   c.hostNetwork,
+			DNSPolicy:                     DNSPolicy,
+			TerminationGracePeriodSeconds: &gracePeriod,
+			NodeSelector:                  map[string]string{v1.LabelHostname: nodeName},
+			Volumes:                       volumes,
+		},
+	}
+	c.annotations.ApplyToObjectMeta(&podSpec.ObjectMeta)
+	c.placement.ApplyToPodSpec(&podSpec.Spec)
+
+	job := &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      k8sutil.TruncateNodeName(fmt.Sprintf(jobNameFmt, appName, containerIndex, "%s"), nodeName),
+			Namespace: c.Namespace,
+			Labels:    c.getLabels(),
+		},
+		Spec: batch.JobSpec{
+			Template:     podSpec,
+			BackoffLimit: &backoffLimit,
+		},
+	}
+	k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &job.ObjectMeta, &c.ownerRef)
+	return job
+}
+
+// evacuateContainer runs the toolbox with the configuration and the state of the target container holding the device,
+// so the command reaches the daemon serving it
+func (c *Cluster) evacuateContainer(containerImage string, containerIndex int, deviceName string) v1.Container {
+
+	privileged := true
+	runAsUser := int64(0)
+	readOnlyRootFilesystem := false
+	securityContext := &v1.SecurityContext{
+		Privileged:             &privileged,
+		RunAsUser:              &runAsUser,
+		ReadOnlyRootFilesystem: &readOnlyRootFilesystem,
+	}
+
+	etcVolumeFolderVar := etcVolumeFolder
+	stateVolumeFolderVar := stateVolumeFolder
+	if containerIndex > 0 {
+		etcVolumeFolderVar = fmt.Sprintf("%s-%d", etcVolumeFolder, containerIndex)
+		stateVolumeFolderVar = fmt.Sprintf("%s-%d", stateVolumeFolder, containerIndex)
+	}
+	volumeMounts := []v1.VolumeMount{
+		{
+			Name:      devicesVolumeName,
+			MountPath: "/dev",
+		},
+		{
+			Name:      dataVolumeName,
+			MountPath: "/opt/nedge/etc",
+			SubPath:   etcVolumeFolderVar,
+		},
+		{
+			Name:      dataVolumeName,
+			MountPath: "/opt/nedge/var/run",
+			SubPath:   stateVolumeFolderVar,
+		},
+	}
+
+	return v1.Container{
+		Name:            appName,
+		Image:           containerImage,
+		ImagePullPolicy: v1.PullAlways,
+		Args:            []string{"toolbox", fmt.Sprintf(evacuateCmdFmt, deviceName)},
+		VolumeMounts:    volumeMounts,
+		Env: []v1.EnvVar{
+			{
+				Name:  "CCOW_LOG_LEVEL",
+				Value: "5",
+			},
+			{
+				Name:  "DAEMON_INDEX",
+				Value: strconv.Itoa(containerIndex),
+			},
+			{
+				Name: "HOST_HOSTNAME",
+				ValueFrom: &v1.EnvVarSource{
+					FieldRef: &v1.ObjectFieldSelector{
+						FieldPath: "spec.nodeName",
+					},
+				},
+			},
+			{
+				Name:  "K8S_NAMESPACE",
+				Value: c.Namespace,
+			},
+		},
+		SecurityContext: securityContext,
+	}
+}
+
+func (c *Cluster) getLabels() map[string]string {
+	return map[string]string{
+		k8sutil.AppAttr:     appName,
+		k8sutil.ClusterAttr: c.Namespace,
+	}
+}
+
+// Device is a device used by a target container of a node
+type Device struct {
+	ContainerIndex int
+	Name           string
+}
+
+// GetNodeDevices returns the devices used by the target containers of a node
+func GetNodeDevices(setupNode edgefsv1beta1.SetupNode) []Device {
+	devices := []Device{}
+	for _, rtDevice := range setupNode.Rtrd.Devices {
+		devices = append(devices, Device{ContainerIndex: 0, Name: getRTDeviceName(rtDevice)})
+	}
+	for i, slave := range setupNode.RtrdSlaves {
+		for _, rtDevice := range slave.Devices {
+			devices = append(devices, Device{ContainerIndex: i + 1, Name: getRTDeviceName(rtDevice)})
+		}
+	}
+	for _, rtlfsDevice := range setupNode.Rtlfs.Devices {
+		devices = append(devices, Device{ContainerIndex: 0, Name: rtlfsDevice.Name})
+	}
+	return devices
+}
+
+func getRTDeviceName(rtDevice edgefsv1beta1.RTDevice) string {
+	if len(rtDevice.Name) > 0 {
+		return rtDevice.Name
+	}
+	return rtDevice.Device
+}
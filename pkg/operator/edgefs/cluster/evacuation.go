@@ -0,0 +1,285 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
+	rookv1alpha2 "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/operator/edgefs/cluster/evacuate"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// getNodeLayouts returns the configuration of the nodes of a previous deployment of the cluster, by node name
+func (c *cluster) getNodeLayouts() (map[string]edgefsv1beta1.SetupNode, error) {
+	cm, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Get(configName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get configmap %s. %+v", configName, err)
+	}
+
+	layouts := map[string]edgefsv1beta1.SetupNode{}
+	if err := json.Unmarshal([]byte(cm.Data["nesetup"]), &layouts); err != nil {
+		return nil, fmt.Errorf("failed to parse the nodes configuration of configmap %s. %+v", configName, err)
+	}
+	return layouts, nil
+}
+
+// getContainersCount returns the target containers count of the nodes of a previous deployment, 0 if none
+func getContainersCount(layouts map[string]edgefsv1beta1.SetupNode) int {
+	for _, layout := range layouts {
+		if len(layout.Rtrd.Devices) > 0 {
+			return len(layout.RtrdSlaves) + 1
+		}
+	}
+	return 0
+}
+
+// getRemovedNodes returns the nodes of a previous deployment no longer in the storage nodes of the cluster spec
+func getRemovedNodes(layouts map[string]edgefsv1beta1.SetupNode, storage rookv1alpha2.StorageScopeSpec) []string {
+	// a node missing from all the nodes is not a node removed by the admin
+	if storage.UseAllNodes {
+		return nil
+	}
+
+	removed := []string{}
+	for nodeName := range layouts {
+		found := false
+		for _, node := range storage.Nodes {
+			if node.Name == nodeName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			removed = append(removed, nodeName)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+func (c *cluster) validateNodeRemoval(layouts map[string]edgefsv1beta1.SetupNode, removed []string) error {
+	if c.Spec.DataVolumeSize.Value() != 0 {
+		return fmt.Errorf("nodes %v can't be removed from a cluster deployed with dataVolumeSize", removed)
+	}
+	for _, nodeName := range removed {
+		layout := layouts[nodeName]
+		if layout.NodeType != "gateway" && len(layout.RtlfsAutodetect) > 0 {
+			return fmt.Errorf("node %s can't be evacuated, its devices were autodetected", nodeName)
+		}
+	}
+	if len(layouts)-len(removed) < 1 {
+		return fmt.Errorf("nodes %v can't be removed, no node would be left", removed)
+	}
+	return nil
+}
+
+// evacuatingMessage is the message of the cluster status while the removed nodes are evacuated
+const evacuatingMessage = "evacuating removed nodes"
+
+// evacuationRetryInterval is the interval between the attempts to evacuate the removed nodes
+var evacuationRetryInterval = time.Minute
+
+// startEvacuation starts evacuating the nodes removed from the cluster in the background, as the evacuation of a
+// device can take hours. It returns true once the nodes are evacuated.
+func (c *cluster) startEvacuation(rookImage string, layouts map[string]edgefsv1beta1.SetupNode, removed []string) (bool, error) {
+	evacuated, err := c.nodesEvacuated(layouts, removed)
+	if err != nil || evacuated {
+		return evacuated, err
+	}
+
+	c.evacuationLock.Lock()
+	defer c.evacuationLock.Unlock()
+	if c.evacuating {
+		logger.Infof("nodes %v of cluster %s are being evacuated", removed, c.Namespace)
+		return false, nil
+	}
+	c.evacuating = true
+	go c.evacuateInBackground(rookImage, layouts, removed)
+	return false, nil
+}
+
+// evacuateInBackground evacuates the removed nodes until it succeeds or the cluster is deleted, then updates the
+// cluster to configure the nodes out of it
+func (c *cluster) evacuateInBackground(rookImage string, layouts map[string]edgefsv1beta1.SetupNode, removed []string) {
+	for {
+		err := c.evacuateNodes(rookImage, layouts, removed)
+		if err == nil {
+			break
+		}
+		logger.Errorf("failed to evacuate nodes %v of cluster %s, retrying in %s. %+v", removed, c.Namespace, evacuationRetryInterval, err)
+		select {
+		case <-c.deleteCh:
+			logger.Infof("stopping the evacuation of nodes %v of deleted cluster %s", removed, c.Namespace)
+			return
+		case <-time.After(evacuationRetryInterval):
+		}
+	}
+
+	c.evacuationLock.Lock()
+	c.evacuating = false
+	c.evacuationLock.Unlock()
+	logger.Infof("nodes %v of cluster %s evacuated", removed, c.Namespace)
+	if c.onEvacuated != nil {
+		c.onEvacuated()
+	}
+}
+
+// isEvacuating returns true while the removed nodes are evacuated in the background
+func (c *cluster) isEvacuating() bool {
+	c.evacuationLock.Lock()
+	defer c.evacuationLock.Unlock()
+	return c.evacuating
+}
+
+// nodesEvacuated returns true if all the devices of the removed nodes are reported evacuated in the cluster status
+func (c *cluster) nodesEvacuated(layouts map[string]edgefsv1beta1.SetupNode, removed []string) (bool, error) {
+	evacuations, err := c.getEvacuations()
+	if err != nil {
+		return false, err
+	}
+	for _, nodeName := range removed {
+		devices := len(evacuate.GetNodeDevices(layouts[nodeName]))
+		status, ok := evacuations[nodeName]
+		if !ok || status.State != edgefsv1beta1.NodeEvacuationStateEvacuating || status.Devices != devices || status.EvacuatedDevices < devices {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// evacuateNodes moves the data off the devices of the nodes removed from the cluster, one device at a time,
+// and reports the progress of every node in the cluster status
+func (c *cluster) evacuateNodes(rookImage string, layouts map[string]edgefsv1beta1.SetupNode, removed []string) error {
+	evacuations, err := c.getEvacuations()
+	if err != nil {
+		return err
+	}
+
+	for _, nodeName := range removed {
+		devices := evacuate.GetNodeDevices(layouts[nodeName])
+		status := edgefsv1beta1.NodeEvacuationStatus{Node: nodeName, State: edgefsv1beta1.NodeEvacuationStateEvacuating, Devices: len(devices)}
+		// resume an evacuation interrupted by a failure or an operator restart
+		if previous, ok := evacuations[nodeName]; ok && previous.Devices == len(devices) && previous.State != edgefsv1beta1.NodeEvacuationStateRemoved {
+			status.EvacuatedDevices = previous.EvacuatedDevices
+		}
+
+		for status.EvacuatedDevices < len(devices) {
+			if err := c.updateEvacuationStatus(status); err != nil {
+				return err
+			}
+			device := devices[status.EvacuatedDevices]
+			if err := c.evacuateDevice(rookImage, nodeName, device.ContainerIndex, device.Name); err != nil {
+				status.State = edgefsv1beta1.NodeEvacuationStateFailed
+				status.Message = err.Error()
+				if err := c.updateEvacuationStatus(status); err != nil {
+					logger.Errorf("failed to report the evacuation failure of node %s. %+v", nodeName, err)
+				}
+				return err
+			}
+			status.EvacuatedDevices++
+		}
+
+		if err := c.updateEvacuationStatus(status); err != nil {
+			return err
+		}
+		logger.Infof("node %s evacuated", nodeName)
+	}
+	return nil
+}
+
+// runEvacuation evacuates a device of a node with a job running the edgefs toolbox on the node
+func (c *cluster) runEvacuation(rookImage, nodeName string, containerIndex int, deviceName string) error {
+	evac := evacuate.New(c.context, c.Namespace, "latest", c.Spec.ServiceAccount, c.Spec.DataDirHostPath,
+		isHostNetworkDefined(c.Spec.Network), edgefsv1beta1.GetTargetAnnotations(c.Spec.Annotations),
+		edgefsv1beta1.GetTargetPlacement(c.Spec.Placement), c.ownerRef)
+	return evac.Start(rookImage, nodeName, containerIndex, deviceName)
+}
+
+// removeNodes takes the evacuated nodes out of the cluster, once the nodes left are configured
+func (c *cluster) removeNodes(removed []string) error {
+	for _, nodeName := range removed {
+		// the target of a removed node is not scheduled again on it
+		err := c.RemoveLabelOffNode(c.context.Clientset, nodeName, []string{c.Namespace})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to remove label %s from node %s. %+v", c.Namespace, nodeName, err)
+		}
+	}
+
+	if err := c.targets.RemoveNodes(removed); err != nil {
+		return err
+	}
+
+	evacuations, err := c.getEvacuations()
+	if err != nil {
+		return err
+	}
+	for _, nodeName := range removed {
+		status := evacuations[nodeName]
+		status.Node = nodeName
+		status.State = edgefsv1beta1.NodeEvacuationStateRemoved
+		if err := c.updateEvacuationStatus(status); err != nil {
+			return err
+		}
+		logger.Infof("node %s removed from cluster %s", nodeName, c.Namespace)
+	}
+	return nil
+}
+
+func (c *cluster) getEvacuations() (map[string]edgefsv1beta1.NodeEvacuationStatus, error) {
+	clust, err := c.context.RookClientset.EdgefsV1beta1().Clusters(c.Namespace).Get(c.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster %s. %+v", c.Name, err)
+	}
+	evacuations := map[string]edgefsv1beta1.NodeEvacuationStatus{}
+	for _, evacuation := range clust.Status.Evacuations {
+		evacuations[evacuation.Node] = evacuation
+	}
+	return evacuations, nil
+}
+
+// updateEvacuationStatus reports the evacuation of a node in the cluster status
+func (c *cluster) updateEvacuationStatus(status edgefsv1beta1.NodeEvacuationStatus) error {
+	clust, err := c.context.RookClientset.EdgefsV1beta1().Clusters(c.Namespace).Get(c.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster %s prior to updating its status. %+v", c.Name, err)
+	}
+
+	found := false
+	for i := range clust.Status.Evacuations {
+		if clust.Status.Evacuations[i].Node == status.Node {
+			clust.Status.Evacuations[i] = status
+			found = true
+		}
+	}
+	if !found {
+		clust.Status.Evacuations = append(clust.Status.Evacuations, status)
+	}
+
+	if _, err := c.context.RookClientset.EdgefsV1beta1().Clusters(c.Namespace).Update(clust); err != nil {
+		return fmt.Errorf("failed to update the evacuation status of node %s. %+v", status.Node, err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func layoutsForTest() map[string]edgefsv1beta1.SetupNode {
+	rtDevices := func(devices ...string) edgefsv1beta1.RTDevices {
+		rt := edgefsv1beta1.RTDevices{}
+		for _, device := range devices {
+			rt.Devices = append(rt.Devices, edgefsv1beta1.RTDevice{Name: "ata-" + device, Device: "/dev/" + device})
+		}
+		return rt
+	}
+	return map[string]edgefsv1beta1.SetupNode{
+		"node1": {Rtrd: rtDevices("sda"), RtrdSlaves: []edgefsv1beta1.RTDevices{rtDevices("sdb")}, NodeType: "target"},
+		"node2": {Rtrd: rtDevices("sda", "sdc"), RtrdSlaves: []edgefsv1beta1.RTDevices{rtDevices("sdb")}, NodeType: "target"},
+		"node3": {NodeType: "gateway"},
+	}
+}
+
+func TestGetNodeLayouts(t *testing.T) {
+	clientset := testop.New(3)
+	c := &cluster{context: &clusterd.Context{Clientset: clientset}, Namespace: "ns"}
+
+	// no previous deployment
+	layouts, err := c.getNodeLayouts()
+	assert.Nil(t, err)
+	assert.Nil(t, layouts)
+
+	nesetup, err := json.Marshal(layoutsForTest())
+	assert.Nil(t, err)
+	_, err = clientset.CoreV1().ConfigMaps("ns").Create(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: configName, Namespace: "ns"},
+		Data:       map[string]string{"nesetup": string(nesetup)},
+	})
+	assert.Nil(t, err)
+	layouts, err = c.getNodeLayouts()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(layouts))
+	assert.Equal(t, "/dev/sdc", layouts["node2"].Rtrd.Devices[1].Device)
+	assert.Equal(t, 2, getContainersCount(layouts))
+	assert.Equal(t, 0, getContainersCount(nil))
+}
+
+func TestGetRemovedNodes(t *testing.T) {
+	layouts := layoutsForTest()
+	storage := rookalpha.StorageScopeSpec{Nodes: []rookalpha.Node{{Name: "node1"}, {Name: "node3"}, {Name: "node4"}}}
+	assert.Equal(t, []string{"node2"}, getRemovedNodes(layouts, storage))
+
+	storage.Nodes = nil
+	assert.Equal(t, []string{"node1", "node2", "node3"}, getRemovedNodes(layouts, storage))
+
+	// nodes missing from all the nodes are not removed
+	storage.UseAllNodes = true
+	assert.Nil(t, getRemovedNodes(layouts, storage))
+
+	// no previous deployment
+	assert.Equal(t, []string{}, getRemovedNodes(nil, rookalpha.StorageScopeSpec{}))
+}
+
+func TestEvacuateNodes(t *testing.T) {
+	clust := &edgefsv1beta1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "edgefs", Namespace: "ns"}}
+	context := &clusterd.Context{Clientset: testop.New(3), RookClientset: rookfake.NewSimpleClientset(clust)}
+	c := newCluster(clust, context)
+	layouts := layoutsForTest()
+
+	evacuated := []string{}
+	failOn := "ata-sdb"
+	c.evacuateDevice = func(rookImage, nodeName string, containerIndex int, deviceName string) error {
+		if deviceName == failOn {
+			return fmt.Errorf("mock failure")
+		}
+		evacuated = append(evacuated, fmt.Sprintf("%s/%d/%s", nodeName, containerIndex, deviceName))
+		return nil
+	}
+
+	// the evacuation stops on a failure, and reports its progress
+	assert.NotNil(t, c.evacuateNodes("edgefs/edgefs:latest", layouts, []string{"node2", "node3"}))
+	assert.Equal(t, []string{"node2/0/ata-sda", "node2/0/ata-sdc"}, evacuated)
+	current, err := context.RookClientset.EdgefsV1beta1().Clusters("ns").Get("edgefs", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(current.Status.Evacuations))
+	assert.Equal(t, edgefsv1beta1.NodeEvacuationStateFailed, current.Status.Evacuations[0].State)
+	assert.Equal(t, 3, current.Status.Evacuations[0].Devices)
+	assert.Equal(t, 2, current.Status.Evacuations[0].EvacuatedDevices)
+
+	// the evacuation resumes where it stopped
+	failOn = ""
+	evacuated = []string{}
+	assert.Nil(t, c.evacuateNodes("edgefs/edgefs:latest", layouts, []string{"node2", "node3"}))
+	assert.Equal(t, []string{"node2/1/ata-sdb"}, evacuated)
+	current, err = context.RookClientset.EdgefsV1beta1().Clusters("ns").Get("edgefs", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []edgefsv1beta1.NodeEvacuationStatus{
+		{Node: "node2", State: edgefsv1beta1.NodeEvacuationStateEvacuating, Devices: 3, EvacuatedDevices: 3},
+		{Node: "node3", State: edgefsv1beta1.NodeEvacuationStateEvacuating},
+	}, current.Status.Evacuations)
+}
+
+func TestStartEvacuation(t *testing.T) {
+	clust := &edgefsv1beta1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "edgefs", Namespace: "ns"}}
+	context := &clusterd.Context{Clientset: testop.New(3), RookClientset: rookfake.NewSimpleClientset(clust)}
+	c := newCluster(clust, context)
+	layouts := layoutsForTest()
+	removed := []string{"node2", "node3"}
+	evacuationRetryInterval = time.Millisecond
+
+	release := make(chan struct{})
+	failures := 1
+	c.evacuateDevice = func(rookImage, nodeName string, containerIndex int, deviceName string) error {
+		<-release
+		if failures > 0 {
+			failures--
+			return fmt.Errorf("mock failure")
+		}
+		return nil
+	}
+	evacuatedCh := make(chan struct{})
+	c.onEvacuated = func() { close(evacuatedCh) }
+
+	// the evacuation runs in the background, only once
+	evacuated, err := c.startEvacuation("edgefs/edgefs:latest", layouts, removed)
+	assert.Nil(t, err)
+	assert.False(t, evacuated)
+	assert.True(t, c.isEvacuating())
+	evacuated, err = c.startEvacuation("edgefs/edgefs:latest", layouts, removed)
+	assert.Nil(t, err)
+	assert.False(t, evacuated)
+
+	// the evacuation is retried after a failure and the cluster is updated once it completes
+	close(release)
+	select {
+	case <-evacuatedCh:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the nodes were not evacuated")
+	}
+	assert.False(t, c.isEvacuating())
+	evacuated, err = c.startEvacuation("edgefs/edgefs:latest", layouts, removed)
+	assert.Nil(t, err)
+	assert.True(t, evacuated)
+}
+
+func TestValidateNodeRemoval(t *testing.T) {
+	c := &cluster{Spec: edgefsv1beta1.ClusterSpec{DataDirHostPath: "/var/lib/edgefs"}}
+	layouts := layoutsForTest()
+	assert.Nil(t, c.validateNodeRemoval(layouts, []string{"node2", "node3"}))
+	assert.NotNil(t, c.validateNodeRemoval(layouts, []string{"node1", "node2", "node3"}))
+
+	// the devices of autodetected rtlfs nodes are unknown
+	layouts["node2"] = edgefsv1beta1.SetupNode{RtlfsAutodetect: "/data", NodeType: "target"}
+	assert.NotNil(t, c.validateNodeRemoval(layouts, []string{"node2"}))
+}
@@ -68,9 +68,12 @@ type Cluster struct {
 	context         *clusterd.Context
 	hostNetworkSpec edgefsv1beta1.NetworkSpec
 	dashboardSpec   edgefsv1beta1.DashboardSpec
+	monitoringSpec  edgefsv1beta1.MonitoringSpec
 	resources       v1.ResourceRequirements
 	resourceProfile string
 	ownerRef        metav1.OwnerReference
+	// monitoringRequest sends a request to the Prometheus operator API at the path
+	monitoringRequest func(verb, path string, body []byte) ([]byte, error)
 }
 
 // New creates an instance of the mgr
@@ -83,6 +86,7 @@ func New(
 	placement rookalpha.Placement,
 	hostNetworkSpec edgefsv1beta1.NetworkSpec,
 	dashboardSpec edgefsv1beta1.DashboardSpec,
+	monitoringSpec edgefsv1beta1.MonitoringSpec,
 	resources v1.ResourceRequirements,
 	resourceProfile string,
 	ownerRef metav1.OwnerReference,
@@ -94,7 +98,7 @@ func New(
 		logger.Infof("setting the mgr pod to use the service account name: %s", serviceAccount)
 	}
 
-	return &Cluster{
+	c := &Cluster{
 		context:         context,
 		Namespace:       namespace,
 		serviceAccount:  serviceAccount,
@@ -106,10 +110,13 @@ func New(
 		dataVolumeSize:  dataVolumeSize,
 		hostNetworkSpec: hostNetworkSpec,
 		dashboardSpec:   dashboardSpec,
+		monitoringSpec:  monitoringSpec,
 		resources:       resources,
 		resourceProfile: resourceProfile,
 		ownerRef:        ownerRef,
 	}
+	c.monitoringRequest = c.sendMonitoringRequest
+	return c
 }
 
 func isHostNetworkDefined(hostNetworkSpec edgefsv1beta1.NetworkSpec) bool {
@@ -168,6 +175,22 @@ func (c *Cluster) Start(rookImage string) error {
 		logger.Infof("ui service started")
 	}
 
+	// create the metrics service
+	metricsService := c.makeMetricsService(metricsSvcName)
+	if _, err := c.context.Clientset.CoreV1().Services(c.Namespace).Create(metricsService); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create metrics service. %+v", err)
+		}
+		logger.Infof("metrics service already exists")
+	} else {
+		logger.Infof("metrics service started")
+	}
+
+	// the cluster runs without the prometheus operator
+	if err := c.syncServiceMonitor(); err != nil {
+		logger.Warningf("failed to sync the service monitor of the metrics. %+v", err)
+	}
+
 	return nil
 }
 
@@ -47,7 +47,7 @@ func TestStartMGR(t *testing.T) {
 		Clientset: testop.New(3)}
 	volSize := resource.NewQuantity(100000.0, resource.BinarySI)
 	c := New(context, "ns", "myversion", "", "", *volSize, rookalpha.Annotations{}, rookalpha.Placement{}, edgefsv1beta1.NetworkSpec{},
-		edgefsv1beta1.DashboardSpec{}, v1.ResourceRequirements{}, "", metav1.OwnerReference{})
+		edgefsv1beta1.DashboardSpec{}, edgefsv1beta1.MonitoringSpec{}, v1.ResourceRequirements{}, "", metav1.OwnerReference{})
 
 	// start a basic service
 	err := c.Start("edgefs")
@@ -68,12 +68,15 @@ func validateStart(t *testing.T, c *Cluster) {
 
 	_, err = c.context.Clientset.CoreV1().Services(c.Namespace).Get("rook-edgefs-mgr", metav1.GetOptions{})
 	assert.Nil(t, err)
+
+	_, err = c.context.Clientset.CoreV1().Services(c.Namespace).Get("rook-edgefs-metrics", metav1.GetOptions{})
+	assert.Nil(t, err)
 }
 
 func TestPodSpec(t *testing.T) {
 	volSize := resource.NewQuantity(100000.0, resource.BinarySI)
 	c := New(&clusterd.Context{Clientset: testop.New(1)}, "ns", "rook/rook:myversion", "", "", *volSize, rookalpha.Annotations{}, rookalpha.Placement{},
-		edgefsv1beta1.NetworkSpec{}, edgefsv1beta1.DashboardSpec{}, v1.ResourceRequirements{
+		edgefsv1beta1.NetworkSpec{}, edgefsv1beta1.DashboardSpec{}, edgefsv1beta1.MonitoringSpec{}, v1.ResourceRequirements{
 			Limits: v1.ResourceList{
 				v1.ResourceCPU: *resource.NewQuantity(100.0, resource.BinarySI),
 			},
@@ -115,7 +118,7 @@ func TestPodSpec(t *testing.T) {
 func TestServiceSpec(t *testing.T) {
 	volSize := resource.NewQuantity(100000.0, resource.BinarySI)
 	c := New(&clusterd.Context{}, "ns", "myversion", "", "", *volSize, rookalpha.Annotations{}, rookalpha.Placement{},
-		edgefsv1beta1.NetworkSpec{}, edgefsv1beta1.DashboardSpec{}, v1.ResourceRequirements{},
+		edgefsv1beta1.NetworkSpec{}, edgefsv1beta1.DashboardSpec{}, edgefsv1beta1.MonitoringSpec{}, v1.ResourceRequirements{},
 		"", metav1.OwnerReference{})
 
 	s := c.makeMgrService("rook-edgefs-mgr")
@@ -127,7 +130,7 @@ func TestServiceSpec(t *testing.T) {
 func TestHostNetwork(t *testing.T) {
 	volSize := resource.NewQuantity(100000.0, resource.BinarySI)
 	c := New(&clusterd.Context{Clientset: testop.New(1)}, "ns", "myversion", "", "", *volSize, rookalpha.Annotations{}, rookalpha.Placement{},
-		edgefsv1beta1.NetworkSpec{ServerIfName: "eth0"}, edgefsv1beta1.DashboardSpec{}, v1.ResourceRequirements{},
+		edgefsv1beta1.NetworkSpec{ServerIfName: "eth0"}, edgefsv1beta1.DashboardSpec{}, edgefsv1beta1.MonitoringSpec{}, v1.ResourceRequirements{},
 		"", metav1.OwnerReference{})
 
 	d := c.makeDeployment("mgr-a", "a", "edgefs", 1)
@@ -0,0 +1,213 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	metricsSvcName        = "rook-edgefs-metrics"
+	metricsPortName       = "http-metrics"
+	metricsPath           = "/metrics"
+	defaultScrapeInterval = "30s"
+	monitoringAPIVersion  = "monitoring.coreos.com/v1"
+	serviceMonitorsPath   = "/apis/monitoring.coreos.com/v1/namespaces/%s/servicemonitors"
+	serviceMonitorPath    = serviceMonitorsPath + "/%s"
+)
+
+// serviceMonitor is the part of the ServiceMonitor of the Prometheus operator that the operator sets
+type serviceMonitor struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              serviceMonitorSpec `json:"spec"`
+}
+
+type serviceMonitorSpec struct {
+	Selector          metav1.LabelSelector     `json:"selector"`
+	NamespaceSelector namespaceSelector        `json:"namespaceSelector"`
+	Endpoints         []serviceMonitorEndpoint `json:"endpoints"`
+}
+
+type namespaceSelector struct {
+	MatchNames []string `json:"matchNames"`
+}
+
+type serviceMonitorEndpoint struct {
+	Port     string `json:"port"`
+	Path     string `json:"path"`
+	Interval string `json:"interval"`
+}
+
+// makeMetricsService makes the service of the metrics exporter of the mgr, with the per target capacity, device
+// health, latency and service throughput of the cluster
+func (c *Cluster) makeMetricsService(name string) *v1.Service {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.Namespace,
+			Labels:    c.getMetricsLabels(),
+		},
+		Spec: v1.ServiceSpec{
+			Selector: c.getLabels(),
+			Type:     v1.ServiceTypeClusterIP,
+			Ports: []v1.ServicePort{
+				{
+					Name:     metricsPortName,
+					Port:     int32(defaultMetricsPort),
+					Protocol: v1.ProtocolTCP,
+				},
+			},
+		},
+	}
+
+	k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &svc.ObjectMeta, &c.ownerRef)
+	return svc
+}
+
+// makeServiceMonitor makes the ServiceMonitor scraping the metrics service
+func (c *Cluster) makeServiceMonitor() *serviceMonitor {
+	interval := c.monitoringSpec.Interval
+	if interval == "" {
+		interval = defaultScrapeInterval
+	}
+
+	monitor := &serviceMonitor{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: monitoringAPIVersion,
+			Kind:       "ServiceMonitor",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      metricsSvcName,
+			Namespace: c.Namespace,
+			Labels:    c.monitoringSpec.Labels,
+		},
+		Spec: serviceMonitorSpec{
+			Selector:          metav1.LabelSelector{MatchLabels: c.getMetricsLabels()},
+			NamespaceSelector: namespaceSelector{MatchNames: []string{c.Namespace}},
+			Endpoints: []serviceMonitorEndpoint{
+				{Port: metricsPortName, Path: metricsPath, Interval: interval},
+			},
+		},
+	}
+	k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &monitor.ObjectMeta, &c.ownerRef)
+	return monitor
+}
+
+// syncServiceMonitor creates or updates the ServiceMonitor of the metrics service as set in the monitoring spec, or
+// deletes it when it is no longer requested. The ServiceMonitor requires the Prometheus operator.
+func (c *Cluster) syncServiceMonitor() error {
+	path := fmt.Sprintf(serviceMonitorPath, c.Namespace, metricsSvcName)
+	if !c.monitoringSpec.ServiceMonitor {
+		if _, err := c.monitoringRequest("DELETE", path, nil); err != nil {
+			// the service monitor or the prometheus operator is missing
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to delete service monitor %s. %+v", metricsSvcName, err)
+		}
+		logger.Infof("service monitor %s deleted", metricsSvcName)
+		return nil
+	}
+
+	monitor := c.makeServiceMonitor()
+	body, err := json.Marshal(monitor)
+	if err != nil {
+		return fmt.Errorf("failed to encode service monitor %s. %+v", metricsSvcName, err)
+	}
+	_, err = c.monitoringRequest("POST", fmt.Sprintf(serviceMonitorsPath, c.Namespace), body)
+	if err == nil {
+		logger.Infof("service monitor %s created", metricsSvcName)
+		return nil
+	}
+	if errors.IsNotFound(err) {
+		return fmt.Errorf("the ServiceMonitor resource is not found, is the prometheus operator running? %+v", err)
+	}
+	if !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create service monitor %s. %+v", metricsSvcName, err)
+	}
+
+	// the existing service monitor is updated with the labels and the spec set by the operator, keeping the other
+	// fields of its spec
+	existingBody, err := c.monitoringRequest("GET", path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get service monitor %s. %+v", metricsSvcName, err)
+	}
+	body, err = updateServiceMonitor(existingBody, monitor)
+	if err != nil {
+		return err
+	}
+	if _, err := c.monitoringRequest("PUT", path, body); err != nil {
+		return fmt.Errorf("failed to update service monitor %s. %+v", metricsSvcName, err)
+	}
+	logger.Infof("service monitor %s updated", metricsSvcName)
+	return nil
+}
+
+// updateServiceMonitor returns the existing service monitor with the labels and the spec of the service monitor
+func updateServiceMonitor(existingBody []byte, monitor *serviceMonitor) ([]byte, error) {
+	var existing map[string]interface{}
+	if err := json.Unmarshal(existingBody, &existing); err != nil {
+		return nil, fmt.Errorf("failed to decode service monitor %s. %+v", metricsSvcName, err)
+	}
+	var updates map[string]interface{}
+	body, err := json.Marshal(monitor)
+	if err == nil {
+		err = json.Unmarshal(body, &updates)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode service monitor %s. %+v", metricsSvcName, err)
+	}
+
+	metadata, _ := existing["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+		existing["metadata"] = metadata
+	}
+	metadata["labels"] = updates["metadata"].(map[string]interface{})["labels"]
+	spec, _ := existing["spec"].(map[string]interface{})
+	if spec == nil {
+		spec = map[string]interface{}{}
+		existing["spec"] = spec
+	}
+	for key, value := range updates["spec"].(map[string]interface{}) {
+		spec[key] = value
+	}
+	return json.Marshal(existing)
+}
+
+// sendMonitoringRequest sends a request to the Prometheus operator API, which has no typed client
+func (c *Cluster) sendMonitoringRequest(verb, path string, body []byte) ([]byte, error) {
+	request := c.context.Clientset.Discovery().RESTClient().Verb(verb).AbsPath(path)
+	if body != nil {
+		request = request.Body(body)
+	}
+	return request.Do().Raw()
+}
+
+func (c *Cluster) getMetricsLabels() map[string]string {
+	return map[string]string{
+		k8sutil.AppAttr:     metricsSvcName,
+		k8sutil.ClusterAttr: c.Namespace,
+	}
+}
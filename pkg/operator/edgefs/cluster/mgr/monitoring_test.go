@@ -0,0 +1,145 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mgr
+
+import (
+	"encoding/json"
+	"testing"
+
+	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func newClusterForMonitoringTest(monitoringSpec edgefsv1beta1.MonitoringSpec) *Cluster {
+	volSize := resource.NewQuantity(100000.0, resource.BinarySI)
+	return New(&clusterd.Context{Clientset: testop.New(1)}, "rook-edgefs", "myversion", "", "", *volSize, rookalpha.Annotations{},
+		rookalpha.Placement{}, edgefsv1beta1.NetworkSpec{}, edgefsv1beta1.DashboardSpec{}, monitoringSpec, v1.ResourceRequirements{},
+		"", metav1.OwnerReference{})
+}
+
+func TestMetricsServiceSpec(t *testing.T) {
+	c := newClusterForMonitoringTest(edgefsv1beta1.MonitoringSpec{})
+
+	s := c.makeMetricsService(metricsSvcName)
+	assert.Equal(t, "rook-edgefs-metrics", s.Name)
+	assert.Equal(t, "rook-edgefs-metrics", s.Labels["app"])
+	// the service selects the mgr pod serving the metrics
+	assert.Equal(t, appName, s.Spec.Selector["app"])
+	assert.Equal(t, "rook-edgefs", s.Spec.Selector["rook_cluster"])
+	assert.Equal(t, 1, len(s.Spec.Ports))
+	assert.Equal(t, "http-metrics", s.Spec.Ports[0].Name)
+	assert.Equal(t, int32(8881), s.Spec.Ports[0].Port)
+}
+
+func TestServiceMonitor(t *testing.T) {
+	c := newClusterForMonitoringTest(edgefsv1beta1.MonitoringSpec{})
+	monitor := c.makeServiceMonitor()
+	assert.Equal(t, "ServiceMonitor", monitor.Kind)
+	assert.Equal(t, "rook-edgefs-metrics", monitor.Spec.Selector.MatchLabels["app"])
+	assert.Equal(t, []string{"rook-edgefs"}, monitor.Spec.NamespaceSelector.MatchNames)
+	assert.Equal(t, []serviceMonitorEndpoint{{Port: "http-metrics", Path: "/metrics", Interval: "30s"}}, monitor.Spec.Endpoints)
+	assert.Equal(t, 0, len(monitor.Labels))
+
+	c = newClusterForMonitoringTest(edgefsv1beta1.MonitoringSpec{ServiceMonitor: true, Labels: map[string]string{"team": "rook"}, Interval: "5s"})
+	monitor = c.makeServiceMonitor()
+	assert.Equal(t, "rook", monitor.Labels["team"])
+	assert.Equal(t, "5s", monitor.Spec.Endpoints[0].Interval)
+}
+
+// fakeMonitoringAPI keeps the objects of the Prometheus operator API by path
+func fakeMonitoringAPI(objects map[string][]byte) func(verb, path string, body []byte) ([]byte, error) {
+	resource := schema.GroupResource{Group: "monitoring.coreos.com", Resource: "servicemonitors"}
+	return func(verb, path string, body []byte) ([]byte, error) {
+		switch verb {
+		case "POST":
+			var created serviceMonitor
+			if err := json.Unmarshal(body, &created); err != nil {
+				return nil, err
+			}
+			path = path + "/" + created.Name
+			if _, ok := objects[path]; ok {
+				return nil, errors.NewAlreadyExists(resource, created.Name)
+			}
+		case "PUT":
+		case "GET", "DELETE":
+			existing, ok := objects[path]
+			if !ok {
+				return nil, errors.NewNotFound(resource, path)
+			}
+			if verb == "DELETE" {
+				delete(objects, path)
+			}
+			return existing, nil
+		}
+		objects[path] = body
+		return body, nil
+	}
+}
+
+func TestStartSyncsServiceMonitor(t *testing.T) {
+	path := "/apis/monitoring.coreos.com/v1/namespaces/rook-edgefs/servicemonitors/rook-edgefs-metrics"
+	objects := map[string][]byte{}
+	c := newClusterForMonitoringTest(edgefsv1beta1.MonitoringSpec{ServiceMonitor: true, Labels: map[string]string{"team": "rook"}})
+	c.monitoringRequest = fakeMonitoringAPI(objects)
+
+	assert.Nil(t, c.Start("edgefs"))
+	var created serviceMonitor
+	assert.Nil(t, json.Unmarshal(objects[path], &created))
+	assert.Equal(t, "monitoring.coreos.com/v1", created.APIVersion)
+	assert.Equal(t, "rook-edgefs-metrics", created.Name)
+	assert.Equal(t, map[string]string{"team": "rook"}, created.Labels)
+
+	// the existing service monitor is updated to the spec, keeping its version and the fields the operator doesn't set
+	objects[path] = []byte(`{"metadata":{"name":"rook-edgefs-metrics","resourceVersion":"12","labels":{"team":"rook"}},` +
+		`"spec":{"jobLabel":"app","endpoints":[{"port":"http-metrics","path":"/metrics","interval":"30s"}]}}`)
+	c.monitoringSpec = edgefsv1beta1.MonitoringSpec{ServiceMonitor: true, Labels: map[string]string{"prometheus": "edgefs"}, Interval: "5s"}
+	assert.Nil(t, c.syncServiceMonitor())
+	var updated struct {
+		serviceMonitor
+		Spec struct {
+			serviceMonitorSpec
+			JobLabel string `json:"jobLabel"`
+		} `json:"spec"`
+	}
+	assert.Nil(t, json.Unmarshal(objects[path], &updated))
+	assert.Equal(t, "12", updated.ResourceVersion)
+	assert.Equal(t, map[string]string{"prometheus": "edgefs"}, updated.Labels)
+	assert.Equal(t, "app", updated.Spec.JobLabel)
+	assert.Equal(t, []serviceMonitorEndpoint{{Port: "http-metrics", Path: "/metrics", Interval: "5s"}}, updated.Spec.Endpoints)
+	assert.Equal(t, "rook-edgefs-metrics", updated.Spec.Selector.MatchLabels["app"])
+
+	// the service monitor is deleted when it is no longer requested
+	c.monitoringSpec = edgefsv1beta1.MonitoringSpec{}
+	assert.Nil(t, c.Start("edgefs"))
+	assert.Equal(t, 0, len(objects))
+	assert.Nil(t, c.syncServiceMonitor())
+
+	// the cluster starts when the prometheus operator is missing
+	c.monitoringSpec = edgefsv1beta1.MonitoringSpec{ServiceMonitor: true}
+	c.monitoringRequest = func(verb, path string, body []byte) ([]byte, error) {
+		return nil, errors.NewNotFound(schema.GroupResource{Group: "monitoring.coreos.com", Resource: "servicemonitors"}, metricsSvcName)
+	}
+	assert.NotNil(t, c.syncServiceMonitor())
+	assert.Nil(t, c.Start("edgefs"))
+}
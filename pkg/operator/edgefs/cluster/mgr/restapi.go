@@ -0,0 +1,129 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	restapiHTTPTimeout = 10 * time.Second
+	servicePathFmt     = "/service/%s"
+	serviceStatsFmt    = "/service/%s/stats"
+	targetPathFmt      = "/system/target/%s"
+	isgwLinkStateUp    = "up"
+	targetStateOnline  = "online"
+)
+
+// serviceResponse is the part of the description of a service by the REST API that the operator uses
+type serviceResponse struct {
+	Response struct {
+		ServiceObjects []string `json:"X-Service-Objects"`
+	} `json:"response"`
+}
+
+// serviceStatsResponse is the part of the statistics of an ISGW service by the REST API that the operator uses
+type serviceStatsResponse struct {
+	Response struct {
+		Stats struct {
+			Link struct {
+				State string `json:"state"`
+				Lag   int64  `json:"lag"`
+			} `json:"link"`
+		} `json:"stats"`
+	} `json:"response"`
+}
+
+// targetResponse is the part of the description of a target by the REST API that the operator uses
+type targetResponse struct {
+	Response struct {
+		State string `json:"state"`
+	} `json:"response"`
+}
+
+// ISGWLink is the state of the replication link of an ISGW service
+type ISGWLink struct {
+	Up bool
+	// the time in seconds the replication lags behind the local segment
+	LagSeconds int64
+}
+
+// RestAPIClient is a client of the REST API served by the EdgeFS mgr
+type RestAPIClient struct {
+	address string
+	client  *http.Client
+}
+
+// RestAPIAddress returns the address of the REST API of the EdgeFS cluster running in the namespace
+func RestAPIAddress(namespace string) string {
+	return fmt.Sprintf("http://%s.%s:%d", restapiSvcName, namespace, defaultRestPort)
+}
+
+// NewRestAPIClient creates a client of the REST API at the address
+func NewRestAPIClient(address string) *RestAPIClient {
+	return &RestAPIClient{
+		address: address,
+		client:  &http.Client{Timeout: restapiHTTPTimeout},
+	}
+}
+
+// GetServiceObjects returns the tenants and buckets served by an EdgeFS service
+func (c *RestAPIClient) GetServiceObjects(svcname string) ([]string, error) {
+	var resp serviceResponse
+	if err := c.get(fmt.Sprintf(servicePathFmt, svcname), &resp); err != nil {
+		return nil, err
+	}
+	return resp.Response.ServiceObjects, nil
+}
+
+// GetISGWLink returns the state of the replication link of an ISGW service
+func (c *RestAPIClient) GetISGWLink(svcname string) (ISGWLink, error) {
+	var resp serviceStatsResponse
+	if err := c.get(fmt.Sprintf(serviceStatsFmt, svcname), &resp); err != nil {
+		return ISGWLink{}, err
+	}
+	link := resp.Response.Stats.Link
+	return ISGWLink{Up: link.State == isgwLinkStateUp, LagSeconds: link.Lag}, nil
+}
+
+// IsTargetOnline returns whether the target of the host joined the cluster and serves its devices
+func (c *RestAPIClient) IsTargetOnline(hostname string) (bool, error) {
+	var resp targetResponse
+	if err := c.get(fmt.Sprintf(targetPathFmt, hostname), &resp); err != nil {
+		return false, err
+	}
+	return resp.Response.State == targetStateOnline, nil
+}
+
+func (c *RestAPIClient) get(path string, result interface{}) error {
+	url := c.address + path
+	resp, err := c.client.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to get %s. %+v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get %s. status %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode %s. %+v", url, err)
+	}
+	return nil
+}
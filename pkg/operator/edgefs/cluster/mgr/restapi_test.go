@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mgr
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestAPIAddress(t *testing.T) {
+	assert.Equal(t, "http://rook-edgefs-restapi.rook-edgefs:8080", RestAPIAddress("rook-edgefs"))
+}
+
+func TestGetServiceObjects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/service/s3-a":
+			w.Write([]byte(`{"response":{"X-Service-Name":"s3-a","X-Service-Objects":["cltest/test","cltest/test2"]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := NewRestAPIClient(server.URL)
+
+	objects, err := client.GetServiceObjects("s3-a")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"cltest/test", "cltest/test2"}, objects)
+
+	_, err = client.GetServiceObjects("s3-b")
+	assert.NotNil(t, err)
+}
+
+func TestGetISGWLink(t *testing.T) {
+	state := "up"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/service/isgw-a/stats", r.URL.Path)
+		w.Write([]byte(`{"response":{"stats":{"link":{"state":"` + state + `","lag":42}}}}`))
+	}))
+	defer server.Close()
+	client := NewRestAPIClient(server.URL)
+
+	link, err := client.GetISGWLink("isgw-a")
+	assert.Nil(t, err)
+	assert.Equal(t, ISGWLink{Up: true, LagSeconds: 42}, link)
+
+	state = "down"
+	link, err = client.GetISGWLink("isgw-a")
+	assert.Nil(t, err)
+	assert.False(t, link.Up)
+
+	server.Close()
+	_, err = client.GetISGWLink("isgw-a")
+	assert.NotNil(t, err)
+}
+
+func TestIsTargetOnline(t *testing.T) {
+	state := "online"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/system/target/rook-edgefs-target-0", r.URL.Path)
+		w.Write([]byte(`{"response":{"state":"` + state + `"}}`))
+	}))
+	defer server.Close()
+	client := NewRestAPIClient(server.URL)
+
+	online, err := client.IsTargetOnline("rook-edgefs-target-0")
+	assert.Nil(t, err)
+	assert.True(t, online)
+
+	state = "offline"
+	online, err = client.IsTargetOnline("rook-edgefs-target-0")
+	assert.Nil(t, err)
+	assert.False(t, online)
+
+	server.Close()
+	_, err = client.IsTargetOnline("rook-edgefs-target-0")
+	assert.NotNil(t, err)
+}
@@ -201,6 +201,66 @@ func GetContainersRTDevices(nodeName string, maxContainerCapacity int64, nodeDis
 	return containersRtDevices, nil
 }
 
+// IsRTDeviceInUse tells whether the disk is one of the devices already used by the containers of a node
+func IsRTDeviceInUse(containers []edgefsv1beta1.RTDevices, disk sys.LocalDisk) bool {
+	name := getIdDevLinkName(disk.DevLinks)
+	for _, container := range containers {
+		for _, rtDevice := range container.Devices {
+			if rtDevice.Device == "/dev/"+disk.Name || (len(name) > 0 && rtDevice.Name == name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SpreadRTDevices spreads the devices of the added containers over the containersCount containers of a node, on top
+// of the devices the existing containers already use. The devices sharing a journal device are kept in the same
+// container, and each device group goes to the container with the fewest devices.
+func SpreadRTDevices(existing []edgefsv1beta1.RTDevices, added []edgefsv1beta1.RTDevices, containersCount int) ([]edgefsv1beta1.RTDevices, error) {
+	if len(existing) > containersCount {
+		return nil, fmt.Errorf("%d containers can't be spread over %d containers", len(existing), containersCount)
+	}
+
+	containers := make([]edgefsv1beta1.RTDevices, containersCount)
+	for i := range existing {
+		containers[i].Devices = append([]edgefsv1beta1.RTDevice{}, existing[i].Devices...)
+	}
+
+	// group the added devices by journal, devices without journal are groups on their own
+	var groups [][]edgefsv1beta1.RTDevice
+	journalGroups := map[string]int{}
+	for _, container := range added {
+		for _, rtDevice := range container.Devices {
+			if len(rtDevice.Journal) > 0 {
+				if i, ok := journalGroups[rtDevice.Journal]; ok {
+					groups[i] = append(groups[i], rtDevice)
+					continue
+				}
+				journalGroups[rtDevice.Journal] = len(groups)
+			}
+			groups = append(groups, []edgefsv1beta1.RTDevice{rtDevice})
+		}
+	}
+
+	for _, group := range groups {
+		smallest := 0
+		for i := range containers {
+			if len(containers[i].Devices) < len(containers[smallest].Devices) {
+				smallest = i
+			}
+		}
+		containers[smallest].Devices = append(containers[smallest].Devices, group...)
+	}
+
+	for i := range containers {
+		if len(containers[i].Devices) == 0 {
+			return nil, fmt.Errorf("not enough devices to fill %d containers", containersCount)
+		}
+	}
+	return containers, nil
+}
+
 func getRTDevices(cntDevs ContainerDevices, storeConfig *config.StoreConfig) (rtDevices []edgefsv1beta1.RTDevice, err error) {
 	rtDevices = make([]edgefsv1beta1.RTDevice, 0)
 
@@ -0,0 +1,74 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package target
+
+import (
+	"testing"
+
+	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/util/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func rtDevicesForTest(devices ...string) edgefsv1beta1.RTDevices {
+	rtDevices := edgefsv1beta1.RTDevices{}
+	for _, device := range devices {
+		rtDevices.Devices = append(rtDevices.Devices, edgefsv1beta1.RTDevice{Name: "ata-" + device, Device: "/dev/" + device})
+	}
+	return rtDevices
+}
+
+func TestIsRTDeviceInUse(t *testing.T) {
+	containers := []edgefsv1beta1.RTDevices{rtDevicesForTest("sda"), rtDevicesForTest("sdb")}
+
+	assert.True(t, IsRTDeviceInUse(containers, sys.LocalDisk{Name: "sdb"}))
+	// the device may have been renamed since it was laid out
+	assert.True(t, IsRTDeviceInUse(containers, sys.LocalDisk{Name: "sdx", DevLinks: "/dev/disk/by-id/ata-sda /dev/disk/by-path/pci-0"}))
+	assert.False(t, IsRTDeviceInUse(containers, sys.LocalDisk{Name: "sdc", DevLinks: "/dev/disk/by-id/ata-sdc"}))
+	assert.False(t, IsRTDeviceInUse(nil, sys.LocalDisk{Name: "sda"}))
+}
+
+func TestSpreadRTDevices(t *testing.T) {
+	existing := []edgefsv1beta1.RTDevices{rtDevicesForTest("sda", "sdb"), rtDevicesForTest("sdc")}
+
+	// the added devices go to the containers with the fewest devices
+	containers, err := SpreadRTDevices(existing, []edgefsv1beta1.RTDevices{rtDevicesForTest("sdd", "sde")}, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []edgefsv1beta1.RTDevices{rtDevicesForTest("sda", "sdb", "sde"), rtDevicesForTest("sdc", "sdd")}, containers)
+	// the existing containers are left untouched
+	assert.Equal(t, 2, len(existing[0].Devices))
+
+	// no device added
+	containers, err = SpreadRTDevices(existing, nil, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, existing, containers)
+
+	// the devices sharing a journal stay in the same container
+	added := rtDevicesForTest("sdd", "sde", "sdf")
+	added.Devices[0].Journal = "nvme0"
+	added.Devices[1].Journal = "nvme1"
+	added.Devices[2].Journal = "nvme0"
+	containers, err = SpreadRTDevices(nil, []edgefsv1beta1.RTDevices{added}, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []edgefsv1beta1.RTDevice{added.Devices[0], added.Devices[2]}, containers[0].Devices)
+	assert.Equal(t, []edgefsv1beta1.RTDevice{added.Devices[1]}, containers[1].Devices)
+
+	// not enough devices for the containers
+	_, err = SpreadRTDevices(nil, []edgefsv1beta1.RTDevices{rtDevicesForTest("sdd")}, 2)
+	assert.NotNil(t, err)
+	_, err = SpreadRTDevices(existing, nil, 1)
+	assert.NotNil(t, err)
+}
@@ -17,12 +17,14 @@ limitations under the License.
 package target
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/coreos/pkg/capnslog"
 	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -133,8 +135,50 @@ func (c *Cluster) Start(rookImage string, nodes []rookalpha.Node, dro edgefsv1be
 			return err
 		}
 		logger.Infof("stateful set %s already exists in namespace %s", statefulSet.Name, statefulSet.Namespace)
-	} else {
-		logger.Infof("stateful set %s created in namespace %s", statefulSet.Name, statefulSet.Namespace)
+		return c.scaleStatefulSet(*statefulSet.Spec.Replicas)
+	}
+	logger.Infof("stateful set %s created in namespace %s", statefulSet.Name, statefulSet.Namespace)
+	return nil
+}
+
+// scaleStatefulSet runs a target on each node added to the cluster, or stops the targets of the nodes removed from it
+func (c *Cluster) scaleStatefulSet(replicas int32) error {
+	statefulSet, err := c.context.Clientset.AppsV1().StatefulSets(c.Namespace).Get(appName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get stateful set %s. %+v", appName, err)
+	}
+	if statefulSet.Spec.Replicas != nil && *statefulSet.Spec.Replicas == replicas {
+		return nil
+	}
+
+	logger.Infof("scaling stateful set %s to %d targets", appName, replicas)
+	statefulSet.Spec.Replicas = &replicas
+	if _, err := c.context.Clientset.AppsV1().StatefulSets(c.Namespace).Update(statefulSet); err != nil {
+		return fmt.Errorf("failed to scale stateful set %s. %+v", appName, err)
+	}
+	return nil
+}
+
+// RemoveNodes deletes the targets still running on the nodes removed from the cluster. Scaling the stateful set down
+// stops the targets with the highest ids whatever their node, so the targets of the removed nodes are deleted for the
+// stateful set to run them again on the nodes left without target.
+func (c *Cluster) RemoveNodes(nodes []string) error {
+	selector := fmt.Sprintf("%s=%s", k8sutil.AppAttr, appName)
+	pods, err := c.context.Clientset.CoreV1().Pods(c.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list target pods. %+v", err)
+	}
+
+	for _, pod := range pods.Items {
+		for _, node := range nodes {
+			if pod.Spec.NodeName != node || pod.DeletionTimestamp != nil {
+				continue
+			}
+			logger.Infof("deleting target %s of removed node %s", pod.Name, node)
+			if err := c.context.Clientset.CoreV1().Pods(c.Namespace).Delete(pod.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete target %s of node %s. %+v", pod.Name, node, err)
+			}
+		}
 	}
 	return nil
 }
@@ -0,0 +1,81 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package target
+
+import (
+	"testing"
+
+	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStartScalesTargets(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	c := New(&clusterd.Context{Clientset: clientset}, "ns", "rook/rook:myversion", "",
+		rookalpha.StorageScopeSpec{}, "/var/lib/edgefs", resource.Quantity{},
+		rookalpha.Annotations{}, rookalpha.Placement{}, edgefsv1beta1.NetworkSpec{}, v1.ResourceRequirements{}, "", resource.Quantity{},
+		metav1.OwnerReference{}, edgefsv1beta1.ClusterDeploymentConfig{})
+	nodes := []rookalpha.Node{{Name: "node1"}, {Name: "node2"}, {Name: "node3"}}
+
+	assert.Nil(t, c.Start("edgefs/edgefs:latest", nodes, edgefsv1beta1.DevicesResurrectOptions{}))
+	statefulSet, err := clientset.AppsV1().StatefulSets("ns").Get(appName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), *statefulSet.Spec.Replicas)
+
+	// a node added to the cluster runs a new target
+	nodes = append(nodes, rookalpha.Node{Name: "node4"})
+	assert.Nil(t, c.Start("edgefs/edgefs:latest", nodes, edgefsv1beta1.DevicesResurrectOptions{}))
+	statefulSet, err = clientset.AppsV1().StatefulSets("ns").Get(appName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(4), *statefulSet.Spec.Replicas)
+
+	// a node removed from the cluster stops a target
+	assert.Nil(t, c.Start("edgefs/edgefs:latest", nodes[1:], edgefsv1beta1.DevicesResurrectOptions{}))
+	statefulSet, err = clientset.AppsV1().StatefulSets("ns").Get(appName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), *statefulSet.Spec.Replicas)
+}
+
+func TestRemoveNodes(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	c := New(&clusterd.Context{Clientset: clientset}, "ns", "rook/rook:myversion", "",
+		rookalpha.StorageScopeSpec{}, "/var/lib/edgefs", resource.Quantity{},
+		rookalpha.Annotations{}, rookalpha.Placement{}, edgefsv1beta1.NetworkSpec{}, v1.ResourceRequirements{}, "", resource.Quantity{},
+		metav1.OwnerReference{}, edgefsv1beta1.ClusterDeploymentConfig{})
+	for i, node := range []string{"node1", "node2", "node3"} {
+		_, err := clientset.CoreV1().Pods("ns").Create(&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: CreateQualifiedHeadlessServiceName(i, "ns"), Namespace: "ns", Labels: map[string]string{k8sutil.AppAttr: appName}},
+			Spec:       v1.PodSpec{NodeName: node},
+		})
+		assert.Nil(t, err)
+	}
+
+	// the target of the removed node is deleted for the stateful set to run it on the node left without target
+	assert.Nil(t, c.RemoveNodes([]string{"node2"}))
+	pods, err := clientset.CoreV1().Pods("ns").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(pods.Items))
+	for _, pod := range pods.Items {
+		assert.NotEqual(t, "node2", pod.Spec.NodeName)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package target
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TargetName returns the name of the pod of the target with the ordinal, which is also the hostname of the target
+func TargetName(ordinal int32) string {
+	return fmt.Sprintf("%s-%d", appName, ordinal)
+}
+
+// GetImage returns the EdgeFS image the targets are deployed with, empty when the targets are not deployed yet
+func (c *Cluster) GetImage() (string, error) {
+	statefulSet, err := c.context.Clientset.AppsV1().StatefulSets(c.Namespace).Get(appName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get stateful set %s. %+v", appName, err)
+	}
+	if len(statefulSet.Spec.Template.Spec.Containers) == 0 {
+		return "", fmt.Errorf("stateful set %s has no containers", appName)
+	}
+	return statefulSet.Spec.Template.Spec.Containers[0].Image, nil
+}
+
+// UpgradeTemplate sets the EdgeFS image of the template of the targets. The targets keep running their image until
+// they are rolled out one at a time, and the count of the targets is returned.
+func (c *Cluster) UpgradeTemplate(image string) (int32, error) {
+	statefulSet, err := c.context.Clientset.AppsV1().StatefulSets(c.Namespace).Get(appName, metav1.GetOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stateful set %s. %+v", appName, err)
+	}
+	replicas := int32(0)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	if templateImageIs(statefulSet, image) {
+		return replicas, nil
+	}
+
+	logger.Infof("upgrading the template of stateful set %s to image %s", appName, image)
+	for i := range statefulSet.Spec.Template.Spec.InitContainers {
+		statefulSet.Spec.Template.Spec.InitContainers[i].Image = image
+	}
+	for i := range statefulSet.Spec.Template.Spec.Containers {
+		statefulSet.Spec.Template.Spec.Containers[i].Image = image
+	}
+	// none of the running targets is restarted by the update of the template
+	statefulSet.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+		Type:          appsv1.RollingUpdateStatefulSetStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{Partition: &replicas},
+	}
+	if _, err := c.context.Clientset.AppsV1().StatefulSets(c.Namespace).Update(statefulSet); err != nil {
+		return 0, fmt.Errorf("failed to update the template of stateful set %s. %+v", appName, err)
+	}
+	return replicas, nil
+}
+
+// RollOut restarts the targets with an ordinal of at least the given one on the template of the stateful set
+func (c *Cluster) RollOut(ordinal int32) error {
+	statefulSet, err := c.context.Clientset.AppsV1().StatefulSets(c.Namespace).Get(appName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get stateful set %s. %+v", appName, err)
+	}
+	rollingUpdate := statefulSet.Spec.UpdateStrategy.RollingUpdate
+	if rollingUpdate != nil && rollingUpdate.Partition != nil && *rollingUpdate.Partition <= ordinal {
+		return nil
+	}
+
+	logger.Infof("rolling out target %s", TargetName(ordinal))
+	statefulSet.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+		Type:          appsv1.RollingUpdateStatefulSetStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{Partition: &ordinal},
+	}
+	if _, err := c.context.Clientset.AppsV1().StatefulSets(c.Namespace).Update(statefulSet); err != nil {
+		return fmt.Errorf("failed to roll out target %s. %+v", TargetName(ordinal), err)
+	}
+	return nil
+}
+
+// IsTargetRunning returns whether the pod of the target with the ordinal runs the image and is ready
+func (c *Cluster) IsTargetRunning(ordinal int32, image string) (bool, error) {
+	pod, err := c.context.Clientset.CoreV1().Pods(c.Namespace).Get(TargetName(ordinal), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get target %s. %+v", TargetName(ordinal), err)
+	}
+	if pod.DeletionTimestamp != nil {
+		return false, nil
+	}
+	for _, container := range pod.Spec.Containers {
+		if container.Image != image {
+			return false, nil
+		}
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue, nil
+		}
+	}
+	return false, nil
+}
+
+func templateImageIs(statefulSet *appsv1.StatefulSet, image string) bool {
+	for _, container := range statefulSet.Spec.Template.Spec.Containers {
+		if container.Image != image {
+			return false
+		}
+	}
+	return true
+}
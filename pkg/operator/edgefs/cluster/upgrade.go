@@ -0,0 +1,293 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cluster
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/operator/edgefs/cluster/target"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	mgrDeploymentName = "rook-edgefs-mgr"
+	// the label of the deployments of the gateway services
+	serviceTypeLabel = "edgefs_svctype"
+)
+
+var (
+	updateDeploymentAndWait = k8sutil.UpdateDeploymentAndWait
+
+	// the interval and the timeout of the wait for an upgraded target to be back online
+	targetUpgradeInterval = 5 * time.Second
+	targetUpgradeTimeout  = 10 * time.Minute
+)
+
+// upgrade rolls the cluster out to the EdgeFS image of its spec, or back to the image it ran before the last upgrade
+// when requested. The mgr is upgraded first, then the targets one at a time, each target back online before the next
+// one is upgraded, and the gateway services last. Returns whether the rollout is complete, which it is not while the
+// upgrade is paused.
+func (c *cluster) upgrade() (bool, error) {
+	clust, err := c.context.RookClientset.EdgefsV1beta1().Clusters(c.Namespace).Get(c.Name, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get cluster %s. %+v", c.Name, err)
+	}
+
+	image := getEdgefsImage(c.Spec)
+	from, err := c.targets.GetImage()
+	if err != nil {
+		return false, err
+	}
+	if from == "" {
+		// the targets are deployed with the image of the spec
+		c.image = image
+		return true, nil
+	}
+
+	upgradeStatus := clust.Status.Upgrade
+	state, completedState := edgefsv1beta1.UpgradeStateUpgrading, edgefsv1beta1.UpgradeStateCompleted
+	switch {
+	case c.Spec.Upgrade.Rollback && upgradeStatus != nil && upgradeStatus.PreviousImage != "":
+		if upgradeStatus.State == edgefsv1beta1.UpgradeStateRolledBack {
+			c.image = upgradeStatus.PreviousImage
+			return true, nil
+		}
+		from, image = upgradeStatus.Image, upgradeStatus.PreviousImage
+		state, completedState = edgefsv1beta1.UpgradeStateRollingBack, edgefsv1beta1.UpgradeStateRolledBack
+	case upgradeStatus != nil && upgradeStatus.Image == image && isUpgradeInProgress(upgradeStatus.State):
+		// resume the upgrade, the targets upgraded already run the image
+		from = upgradeStatus.PreviousImage
+	case from == image:
+		c.image = image
+		if clust.Status.Image != image {
+			return true, c.updateUpgradeStatus(upgradeStatus, image)
+		}
+		return true, nil
+	default:
+		if c.Spec.Upgrade.Rollback {
+			logger.Warningf("no previous image to roll cluster %s back to", c.Namespace)
+		}
+		upgradeStatus = &edgefsv1beta1.UpgradeStatus{PreviousImage: from, Image: image}
+	}
+
+	// the cluster runs the previous image until the rollout completes
+	c.image = from
+	if c.Spec.Upgrade.Paused && upgradeStatus.UpgradedTargets == 0 {
+		logger.Infof("upgrade of cluster %s to image %s is paused", c.Namespace, image)
+		upgradeStatus.State = edgefsv1beta1.UpgradeStatePaused
+		upgradeStatus.Message = fmt.Sprintf("paused before the rollout of image %s", image)
+		return false, c.updateUpgradeStatus(upgradeStatus, "")
+	}
+
+	logger.Infof("rolling cluster %s out from image %s to %s", c.Namespace, from, image)
+	upgradeStatus.State = state
+	upgradeStatus.Message = ""
+	if err := c.updateUpgradeStatus(upgradeStatus, ""); err != nil {
+		return false, err
+	}
+
+	mgrDeployment, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(mgrDeploymentName, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return false, c.failUpgrade(upgradeStatus, fmt.Errorf("failed to get deployment %s. %+v", mgrDeploymentName, err))
+	}
+	if err == nil {
+		if err := c.upgradeDeployment(mgrDeployment, from, image); err != nil {
+			return false, c.failUpgrade(upgradeStatus, err)
+		}
+	}
+
+	done, err := c.upgradeTargets(upgradeStatus, image)
+	if err != nil {
+		return false, c.failUpgrade(upgradeStatus, err)
+	}
+	if !done {
+		return false, nil
+	}
+
+	selector := metav1.ListOptions{LabelSelector: serviceTypeLabel}
+	services, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).List(selector)
+	if err != nil {
+		return false, c.failUpgrade(upgradeStatus, fmt.Errorf("failed to list the deployments of the services. %+v", err))
+	}
+	for i := range services.Items {
+		if err := c.upgradeDeployment(&services.Items[i], from, image); err != nil {
+			return false, c.failUpgrade(upgradeStatus, err)
+		}
+	}
+
+	logger.Infof("cluster %s rolled out to image %s", c.Namespace, image)
+	upgradeStatus.State = completedState
+	c.image = image
+	return true, c.updateUpgradeStatus(upgradeStatus, image)
+}
+
+// upgradeTargets restarts the targets on the image from the highest ordinal down, waiting for each target to be back
+// online before the next one is restarted. Returns whether all the targets run the image, which they don't when the
+// upgrade is paused.
+func (c *cluster) upgradeTargets(upgradeStatus *edgefsv1beta1.UpgradeStatus, image string) (bool, error) {
+	replicas, err := c.targets.UpgradeTemplate(image)
+	if err != nil {
+		return false, err
+	}
+	upgradeStatus.Targets = replicas
+
+	for ordinal := replicas - 1; ordinal >= 0; ordinal-- {
+		upgradeStatus.UpgradedTargets = replicas - 1 - ordinal
+		running, err := c.targets.IsTargetRunning(ordinal, image)
+		if err != nil {
+			return false, err
+		}
+		if !running {
+			// the upgrade may be paused while the previous targets are upgraded
+			clust, err := c.context.RookClientset.EdgefsV1beta1().Clusters(c.Namespace).Get(c.Name, metav1.GetOptions{})
+			if err != nil {
+				return false, fmt.Errorf("failed to get cluster %s. %+v", c.Name, err)
+			}
+			if clust.Spec.Upgrade.Paused {
+				logger.Infof("upgrade of cluster %s is paused before target %s", c.Namespace, target.TargetName(ordinal))
+				upgradeStatus.State = edgefsv1beta1.UpgradeStatePaused
+				upgradeStatus.Message = fmt.Sprintf("paused before the upgrade of target %s", target.TargetName(ordinal))
+				return false, c.updateUpgradeStatus(upgradeStatus, "")
+			}
+			if err := c.updateUpgradeStatus(upgradeStatus, ""); err != nil {
+				return false, err
+			}
+		}
+
+		if err := c.targets.RollOut(ordinal); err != nil {
+			return false, err
+		}
+		if err := c.waitForTarget(ordinal, image); err != nil {
+			return false, err
+		}
+	}
+
+	upgradeStatus.UpgradedTargets = replicas
+	return true, nil
+}
+
+// waitForTarget waits for the target with the ordinal to run the image and to be back online in the cluster
+func (c *cluster) waitForTarget(ordinal int32, image string) error {
+	name := target.TargetName(ordinal)
+	err := wait.PollImmediate(targetUpgradeInterval, targetUpgradeTimeout, func() (bool, error) {
+		running, err := c.targets.IsTargetRunning(ordinal, image)
+		if err != nil {
+			logger.Warningf("failed to get the state of target %s. %+v", name, err)
+			return false, nil
+		}
+		if !running {
+			return false, nil
+		}
+		online, err := c.restapi.IsTargetOnline(name)
+		if err != nil {
+			// the mgr may be restarting
+			logger.Debugf("failed to get the state of target %s from the mgr. %+v", name, err)
+			return false, nil
+		}
+		return online, nil
+	})
+	if err != nil {
+		return fmt.Errorf("target %s is not back online with image %s after %s", name, image, targetUpgradeTimeout)
+	}
+	logger.Infof("target %s is back online with image %s", name, image)
+	return nil
+}
+
+// upgradeDeployment updates the containers of a deployment from an EdgeFS image to another and waits for its pods to
+// be restarted
+func (c *cluster) upgradeDeployment(d *apps.Deployment, from, to string) error {
+	changed := false
+	for i, container := range d.Spec.Template.Spec.Containers {
+		containerImage := upgradeImage(container.Image, from, to)
+		if containerImage != container.Image {
+			d.Spec.Template.Spec.Containers[i].Image = containerImage
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	logger.Infof("upgrading deployment %s to image %s", d.Name, to)
+	if _, err := updateDeploymentAndWait(c.context, d, c.Namespace); err != nil {
+		return fmt.Errorf("failed to upgrade deployment %s. %+v", d.Name, err)
+	}
+	return nil
+}
+
+// failUpgrade records the failure of the upgrade, which is retried with the updates of the cluster
+func (c *cluster) failUpgrade(upgradeStatus *edgefsv1beta1.UpgradeStatus, err error) error {
+	upgradeStatus.State = edgefsv1beta1.UpgradeStateFailed
+	upgradeStatus.Message = err.Error()
+	if err := c.updateUpgradeStatus(upgradeStatus, ""); err != nil {
+		logger.Errorf("failed to update the upgrade status of cluster %s. %+v", c.Namespace, err)
+	}
+	return err
+}
+
+// updateUpgradeStatus records the progress of the upgrade in the cluster status, and the image the cluster runs unless
+// empty
+func (c *cluster) updateUpgradeStatus(upgradeStatus *edgefsv1beta1.UpgradeStatus, image string) error {
+	clust, err := c.context.RookClientset.EdgefsV1beta1().Clusters(c.Namespace).Get(c.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster %s prior to updating its upgrade status. %+v", c.Name, err)
+	}
+
+	clust.Status.Upgrade = upgradeStatus
+	if image != "" {
+		clust.Status.Image = image
+	}
+	if _, err := c.context.RookClientset.EdgefsV1beta1().Clusters(c.Namespace).Update(clust); err != nil {
+		return fmt.Errorf("failed to update the upgrade status of cluster %s. %+v", c.Name, err)
+	}
+	return nil
+}
+
+func isUpgradeInProgress(state edgefsv1beta1.UpgradeState) bool {
+	return state == edgefsv1beta1.UpgradeStateUpgrading || state == edgefsv1beta1.UpgradeStatePaused ||
+		state == edgefsv1beta1.UpgradeStateFailed
+}
+
+// upgradeImage returns the image of a container upgraded from an EdgeFS image to another. The images of the containers
+// deployed along EdgeFS, such as the restapi and the ui of the mgr, follow the tag of the EdgeFS image.
+func upgradeImage(containerImage, from, to string) string {
+	if containerImage == from {
+		return to
+	}
+	repo, tag := splitImage(containerImage)
+	_, fromTag := splitImage(from)
+	_, toTag := splitImage(to)
+	if tag == fromTag && fromTag != toTag {
+		return repo + ":" + toTag
+	}
+	return containerImage
+}
+
+// splitImage returns the repository and the tag of an image, the tag is latest unless given
+func splitImage(image string) (string, string) {
+	components := strings.Split(image, ":")
+	if len(components) == 2 {
+		return components[0], components[1]
+	}
+	return image, "latest"
+}
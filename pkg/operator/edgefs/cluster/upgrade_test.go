@@ -0,0 +1,206 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cluster
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/edgefs/cluster/mgr"
+	"github.com/rook/rook/pkg/operator/edgefs/cluster/target"
+	testopk8s "github.com/rook/rook/pkg/operator/k8sutil/test"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	oldImage = "edgefs/edgefs:1.1"
+	newImage = "edgefs/edgefs:1.2"
+)
+
+// setTargetPods runs the targets with the ordinals on the image
+func setTargetPods(t *testing.T, clientset kubernetes.Interface, namespace, image string, ordinals ...int32) {
+	for _, ordinal := range ordinals {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: target.TargetName(ordinal), Namespace: namespace},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "daemon", Image: image}}},
+			Status:     v1.PodStatus{Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}},
+		}
+		clientset.CoreV1().Pods(namespace).Delete(pod.Name, &metav1.DeleteOptions{})
+		_, err := clientset.CoreV1().Pods(namespace).Create(pod)
+		assert.Nil(t, err)
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	namespace := "rook-edgefs"
+	targetUpgradeInterval = time.Millisecond
+	targetUpgradeTimeout = 100 * time.Millisecond
+	var deploymentsUpdated *[]*apps.Deployment
+	updateDeploymentAndWait, deploymentsUpdated = testopk8s.UpdateDeploymentAndWaitStub()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"response":{"state":"online"}}`))
+	}))
+	defer server.Close()
+
+	clientset := testop.New(3)
+	clusterObj := &edgefsv1beta1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "edgefs", Namespace: namespace},
+		Spec: edgefsv1beta1.ClusterSpec{
+			EdgefsImageName: newImage,
+			Upgrade:         edgefsv1beta1.UpgradeSpec{Paused: true},
+		},
+	}
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookfake.NewSimpleClientset(clusterObj)}
+	c := newCluster(clusterObj, context)
+	c.restapi = mgr.NewRestAPIClient(server.URL)
+	c.targets = target.New(context, namespace, "latest", "", rookalpha.StorageScopeSpec{}, "/var/lib/edgefs", resource.Quantity{},
+		rookalpha.Annotations{}, rookalpha.Placement{}, edgefsv1beta1.NetworkSpec{}, v1.ResourceRequirements{}, "", resource.Quantity{},
+		metav1.OwnerReference{}, edgefsv1beta1.ClusterDeploymentConfig{})
+	nodes := []rookalpha.Node{{Name: "node0"}, {Name: "node1"}, {Name: "node2"}}
+	assert.Nil(t, c.targets.Start(oldImage, nodes, edgefsv1beta1.DevicesResurrectOptions{}))
+	setTargetPods(t, clientset, namespace, oldImage, 0, 1, 2)
+
+	_, err := clientset.AppsV1().Deployments(namespace).Create(&apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: mgrDeploymentName, Namespace: namespace},
+		Spec: apps.DeploymentSpec{Template: v1.PodTemplateSpec{Spec: v1.PodSpec{Containers: []v1.Container{
+			{Name: "rest", Image: "edgefs/edgefs-restapi:1.1"}, {Name: "grpc", Image: oldImage}, {Name: "ui", Image: "edgefs/edgefs-ui:1.1"},
+		}}}},
+	})
+	assert.Nil(t, err)
+	_, err = clientset.AppsV1().Deployments(namespace).Create(&apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-edgefs-nfs-nfs01", Namespace: namespace, Labels: map[string]string{serviceTypeLabel: "nfs"}},
+		Spec:       apps.DeploymentSpec{Template: v1.PodTemplateSpec{Spec: v1.PodSpec{Containers: []v1.Container{{Name: "nfs01", Image: oldImage}}}}},
+	})
+	assert.Nil(t, err)
+
+	getStatus := func() edgefsv1beta1.ClusterStatus {
+		clust, err := context.RookClientset.EdgefsV1beta1().Clusters(namespace).Get("edgefs", metav1.GetOptions{})
+		assert.Nil(t, err)
+		return clust.Status
+	}
+	setPaused := func(paused bool) {
+		clust, err := context.RookClientset.EdgefsV1beta1().Clusters(namespace).Get("edgefs", metav1.GetOptions{})
+		assert.Nil(t, err)
+		clust.Spec.Upgrade.Paused = paused
+		_, err = context.RookClientset.EdgefsV1beta1().Clusters(namespace).Update(clust)
+		assert.Nil(t, err)
+	}
+
+	// nothing is upgraded while the upgrade is paused
+	done, err := c.upgrade()
+	assert.Nil(t, err)
+	assert.False(t, done)
+	assert.Equal(t, oldImage, c.image)
+	status := getStatus()
+	assert.Equal(t, edgefsv1beta1.UpgradeStatePaused, status.Upgrade.State)
+	assert.Equal(t, oldImage, status.Upgrade.PreviousImage)
+	assert.Equal(t, newImage, status.Upgrade.Image)
+	assert.Equal(t, 0, len(*deploymentsUpdated))
+	image, err := c.targets.GetImage()
+	assert.Nil(t, err)
+	assert.Equal(t, oldImage, image)
+
+	// the mgr is upgraded first, then the targets one at a time until the upgrade is paused again
+	c.Spec.Upgrade.Paused = false
+	setTargetPods(t, clientset, namespace, newImage, 2)
+	done, err = c.upgrade()
+	assert.Nil(t, err)
+	assert.False(t, done)
+	assert.Equal(t, []string{mgrDeploymentName}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
+	containers := (*deploymentsUpdated)[0].Spec.Template.Spec.Containers
+	assert.Equal(t, "edgefs/edgefs-restapi:1.2", containers[0].Image)
+	assert.Equal(t, newImage, containers[1].Image)
+	assert.Equal(t, "edgefs/edgefs-ui:1.2", containers[2].Image)
+	status = getStatus()
+	assert.Equal(t, edgefsv1beta1.UpgradeStatePaused, status.Upgrade.State)
+	assert.Equal(t, int32(3), status.Upgrade.Targets)
+	assert.Equal(t, int32(1), status.Upgrade.UpgradedTargets)
+	statefulSet, err := clientset.AppsV1().StatefulSets(namespace).Get("rook-edgefs-target", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, newImage, statefulSet.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, int32(2), *statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition)
+
+	// a target not back online fails the upgrade
+	setPaused(false)
+	done, err = c.upgrade()
+	assert.NotNil(t, err)
+	assert.False(t, done)
+	assert.Equal(t, edgefsv1beta1.UpgradeStateFailed, getStatus().Upgrade.State)
+
+	// the upgrade resumes with the remaining targets, then the gateway services
+	setTargetPods(t, clientset, namespace, newImage, 0, 1)
+	done, err = c.upgrade()
+	assert.Nil(t, err)
+	assert.True(t, done)
+	assert.Equal(t, newImage, c.image)
+	status = getStatus()
+	assert.Equal(t, edgefsv1beta1.UpgradeStateCompleted, status.Upgrade.State)
+	assert.Equal(t, int32(3), status.Upgrade.UpgradedTargets)
+	assert.Equal(t, newImage, status.Image)
+	assert.Contains(t, testopk8s.DeploymentNamesUpdated(deploymentsUpdated), "rook-edgefs-nfs-nfs01")
+	statefulSet, err = clientset.AppsV1().StatefulSets(namespace).Get("rook-edgefs-target", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(0), *statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition)
+
+	// the cluster rolls back to the image recorded in the status
+	c.Spec.Upgrade.Rollback = true
+	setTargetPods(t, clientset, namespace, oldImage, 0, 1, 2)
+	done, err = c.upgrade()
+	assert.Nil(t, err)
+	assert.True(t, done)
+	assert.Equal(t, oldImage, c.image)
+	status = getStatus()
+	assert.Equal(t, edgefsv1beta1.UpgradeStateRolledBack, status.Upgrade.State)
+	assert.Equal(t, oldImage, status.Image)
+	image, err = c.targets.GetImage()
+	assert.Nil(t, err)
+	assert.Equal(t, oldImage, image)
+}
+
+func TestUpgradeImage(t *testing.T) {
+	assert.Equal(t, "edgefs/edgefs:1.2", upgradeImage("edgefs/edgefs:1.1", "edgefs/edgefs:1.1", "edgefs/edgefs:1.2"))
+	assert.Equal(t, "edgefs/edgefs-ui:1.2", upgradeImage("edgefs/edgefs-ui:1.1", "edgefs/edgefs:1.1", "edgefs/edgefs:1.2"))
+	assert.Equal(t, "edgefs/edgefs-ui:1.2", upgradeImage("edgefs/edgefs-ui:latest", "edgefs/edgefs", "edgefs/edgefs:1.2"))
+	assert.Equal(t, "edgefs/edgefs-ui:1.0", upgradeImage("edgefs/edgefs-ui:1.0", "edgefs/edgefs:1.1", "edgefs/edgefs:1.2"))
+	assert.Equal(t, "edgefs/edgefs-ui:1.1", upgradeImage("edgefs/edgefs-ui:1.1", "edgefs/edgefs:1.1", "mirror/edgefs:1.1"))
+	assert.Equal(t, "mirror/edgefs:1.1", upgradeImage("edgefs/edgefs:1.1", "edgefs/edgefs:1.1", "mirror/edgefs:1.1"))
+}
+
+func TestClusterChangedImage(t *testing.T) {
+	old := edgefsv1beta1.ClusterSpec{EdgefsImageName: oldImage}
+	new := edgefsv1beta1.ClusterSpec{EdgefsImageName: newImage}
+	assert.True(t, clusterChanged(old, new))
+
+	new.EdgefsImageName = oldImage
+	new.Upgrade.Rollback = true
+	assert.True(t, clusterChanged(old, new))
+
+	new.Upgrade.Rollback = false
+	assert.False(t, clusterChanged(old, new))
+}
@@ -98,8 +98,12 @@ func (c *cluster) getClusterNodes() ([]rookalpha.Node, error) {
 	return validNodes, nil
 }
 
-func (c *cluster) createDeploymentConfig(nodes []rookalpha.Node, resurrect bool) (edgefsv1beta1.ClusterDeploymentConfig, error) {
+// createDeploymentConfig creates the devices layout of the nodes. The layouts of the nodes of a previous deployment are
+// kept, as their devices are formatted by now, and only the devices added to the cluster since are laid out.
+func (c *cluster) createDeploymentConfig(nodes []rookalpha.Node, layouts map[string]edgefsv1beta1.SetupNode, resurrect bool) (edgefsv1beta1.ClusterDeploymentConfig, error) {
 	deploymentConfig := edgefsv1beta1.ClusterDeploymentConfig{DevConfig: make(map[string]edgefsv1beta1.DevicesConfig, 0)}
+	// the target containers count is shared by all the nodes of a deployed cluster
+	containersCount := getContainersCount(layouts)
 	// Fill deploymentConfig devices struct
 	for _, node := range nodes {
 		n := c.resolveNode(node.Name)
@@ -136,11 +140,17 @@ func (c *cluster) createDeploymentConfig(nodes []rookalpha.Node, resurrect bool)
 				n.Name, c.Namespace, deviceErr)
 		}
 
-		// Selects Disks from availDevs and translate to RTDevices
+		// Containers of the node laid out by a previous deployment
+		var nodeContainers []edgefsv1beta1.RTDevices
+		if layout, ok := layouts[node.Name]; ok && len(layout.Rtrd.Devices) > 0 {
+			nodeContainers = append([]edgefsv1beta1.RTDevices{layout.Rtrd}, layout.RtrdSlaves...)
+		}
+
+		// Selects Disks from availDevs not used yet and translate to RTDevices
 		availDisks := []sys.LocalDisk{}
 		for _, dev := range availDevs {
 			for _, disk := range nodeDevices[n.Name] {
-				if disk.Name == dev.Name {
+				if disk.Name == dev.Name && !target.IsRTDeviceInUse(nodeContainers, disk) {
 					availDisks = append(availDisks, disk)
 				}
 			}
@@ -151,6 +161,20 @@ func (c *cluster) createDeploymentConfig(nodes []rookalpha.Node, resurrect bool)
 			logger.Warningf("Can't get rtDevices for node %s due %v", n.Name, err)
 			rtDevices = make([]edgefsv1beta1.RTDevices, 1)
 		}
+
+		if len(nodeContainers) > 0 {
+			// Spread the devices added to the node over its containers
+			rtDevices, err = target.SpreadRTDevices(nodeContainers, rtDevices, len(nodeContainers))
+			if err != nil {
+				return deploymentConfig, fmt.Errorf("failed to add devices to node %s. %+v", n.Name, err)
+			}
+		} else if containersCount > 0 && len(availDisks) > 0 {
+			// Lay the devices of the node added to the cluster out over as many containers as the other nodes
+			rtDevices, err = target.SpreadRTDevices(nil, rtDevices, containersCount)
+			if err != nil {
+				return deploymentConfig, fmt.Errorf("failed to add node %s with %d containers like the other nodes. %+v", n.Name, containersCount, err)
+			}
+		}
 		if len(rtDevices) > 0 {
 			devicesConfig.Rtrd.Devices = rtDevices[0].Devices
 			// append to RtrdSlaves in case of additional containers
@@ -168,10 +192,20 @@ func (c *cluster) createDeploymentConfig(nodes []rookalpha.Node, resurrect bool)
 		return deploymentConfig, err
 	}
 
+	err = c.applyNodeZones(&deploymentConfig, layouts)
+	if err != nil {
+		return deploymentConfig, err
+	}
+
 	err = ValidateZones(&deploymentConfig)
 	if err != nil {
 		return deploymentConfig, err
 	}
+
+	err = ValidateReplication(&deploymentConfig, c.Spec.Zoning)
+	if err != nil {
+		return deploymentConfig, err
+	}
 	// Add Directories to deploymentConfig
 	clusterStorageConfig := config.ToStoreConfig(c.Spec.Storage.Config)
 	deploymentConfig.Directories = target.GetRtlfsDevices(c.Spec.Storage.Directories, &clusterStorageConfig)
@@ -0,0 +1,377 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package validate to check the hosts of an Edgefs cluster can run targets before they are deployed.
+package validate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	batch "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "edgefs-op-validate")
+
+const (
+	appName                   = "host-validate"
+	defaultServiceAccountName = "rook-edgefs-cluster"
+	jobNameFmt                = "%s-%s"
+	devicesVolumeName         = "devices"
+	validateTimeout           = 5 * time.Minute
+	// the facts are reported from /tmp, as the /dev of the job is the /dev of the host
+	terminationMessagePath = "/tmp/host-facts"
+
+	// the oldest kernel the targets run on
+	minKernelVersion = "4.4"
+
+	// the facts of the host reported by the validation job
+	kernelFact               = "kernel"
+	memTotalFact             = "memTotalKB"
+	transparentHugePagesFact = "transparentHugePages"
+	sysctlFactPrefix         = "sysctl."
+	interfaceFactPrefix      = "interface."
+	smartFactPrefix          = "smart."
+
+	// the script gathering the facts of the host, reported in the termination message of the job
+	factsScript = `
+{
+echo "kernel=$(uname -r)"
+echo "memTotalKB=$(awk '/^MemTotal:/ {print $2}' /proc/meminfo)"
+echo "transparentHugePages=$(sed -e 's/.*\[\(.*\)\].*/\1/' /sys/kernel/mm/transparent_hugepage/enabled 2>/dev/null)"
+for s in $SYSCTLS; do
+  echo "sysctl.$s=$(cat /proc/sys/$(echo $s | tr . /) 2>/dev/null)"
+done
+for i in $INTERFACES; do
+  echo "interface.$i=$(cat /sys/class/net/$i/operstate 2>/dev/null || echo missing)"
+done
+for d in $DEVICES; do
+  h=""
+  if command -v smartctl >/dev/null 2>&1; then
+    h=$(smartctl -H $d | awk -F': *' '/overall-health|Health Status/ {print $2}')
+  fi
+  echo "smart.$d=${h:-unknown}"
+done
+} > /tmp/host-facts
+`
+)
+
+// sysctlLimit is the range of a kernel setting tuned by the host preparation
+type sysctlLimit struct {
+	min int64
+	max int64
+}
+
+// requiredSysctls are the kernel settings the targets need, set by the host preparation unless skipped
+var requiredSysctls = map[string]sysctlLimit{
+	"net.core.rmem_max": {min: 80331648},
+	"net.core.wmem_max": {min: 50331648},
+	"vm.swappiness":     {max: 25},
+}
+
+// Requirements are the resources of a host needed by the target of a node
+type Requirements struct {
+	// the memory of the host in bytes
+	Memory int64
+	// the network interfaces of the host network of the cluster
+	Interfaces []string
+	// the device paths of the target
+	Devices []string
+}
+
+// Cluster is the edgefs host validation manager
+type Cluster struct {
+	Namespace      string
+	Version        string
+	serviceAccount string
+	annotations    rookalpha.Annotations
+	placement      rookalpha.Placement
+	context        *clusterd.Context
+	ownerRef       metav1.OwnerReference
+}
+
+// New creates an instance of the host validation
+func New(
+	context *clusterd.Context, namespace, version string,
+	serviceAccount string,
+	annotations rookalpha.Annotations,
+	placement rookalpha.Placement,
+	ownerRef metav1.OwnerReference,
+) *Cluster {
+
+	if serviceAccount == "" {
+		// if the service account was not set, make a best effort with the example service account name since the default is unlikely to be sufficient.
+		serviceAccount = defaultServiceAccountName
+		logger.Infof("setting the validate pods to use the service account name: %s", serviceAccount)
+	}
+
+	return &Cluster{
+		context:        context,
+		Namespace:      namespace,
+		serviceAccount: serviceAccount,
+		annotations:    annotations,
+		placement:      placement,
+		Version:        version,
+		ownerRef:       ownerRef,
+	}
+}
+
+// Start the validation of the host of a node, and return the checks the host failed
+func (c *Cluster) Start(rookImage, nodeName string, requirements Requirements) ([]string, error) {
+	logger.Infof("start validating host of node %s", nodeName)
+
+	job := c.makeJob(rookImage, nodeName, requirements)
+	if err := k8sutil.RunReplaceableJob(c.context.Clientset, job, true); err != nil {
+		return nil, fmt.Errorf("failed to create %s job. %+v", job.Name, err)
+	}
+
+	if err := k8sutil.WaitForJobCompletion(c.context.Clientset, job, validateTimeout); err != nil {
+		return nil, fmt.Errorf("failed to validate host of node %s. %+v", nodeName, err)
+	}
+
+	facts, err := c.getFacts(job)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := k8sutil.DeleteBatchJob(c.context.Clientset, c.Namespace, job.Name, false); err != nil {
+		logger.Warningf("Failed to delete job %s. %+v", job.Name, err)
+	}
+
+	failures := Check(facts, requirements)
+	logger.Infof("host of node %s validated with %d failed checks", nodeName, len(failures))
+	return failures, nil
+}
+
+// getFacts returns the facts of the host reported in the termination message of the pod of the job
+func (c *Cluster) getFacts(job *batch.Job) (map[string]string, error) {
+	pods, err := c.context.Clientset.CoreV1().Pods(c.Namespace).List(metav1.ListOptions{LabelSelector: "job-name=" + job.Name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pods of job %s. %+v", job.Name, err)
+	}
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated != nil && status.State.Terminated.ExitCode == 0 {
+				return ParseFacts(status.State.Terminated.Message), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no facts reported by job %s", job.Name)
+}
+
+func (c *Cluster) makeJob(rookImage, nodeName string, requirements Requirements) *batch.Job {
+	volumes := []v1.Volume{
+		{
+			Name: devicesVolumeName,
+			VolumeSource: v1.VolumeSource{
+				HostPath: &v1.HostPathVolumeSource{
+					Path: "/dev",
+				},
+			},
+		},
+	}
+
+	gracePeriod := int64(0)
+	backoffLimit := int32(0)
+	podSpec := v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   appName,
+			Labels: c.getLabels(),
+		},
+		Spec: v1.PodSpec{
+			ServiceAccountName:            c.serviceAccount,
+			Containers:                    []v1.Container{c.validateContainer(rookImage, requirements)},
+			RestartPolicy:                 v1.RestartPolicyNever,
+			HostNetwork:                   true,
+			DNSPolicy:                     v1.DNSClusterFirstWithHostNet,
+			TerminationGracePeriodSeconds: &gracePeriod,
+			NodeSelector:                  map[string]string{v1.LabelHostname: nodeName},
+			Volumes:                       volumes,
+		},
+	}
+	c.annotations.ApplyToObjectMeta(&podSpec.ObjectMeta)
+	c.placement.ApplyToPodSpec(&podSpec.Spec)
+
+	job := &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      k8sutil.TruncateNodeName(fmt.Sprintf(jobNameFmt, appName, "%s"), nodeName),
+			Namespace: c.Namespace,
+			Labels:    c.getLabels(),
+		},
+		Spec: batch.JobSpec{
+			Template:     podSpec,
+			BackoffLimit: &backoffLimit,
+		},
+	}
+	k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &job.ObjectMeta, &c.ownerRef)
+	return job
+}
+
+// validateContainer gathers the facts of the host, in the host network to see the interfaces of the host
+func (c *Cluster) validateContainer(containerImage string, requirements Requirements) v1.Container {
+
+	privileged := true
+	runAsUser := int64(0)
+	readOnlyRootFilesystem := false
+	securityContext := &v1.SecurityContext{
+		Privileged:             &privileged,
+		RunAsUser:              &runAsUser,
+		ReadOnlyRootFilesystem: &readOnlyRootFilesystem,
+	}
+
+	sysctls := []string{}
+	for name := range requiredSysctls {
+		sysctls = append(sysctls, name)
+	}
+	sort.Strings(sysctls)
+
+	return v1.Container{
+		Name:                   appName,
+		Image:                  containerImage,
+		ImagePullPolicy:        v1.PullAlways,
+		Command:                []string{"/bin/sh", "-c", factsScript},
+		TerminationMessagePath: terminationMessagePath,
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      devicesVolumeName,
+				MountPath: "/dev",
+			},
+		},
+		Env: []v1.EnvVar{
+			{Name: "SYSCTLS", Value: strings.Join(sysctls, " ")},
+			{Name: "INTERFACES", Value: strings.Join(requirements.Interfaces, " ")},
+			{Name: "DEVICES", Value: strings.Join(requirements.Devices, " ")},
+		},
+		SecurityContext: securityContext,
+	}
+}
+
+func (c *Cluster) getLabels() map[string]string {
+	return map[string]string{
+		k8sutil.AppAttr:     appName,
+		k8sutil.ClusterAttr: c.Namespace,
+	}
+}
+
+// ParseFacts parses the facts of a host, reported one key=value per line
+func ParseFacts(message string) map[string]string {
+	facts := map[string]string{}
+	for _, line := range strings.Split(message, "\n") {
+		kv := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(kv) == 2 {
+			facts[kv[0]] = strings.TrimSpace(kv[1])
+		}
+	}
+	return facts
+}
+
+// Check returns the checks the facts of a host fail for the requirements of the target of its node
+func Check(facts map[string]string, requirements Requirements) []string {
+	failures := []string{}
+
+	kernel := facts[kernelFact]
+	if !isKernelSupported(kernel) {
+		failures = append(failures, fmt.Sprintf("kernel %q is older than %s", kernel, minKernelVersion))
+	}
+
+	memTotalKB, err := strconv.ParseInt(facts[memTotalFact], 10, 64)
+	if err != nil {
+		failures = append(failures, fmt.Sprintf("memory of the host unknown %q", facts[memTotalFact]))
+	} else if memTotalKB*1024 < requirements.Memory {
+		failures = append(failures, fmt.Sprintf("memory %dMi is less than the %dMi of the resource profile", memTotalKB/1024, requirements.Memory/(1024*1024)))
+	}
+
+	// the transparent huge pages stall the targets on memory compaction
+	if facts[transparentHugePagesFact] == "always" {
+		failures = append(failures, "transparent huge pages are always enabled, set them to madvise or never")
+	}
+
+	sysctls := []string{}
+	for name := range requiredSysctls {
+		sysctls = append(sysctls, name)
+	}
+	sort.Strings(sysctls)
+	for _, name := range sysctls {
+		limit := requiredSysctls[name]
+		value, err := strconv.ParseInt(facts[sysctlFactPrefix+name], 10, 64)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("sysctl %s unknown", name))
+			continue
+		}
+		if limit.min > 0 && value < limit.min {
+			failures = append(failures, fmt.Sprintf("sysctl %s is %d, less than %d", name, value, limit.min))
+		}
+		if limit.max > 0 && value > limit.max {
+			failures = append(failures, fmt.Sprintf("sysctl %s is %d, more than %d", name, value, limit.max))
+		}
+	}
+
+	for _, name := range requirements.Interfaces {
+		switch state := facts[interfaceFactPrefix+name]; state {
+		case "", "missing":
+			failures = append(failures, fmt.Sprintf("network interface %s not found", name))
+		case "down":
+			failures = append(failures, fmt.Sprintf("network interface %s is down", name))
+		}
+	}
+
+	for _, device := range requirements.Devices {
+		health := facts[smartFactPrefix+device]
+		// the health of the devices without SMART is unknown
+		if health != "" && health != "unknown" && health != "PASSED" && health != "OK" {
+			failures = append(failures, fmt.Sprintf("device %s SMART health is %s", device, health))
+		}
+	}
+	return failures
+}
+
+// isKernelSupported returns whether a kernel release, e.g. 4.15.0-45-generic, is at least the minimal version
+func isKernelSupported(release string) bool {
+	version, ok := parseKernelVersion(release)
+	if !ok {
+		return false
+	}
+	minVersion, _ := parseKernelVersion(minKernelVersion)
+	if version[0] != minVersion[0] {
+		return version[0] > minVersion[0]
+	}
+	return version[1] >= minVersion[1]
+}
+
+func parseKernelVersion(release string) ([2]int, bool) {
+	version := [2]int{}
+	components := strings.SplitN(strings.SplitN(release, "-", 2)[0], ".", 3)
+	if len(components) < 2 {
+		return version, false
+	}
+	for i := 0; i < 2; i++ {
+		n, err := strconv.Atoi(components[i])
+		if err != nil {
+			return version, false
+		}
+		version[i] = n
+	}
+	return version, true
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package validate
+
+import (
+	"testing"
+
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const healthyFacts = `kernel=4.15.0-45-generic
+memTotalKB=16777216
+transparentHugePages=madvise
+sysctl.net.core.rmem_max=80331648
+sysctl.net.core.wmem_max=50331648
+sysctl.vm.swappiness=15
+interface.eth1=up
+smart./dev/sda=PASSED
+smart./dev/sdb=unknown
+`
+
+func TestMakeJob(t *testing.T) {
+	c := New(&clusterd.Context{Clientset: testop.New(1)}, "ns", "latest", "", rookalpha.Annotations{}, rookalpha.Placement{}, metav1.OwnerReference{})
+	requirements := Requirements{Interfaces: []string{"eth1"}, Devices: []string{"/dev/sda", "/dev/sdb"}}
+	job := c.makeJob("edgefs/edgefs:latest", "node0", requirements)
+
+	assert.Equal(t, "host-validate-node0", job.Name)
+	assert.Equal(t, int32(0), *job.Spec.BackoffLimit)
+	podSpec := job.Spec.Template.Spec
+	assert.True(t, podSpec.HostNetwork)
+	assert.Equal(t, v1.RestartPolicyNever, podSpec.RestartPolicy)
+	assert.Equal(t, "node0", podSpec.NodeSelector[v1.LabelHostname])
+	assert.Equal(t, defaultServiceAccountName, podSpec.ServiceAccountName)
+	container := podSpec.Containers[0]
+	assert.Equal(t, "edgefs/edgefs:latest", container.Image)
+	assert.True(t, *container.SecurityContext.Privileged)
+	assert.Equal(t, []v1.EnvVar{
+		{Name: "SYSCTLS", Value: "net.core.rmem_max net.core.wmem_max vm.swappiness"},
+		{Name: "INTERFACES", Value: "eth1"},
+		{Name: "DEVICES", Value: "/dev/sda /dev/sdb"},
+	}, container.Env)
+}
+
+func TestCheck(t *testing.T) {
+	requirements := Requirements{Memory: 8 * 1024 * 1024 * 1024, Interfaces: []string{"eth1"}, Devices: []string{"/dev/sda", "/dev/sdb"}}
+	facts := ParseFacts(healthyFacts)
+	assert.Equal(t, "4.15.0-45-generic", facts[kernelFact])
+	assert.Equal(t, 0, len(Check(facts, requirements)))
+
+	facts = ParseFacts(healthyFacts)
+	facts[kernelFact] = "3.10.0-957.el7.x86_64"
+	facts[memTotalFact] = "4194304"
+	facts[transparentHugePagesFact] = "always"
+	facts[sysctlFactPrefix+"vm.swappiness"] = "60"
+	delete(facts, sysctlFactPrefix+"net.core.rmem_max")
+	facts[interfaceFactPrefix+"eth1"] = "missing"
+	facts[smartFactPrefix+"/dev/sda"] = "FAILED!"
+	assert.Equal(t, []string{
+		`kernel "3.10.0-957.el7.x86_64" is older than 4.4`,
+		"memory 4096Mi is less than the 8192Mi of the resource profile",
+		"transparent huge pages are always enabled, set them to madvise or never",
+		"sysctl net.core.rmem_max unknown",
+		"sysctl vm.swappiness is 60, more than 25",
+		"network interface eth1 not found",
+		"device /dev/sda SMART health is FAILED!",
+	}, Check(facts, requirements))
+}
+
+func TestIsKernelSupported(t *testing.T) {
+	assert.True(t, isKernelSupported("4.4.0"))
+	assert.True(t, isKernelSupported("4.15.0-45-generic"))
+	assert.True(t, isKernelSupported("5.0.9-301.fc30.x86_64"))
+	assert.False(t, isKernelSupported("3.10.0-957.el7.x86_64"))
+	assert.False(t, isKernelSupported("4.3"))
+	assert.False(t, isKernelSupported(""))
+}
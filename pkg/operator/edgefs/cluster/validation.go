@@ -0,0 +1,150 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/operator/edgefs/cluster/validate"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// the memory of a target container by resource profile
+var profileMemory = map[string]string{
+	"performance": "8Gi",
+	"embedded":    "1Gi",
+}
+
+// validateHostNodes validates the hosts of the nodes not deployed yet by a previous deployment, and reports the
+// result of every node in the cluster status. The targets are not deployed unless all the hosts pass.
+func (c *cluster) validateHostNodes(rookImage string, deploymentConfig edgefsv1beta1.ClusterDeploymentConfig, layouts map[string]edgefsv1beta1.SetupNode) error {
+	nodeNames := []string{}
+	for nodeName := range deploymentConfig.DevConfig {
+		if _, ok := layouts[nodeName]; !ok {
+			nodeNames = append(nodeNames, nodeName)
+		}
+	}
+	sort.Strings(nodeNames)
+
+	failed := []string{}
+	for _, nodeName := range nodeNames {
+		requirements := c.getHostRequirements(deploymentConfig.DevConfig[nodeName])
+		status := edgefsv1beta1.NodeValidationStatus{Node: nodeName, State: edgefsv1beta1.NodeValidationStatePassed}
+
+		failedChecks, err := c.validateNode(rookImage, nodeName, requirements)
+		if err != nil {
+			status.State = edgefsv1beta1.NodeValidationStateFailed
+			status.Message = err.Error()
+		} else if len(failedChecks) > 0 {
+			status.State = edgefsv1beta1.NodeValidationStateFailed
+			status.FailedChecks = failedChecks
+		}
+		if status.State == edgefsv1beta1.NodeValidationStateFailed {
+			logger.Errorf("host of node %s failed the validation. %s %v", nodeName, status.Message, status.FailedChecks)
+			failed = append(failed, nodeName)
+		}
+
+		if err := c.updateValidationStatus(status); err != nil {
+			return err
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("hosts of nodes %v failed the validation, see the hostValidations of the cluster status or set skipHostValidation", failed)
+	}
+	return nil
+}
+
+// getHostRequirements returns the resources the host of a node needs for the target containers of its devices
+func (c *cluster) getHostRequirements(devicesConfig edgefsv1beta1.DevicesConfig) validate.Requirements {
+	requirements := validate.Requirements{}
+
+	profile := c.Spec.ResourceProfile
+	if profile == "" {
+		profile = "performance"
+	}
+	memory := resource.MustParse(profileMemory[profile])
+	if request := c.Spec.Resources.Requests.Memory(); request.Cmp(memory) > 0 {
+		memory = *request
+	}
+	containers := int64(1 + len(devicesConfig.RtrdSlaves))
+	requirements.Memory = memory.Value() * containers
+
+	if isHostNetworkDefined(c.Spec.Network) {
+		for _, name := range []string{c.Spec.Network.ServerIfName, c.Spec.Network.BrokerIfName} {
+			if name != "" && !containsString(requirements.Interfaces, name) {
+				requirements.Interfaces = append(requirements.Interfaces, name)
+			}
+		}
+	}
+
+	rtDevices := append([]edgefsv1beta1.RTDevices{devicesConfig.Rtrd}, devicesConfig.RtrdSlaves...)
+	for _, devices := range rtDevices {
+		for _, device := range devices.Devices {
+			path := device.Device
+			if !strings.HasPrefix(path, "/dev/") {
+				path = "/dev/" + device.Name
+			}
+			requirements.Devices = append(requirements.Devices, path)
+		}
+	}
+	return requirements
+}
+
+// runValidation validates the host of a node with a job gathering its facts on the node
+func (c *cluster) runValidation(rookImage, nodeName string, requirements validate.Requirements) ([]string, error) {
+	val := validate.New(c.context, c.Namespace, "latest", c.Spec.ServiceAccount,
+		edgefsv1beta1.GetPrepareAnnotations(c.Spec.Annotations), edgefsv1beta1.GetPreparePlacement(c.Spec.Placement), c.ownerRef)
+	return val.Start(rookImage, nodeName, requirements)
+}
+
+// updateValidationStatus reports the validation of the host of a node in the cluster status
+func (c *cluster) updateValidationStatus(status edgefsv1beta1.NodeValidationStatus) error {
+	clust, err := c.context.RookClientset.EdgefsV1beta1().Clusters(c.Namespace).Get(c.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster %s prior to updating its status. %+v", c.Name, err)
+	}
+
+	found := false
+	for i := range clust.Status.HostValidations {
+		if clust.Status.HostValidations[i].Node == status.Node {
+			clust.Status.HostValidations[i] = status
+			found = true
+		}
+	}
+	if !found {
+		clust.Status.HostValidations = append(clust.Status.HostValidations, status)
+	}
+
+	if _, err := c.context.RookClientset.EdgefsV1beta1().Clusters(c.Namespace).Update(clust); err != nil {
+		return fmt.Errorf("failed to update the validation status of node %s. %+v", status.Node, err)
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
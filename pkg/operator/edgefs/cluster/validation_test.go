@@ -0,0 +1,95 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/edgefs/cluster/validate"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateHostNodes(t *testing.T) {
+	clust := &edgefsv1beta1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "edgefs", Namespace: "ns"}}
+	context := &clusterd.Context{Clientset: testop.New(3), RookClientset: rookfake.NewSimpleClientset(clust)}
+	c := newCluster(clust, context)
+
+	validated := []string{}
+	c.validateNode = func(rookImage, nodeName string, requirements validate.Requirements) ([]string, error) {
+		validated = append(validated, nodeName)
+		switch nodeName {
+		case "node2":
+			return []string{"network interface eth1 not found"}, nil
+		case "node3":
+			return nil, fmt.Errorf("mock failure")
+		}
+		return nil, nil
+	}
+
+	// the nodes of a previous deployment are not validated again
+	deploymentConfig := deploymentConfigForTest("node0", "node1", "node2", "node3")
+	layouts := map[string]edgefsv1beta1.SetupNode{"node0": {}}
+	assert.NotNil(t, c.validateHostNodes("edgefs/edgefs:latest", *deploymentConfig, layouts))
+	assert.Equal(t, []string{"node1", "node2", "node3"}, validated)
+
+	current, err := context.RookClientset.EdgefsV1beta1().Clusters("ns").Get("edgefs", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []edgefsv1beta1.NodeValidationStatus{
+		{Node: "node1", State: edgefsv1beta1.NodeValidationStatePassed},
+		{Node: "node2", State: edgefsv1beta1.NodeValidationStateFailed, FailedChecks: []string{"network interface eth1 not found"}},
+		{Node: "node3", State: edgefsv1beta1.NodeValidationStateFailed, Message: "mock failure"},
+	}, current.Status.HostValidations)
+
+	// the results of a node are replaced by its next validation
+	validated = []string{}
+	assert.Nil(t, c.validateHostNodes("edgefs/edgefs:latest", *deploymentConfigForTest("node0", "node1"), layouts))
+	assert.Equal(t, []string{"node1"}, validated)
+	current, err = context.RookClientset.EdgefsV1beta1().Clusters("ns").Get("edgefs", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(current.Status.HostValidations))
+}
+
+func TestGetHostRequirements(t *testing.T) {
+	c := &cluster{Spec: edgefsv1beta1.ClusterSpec{Network: edgefsv1beta1.NetworkSpec{ServerIfName: "eth1", BrokerIfName: "eth1"}}}
+	devicesConfig := edgefsv1beta1.DevicesConfig{
+		Rtrd:       edgefsv1beta1.RTDevices{Devices: []edgefsv1beta1.RTDevice{{Name: "sda", Device: "/dev/sda"}}},
+		RtrdSlaves: []edgefsv1beta1.RTDevices{{Devices: []edgefsv1beta1.RTDevice{{Name: "sdb"}}}},
+	}
+
+	// the performance profile by default, for every target container
+	requirements := c.getHostRequirements(devicesConfig)
+	assert.Equal(t, int64(16*1024*1024*1024), requirements.Memory)
+	assert.Equal(t, []string{"eth1"}, requirements.Interfaces)
+	assert.Equal(t, []string{"/dev/sda", "/dev/sdb"}, requirements.Devices)
+
+	// the memory requested takes precedence over the profile when larger
+	c.Spec = edgefsv1beta1.ClusterSpec{ResourceProfile: "embedded"}
+	requirements = c.getHostRequirements(edgefsv1beta1.DevicesConfig{IsGatewayNode: true})
+	assert.Equal(t, int64(1024*1024*1024), requirements.Memory)
+	assert.Equal(t, 0, len(requirements.Interfaces))
+	assert.Equal(t, 0, len(requirements.Devices))
+	c.Spec.Resources = v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("2Gi")}}
+	requirements = c.getHostRequirements(edgefsv1beta1.DevicesConfig{})
+	assert.Equal(t, int64(2*1024*1024*1024), requirements.Memory)
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cluster
+
+import (
+	"fmt"
+	"sort"
+
+	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
+)
+
+const (
+	defaultReplicationCount = 3
+
+	// the failure domains of the replicas in ccow.json
+	failureDomainHost = 1
+	failureDomainZone = 2
+)
+
+// applyNodeZones sets the zones of the nodes from the zone label of the nodes. A zone keeps the number given by a
+// previous deployment, and the zones new to the cluster are numbered after the known ones.
+func (c *cluster) applyNodeZones(deploymentConfig *edgefsv1beta1.ClusterDeploymentConfig, layouts map[string]edgefsv1beta1.SetupNode) error {
+	labelKey := c.Spec.Zoning.LabelKey
+	if labelKey == "" {
+		return nil
+	}
+
+	nodeNames := make([]string, 0, len(deploymentConfig.DevConfig))
+	for nodeName := range deploymentConfig.DevConfig {
+		nodeNames = append(nodeNames, nodeName)
+	}
+	sort.Strings(nodeNames)
+
+	// the zone label of the nodes without a zone in their storage config
+	labels := map[string]string{}
+	for _, nodeName := range nodeNames {
+		if deploymentConfig.DevConfig[nodeName].Zone > 0 {
+			continue
+		}
+		nodeLabels, err := c.getNodeLabels(c.context.Clientset, nodeName)
+		if err != nil {
+			return fmt.Errorf("failed to get labels of node %s. %+v", nodeName, err)
+		}
+		value, ok := nodeLabels[labelKey]
+		if !ok || value == "" {
+			return fmt.Errorf("node %s has no zone label %s", nodeName, labelKey)
+		}
+		labels[nodeName] = value
+	}
+
+	zones := map[string]int{}
+	maxZone := 0
+	for _, nodeName := range nodeNames {
+		zone := deploymentConfig.DevConfig[nodeName].Zone
+		if layout, ok := layouts[nodeName]; ok && layout.Ccowd.Zone > 0 {
+			zone = layout.Ccowd.Zone
+			if value, ok := labels[nodeName]; ok {
+				if _, found := zones[value]; !found {
+					zones[value] = zone
+				}
+			}
+		}
+		if zone > maxZone {
+			maxZone = zone
+		}
+	}
+
+	for _, nodeName := range nodeNames {
+		value, ok := labels[nodeName]
+		if !ok {
+			continue
+		}
+		zone, found := zones[value]
+		if !found {
+			maxZone++
+			zone = maxZone
+			zones[value] = zone
+		}
+		devConfig := deploymentConfig.DevConfig[nodeName]
+		devConfig.Zone = zone
+		deploymentConfig.DevConfig[nodeName] = devConfig
+		logger.Debugf("node %s is in zone %d (%s=%s)", nodeName, zone, labelKey, value)
+	}
+	return nil
+}
+
+// ValidateReplication validates the replicas of the data can be placed on distinct failure domains of the cluster
+func ValidateReplication(deploymentConfig *edgefsv1beta1.ClusterDeploymentConfig, zoning edgefsv1beta1.ZoningSpec) error {
+	placement := getReplicaPlacement(deploymentConfig, zoning)
+	if placement == "" {
+		// no replication settings, the failure domain is the host as in previous versions
+		return nil
+	}
+
+	replicationCount := getReplicationCount(zoning)
+	hosts := 0
+	zones := map[int]bool{}
+	for _, devConfig := range deploymentConfig.DevConfig {
+		// gateways don't store data
+		if devConfig.IsGatewayNode {
+			continue
+		}
+		hosts++
+		if devConfig.Zone > 0 {
+			zones[devConfig.Zone] = true
+		}
+	}
+
+	switch placement {
+	case edgefsv1beta1.ReplicaPlacementZone:
+		if len(zones) < replicationCount {
+			return fmt.Errorf("%d replicas can't be placed in distinct zones of the %d zones of the target nodes", replicationCount, len(zones))
+		}
+	case edgefsv1beta1.ReplicaPlacementHost:
+		if hosts < replicationCount {
+			return fmt.Errorf("%d replicas can't be placed on distinct hosts of the %d target nodes", replicationCount, hosts)
+		}
+	default:
+		return fmt.Errorf("unknown replica placement %s", placement)
+	}
+	return nil
+}
+
+// getReplicaPlacement returns the failure domain of the replicas, the zone when the nodes have zones unless set
+func getReplicaPlacement(deploymentConfig *edgefsv1beta1.ClusterDeploymentConfig, zoning edgefsv1beta1.ZoningSpec) edgefsv1beta1.ReplicaPlacement {
+	if zoning.ReplicaPlacement != "" {
+		return zoning.ReplicaPlacement
+	}
+	for _, devConfig := range deploymentConfig.DevConfig {
+		if devConfig.Zone > 0 {
+			return edgefsv1beta1.ReplicaPlacementZone
+		}
+	}
+	if zoning.ReplicationCount > 0 {
+		return edgefsv1beta1.ReplicaPlacementHost
+	}
+	return ""
+}
+
+func getReplicationCount(zoning edgefsv1beta1.ZoningSpec) int {
+	if zoning.ReplicationCount > 0 {
+		return zoning.ReplicationCount
+	}
+	return defaultReplicationCount
+}
+
+// getFailureDomain returns the failure domain of ccow.json for the replica placement
+func getFailureDomain(placement edgefsv1beta1.ReplicaPlacement) int {
+	if placement == edgefsv1beta1.ReplicaPlacementZone {
+		return failureDomainZone
+	}
+	return failureDomainHost
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cluster
+
+import (
+	"testing"
+
+	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const zoneLabel = "failure-domain.beta.kubernetes.io/zone"
+
+func deploymentConfigForTest(nodeNames ...string) *edgefsv1beta1.ClusterDeploymentConfig {
+	deploymentConfig := &edgefsv1beta1.ClusterDeploymentConfig{DevConfig: map[string]edgefsv1beta1.DevicesConfig{}}
+	for _, nodeName := range nodeNames {
+		deploymentConfig.DevConfig[nodeName] = edgefsv1beta1.DevicesConfig{}
+	}
+	return deploymentConfig
+}
+
+func TestApplyNodeZones(t *testing.T) {
+	clientset := testop.New(0)
+	for name, zone := range map[string]string{"node1": "us-east-1a", "node2": "us-east-1b", "node3": "us-east-1a", "node4": "us-east-1c"} {
+		_, err := clientset.CoreV1().Nodes().Create(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{zoneLabel: zone}}})
+		assert.Nil(t, err)
+	}
+	c := &cluster{context: &clusterd.Context{Clientset: clientset}, Namespace: "ns"}
+
+	// the zones are ignored without a label key
+	deploymentConfig := deploymentConfigForTest("node1", "node2", "node3")
+	assert.Nil(t, c.applyNodeZones(deploymentConfig, nil))
+	assert.Equal(t, 0, deploymentConfig.DevConfig["node1"].Zone)
+
+	c.Spec.Zoning.LabelKey = zoneLabel
+	assert.Nil(t, c.applyNodeZones(deploymentConfig, nil))
+	assert.Equal(t, 1, deploymentConfig.DevConfig["node1"].Zone)
+	assert.Equal(t, 2, deploymentConfig.DevConfig["node2"].Zone)
+	assert.Equal(t, 1, deploymentConfig.DevConfig["node3"].Zone)
+
+	// the zones of a previous deployment keep their numbers, and a zone of a storage config is kept
+	deploymentConfig = deploymentConfigForTest("node1", "node2", "node3", "node4")
+	deploymentConfig.DevConfig["node3"] = edgefsv1beta1.DevicesConfig{Zone: 7}
+	layouts := map[string]edgefsv1beta1.SetupNode{
+		"node1": {Ccowd: edgefsv1beta1.CcowdConf{Zone: 2}},
+		"node2": {Ccowd: edgefsv1beta1.CcowdConf{Zone: 1}},
+	}
+	assert.Nil(t, c.applyNodeZones(deploymentConfig, layouts))
+	assert.Equal(t, 2, deploymentConfig.DevConfig["node1"].Zone)
+	assert.Equal(t, 1, deploymentConfig.DevConfig["node2"].Zone)
+	assert.Equal(t, 7, deploymentConfig.DevConfig["node3"].Zone)
+	assert.Equal(t, 8, deploymentConfig.DevConfig["node4"].Zone)
+
+	// every node without a zone must have the label
+	_, err := clientset.CoreV1().Nodes().Create(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node5"}})
+	assert.Nil(t, err)
+	deploymentConfig = deploymentConfigForTest("node1", "node5")
+	assert.NotNil(t, c.applyNodeZones(deploymentConfig, nil))
+}
+
+func TestValidateReplication(t *testing.T) {
+	deploymentConfig := deploymentConfigForTest("node1", "node2", "node3")
+
+	// no zones nor replication settings
+	assert.Nil(t, ValidateReplication(deploymentConfig, edgefsv1beta1.ZoningSpec{}))
+	assert.Equal(t, edgefsv1beta1.ReplicaPlacement(""), getReplicaPlacement(deploymentConfig, edgefsv1beta1.ZoningSpec{}))
+	assert.Nil(t, ValidateReplication(deploymentConfig, edgefsv1beta1.ZoningSpec{ReplicationCount: 3}))
+	assert.NotNil(t, ValidateReplication(deploymentConfig, edgefsv1beta1.ZoningSpec{ReplicationCount: 4}))
+	assert.NotNil(t, ValidateReplication(deploymentConfig, edgefsv1beta1.ZoningSpec{ReplicaPlacement: "rack"}))
+
+	// two zones
+	deploymentConfig.DevConfig["node1"] = edgefsv1beta1.DevicesConfig{Zone: 1}
+	deploymentConfig.DevConfig["node2"] = edgefsv1beta1.DevicesConfig{Zone: 2}
+	deploymentConfig.DevConfig["node3"] = edgefsv1beta1.DevicesConfig{Zone: 2}
+	assert.Equal(t, edgefsv1beta1.ReplicaPlacementZone, getReplicaPlacement(deploymentConfig, edgefsv1beta1.ZoningSpec{}))
+	assert.NotNil(t, ValidateReplication(deploymentConfig, edgefsv1beta1.ZoningSpec{}))
+	assert.Nil(t, ValidateReplication(deploymentConfig, edgefsv1beta1.ZoningSpec{ReplicationCount: 2}))
+	assert.Nil(t, ValidateReplication(deploymentConfig, edgefsv1beta1.ZoningSpec{ReplicaPlacement: edgefsv1beta1.ReplicaPlacementHost}))
+
+	// the gateways don't count
+	deploymentConfig.DevConfig["node4"] = edgefsv1beta1.DevicesConfig{Zone: 3, IsGatewayNode: true}
+	assert.NotNil(t, ValidateReplication(deploymentConfig, edgefsv1beta1.ZoningSpec{}))
+	deploymentConfig.DevConfig["node4"] = edgefsv1beta1.DevicesConfig{Zone: 3}
+	assert.Nil(t, ValidateReplication(deploymentConfig, edgefsv1beta1.ZoningSpec{}))
+
+	assert.Equal(t, 2, getFailureDomain(edgefsv1beta1.ReplicaPlacementZone))
+	assert.Equal(t, 1, getFailureDomain(edgefsv1beta1.ReplicaPlacementHost))
+	assert.Equal(t, 1, getFailureDomain(""))
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package csi to deploy the EdgeFS CSI drivers of the NFS and iSCSI services.
+package csi
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "edgefs-op-csi")
+
+type Param struct {
+	Namespace string
+
+	PluginImage      string
+	RegistrarImage   string
+	ProvisionerImage string
+	AttacherImage    string
+	SnapshotterImage string
+}
+
+var (
+	CSIParam Param
+
+	EnableNFS   = false
+	EnableISCSI = false
+
+	// template paths
+	NFSNodeTemplatePath       string
+	NFSControllerTemplatePath string
+
+	ISCSINodeTemplatePath       string
+	ISCSIControllerTemplatePath string
+)
+
+const (
+	KubeMinMajor = "1"
+	KubeMinMinor = "13"
+
+	// image names
+	DefaultPluginImage      = "edgefs/edgefs-csi:1.1.8"
+	DefaultRegistrarImage   = "quay.io/k8scsi/csi-node-driver-registrar:v1.0.1"
+	DefaultProvisionerImage = "quay.io/k8scsi/csi-provisioner:v1.0.1"
+	DefaultAttacherImage    = "quay.io/k8scsi/csi-attacher:v1.0.1"
+	DefaultSnapshotterImage = "quay.io/k8scsi/csi-snapshotter:v1.0.1"
+
+	// template
+	DefaultNFSNodeTemplatePath         = "/etc/edgefs-csi/nfs/edgefs-nfs-csi-node.yaml"
+	DefaultNFSControllerTemplatePath   = "/etc/edgefs-csi/nfs/edgefs-nfs-csi-controller.yaml"
+	DefaultISCSINodeTemplatePath       = "/etc/edgefs-csi/iscsi/edgefs-iscsi-csi-node.yaml"
+	DefaultISCSIControllerTemplatePath = "/etc/edgefs-csi/iscsi/edgefs-iscsi-csi-controller.yaml"
+
+	ExitOnError = false // don't exit if CSI fails to deploy, the services can still be consumed with static volumes
+
+	nfsDriver   = "nfs"
+	iscsiDriver = "iscsi"
+)
+
+func CSIEnabled() bool {
+	return EnableNFS || EnableISCSI
+}
+
+func SetCSINamespace(namespace string) {
+	CSIParam.Namespace = namespace
+}
+
+func ValidateCSIParam() error {
+	if !CSIEnabled() {
+		return nil
+	}
+
+	if len(CSIParam.PluginImage) == 0 {
+		return errors.New("missing csi edgefs plugin image")
+	}
+	if len(CSIParam.RegistrarImage) == 0 {
+		return errors.New("missing csi registrar image")
+	}
+	if len(CSIParam.ProvisionerImage) == 0 {
+		return errors.New("missing csi provisioner image")
+	}
+	if len(CSIParam.AttacherImage) == 0 {
+		return errors.New("missing csi attacher image")
+	}
+
+	if EnableNFS {
+		if len(NFSNodeTemplatePath) == 0 {
+			return errors.New("missing nfs node template path")
+		}
+		if len(NFSControllerTemplatePath) == 0 {
+			return errors.New("missing nfs controller template path")
+		}
+	}
+
+	if EnableISCSI {
+		if len(CSIParam.SnapshotterImage) == 0 {
+			return errors.New("missing csi snapshotter image")
+		}
+		if len(ISCSINodeTemplatePath) == 0 {
+			return errors.New("missing iscsi node template path")
+		}
+		if len(ISCSIControllerTemplatePath) == 0 {
+			return errors.New("missing iscsi controller template path")
+		}
+	}
+	return nil
+}
+
+func StartCSIDrivers(namespace string, clientset kubernetes.Interface) error {
+	if EnableNFS {
+		if err := startDriver(nfsDriver, NFSNodeTemplatePath, NFSControllerTemplatePath, namespace, clientset); err != nil {
+			return err
+		}
+	}
+	if EnableISCSI {
+		if err := startDriver(iscsiDriver, ISCSINodeTemplatePath, ISCSIControllerTemplatePath, namespace, clientset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startDriver starts the node plugin and the controller of the driver of a type of EdgeFS service
+func startDriver(driverType, nodeTemplatePath, controllerTemplatePath, namespace string, clientset kubernetes.Interface) error {
+	node, err := templateToDaemonSet(fmt.Sprintf("%s-node", driverType), nodeTemplatePath)
+	if err != nil {
+		return fmt.Errorf("failed to load %s node template: %v", driverType, err)
+	}
+	controller, err := templateToStatefulSet(fmt.Sprintf("%s-controller", driverType), controllerTemplatePath)
+	if err != nil {
+		return fmt.Errorf("failed to load %s controller template: %v", driverType, err)
+	}
+
+	// the pods of the driver don't start until the secret with the config of the EdgeFS clusters is created
+	secretName := configSecretName(driverType)
+	if _, err := clientset.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{}); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get secret %s: %v", secretName, err)
+		}
+		logger.Warningf("secret %s is not found in namespace %s, the %s csi driver will start once it is created", secretName, namespace, driverType)
+	}
+
+	err = k8sutil.CreateDaemonSet(fmt.Sprintf("csi edgefs %s node", driverType), namespace, clientset, node)
+	if err != nil {
+		return fmt.Errorf("failed to start %s node daemonset: %v\n%v", driverType, err, node)
+	}
+	_, err = k8sutil.CreateStatefulSet(fmt.Sprintf("csi edgefs %s controller", driverType), namespace, controllerName(driverType), clientset, controller)
+	if err != nil {
+		return fmt.Errorf("failed to start %s controller statefulset: %v\n%v", driverType, err, controller)
+	}
+	return nil
+}
+
+func configSecretName(driverType string) string {
+	return fmt.Sprintf("edgefs-%s-csi-driver-config", driverType)
+}
+
+func controllerName(driverType string) string {
+	return fmt.Sprintf("edgefs-%s-csi-controller", driverType)
+}
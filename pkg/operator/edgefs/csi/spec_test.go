@@ -0,0 +1,70 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"testing"
+
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStartCSI(t *testing.T) {
+	EnableNFS = true
+	EnableISCSI = true
+	NFSNodeTemplatePath = "edgefs-nfs-csi-node.yaml"
+	NFSControllerTemplatePath = "edgefs-nfs-csi-controller.yaml"
+	ISCSINodeTemplatePath = "edgefs-iscsi-csi-node.yaml"
+	ISCSIControllerTemplatePath = "edgefs-iscsi-csi-controller.yaml"
+
+	CSIParam = Param{
+		PluginImage:      "image",
+		RegistrarImage:   "image",
+		ProvisionerImage: "image",
+		AttacherImage:    "image",
+		SnapshotterImage: "image",
+	}
+	assert.Nil(t, ValidateCSIParam())
+	SetCSINamespace("ns")
+
+	clientset := test.New(3)
+	err := StartCSIDrivers("ns", clientset)
+	assert.Nil(t, err)
+
+	for _, driverType := range []string{"nfs", "iscsi"} {
+		node, err := clientset.AppsV1().DaemonSets("ns").Get("edgefs-"+driverType+"-csi-node", metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, "ns", node.Namespace)
+		assert.Equal(t, "image", node.Spec.Template.Spec.Containers[1].Image)
+		assert.Equal(t, "edgefs-"+driverType+"-csi-driver-config", node.Spec.Template.Spec.Volumes[len(node.Spec.Template.Spec.Volumes)-1].Secret.SecretName)
+
+		controller, err := clientset.AppsV1().StatefulSets("ns").Get("edgefs-"+driverType+"-csi-controller", metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Contains(t, controller.Spec.Template.Spec.Containers[0].Args, "--provisioner=io.edgefs.csi."+driverType)
+	}
+
+	// the snapshotter is only required by the iscsi driver
+	EnableISCSI = false
+	CSIParam.SnapshotterImage = ""
+	assert.Nil(t, ValidateCSIParam())
+	EnableISCSI = true
+	assert.NotNil(t, ValidateCSIParam())
+
+	EnableNFS = false
+	EnableISCSI = false
+}
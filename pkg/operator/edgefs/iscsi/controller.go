@@ -26,6 +26,7 @@ import (
 	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/edgefs/status"
 	"k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -61,7 +62,10 @@ type ISCSIController struct {
 	annotations     rookalpha.Annotations
 	resources       v1.ResourceRequirements
 	resourceProfile string
+	serviceAccount  string
 	ownerRef        metav1.OwnerReference
+	// monitor reports the status of the services
+	monitor *status.Monitor
 }
 
 // NewISCSIController create controller for watching ISCSI custom resources created
@@ -73,9 +77,13 @@ func NewISCSIController(
 	placement rookalpha.Placement,
 	resources v1.ResourceRequirements,
 	resourceProfile string,
+	serviceAccount string,
 	ownerRef metav1.OwnerReference,
 ) *ISCSIController {
-	return &ISCSIController{
+	if serviceAccount == "" {
+		serviceAccount = defaultServiceAccountName
+	}
+	c := &ISCSIController{
 		context:         context,
 		rookImage:       rookImage,
 		hostNetwork:     hostNetwork,
@@ -84,8 +92,11 @@ func NewISCSIController(
 		placement:       placement,
 		resources:       resources,
 		resourceProfile: resourceProfile,
+		serviceAccount:  serviceAccount,
 		ownerRef:        ownerRef,
 	}
+	c.monitor = c.newMonitor()
+	return c
 }
 
 // StartWatch watches for instances of ISCSI custom resources and acts on them
@@ -100,6 +111,7 @@ func (c *ISCSIController) StartWatch(namespace string, stopCh chan struct{}) err
 	logger.Infof("start watching iscsi resources in namespace %s", namespace)
 	watcher := opkit.NewWatcher(ISCSIResource, namespace, resourceHandlerFuncs, c.context.RookClientset.EdgefsV1beta1().RESTClient())
 	go watcher.Watch(&edgefsv1beta1.ISCSI{}, stopCh)
+	go c.monitor.Run(namespace, stopCh)
 
 	return nil
 }
@@ -111,9 +123,13 @@ func (c *ISCSIController) onAdd(obj interface{}) {
 		return
 	}
 
+	c.monitor.SetState(iscsi.Namespace, iscsi.Name, edgefsv1beta1.ServiceStateCreating, "")
 	if err = c.CreateService(*iscsi, c.serviceOwners(iscsi)); err != nil {
 		logger.Errorf("failed to create iscsi %s. %+v", iscsi.Name, err)
+		c.monitor.SetState(iscsi.Namespace, iscsi.Name, edgefsv1beta1.ServiceStateError, err.Error())
+		return
 	}
+	c.monitor.SetState(iscsi.Namespace, iscsi.Name, edgefsv1beta1.ServiceStateCreated, "")
 }
 
 func (c *ISCSIController) onUpdate(oldObj, newObj interface{}) {
@@ -136,7 +152,10 @@ func (c *ISCSIController) onUpdate(oldObj, newObj interface{}) {
 	logger.Infof("applying iscsi %s changes", newService.Name)
 	if err = c.UpdateService(*newService, c.serviceOwners(newService)); err != nil {
 		logger.Errorf("failed to create (modify) iscsi %s. %+v", newService.Name, err)
+		c.monitor.SetState(newService.Namespace, newService.Name, edgefsv1beta1.ServiceStateError, err.Error())
+		return
 	}
+	c.monitor.SetState(newService.Namespace, newService.Name, edgefsv1beta1.ServiceStateCreated, "")
 }
 
 func (c *ISCSIController) onDelete(obj interface{}) {
@@ -159,6 +178,47 @@ func (c *ISCSIController) serviceOwners(service *edgefsv1beta1.ISCSI) []metav1.O
 	return []metav1.OwnerReference{c.ownerRef}
 }
 
+// newMonitor returns the monitor of the status of the iscsi services
+func (c *ISCSIController) newMonitor() *status.Monitor {
+	return &status.Monitor{
+		Kind:      "iscsi",
+		Clientset: c.context.Clientset,
+		List: func(namespace string) ([]status.Service, error) {
+			list, err := c.context.RookClientset.EdgefsV1beta1().ISCSIs(namespace).List(metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			services := []status.Service{}
+			for i := range list.Items {
+				services = append(services, c.statusService(&list.Items[i]))
+			}
+			return services, nil
+		},
+		Get: func(namespace, name string) (status.Service, error) {
+			iscsi, err := c.context.RookClientset.EdgefsV1beta1().ISCSIs(namespace).Get(name, metav1.GetOptions{})
+			if err != nil {
+				return status.Service{}, err
+			}
+			return c.statusService(iscsi), nil
+		},
+	}
+}
+
+// statusService returns the iscsi service with the function writing its status
+func (c *ISCSIController) statusService(iscsi *edgefsv1beta1.ISCSI) status.Service {
+	return status.Service{
+		Name:         iscsi.Name,
+		Namespace:    iscsi.Namespace,
+		InstanceName: instanceName(iscsi.Name),
+		Status:       status.Status{ServiceStatus: iscsi.Status},
+		Update: func(svcStatus status.Status) error {
+			iscsi.Status = svcStatus.ServiceStatus
+			_, err := c.context.RookClientset.EdgefsV1beta1().ISCSIs(iscsi.Namespace).Update(iscsi)
+			return err
+		},
+	}
+}
+
 func serviceChanged(oldService, newService edgefsv1beta1.ISCSISpec) bool {
 	return false
 }
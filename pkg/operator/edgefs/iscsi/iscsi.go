@@ -34,13 +34,13 @@ const (
 	appName = "rook-edgefs-iscsi"
 
 	/* Volumes definitions */
-	serviceAccountName  = "rook-edgefs-cluster"
-	defaultTargetName   = "iqn.2018-11.edgefs.io"
-	defaultTargetParams = "{}"
-	dataVolumeName      = "edgefs-datadir"
-	stateVolumeFolder   = ".state"
-	etcVolumeFolder     = ".etc"
-	defaultPort         = 3260
+	defaultServiceAccountName = "rook-edgefs-cluster"
+	defaultTargetName         = "iqn.2018-11.edgefs.io"
+	defaultTargetParams       = "{}"
+	dataVolumeName            = "edgefs-datadir"
+	stateVolumeFolder         = ".state"
+	etcVolumeFolder           = ".etc"
+	defaultPort               = 3260
 )
 
 // Start the ISCSI manager
@@ -161,7 +161,7 @@ func (c *ISCSIController) makeDeployment(svcname, namespace, rookImage string, i
 			HostIPC:            true,
 			HostNetwork:        c.hostNetwork,
 			NodeSelector:       map[string]string{namespace: "cluster"},
-			ServiceAccountName: serviceAccountName,
+			ServiceAccountName: c.serviceAccount,
 		},
 	}
 	if c.hostNetwork {
@@ -26,6 +26,7 @@ import (
 	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/edgefs/status"
 	"k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -61,7 +62,10 @@ type ISGWController struct {
 	placement       rookalpha.Placement
 	resources       v1.ResourceRequirements
 	resourceProfile string
+	serviceAccount  string
 	ownerRef        metav1.OwnerReference
+	// monitor reports the status of the services
+	monitor *status.Monitor
 }
 
 // NewISGWController create controller for watching ISGW custom resources created
@@ -73,9 +77,13 @@ func NewISGWController(
 	placement rookalpha.Placement,
 	resources v1.ResourceRequirements,
 	resourceProfile string,
+	serviceAccount string,
 	ownerRef metav1.OwnerReference,
 ) *ISGWController {
-	return &ISGWController{
+	if serviceAccount == "" {
+		serviceAccount = defaultServiceAccountName
+	}
+	c := &ISGWController{
 		context:         context,
 		rookImage:       rookImage,
 		hostNetwork:     hostNetwork,
@@ -84,8 +92,11 @@ func NewISGWController(
 		placement:       placement,
 		resources:       resources,
 		resourceProfile: resourceProfile,
+		serviceAccount:  serviceAccount,
 		ownerRef:        ownerRef,
 	}
+	c.monitor = c.newMonitor()
+	return c
 }
 
 // StartWatch watches for instances of ISGW custom resources and acts on them
@@ -100,6 +111,7 @@ func (c *ISGWController) StartWatch(namespace string, stopCh chan struct{}) erro
 	logger.Infof("start watching isgw resources in namespace %s", namespace)
 	watcher := opkit.NewWatcher(ISGWResource, namespace, resourceHandlerFuncs, c.context.RookClientset.EdgefsV1beta1().RESTClient())
 	go watcher.Watch(&edgefsv1beta1.ISGW{}, stopCh)
+	go c.monitor.Run(namespace, stopCh)
 
 	return nil
 }
@@ -111,9 +123,13 @@ func (c *ISGWController) onAdd(obj interface{}) {
 		return
 	}
 
+	c.monitor.SetState(isgw.Namespace, isgw.Name, edgefsv1beta1.ServiceStateCreating, "")
 	if err = c.CreateService(*isgw, c.serviceOwners(isgw)); err != nil {
 		logger.Errorf("failed to create isgw %s. %+v", isgw.Name, err)
+		c.monitor.SetState(isgw.Namespace, isgw.Name, edgefsv1beta1.ServiceStateError, err.Error())
+		return
 	}
+	c.monitor.SetState(isgw.Namespace, isgw.Name, edgefsv1beta1.ServiceStateCreated, "")
 }
 
 func (c *ISGWController) onUpdate(oldObj, newObj interface{}) {
@@ -136,7 +152,10 @@ func (c *ISGWController) onUpdate(oldObj, newObj interface{}) {
 	logger.Infof("applying isgw %s changes", newService.Name)
 	if err = c.UpdateService(*newService, c.serviceOwners(newService)); err != nil {
 		logger.Errorf("failed to create (modify) isgw %s. %+v", newService.Name, err)
+		c.monitor.SetState(newService.Namespace, newService.Name, edgefsv1beta1.ServiceStateError, err.Error())
+		return
 	}
+	c.monitor.SetState(newService.Namespace, newService.Name, edgefsv1beta1.ServiceStateCreated, "")
 }
 
 func (c *ISGWController) onDelete(obj interface{}) {
@@ -159,6 +178,51 @@ func (c *ISGWController) serviceOwners(service *edgefsv1beta1.ISGW) []metav1.Own
 	return []metav1.OwnerReference{c.ownerRef}
 }
 
+// newMonitor returns the monitor of the status of the isgw services
+func (c *ISGWController) newMonitor() *status.Monitor {
+	return &status.Monitor{
+		Kind:      "isgw",
+		Clientset: c.context.Clientset,
+		List: func(namespace string) ([]status.Service, error) {
+			list, err := c.context.RookClientset.EdgefsV1beta1().ISGWs(namespace).List(metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			services := []status.Service{}
+			for i := range list.Items {
+				services = append(services, c.statusService(&list.Items[i]))
+			}
+			return services, nil
+		},
+		Get: func(namespace, name string) (status.Service, error) {
+			isgw, err := c.context.RookClientset.EdgefsV1beta1().ISGWs(namespace).Get(name, metav1.GetOptions{})
+			if err != nil {
+				return status.Service{}, err
+			}
+			return c.statusService(isgw), nil
+		},
+	}
+}
+
+// statusService returns the isgw service with the function writing its status
+func (c *ISGWController) statusService(isgw *edgefsv1beta1.ISGW) status.Service {
+	link := isgw.Status.Link
+	return status.Service{
+		Name:         isgw.Name,
+		Namespace:    isgw.Namespace,
+		InstanceName: instanceName(isgw.Name),
+		Status:       status.Status{ServiceStatus: isgw.Status.ServiceStatus, Link: &link},
+		Update: func(svcStatus status.Status) error {
+			if svcStatus.Link.State == edgefsv1beta1.ISGWLinkStateDown && isgw.Status.Link.State != edgefsv1beta1.ISGWLinkStateDown {
+				logger.Warningf("isgw %s replication link to %s is down", isgw.Name, isgw.Spec.RemoteURL)
+			}
+			isgw.Status = edgefsv1beta1.ISGWStatus{ServiceStatus: svcStatus.ServiceStatus, Link: *svcStatus.Link}
+			_, err := c.context.RookClientset.EdgefsV1beta1().ISGWs(isgw.Namespace).Update(isgw)
+			return err
+		},
+	}
+}
+
 func serviceChanged(oldService, newService edgefsv1beta1.ISGWSpec) bool {
 	return false
 }
@@ -35,15 +35,15 @@ const (
 	appName = "rook-edgefs-isgw"
 
 	/* ISGW definitions */
-	serviceAccountName      = "rook-edgefs-cluster"
-	defaultReplicationType  = "initial+continuous"
-	defaultDynamicFetchPort = 49678
-	defaultLocalIPAddr      = "0.0.0.0"
-	defaultLocalIPv6Addr    = "::"
-	defaultLocalPort        = 14000
-	dataVolumeName          = "edgefs-datadir"
-	stateVolumeFolder       = ".state"
-	etcVolumeFolder         = ".etc"
+	defaultServiceAccountName = "rook-edgefs-cluster"
+	defaultReplicationType    = "initial+continuous"
+	defaultDynamicFetchPort   = 49678
+	defaultLocalIPAddr        = "0.0.0.0"
+	defaultLocalIPv6Addr      = "::"
+	defaultLocalPort          = 14000
+	dataVolumeName            = "edgefs-datadir"
+	stateVolumeFolder         = ".state"
+	etcVolumeFolder           = ".etc"
 )
 
 // Start the ISGW manager
@@ -210,7 +210,7 @@ func (c *ISGWController) makeDeployment(svcname, namespace, rookImage string, is
 			HostIPC:            true,
 			HostNetwork:        c.hostNetwork,
 			NodeSelector:       map[string]string{namespace: "cluster"},
-			ServiceAccountName: serviceAccountName,
+			ServiceAccountName: c.serviceAccount,
 		},
 	}
 	if c.hostNetwork {
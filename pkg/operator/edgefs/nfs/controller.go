@@ -26,6 +26,7 @@ import (
 	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/edgefs/status"
 	"k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -61,7 +62,10 @@ type NFSController struct {
 	placement       rookalpha.Placement
 	resources       v1.ResourceRequirements
 	resourceProfile string
+	serviceAccount  string
 	ownerRef        metav1.OwnerReference
+	// monitor reports the status of the services
+	monitor *status.Monitor
 }
 
 // NewNFSController create controller for watching nfs custom resources created
@@ -73,9 +77,13 @@ func NewNFSController(
 	placement rookalpha.Placement,
 	resources v1.ResourceRequirements,
 	resourceProfile string,
+	serviceAccount string,
 	ownerRef metav1.OwnerReference,
 ) *NFSController {
-	return &NFSController{
+	if serviceAccount == "" {
+		serviceAccount = defaultServiceAccountName
+	}
+	c := &NFSController{
 		context:         context,
 		rookImage:       rookImage,
 		hostNetwork:     hostNetwork,
@@ -84,8 +92,11 @@ func NewNFSController(
 		placement:       placement,
 		resources:       resources,
 		resourceProfile: resourceProfile,
+		serviceAccount:  serviceAccount,
 		ownerRef:        ownerRef,
 	}
+	c.monitor = c.newMonitor()
+	return c
 }
 
 // StartWatch watches for instances of NFS custom resources and acts on them
@@ -100,6 +111,7 @@ func (c *NFSController) StartWatch(namespace string, stopCh chan struct{}) error
 	logger.Infof("start watching nfs resources in namespace %s", namespace)
 	watcher := opkit.NewWatcher(NFSResource, namespace, resourceHandlerFuncs, c.context.RookClientset.EdgefsV1beta1().RESTClient())
 	go watcher.Watch(&edgefsv1beta1.NFS{}, stopCh)
+	go c.monitor.Run(namespace, stopCh)
 
 	return nil
 }
@@ -111,9 +123,13 @@ func (c *NFSController) onAdd(obj interface{}) {
 		return
 	}
 
+	c.monitor.SetState(nfs.Namespace, nfs.Name, edgefsv1beta1.ServiceStateCreating, "")
 	if err = c.CreateService(*nfs, c.serviceOwners(nfs)); err != nil {
 		logger.Errorf("failed to create nfs %s. %+v", nfs.Name, err)
+		c.monitor.SetState(nfs.Namespace, nfs.Name, edgefsv1beta1.ServiceStateError, err.Error())
+		return
 	}
+	c.monitor.SetState(nfs.Namespace, nfs.Name, edgefsv1beta1.ServiceStateCreated, "")
 }
 
 func (c *NFSController) onUpdate(oldObj, newObj interface{}) {
@@ -136,7 +152,10 @@ func (c *NFSController) onUpdate(oldObj, newObj interface{}) {
 	logger.Infof("applying nfs %s changes", newService.Name)
 	if err = c.UpdateService(*newService, c.serviceOwners(newService)); err != nil {
 		logger.Errorf("failed to create (modify) nfs %s. %+v", newService.Name, err)
+		c.monitor.SetState(newService.Namespace, newService.Name, edgefsv1beta1.ServiceStateError, err.Error())
+		return
 	}
+	c.monitor.SetState(newService.Namespace, newService.Name, edgefsv1beta1.ServiceStateCreated, "")
 }
 
 func (c *NFSController) onDelete(obj interface{}) {
@@ -159,6 +178,47 @@ func (c *NFSController) serviceOwners(service *edgefsv1beta1.NFS) []metav1.Owner
 	return []metav1.OwnerReference{c.ownerRef}
 }
 
+// newMonitor returns the monitor of the status of the nfs services
+func (c *NFSController) newMonitor() *status.Monitor {
+	return &status.Monitor{
+		Kind:      "nfs",
+		Clientset: c.context.Clientset,
+		List: func(namespace string) ([]status.Service, error) {
+			list, err := c.context.RookClientset.EdgefsV1beta1().NFSs(namespace).List(metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			services := []status.Service{}
+			for i := range list.Items {
+				services = append(services, c.statusService(&list.Items[i]))
+			}
+			return services, nil
+		},
+		Get: func(namespace, name string) (status.Service, error) {
+			nfs, err := c.context.RookClientset.EdgefsV1beta1().NFSs(namespace).Get(name, metav1.GetOptions{})
+			if err != nil {
+				return status.Service{}, err
+			}
+			return c.statusService(nfs), nil
+		},
+	}
+}
+
+// statusService returns the nfs service with the function writing its status
+func (c *NFSController) statusService(nfs *edgefsv1beta1.NFS) status.Service {
+	return status.Service{
+		Name:         nfs.Name,
+		Namespace:    nfs.Namespace,
+		InstanceName: instanceName(nfs.Name),
+		Status:       status.Status{ServiceStatus: nfs.Status},
+		Update: func(svcStatus status.Status) error {
+			nfs.Status = svcStatus.ServiceStatus
+			_, err := c.context.RookClientset.EdgefsV1beta1().NFSs(nfs.Namespace).Update(nfs)
+			return err
+		},
+	}
+}
+
 func serviceChanged(oldService, newService edgefsv1beta1.NFSSpec) bool {
 	return false
 }
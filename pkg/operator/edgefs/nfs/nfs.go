@@ -33,10 +33,10 @@ const (
 	appName = "rook-edgefs-nfs"
 
 	/* Volumes definitions */
-	serviceAccountName = "rook-edgefs-cluster"
-	dataVolumeName     = "edgefs-datadir"
-	stateVolumeFolder  = ".state"
-	etcVolumeFolder    = ".etc"
+	defaultServiceAccountName = "rook-edgefs-cluster"
+	dataVolumeName            = "edgefs-datadir"
+	stateVolumeFolder         = ".state"
+	etcVolumeFolder           = ".etc"
 )
 
 // Start the rgw manager
@@ -164,7 +164,7 @@ func (c *NFSController) makeDeployment(svcname, namespace, rookImage string, nfs
 			HostIPC:            true,
 			HostNetwork:        c.hostNetwork,
 			NodeSelector:       map[string]string{namespace: "cluster"},
-			ServiceAccountName: serviceAccountName,
+			ServiceAccountName: c.serviceAccount,
 		},
 	}
 	if c.hostNetwork {
@@ -28,6 +28,7 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/discover"
 	"github.com/rook/rook/pkg/operator/edgefs/cluster"
+	"github.com/rook/rook/pkg/operator/edgefs/csi"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"k8s.io/api/core/v1"
 )
@@ -69,6 +70,31 @@ func (o *Operator) Run() error {
 		return fmt.Errorf("Error starting device discovery daemonset: %v", err)
 	}
 
+	serverVersion, err := o.context.Clientset.Discovery().ServerVersion()
+	if err != nil {
+		return fmt.Errorf("Error getting server version: %v", err)
+	}
+
+	if serverVersion.Major >= csi.KubeMinMajor && serverVersion.Minor >= csi.KubeMinMinor && csi.CSIEnabled() {
+		logger.Infof("EdgeFS CSI driver is enabled, validate csi param")
+		if err = csi.ValidateCSIParam(); err != nil {
+			logger.Warningf("invalid csi params: %v", err)
+			if csi.ExitOnError {
+				return err
+			}
+		} else {
+			csi.SetCSINamespace(namespace)
+			if err = csi.StartCSIDrivers(namespace, o.context.Clientset); err != nil {
+				logger.Warningf("failed to start EdgeFS csi drivers: %v", err)
+				if csi.ExitOnError {
+					return err
+				}
+			} else {
+				logger.Infof("successfully started EdgeFS csi drivers")
+			}
+		}
+	}
+
 	signalChan := make(chan os.Signal, 1)
 	stopChan := make(chan struct{})
 	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
@@ -26,6 +26,7 @@ import (
 	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/edgefs/status"
 	"k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -61,7 +62,10 @@ type S3Controller struct {
 	placement       rookalpha.Placement
 	resources       v1.ResourceRequirements
 	resourceProfile string
+	serviceAccount  string
 	ownerRef        metav1.OwnerReference
+	// monitor reports the status of the services
+	monitor *status.Monitor
 }
 
 // NewS3Controller create controller for watching S3 custom resources created
@@ -73,9 +77,13 @@ func NewS3Controller(
 	placement rookalpha.Placement,
 	resources v1.ResourceRequirements,
 	resourceProfile string,
+	serviceAccount string,
 	ownerRef metav1.OwnerReference,
 ) *S3Controller {
-	return &S3Controller{
+	if serviceAccount == "" {
+		serviceAccount = defaultServiceAccountName
+	}
+	c := &S3Controller{
 		context:         context,
 		rookImage:       rookImage,
 		hostNetwork:     hostNetwork,
@@ -84,8 +92,11 @@ func NewS3Controller(
 		placement:       placement,
 		resources:       resources,
 		resourceProfile: resourceProfile,
+		serviceAccount:  serviceAccount,
 		ownerRef:        ownerRef,
 	}
+	c.monitor = c.newMonitor()
+	return c
 }
 
 // StartWatch watches for instances of S3 custom resources and acts on them
@@ -100,6 +111,7 @@ func (c *S3Controller) StartWatch(namespace string, stopCh chan struct{}) error
 	logger.Infof("start watching s3 resources in namespace %s", namespace)
 	watcher := opkit.NewWatcher(S3Resource, namespace, resourceHandlerFuncs, c.context.RookClientset.EdgefsV1beta1().RESTClient())
 	go watcher.Watch(&edgefsv1beta1.S3{}, stopCh)
+	go c.monitor.Run(namespace, stopCh)
 
 	return nil
 }
@@ -111,9 +123,13 @@ func (c *S3Controller) onAdd(obj interface{}) {
 		return
 	}
 
+	c.monitor.SetState(s3.Namespace, s3.Name, edgefsv1beta1.ServiceStateCreating, "")
 	if err = c.CreateService(*s3, c.serviceOwners(s3)); err != nil {
 		logger.Errorf("failed to create s3 %s. %+v", s3.Name, err)
+		c.monitor.SetState(s3.Namespace, s3.Name, edgefsv1beta1.ServiceStateError, err.Error())
+		return
 	}
+	c.monitor.SetState(s3.Namespace, s3.Name, edgefsv1beta1.ServiceStateCreated, "")
 }
 
 func (c *S3Controller) onUpdate(oldObj, newObj interface{}) {
@@ -136,7 +152,10 @@ func (c *S3Controller) onUpdate(oldObj, newObj interface{}) {
 	logger.Infof("applying s3 %s changes", newService.Name)
 	if err = c.UpdateService(*newService, c.serviceOwners(newService)); err != nil {
 		logger.Errorf("failed to create (modify) s3 %s. %+v", newService.Name, err)
+		c.monitor.SetState(newService.Namespace, newService.Name, edgefsv1beta1.ServiceStateError, err.Error())
+		return
 	}
+	c.monitor.SetState(newService.Namespace, newService.Name, edgefsv1beta1.ServiceStateCreated, "")
 }
 
 func (c *S3Controller) onDelete(obj interface{}) {
@@ -159,6 +178,47 @@ func (c *S3Controller) serviceOwners(service *edgefsv1beta1.S3) []metav1.OwnerRe
 	return []metav1.OwnerReference{c.ownerRef}
 }
 
+// newMonitor returns the monitor of the status of the s3 services
+func (c *S3Controller) newMonitor() *status.Monitor {
+	return &status.Monitor{
+		Kind:      "s3",
+		Clientset: c.context.Clientset,
+		List: func(namespace string) ([]status.Service, error) {
+			list, err := c.context.RookClientset.EdgefsV1beta1().S3s(namespace).List(metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			services := []status.Service{}
+			for i := range list.Items {
+				services = append(services, c.statusService(&list.Items[i]))
+			}
+			return services, nil
+		},
+		Get: func(namespace, name string) (status.Service, error) {
+			s3, err := c.context.RookClientset.EdgefsV1beta1().S3s(namespace).Get(name, metav1.GetOptions{})
+			if err != nil {
+				return status.Service{}, err
+			}
+			return c.statusService(s3), nil
+		},
+	}
+}
+
+// statusService returns the s3 service with the function writing its status
+func (c *S3Controller) statusService(s3 *edgefsv1beta1.S3) status.Service {
+	return status.Service{
+		Name:         s3.Name,
+		Namespace:    s3.Namespace,
+		InstanceName: instanceName(s3.Name),
+		Status:       status.Status{ServiceStatus: s3.Status},
+		Update: func(svcStatus status.Status) error {
+			s3.Status = svcStatus.ServiceStatus
+			_, err := c.context.RookClientset.EdgefsV1beta1().S3s(s3.Namespace).Update(s3)
+			return err
+		},
+	}
+}
+
 func serviceChanged(oldService, newService edgefsv1beta1.S3Spec) bool {
 	return false
 }
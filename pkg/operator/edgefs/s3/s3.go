@@ -34,15 +34,15 @@ const (
 	appName = "rook-edgefs-s3"
 
 	/* Volumes definitions */
-	serviceAccountName = "rook-edgefs-cluster"
-	defaultS3Image     = "edgefs/edgefs-restapi"
-	sslCertVolumeName  = "ssl-cert-volume"
-	sslMountPath       = "/opt/nedge/etc/ssl/"
-	dataVolumeName     = "edgefs-datadir"
-	stateVolumeFolder  = ".state"
-	etcVolumeFolder    = ".etc"
-	defaultPort        = 9982
-	defaultSecurePort  = 9443
+	defaultServiceAccountName = "rook-edgefs-cluster"
+	defaultS3Image            = "edgefs/edgefs-restapi"
+	sslCertVolumeName         = "ssl-cert-volume"
+	sslMountPath              = "/opt/nedge/etc/ssl/"
+	dataVolumeName            = "edgefs-datadir"
+	stateVolumeFolder         = ".state"
+	etcVolumeFolder           = ".etc"
+	defaultPort               = 9982
+	defaultSecurePort         = 9443
 )
 
 // Start the S3 manager
@@ -215,7 +215,7 @@ func (c *S3Controller) makeDeployment(svcname, namespace, rookImage, imageArgs s
 			HostIPC:            true,
 			HostNetwork:        c.hostNetwork,
 			NodeSelector:       map[string]string{namespace: "cluster"},
-			ServiceAccountName: serviceAccountName,
+			ServiceAccountName: c.serviceAccount,
 		},
 	}
 	if c.hostNetwork {
@@ -26,6 +26,7 @@ import (
 	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/edgefs/status"
 	"k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -61,7 +62,10 @@ type S3XController struct {
 	placement       rookalpha.Placement
 	resources       v1.ResourceRequirements
 	resourceProfile string
+	serviceAccount  string
 	ownerRef        metav1.OwnerReference
+	// monitor reports the status of the services
+	monitor *status.Monitor
 }
 
 // NewS3XController create controller for watching S3X custom resources created
@@ -73,9 +77,13 @@ func NewS3XController(
 	placement rookalpha.Placement,
 	resources v1.ResourceRequirements,
 	resourceProfile string,
+	serviceAccount string,
 	ownerRef metav1.OwnerReference,
 ) *S3XController {
-	return &S3XController{
+	if serviceAccount == "" {
+		serviceAccount = defaultServiceAccountName
+	}
+	c := &S3XController{
 		context:         context,
 		rookImage:       rookImage,
 		hostNetwork:     hostNetwork,
@@ -84,8 +92,11 @@ func NewS3XController(
 		placement:       placement,
 		resources:       resources,
 		resourceProfile: resourceProfile,
+		serviceAccount:  serviceAccount,
 		ownerRef:        ownerRef,
 	}
+	c.monitor = c.newMonitor()
+	return c
 }
 
 // StartWatch watches for instances of S3X custom resources and acts on them
@@ -100,6 +111,7 @@ func (c *S3XController) StartWatch(namespace string, stopCh chan struct{}) error
 	logger.Infof("start watching s3x resources in namespace %s", namespace)
 	watcher := opkit.NewWatcher(S3XResource, namespace, resourceHandlerFuncs, c.context.RookClientset.EdgefsV1beta1().RESTClient())
 	go watcher.Watch(&edgefsv1beta1.S3X{}, stopCh)
+	go c.monitor.Run(namespace, stopCh)
 
 	return nil
 }
@@ -111,9 +123,13 @@ func (c *S3XController) onAdd(obj interface{}) {
 		return
 	}
 
+	c.monitor.SetState(s3x.Namespace, s3x.Name, edgefsv1beta1.ServiceStateCreating, "")
 	if err = c.CreateService(*s3x, c.serviceOwners(s3x)); err != nil {
 		logger.Errorf("failed to create s3x %s. %+v", s3x.Name, err)
+		c.monitor.SetState(s3x.Namespace, s3x.Name, edgefsv1beta1.ServiceStateError, err.Error())
+		return
 	}
+	c.monitor.SetState(s3x.Namespace, s3x.Name, edgefsv1beta1.ServiceStateCreated, "")
 }
 
 func (c *S3XController) onUpdate(oldObj, newObj interface{}) {
@@ -136,7 +152,10 @@ func (c *S3XController) onUpdate(oldObj, newObj interface{}) {
 	logger.Infof("applying s3x %s changes", newService.Name)
 	if err = c.UpdateService(*newService, c.serviceOwners(newService)); err != nil {
 		logger.Errorf("failed to create (modify) s3x %s. %+v", newService.Name, err)
+		c.monitor.SetState(newService.Namespace, newService.Name, edgefsv1beta1.ServiceStateError, err.Error())
+		return
 	}
+	c.monitor.SetState(newService.Namespace, newService.Name, edgefsv1beta1.ServiceStateCreated, "")
 }
 
 func (c *S3XController) onDelete(obj interface{}) {
@@ -159,6 +178,47 @@ func (c *S3XController) serviceOwners(service *edgefsv1beta1.S3X) []metav1.Owner
 	return []metav1.OwnerReference{c.ownerRef}
 }
 
+// newMonitor returns the monitor of the status of the s3x services
+func (c *S3XController) newMonitor() *status.Monitor {
+	return &status.Monitor{
+		Kind:      "s3x",
+		Clientset: c.context.Clientset,
+		List: func(namespace string) ([]status.Service, error) {
+			list, err := c.context.RookClientset.EdgefsV1beta1().S3Xs(namespace).List(metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			services := []status.Service{}
+			for i := range list.Items {
+				services = append(services, c.statusService(&list.Items[i]))
+			}
+			return services, nil
+		},
+		Get: func(namespace, name string) (status.Service, error) {
+			s3x, err := c.context.RookClientset.EdgefsV1beta1().S3Xs(namespace).Get(name, metav1.GetOptions{})
+			if err != nil {
+				return status.Service{}, err
+			}
+			return c.statusService(s3x), nil
+		},
+	}
+}
+
+// statusService returns the s3x service with the function writing its status
+func (c *S3XController) statusService(s3x *edgefsv1beta1.S3X) status.Service {
+	return status.Service{
+		Name:         s3x.Name,
+		Namespace:    s3x.Namespace,
+		InstanceName: instanceName(s3x.Name),
+		Status:       status.Status{ServiceStatus: s3x.Status},
+		Update: func(svcStatus status.Status) error {
+			s3x.Status = svcStatus.ServiceStatus
+			_, err := c.context.RookClientset.EdgefsV1beta1().S3Xs(s3x.Namespace).Update(s3x)
+			return err
+		},
+	}
+}
+
 func serviceChanged(oldService, newService edgefsv1beta1.S3XSpec) bool {
 	return false
 }
@@ -34,15 +34,15 @@ const (
 	appName = "rook-edgefs-s3x"
 
 	/* Volumes definitions */
-	serviceAccountName = "rook-edgefs-cluster"
-	sslCertVolumeName  = "ssl-cert-volume"
-	defaultS3Image     = "edgefs/edgefs-restapi"
-	sslMountPath       = "/opt/nedge/etc/ssl/"
-	dataVolumeName     = "edgefs-datadir"
-	stateVolumeFolder  = ".state"
-	etcVolumeFolder    = ".etc"
-	defaultPort        = 4000
-	defaultSecurePort  = 4443
+	defaultServiceAccountName = "rook-edgefs-cluster"
+	sslCertVolumeName         = "ssl-cert-volume"
+	defaultS3Image            = "edgefs/edgefs-restapi"
+	sslMountPath              = "/opt/nedge/etc/ssl/"
+	dataVolumeName            = "edgefs-datadir"
+	stateVolumeFolder         = ".state"
+	etcVolumeFolder           = ".etc"
+	defaultPort               = 4000
+	defaultSecurePort         = 4443
 )
 
 // Start the rgw manager
@@ -199,7 +199,7 @@ func (c *S3XController) makeDeployment(svcname, namespace, rookImage string, s3x
 			HostIPC:            true,
 			HostNetwork:        c.hostNetwork,
 			NodeSelector:       map[string]string{namespace: "cluster"},
-			ServiceAccountName: serviceAccountName,
+			ServiceAccountName: c.serviceAccount,
 		},
 	}
 	if c.hostNetwork {
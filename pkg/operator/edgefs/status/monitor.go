@@ -0,0 +1,121 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/operator/edgefs/cluster/mgr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Status is the status of an EdgeFS service of any kind
+type Status struct {
+	edgefsv1beta1.ServiceStatus
+	// Link is the replication link of an isgw service, nil for the other kinds
+	Link *edgefsv1beta1.ISGWLinkStatus
+}
+
+// Service is an EdgeFS service of any kind
+type Service struct {
+	Name      string
+	Namespace string
+	// InstanceName is the name of the deployment and of the kubernetes service of the service
+	InstanceName string
+	Status       Status
+	// Update writes the status of the service
+	Update func(status Status) error
+}
+
+// Monitor reports the status of the EdgeFS services of a kind. Its functions are the typed glue between the services
+// and the monitoring.
+type Monitor struct {
+	// Kind names the services in the logs
+	Kind      string
+	Clientset kubernetes.Interface
+	// List returns the services of a namespace
+	List func(namespace string) ([]Service, error)
+	// Get returns a service
+	Get func(namespace, name string) (Service, error)
+}
+
+// Run periodically reports the ready instances, the endpoints and the served objects of every service in their
+// status, and the replication link of the isgw services
+func (m *Monitor) Run(namespace string, stopCh chan struct{}) {
+	restapi := mgr.NewRestAPIClient(mgr.RestAPIAddress(namespace))
+	for {
+		select {
+		case <-stopCh:
+			logger.Infof("stopping monitoring of %s services", m.Kind)
+			return
+		case <-time.After(Interval):
+			services, err := m.List(namespace)
+			if err != nil {
+				logger.Warningf("failed to list %s services: %+v", m.Kind, err)
+				continue
+			}
+			for _, service := range services {
+				if err := m.updateStatus(restapi, service); err != nil {
+					logger.Warningf("failed to update the status of %s %s: %+v", m.Kind, service.Name, err)
+				}
+			}
+		}
+	}
+}
+
+// updateStatus reports the ready instances, the endpoints, the served objects and the replication link of a service
+// in its status
+func (m *Monitor) updateStatus(restapi *mgr.RestAPIClient, service Service) error {
+	svcStatus, err := Get(m.Clientset, restapi, service.Namespace, service.InstanceName, service.Name, service.Status.ServiceStatus)
+	if err != nil {
+		return err
+	}
+	status := Status{ServiceStatus: svcStatus}
+	if service.Status.Link != nil {
+		link := GetISGWLink(restapi, service.Name)
+		status.Link = &link
+	}
+	// the status is only written when it changed, as every update of the service is watched
+	if reflect.DeepEqual(service.Status, status) {
+		return nil
+	}
+	if err := service.Update(status); err != nil {
+		return fmt.Errorf("failed to update %s %s status. %+v", m.Kind, service.Name, err)
+	}
+	return nil
+}
+
+// SetState reports the state of the deployment of a service in its status
+func (m *Monitor) SetState(namespace, name string, state edgefsv1beta1.ServiceState, message string) {
+	service, err := m.Get(namespace, name)
+	if err != nil {
+		logger.Errorf("failed to get %s %s prior to updating its state. %+v", m.Kind, name, err)
+		return
+	}
+	if service.Status.State == state && service.Status.Message == message {
+		return
+	}
+	status := service.Status
+	status.State = state
+	status.Message = message
+	if err := service.Update(status); err != nil {
+		logger.Errorf("failed to update %s %s state to %s. %+v", m.Kind, name, state, err)
+	}
+}
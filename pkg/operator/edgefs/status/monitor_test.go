@@ -0,0 +1,118 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package status
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/operator/edgefs/cluster/mgr"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// newMonitorForTest returns a monitor of services kept in a map, and the count of the updates of their status
+func newMonitorForTest(services map[string]*Status) (*Monitor, *int) {
+	updates := 0
+	service := func(name string) Service {
+		return Service{
+			Name:         name,
+			Namespace:    "rook-edgefs",
+			InstanceName: "rook-edgefs-" + name,
+			Status:       *services[name],
+			Update: func(status Status) error {
+				updates++
+				*services[name] = status
+				return nil
+			},
+		}
+	}
+	return &Monitor{
+		Kind:      "test",
+		Clientset: testop.New(3),
+		List: func(namespace string) ([]Service, error) {
+			list := []Service{}
+			for name := range services {
+				list = append(list, service(name))
+			}
+			return list, nil
+		},
+		Get: func(namespace, name string) (Service, error) {
+			if _, ok := services[name]; !ok {
+				return Service{}, fmt.Errorf("service %s not found", name)
+			}
+			return service(name), nil
+		},
+	}, &updates
+}
+
+func TestMonitorUpdateStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"response":{"X-Service-Objects":["cltest/test"],"stats":{"link":{"state":"down","lag":300}}}}`))
+	}))
+	defer server.Close()
+	restapi := mgr.NewRestAPIClient(server.URL)
+	services := map[string]*Status{
+		"nfs01":  {ServiceStatus: edgefsv1beta1.ServiceStatus{State: edgefsv1beta1.ServiceStateCreated}},
+		"isgw01": {Link: &edgefsv1beta1.ISGWLinkStatus{}},
+	}
+	monitor, updates := newMonitorForTest(services)
+
+	service, err := monitor.Get("rook-edgefs", "nfs01")
+	assert.Nil(t, err)
+	assert.Nil(t, monitor.updateStatus(restapi, service))
+	assert.Equal(t, 1, *updates)
+	assert.Equal(t, edgefsv1beta1.ServiceStateCreated, services["nfs01"].State)
+	assert.Equal(t, []string{"cltest/test"}, services["nfs01"].ServiceObjects)
+	assert.Nil(t, services["nfs01"].Link)
+
+	// the status is only written when it changed
+	service, err = monitor.Get("rook-edgefs", "nfs01")
+	assert.Nil(t, err)
+	assert.Nil(t, monitor.updateStatus(restapi, service))
+	assert.Equal(t, 1, *updates)
+
+	// the replication link of an isgw is reported
+	service, err = monitor.Get("rook-edgefs", "isgw01")
+	assert.Nil(t, err)
+	assert.Nil(t, monitor.updateStatus(restapi, service))
+	assert.Equal(t, 2, *updates)
+	assert.Equal(t, edgefsv1beta1.ISGWLinkStatus{State: edgefsv1beta1.ISGWLinkStateDown, LagSeconds: 300}, *services["isgw01"].Link)
+}
+
+func TestMonitorSetState(t *testing.T) {
+	services := map[string]*Status{
+		"nfs01": {ServiceStatus: edgefsv1beta1.ServiceStatus{ReadyInstances: 1}},
+	}
+	monitor, updates := newMonitorForTest(services)
+
+	monitor.SetState("rook-edgefs", "nfs01", edgefsv1beta1.ServiceStateError, "failed")
+	assert.Equal(t, 1, *updates)
+	assert.Equal(t, edgefsv1beta1.ServiceStateError, services["nfs01"].State)
+	assert.Equal(t, "failed", services["nfs01"].Message)
+	assert.Equal(t, int32(1), services["nfs01"].ReadyInstances)
+
+	// the state is only written when it changed
+	monitor.SetState("rook-edgefs", "nfs01", edgefsv1beta1.ServiceStateError, "failed")
+	assert.Equal(t, 1, *updates)
+
+	// a missing service is ignored
+	monitor.SetState("rook-edgefs", "nfs02", edgefsv1beta1.ServiceStateCreated, "")
+	assert.Equal(t, 1, *updates)
+}
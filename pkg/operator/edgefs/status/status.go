@@ -0,0 +1,119 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package status to report the health of the EdgeFS services.
+package status
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/operator/edgefs/cluster/mgr"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "edgefs-op-status")
+
+const (
+	// Interval is the period the status of the services is refreshed at
+	Interval = 1 * time.Minute
+	// the grpc port is only used within the EdgeFS cluster
+	grpcPortName = "grpc"
+)
+
+// Get returns the status of an EdgeFS service: the ready pods of its deployment, the addresses of its kubernetes
+// service and the objects it serves. The state and the message are kept from the current status, and so are the
+// served objects when the mgr can't be reached.
+func Get(clientset kubernetes.Interface, restapi *mgr.RestAPIClient, namespace, instanceName, svcname string,
+	current edgefsv1beta1.ServiceStatus) (edgefsv1beta1.ServiceStatus, error) {
+
+	status := edgefsv1beta1.ServiceStatus{State: current.State, Message: current.Message}
+
+	deployment, err := clientset.AppsV1().Deployments(namespace).Get(instanceName, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return status, fmt.Errorf("failed to get deployment %s. %+v", instanceName, err)
+	}
+	if err == nil {
+		status.ReadyInstances = deployment.Status.ReadyReplicas
+	}
+
+	svc, err := clientset.CoreV1().Services(namespace).Get(instanceName, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return status, fmt.Errorf("failed to get service %s. %+v", instanceName, err)
+	}
+	if err == nil {
+		status.Endpoints = getEndpoints(svc)
+	}
+
+	objects, err := restapi.GetServiceObjects(svcname)
+	if err != nil {
+		logger.Warningf("failed to get the objects served by service %s. %+v", svcname, err)
+		status.ServiceObjects = current.ServiceObjects
+	} else {
+		status.ServiceObjects = objects
+	}
+	return status, nil
+}
+
+// GetISGWLink returns the status of the replication link of an ISGW service, unknown when the mgr can't be reached
+func GetISGWLink(restapi *mgr.RestAPIClient, svcname string) edgefsv1beta1.ISGWLinkStatus {
+	link, err := restapi.GetISGWLink(svcname)
+	if err != nil {
+		return edgefsv1beta1.ISGWLinkStatus{State: edgefsv1beta1.ISGWLinkStateUnknown, Message: err.Error()}
+	}
+	if !link.Up {
+		return edgefsv1beta1.ISGWLinkStatus{State: edgefsv1beta1.ISGWLinkStateDown, LagSeconds: link.LagSeconds}
+	}
+	return edgefsv1beta1.ISGWLinkStatus{State: edgefsv1beta1.ISGWLinkStateUp, LagSeconds: link.LagSeconds}
+}
+
+// getEndpoints returns the addresses of the ports of a kubernetes service reached by the clients
+func getEndpoints(svc *v1.Service) []string {
+	ips := []string{}
+	if svc.Spec.ClusterIP != "" && svc.Spec.ClusterIP != v1.ClusterIPNone {
+		ips = append(ips, svc.Spec.ClusterIP)
+	}
+	ips = append(ips, svc.Spec.ExternalIPs...)
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			ips = append(ips, ingress.IP)
+		}
+	}
+
+	endpoints := []string{}
+	found := map[string]bool{}
+	for _, ip := range ips {
+		for _, port := range svc.Spec.Ports {
+			if port.Name == grpcPortName {
+				continue
+			}
+			// the tcp and udp ports of a protocol have the same address
+			endpoint := net.JoinHostPort(ip, strconv.Itoa(int(port.Port)))
+			if !found[endpoint] {
+				found[endpoint] = true
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+	}
+	return endpoints
+}
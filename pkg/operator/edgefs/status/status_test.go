@@ -0,0 +1,125 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package status
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/operator/edgefs/cluster/mgr"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGet(t *testing.T) {
+	namespace := "rook-edgefs"
+	reachable := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !reachable {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"response":{"X-Service-Objects":["cltest/test"]}}`))
+	}))
+	defer server.Close()
+	restapi := mgr.NewRestAPIClient(server.URL)
+	clientset := testop.New(3)
+	current := edgefsv1beta1.ServiceStatus{State: edgefsv1beta1.ServiceStateCreated}
+
+	// the service is not deployed yet
+	status, err := Get(clientset, restapi, namespace, "rook-edgefs-nfs-nfs01", "nfs01", current)
+	assert.Nil(t, err)
+	assert.Equal(t, edgefsv1beta1.ServiceStateCreated, status.State)
+	assert.Equal(t, int32(0), status.ReadyInstances)
+	assert.Equal(t, 0, len(status.Endpoints))
+	assert.Equal(t, []string{"cltest/test"}, status.ServiceObjects)
+
+	_, err = clientset.AppsV1().Deployments(namespace).Create(&apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-edgefs-nfs-nfs01", Namespace: namespace},
+		Status:     apps.DeploymentStatus{Replicas: 3, ReadyReplicas: 2},
+	})
+	assert.Nil(t, err)
+	_, err = clientset.CoreV1().Services(namespace).Create(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-edgefs-nfs-nfs01", Namespace: namespace},
+		Spec: v1.ServiceSpec{
+			ClusterIP: "10.0.0.12",
+			Ports: []v1.ServicePort{
+				{Name: "grpc", Port: 49000, Protocol: v1.ProtocolTCP},
+				{Name: "nfs-tcp", Port: 2049, Protocol: v1.ProtocolTCP},
+				{Name: "nfs-udp", Port: 2049, Protocol: v1.ProtocolUDP},
+				{Name: "mountd-tcp", Port: 20048, Protocol: v1.ProtocolTCP},
+			},
+		},
+	})
+	assert.Nil(t, err)
+
+	status, err = Get(clientset, restapi, namespace, "rook-edgefs-nfs-nfs01", "nfs01", current)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), status.ReadyInstances)
+	assert.Equal(t, []string{"10.0.0.12:2049", "10.0.0.12:20048"}, status.Endpoints)
+
+	// the served objects are kept while the mgr can't be reached
+	reachable = false
+	status, err = Get(clientset, restapi, namespace, "rook-edgefs-nfs-nfs01", "nfs01", status)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"cltest/test"}, status.ServiceObjects)
+}
+
+func TestGetISGWLink(t *testing.T) {
+	state := "down"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"response":{"stats":{"link":{"state":"` + state + `","lag":300}}}}`))
+	}))
+	defer server.Close()
+	restapi := mgr.NewRestAPIClient(server.URL)
+
+	link := GetISGWLink(restapi, "isgw01")
+	assert.Equal(t, edgefsv1beta1.ISGWLinkStatus{State: edgefsv1beta1.ISGWLinkStateDown, LagSeconds: 300}, link)
+
+	state = "up"
+	link = GetISGWLink(restapi, "isgw01")
+	assert.Equal(t, edgefsv1beta1.ISGWLinkStateUp, link.State)
+
+	server.Close()
+	link = GetISGWLink(restapi, "isgw01")
+	assert.Equal(t, edgefsv1beta1.ISGWLinkStateUnknown, link.State)
+	assert.NotEmpty(t, link.Message)
+}
+
+func TestGetEndpoints(t *testing.T) {
+	svc := &v1.Service{
+		Spec: v1.ServiceSpec{
+			ClusterIP:   "10.0.0.12",
+			ExternalIPs: []string{"fd00::1"},
+			Ports: []v1.ServicePort{
+				{Name: "grpc", Port: 49000},
+				{Name: "port", Port: 9982},
+			},
+		},
+		Status: v1.ServiceStatus{LoadBalancer: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "192.168.1.10"}, {Hostname: "s3.acme.com"}}}},
+	}
+	assert.Equal(t, []string{"10.0.0.12:9982", "[fd00::1]:9982", "192.168.1.10:9982"}, getEndpoints(svc))
+
+	svc.Spec.ClusterIP = v1.ClusterIPNone
+	svc.Spec.ExternalIPs = nil
+	svc.Status = v1.ServiceStatus{}
+	assert.Equal(t, 0, len(getEndpoints(svc)))
+}
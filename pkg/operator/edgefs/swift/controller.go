@@ -26,6 +26,7 @@ import (
 	edgefsv1beta1 "github.com/rook/rook/pkg/apis/edgefs.rook.io/v1beta1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/edgefs/status"
 	"k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -60,7 +61,10 @@ type SWIFTController struct {
 	placement       rookalpha.Placement
 	resources       v1.ResourceRequirements
 	resourceProfile string
+	serviceAccount  string
 	ownerRef        metav1.OwnerReference
+	// monitor reports the status of the services
+	monitor *status.Monitor
 }
 
 // NewSWIFTController create controller for watching SWIFT custom resources created
@@ -72,9 +76,13 @@ func NewSWIFTController(
 	placement rookalpha.Placement,
 	resources v1.ResourceRequirements,
 	resourceProfile string,
+	serviceAccount string,
 	ownerRef metav1.OwnerReference,
 ) *SWIFTController {
-	return &SWIFTController{
+	if serviceAccount == "" {
+		serviceAccount = defaultServiceAccountName
+	}
+	c := &SWIFTController{
 		context:         context,
 		rookImage:       rookImage,
 		hostNetwork:     hostNetwork,
@@ -83,8 +91,11 @@ func NewSWIFTController(
 		placement:       placement,
 		resources:       resources,
 		resourceProfile: resourceProfile,
+		serviceAccount:  serviceAccount,
 		ownerRef:        ownerRef,
 	}
+	c.monitor = c.newMonitor()
+	return c
 }
 
 // StartWatch watches for instances of SWIFT custom resources and acts on them
@@ -99,6 +110,7 @@ func (c *SWIFTController) StartWatch(namespace string, stopCh chan struct{}) err
 	logger.Infof("start watching swift resources in namespace %s", namespace)
 	watcher := opkit.NewWatcher(SWIFTResource, namespace, resourceHandlerFuncs, c.context.RookClientset.EdgefsV1beta1().RESTClient())
 	go watcher.Watch(&edgefsv1beta1.SWIFT{}, stopCh)
+	go c.monitor.Run(namespace, stopCh)
 
 	return nil
 }
@@ -110,9 +122,13 @@ func (c *SWIFTController) onAdd(obj interface{}) {
 		return
 	}
 
+	c.monitor.SetState(swift.Namespace, swift.Name, edgefsv1beta1.ServiceStateCreating, "")
 	if err = c.CreateService(*swift, c.serviceOwners(swift)); err != nil {
 		logger.Errorf("failed to create swift %s. %+v", swift.Name, err)
+		c.monitor.SetState(swift.Namespace, swift.Name, edgefsv1beta1.ServiceStateError, err.Error())
+		return
 	}
+	c.monitor.SetState(swift.Namespace, swift.Name, edgefsv1beta1.ServiceStateCreated, "")
 }
 
 func (c *SWIFTController) onUpdate(oldObj, newObj interface{}) {
@@ -135,7 +151,10 @@ func (c *SWIFTController) onUpdate(oldObj, newObj interface{}) {
 	logger.Infof("applying swift %s changes", newService.Name)
 	if err = c.UpdateService(*newService, c.serviceOwners(newService)); err != nil {
 		logger.Errorf("failed to create (modify) swift %s. %+v", newService.Name, err)
+		c.monitor.SetState(newService.Namespace, newService.Name, edgefsv1beta1.ServiceStateError, err.Error())
+		return
 	}
+	c.monitor.SetState(newService.Namespace, newService.Name, edgefsv1beta1.ServiceStateCreated, "")
 }
 
 func (c *SWIFTController) onDelete(obj interface{}) {
@@ -158,6 +177,47 @@ func (c *SWIFTController) serviceOwners(service *edgefsv1beta1.SWIFT) []metav1.O
 	return []metav1.OwnerReference{c.ownerRef}
 }
 
+// newMonitor returns the monitor of the status of the swift services
+func (c *SWIFTController) newMonitor() *status.Monitor {
+	return &status.Monitor{
+		Kind:      "swift",
+		Clientset: c.context.Clientset,
+		List: func(namespace string) ([]status.Service, error) {
+			list, err := c.context.RookClientset.EdgefsV1beta1().SWIFTs(namespace).List(metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			services := []status.Service{}
+			for i := range list.Items {
+				services = append(services, c.statusService(&list.Items[i]))
+			}
+			return services, nil
+		},
+		Get: func(namespace, name string) (status.Service, error) {
+			swift, err := c.context.RookClientset.EdgefsV1beta1().SWIFTs(namespace).Get(name, metav1.GetOptions{})
+			if err != nil {
+				return status.Service{}, err
+			}
+			return c.statusService(swift), nil
+		},
+	}
+}
+
+// statusService returns the swift service with the function writing its status
+func (c *SWIFTController) statusService(swift *edgefsv1beta1.SWIFT) status.Service {
+	return status.Service{
+		Name:         swift.Name,
+		Namespace:    swift.Namespace,
+		InstanceName: instanceName(swift.Name),
+		Status:       status.Status{ServiceStatus: swift.Status},
+		Update: func(svcStatus status.Status) error {
+			swift.Status = svcStatus.ServiceStatus
+			_, err := c.context.RookClientset.EdgefsV1beta1().SWIFTs(swift.Namespace).Update(swift)
+			return err
+		},
+	}
+}
+
 func serviceChanged(oldService, newService edgefsv1beta1.SWIFTSpec) bool {
 	return false
 }
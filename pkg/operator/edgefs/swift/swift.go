@@ -34,15 +34,15 @@ const (
 	appName = "rook-edgefs-swift"
 
 	/* Volumes definitions */
-	serviceAccountName = "rook-edgefs-cluster"
-	defaultSWIFTImage  = "edgefs/edgefs-restapi"
-	sslCertVolumeName  = "ssl-cert-volume"
-	sslMountPath       = "/opt/nedge/etc/ssl/"
-	dataVolumeName     = "edgefs-datadir"
-	stateVolumeFolder  = ".state"
-	etcVolumeFolder    = ".etc"
-	defaultPort        = 9981
-	defaultSecurePort  = 443
+	defaultServiceAccountName = "rook-edgefs-cluster"
+	defaultSWIFTImage         = "edgefs/edgefs-restapi"
+	sslCertVolumeName         = "ssl-cert-volume"
+	sslMountPath              = "/opt/nedge/etc/ssl/"
+	dataVolumeName            = "edgefs-datadir"
+	stateVolumeFolder         = ".state"
+	etcVolumeFolder           = ".etc"
+	defaultPort               = 9981
+	defaultSecurePort         = 443
 )
 
 // Start the SWIFT manager
@@ -203,7 +203,7 @@ func (c *SWIFTController) makeDeployment(svcname, namespace, rookImage, imageArg
 			HostIPC:            true,
 			HostNetwork:        c.hostNetwork,
 			NodeSelector:       map[string]string{namespace: "cluster"},
-			ServiceAccountName: serviceAccountName,
+			ServiceAccountName: c.serviceAccount,
 		},
 	}
 	if c.hostNetwork {
@@ -20,6 +20,8 @@ package minio
 import (
 	"fmt"
 	"reflect"
+	"strconv"
+	"time"
 
 	"github.com/coreos/pkg/capnslog"
 	opkit "github.com/rook/operator-kit"
@@ -37,8 +39,6 @@ import (
 	"k8s.io/client-go/tools/cache"
 )
 
-// TODO: A lot of these constants are specific to the KubeCon demo. Let's
-// revisit these and determine what should be specified in the resource spec.
 const (
 	objectStoreDataDirTemplate  = "/data/%s"
 	objectStoreDataEmptyDirName = "minio-data"
@@ -47,9 +47,20 @@ const (
 	minioCtrName                = "minio"
 	minioLabel                  = "minio"
 	minioObjectStoreLabel       = "objectstore"
+	minioPoolLabel              = "pool"
 	minioPVCName                = "minio-pvc"
 	minioServerSuffixFmt        = "%s.svc.%s" // namespace.svc.clusterDomain, e.g., default.svc.cluster.local
-	minioPort                   = int32(9000)
+	minioPort                   = miniov1alpha1.PortDefault
+	minioCertsDir               = "/etc/minio/certs"
+	minioTLSVolumeName          = "minio-tls"
+	tlsCertName                 = "public.crt"
+	tlsKeyName                  = "private.key"
+	tlsCAName                   = "ca.crt"
+)
+
+var (
+	statefulSetDeleteRetries  = 30
+	statefulSetDeleteInterval = 2 * time.Second
 )
 
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", "minio-op-object")
@@ -66,15 +77,19 @@ var ObjectStoreResource = opkit.CustomResource{
 
 // Controller represents a controller object for object store custom resources
 type Controller struct {
-	context   *clusterd.Context
-	rookImage string
+	context      *clusterd.Context
+	rookImage    string
+	adminAddress func(name, namespace string, spec miniov1alpha1.ObjectStoreSpec) string
+	runMC        mcRunner
 }
 
 // NewController create controller for watching object store custom resources created
 func NewController(context *clusterd.Context, rookImage string) *Controller {
 	return &Controller{
-		context:   context,
-		rookImage: rookImage,
+		context:      context,
+		rookImage:    rookImage,
+		adminAddress: createAdminAddress,
+		runMC:        runMC,
 	}
 }
 
@@ -90,9 +105,35 @@ func (c *Controller) StartWatch(namespace string, stopCh chan struct{}) error {
 	watcher := opkit.NewWatcher(ObjectStoreResource, namespace, resourceHandlerFuncs, c.context.RookClientset.MinioV1alpha1().RESTClient())
 	go watcher.Watch(&miniov1alpha1.ObjectStore{}, stopCh)
 
+	go c.monitorObjectStores(namespace, stopCh)
+
 	return nil
 }
 
+// pool is a pool of servers of an object store, run by its own stateful set
+type pool struct {
+	statefulSetName     string
+	labels              map[string]string
+	storage             rookalpha.StorageScopeSpec
+	podManagementPolicy apps.PodManagementPolicyType
+}
+
+// getPools returns the pools of the object store. The servers in the scope of the object store form the first pool.
+func getPools(name string, spec miniov1alpha1.ObjectStoreSpec) []pool {
+	pools := []pool{{statefulSetName: name, labels: getPoolLabels(name, name), storage: spec.Storage}}
+	for _, p := range spec.Pools {
+		// the servers of a pool are started together, as Minio waits for all of them to join the pool
+		statefulSetName := fmt.Sprintf("%s-%s", name, p.Name)
+		pools = append(pools, pool{
+			statefulSetName:     statefulSetName,
+			labels:              getPoolLabels(name, statefulSetName),
+			storage:             p.Storage,
+			podManagementPolicy: apps.ParallelPodManagement,
+		})
+	}
+	return pools
+}
+
 func (c *Controller) makeMinioHeadlessService(name, namespace string, spec miniov1alpha1.ObjectStoreSpec, ownerRef meta_v1.OwnerReference) (*v1.Service, error) {
 	svc := &v1.Service{
 		ObjectMeta: meta_v1.ObjectMeta{
@@ -101,30 +142,57 @@ func (c *Controller) makeMinioHeadlessService(name, namespace string, spec minio
 			Labels:    getMinioLabels(name),
 		},
 		Spec: v1.ServiceSpec{
-			Selector:  map[string]string{k8sutil.AppAttr: minioLabel},
-			Ports:     []v1.ServicePort{{Port: minioPort}},
+			// the servers of all the pools are resolved through the same service
+			Selector:  getMinioLabels(name),
+			Ports:     []v1.ServicePort{{Port: getPort(spec)}},
 			ClusterIP: v1.ClusterIPNone,
 		},
 	}
 	k8sutil.SetOwnerRef(c.context.Clientset, namespace, &svc.ObjectMeta, &ownerRef)
 
-	svc, err := c.context.Clientset.CoreV1().Services(namespace).Create(svc)
-	if err != nil && !k8serrors.IsAlreadyExists(err) {
+	created, err := c.context.Clientset.CoreV1().Services(namespace).Create(svc)
+	if err == nil {
+		return created, nil
+	}
+	if !k8serrors.IsAlreadyExists(err) {
 		return nil, fmt.Errorf("failed to create minio headless service. %+v", err)
 	}
-	svc, err = c.context.Clientset.CoreV1().Services(namespace).Update(svc)
+
+	existing, err := c.context.Clientset.CoreV1().Services(namespace).Get(name, meta_v1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get minio headless service. %+v", err)
+	}
+	existing.Spec.Selector = svc.Spec.Selector
+	existing.Spec.Ports = svc.Spec.Ports
+	updated, err := c.context.Clientset.CoreV1().Services(namespace).Update(existing)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update minio headless service. %+v", err)
 	}
 
-	return svc, nil
+	return updated, nil
 }
 
-func (c *Controller) buildMinioCtrArgs(statefulSetPrefix, headlessServiceName, namespace, clusterDomain string, serverCount int32, volumeMounts []v1.VolumeMount) []string {
+func (c *Controller) buildMinioCtrArgs(name, namespace string, spec miniov1alpha1.ObjectStoreSpec) []string {
 	args := []string{"server"}
-	for i := int32(0); i < serverCount; i++ {
-		for _, mount := range volumeMounts {
-			args = append(args, makeServerAddress(statefulSetPrefix, headlessServiceName, namespace, clusterDomain, i, getPVCDataDir(mount.Name)))
+	if port := getPort(spec); port != minioPort {
+		args = append(args, "--address", fmt.Sprintf(":%d", port))
+	}
+	if spec.TLS != nil {
+		args = append(args, "--certs-dir", minioCertsDir)
+	}
+
+	scheme := getScheme(spec)
+	if len(spec.Pools) == 0 {
+		for i := 0; i < spec.Storage.NodeCount; i++ {
+			for _, dataDir := range getDataDirs(spec.Storage) {
+				args = append(args, makeServerAddress(scheme, name, name, namespace, spec.ClusterDomain, strconv.Itoa(i), dataDir))
+			}
+		}
+	} else {
+		// Minio expands an object store with pools given with the ellipsis syntax, one argument per pool
+		for _, p := range getPools(name, spec) {
+			servers := fmt.Sprintf("{0...%d}", p.storage.NodeCount-1)
+			args = append(args, makeServerAddress(scheme, p.statefulSetName, name, namespace, spec.ClusterDomain, servers, getDataDirs(p.storage)[0]))
 		}
 	}
 
@@ -133,25 +201,24 @@ func (c *Controller) buildMinioCtrArgs(statefulSetPrefix, headlessServiceName, n
 }
 
 // Generates the full server address for the given server params, e.g., http://my-store-0.my-store.rook-minio.svc.cluster.local/data
-func makeServerAddress(statefulSetPrefix, headlessServiceName, namespace, clusterDomain string, serverNum int32, pvcDataDir string) string {
+func makeServerAddress(scheme, statefulSetPrefix, headlessServiceName, namespace, clusterDomain, server, pvcDataDir string) string {
+	return fmt.Sprintf("%s://%s-%s.%s.%s%s", scheme, statefulSetPrefix, server, headlessServiceName, makeDNSSuffix(namespace, clusterDomain), pvcDataDir)
+}
+
+func makeDNSSuffix(namespace, clusterDomain string) string {
 	if clusterDomain == "" {
 		clusterDomain = miniov1alpha1.ClusterDomainDefault
 	}
-
-	dnsSuffix := fmt.Sprintf(minioServerSuffixFmt, namespace, clusterDomain)
-	return fmt.Sprintf("http://%s-%d.%s.%s%s", statefulSetPrefix, serverNum, headlessServiceName, dnsSuffix, pvcDataDir)
+	return fmt.Sprintf(minioServerSuffixFmt, namespace, clusterDomain)
 }
 
 func (c *Controller) makeMinioPodSpec(
 	name string,
 	namespace string,
-	ctrName string,
-	ctrImage string,
-	clusterDomain string,
+	p pool,
+	spec miniov1alpha1.ObjectStoreSpec,
 	envVars map[string]string,
-	numServers int32,
-	volumeClaims []v1.PersistentVolumeClaim,
-	annotations rookalpha.Annotations,
+	tlsVolume *v1.Volume,
 ) v1.PodTemplateSpec {
 	var env []v1.EnvVar
 	for k, v := range envVars {
@@ -160,11 +227,11 @@ func (c *Controller) makeMinioPodSpec(
 
 	volumes := []v1.Volume{}
 	volumeMounts := []v1.VolumeMount{}
-	if len(volumeClaims) > 0 {
-		for i := range volumeClaims {
+	if len(p.storage.VolumeClaimTemplates) > 0 {
+		for i := range p.storage.VolumeClaimTemplates {
 			volumeMounts = append(volumeMounts, v1.VolumeMount{
-				Name:      volumeClaims[i].GetName(),
-				MountPath: getPVCDataDir(volumeClaims[i].GetName()),
+				Name:      p.storage.VolumeClaimTemplates[i].GetName(),
+				MountPath: getPVCDataDir(p.storage.VolumeClaimTemplates[i].GetName()),
 			})
 		}
 	} else {
@@ -181,27 +248,38 @@ func (c *Controller) makeMinioPodSpec(
 		})
 	}
 
+	probeScheme := v1.URISchemeHTTP
+	if tlsVolume != nil {
+		volumes = append(volumes, *tlsVolume)
+		volumeMounts = append(volumeMounts, v1.VolumeMount{Name: minioTLSVolumeName, MountPath: minioCertsDir, ReadOnly: true})
+		probeScheme = v1.URISchemeHTTPS
+	}
+
+	port := getPort(spec)
 	podSpec := v1.PodTemplateSpec{
 		ObjectMeta: meta_v1.ObjectMeta{
-			Name:      name,
+			Name:      p.statefulSetName,
 			Namespace: namespace,
-			Labels:    getMinioLabels(name),
+			Labels:    p.labels,
 		},
 		Spec: v1.PodSpec{
 			Containers: []v1.Container{
 				{
-					Name:         ctrName,
-					Image:        ctrImage,
+					Name:         minioCtrName,
+					Image:        c.rookImage,
 					Env:          env,
 					Command:      []string{"/usr/bin/minio"},
-					Ports:        []v1.ContainerPort{{ContainerPort: minioPort}},
-					Args:         c.buildMinioCtrArgs(name, name, namespace, clusterDomain, numServers, volumeMounts),
+					Ports:        []v1.ContainerPort{{ContainerPort: port}},
+					Args:         c.buildMinioCtrArgs(name, namespace, spec),
 					VolumeMounts: volumeMounts,
+					Resources:    spec.Resources,
+					// the server is ready once it can serve requests, i.e. enough drives of its erasure sets are online
 					ReadinessProbe: &v1.Probe{
 						Handler: v1.Handler{
 							HTTPGet: &v1.HTTPGetAction{
-								Path: "/minio/health/ready",
-								Port: intstr.FromInt(int(minioPort)),
+								Path:   "/minio/health/ready",
+								Port:   intstr.FromInt(int(port)),
+								Scheme: probeScheme,
 							},
 						},
 						InitialDelaySeconds: 5,
@@ -213,8 +291,9 @@ func (c *Controller) makeMinioPodSpec(
 					LivenessProbe: &v1.Probe{
 						Handler: v1.Handler{
 							HTTPGet: &v1.HTTPGetAction{
-								Path: "/minio/health/live",
-								Port: intstr.FromInt(int(minioPort)),
+								Path:   "/minio/health/live",
+								Port:   intstr.FromInt(int(port)),
+								Scheme: probeScheme,
 							},
 						},
 						InitialDelaySeconds: 8,
@@ -228,7 +307,7 @@ func (c *Controller) makeMinioPodSpec(
 			Volumes: volumes,
 		},
 	}
-	annotations.ApplyToObjectMeta(&podSpec.ObjectMeta)
+	spec.Annotations.ApplyToObjectMeta(&podSpec.ObjectMeta)
 
 	return podSpec
 }
@@ -245,9 +324,79 @@ func (c *Controller) getAccessCredentials(secretName, namespace string) (string,
 	return string(val.Data["username"]), string(val.Data["password"]), nil
 }
 
+// getTLSSecret returns the secret with the certificate and key of the servers of the object store
+func (c *Controller) getTLSSecret(namespace string, tls *miniov1alpha1.TLSSpec) (*v1.Secret, error) {
+	secret, err := c.context.Clientset.CoreV1().Secrets(namespace).Get(tls.SecretName, meta_v1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tls secret %s. %+v", tls.SecretName, err)
+	}
+	for _, key := range []string{tlsCertName, tlsKeyName} {
+		if len(secret.Data[key]) == 0 {
+			return nil, fmt.Errorf("tls secret %s has no %s", tls.SecretName, key)
+		}
+	}
+	return secret, nil
+}
+
+// makeTLSVolume creates the volume of the certs dir of Minio from the tls secret. Minio trusts the certificates in
+// the CAs dir, where the optional ca.crt of the secret is mounted.
+func makeTLSVolume(secret *v1.Secret) *v1.Volume {
+	items := []v1.KeyToPath{{Key: tlsCertName, Path: tlsCertName}, {Key: tlsKeyName, Path: tlsKeyName}}
+	if len(secret.Data[tlsCAName]) > 0 {
+		items = append(items, v1.KeyToPath{Key: tlsCAName, Path: "CAs/" + tlsCAName})
+	}
+	return &v1.Volume{
+		Name: minioTLSVolumeName,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{SecretName: secret.Name, Items: items},
+		},
+	}
+}
+
 func validateObjectStoreSpec(spec miniov1alpha1.ObjectStoreSpec) error {
+	if err := validateStorageScope(spec.Storage); err != nil {
+		return err
+	}
+	if spec.Port < 0 || spec.Port > 65535 {
+		return fmt.Errorf("invalid port %d", spec.Port)
+	}
+	if spec.TLS != nil && spec.TLS.SecretName == "" {
+		return fmt.Errorf("the secretName of the tls settings is required")
+	}
+	if err := validateIAMSpec(spec); err != nil {
+		return err
+	}
+
+	if len(spec.Pools) == 0 {
+		return nil
+	}
+	// the drives of every pool are given to Minio as a single argument
+	if len(spec.Storage.VolumeClaimTemplates) > 1 {
+		return fmt.Errorf("an object store with pools must have at most one volume claim template")
+	}
+	names := map[string]bool{}
+	for _, p := range spec.Pools {
+		if p.Name == "" {
+			return fmt.Errorf("the name of every pool is required")
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate pool %s", p.Name)
+		}
+		names[p.Name] = true
+		if err := validateStorageScope(p.Storage); err != nil {
+			return fmt.Errorf("invalid pool %s. %+v", p.Name, err)
+		}
+		if len(p.Storage.VolumeClaimTemplates) > 1 {
+			return fmt.Errorf("pool %s must have at most one volume claim template", p.Name)
+		}
+	}
+
+	return nil
+}
+
+func validateStorageScope(storage rookalpha.StorageScopeSpec) error {
 	// Verify node count.
-	count := spec.Storage.NodeCount
+	count := storage.NodeCount
 	if count < 4 || count%2 != 0 {
 		return fmt.Errorf("node count must be greater than 3 and even")
 	}
@@ -255,7 +404,31 @@ func validateObjectStoreSpec(spec miniov1alpha1.ObjectStoreSpec) error {
 	return nil
 }
 
-func (c *Controller) makeMinioStatefulSet(name, namespace string, spec miniov1alpha1.ObjectStoreSpec, ownerRef meta_v1.OwnerReference, annotations rookalpha.Annotations) (*apps.StatefulSet, error) {
+// validateObjectStoreUpdate verifies that the update keeps the existing servers of the object store, which is
+// expanded by adding pools
+func validateObjectStoreUpdate(oldSpec, newSpec miniov1alpha1.ObjectStoreSpec) error {
+	if oldSpec.Storage.NodeCount != newSpec.Storage.NodeCount ||
+		!reflect.DeepEqual(oldSpec.Storage.VolumeClaimTemplates, newSpec.Storage.VolumeClaimTemplates) {
+		return fmt.Errorf("the servers of the object store cannot be changed, add a pool to expand it")
+	}
+	if len(newSpec.Pools) < len(oldSpec.Pools) {
+		return fmt.Errorf("pools cannot be removed")
+	}
+	for i, p := range oldSpec.Pools {
+		if !reflect.DeepEqual(p, newSpec.Pools[i]) {
+			return fmt.Errorf("pool %s cannot be changed", p.Name)
+		}
+	}
+	if getPort(oldSpec) != getPort(newSpec) {
+		return fmt.Errorf("the port cannot be changed")
+	}
+	if (oldSpec.TLS == nil) != (newSpec.TLS == nil) {
+		return fmt.Errorf("tls cannot be enabled or disabled")
+	}
+	return nil
+}
+
+func (c *Controller) makeMinioStatefulSet(name, namespace string, p pool, spec miniov1alpha1.ObjectStoreSpec, ownerRef meta_v1.OwnerReference, annotations rookalpha.Annotations) (*apps.StatefulSet, error) {
 	accessKey, secretKey, err := c.getAccessCredentials(spec.Credentials.Name, spec.Credentials.Namespace)
 	if err != nil {
 		return nil, err
@@ -266,89 +439,226 @@ func (c *Controller) makeMinioStatefulSet(name, namespace string, spec miniov1al
 		"MINIO_SECRET_KEY": secretKey,
 	}
 
-	podSpec := c.makeMinioPodSpec(
-		name,
-		namespace,
-		minioCtrName,
-		c.rookImage,
-		spec.ClusterDomain,
-		envVars,
-		int32(spec.Storage.NodeCount),
-		spec.Storage.VolumeClaimTemplates,
-		spec.Annotations,
-	)
+	var tlsVolume *v1.Volume
+	if spec.TLS != nil {
+		secret, err := c.getTLSSecret(namespace, spec.TLS)
+		if err != nil {
+			return nil, err
+		}
+		tlsVolume = makeTLSVolume(secret)
+	}
+
+	podSpec := c.makeMinioPodSpec(name, namespace, p, spec, envVars, tlsVolume)
 
-	nodeCount := int32(spec.Storage.NodeCount)
+	nodeCount := int32(p.storage.NodeCount)
 	sts := &apps.StatefulSet{
 		ObjectMeta: meta_v1.ObjectMeta{
-			Name:      name,
+			Name:      p.statefulSetName,
 			Namespace: namespace,
-			Labels:    getMinioLabels(name),
+			Labels:    p.labels,
 		},
 		Spec: apps.StatefulSetSpec{
 			Replicas: &nodeCount,
 			Selector: &meta_v1.LabelSelector{
-				MatchLabels: getMinioLabels(name),
+				MatchLabels: p.labels,
 			},
 			Template:             podSpec,
-			VolumeClaimTemplates: spec.Storage.VolumeClaimTemplates,
+			VolumeClaimTemplates: p.storage.VolumeClaimTemplates,
 			ServiceName:          name,
+			PodManagementPolicy:  p.podManagementPolicy,
 		},
 	}
 	annotations.ApplyToObjectMeta(&sts.ObjectMeta)
 	k8sutil.SetOwnerRef(c.context.Clientset, namespace, &sts.ObjectMeta, &ownerRef)
-	sts, err = c.context.Clientset.AppsV1().StatefulSets(namespace).Create(sts)
-	if err != nil && !k8serrors.IsAlreadyExists(err) {
+	created, err := c.context.Clientset.AppsV1().StatefulSets(namespace).Create(sts)
+	if err == nil {
+		return created, nil
+	}
+	if !k8serrors.IsAlreadyExists(err) {
 		return nil, fmt.Errorf("failed to create minio statefulset. %+v", err)
 	}
-	sts, err = c.context.Clientset.AppsV1().StatefulSets(namespace).Update(sts)
+
+	// the servers and the storage of an existing pool never change, only its pod template is updated
+	existing, err := c.context.Clientset.AppsV1().StatefulSets(namespace).Get(p.statefulSetName, meta_v1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get minio statefulset. %+v", err)
+	}
+	if !reflect.DeepEqual(existing.Spec.Selector, sts.Spec.Selector) {
+		return c.replaceStatefulSet(existing, sts)
+	}
+	existing.Spec.Template = podSpec
+	updated, err := c.context.Clientset.AppsV1().StatefulSets(namespace).Update(existing)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update minio statefulset. %+v", err)
 	}
 
-	return sts, err
+	return updated, nil
 }
 
-func (c *Controller) onAdd(obj interface{}) {
-	objectstore := obj.(*miniov1alpha1.ObjectStore).DeepCopy()
+// replaceStatefulSet recreates a stateful set whose selector changed, as the selector of a stateful set can't be
+// updated, e.g. the stateful set of the first pool created before the pools had their own label. The servers keep
+// running: their pods get the labels of the new selector and are adopted by the new stateful set.
+func (c *Controller) replaceStatefulSet(existing, sts *apps.StatefulSet) (*apps.StatefulSet, error) {
+	namespace := existing.Namespace
+	logger.Infof("Recreating Minio stateful set %s with selector %v.", sts.Name, sts.Spec.Selector.MatchLabels)
 
-	ownerRef := meta_v1.OwnerReference{
-		APIVersion: ObjectStoreResource.Version,
-		Kind:       ObjectStoreResource.Kind,
-		Name:       objectstore.Namespace,
-		UID:        types.UID(objectstore.ObjectMeta.UID),
+	selector := meta_v1.FormatLabelSelector(existing.Spec.Selector)
+	pods, err := c.context.Clientset.CoreV1().Pods(namespace).List(meta_v1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pods of minio statefulset %s. %+v", existing.Name, err)
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !meta_v1.IsControlledBy(pod, existing) {
+			continue
+		}
+		for k, v := range sts.Spec.Selector.MatchLabels {
+			pod.Labels[k] = v
+		}
+		if _, err := c.context.Clientset.CoreV1().Pods(namespace).Update(pod); err != nil {
+			return nil, fmt.Errorf("failed to label minio pod %s. %+v", pod.Name, err)
+		}
 	}
 
-	// Validate object store config.
-	err := validateObjectStoreSpec(objectstore.Spec)
-	if err != nil {
-		logger.Errorf("failed to validate object store config")
-		return
+	orphan := meta_v1.DeletePropagationOrphan
+	err = c.context.Clientset.AppsV1().StatefulSets(namespace).Delete(existing.Name, &meta_v1.DeleteOptions{PropagationPolicy: &orphan})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to delete minio statefulset %s. %+v", existing.Name, err)
 	}
+	// the stateful set is only gone once the garbage collector orphaned its pods
+	for i := 0; i < statefulSetDeleteRetries; i++ {
+		created, err := c.context.Clientset.AppsV1().StatefulSets(namespace).Create(sts)
+		if err == nil {
+			return created, nil
+		}
+		if !k8serrors.IsAlreadyExists(err) {
+			return nil, fmt.Errorf("failed to create minio statefulset. %+v", err)
+		}
+		logger.Infof("Minio stateful set %s still found. waiting...", sts.Name)
+		time.Sleep(statefulSetDeleteInterval)
+	}
+	return nil, fmt.Errorf("gave up waiting for minio statefulset %s to be deleted", sts.Name)
+}
+
+// createOrUpdateObjectStore creates or updates the headless service and the stateful sets of the pools
+func (c *Controller) createOrUpdateObjectStore(objectstore *miniov1alpha1.ObjectStore) error {
+	ownerRef := makeOwnerRef(objectstore)
 
 	// Create the headless service.
 	logger.Infof("Creating Minio headless service %s in namespace %s.", objectstore.Name, objectstore.Namespace)
-	_, err = c.makeMinioHeadlessService(objectstore.Name, objectstore.Namespace, objectstore.Spec, ownerRef)
-	if err != nil {
-		logger.Errorf(err.Error())
-		return
+	if _, err := c.makeMinioHeadlessService(objectstore.Name, objectstore.Namespace, objectstore.Spec, ownerRef); err != nil {
+		return err
 	}
 	logger.Infof("Finished creating/updating Minio headless service %s in namespace %s.", objectstore.Name, objectstore.Namespace)
 
-	// Create the stateful set.
-	logger.Infof("Creating/Updating Minio stateful set %s.", objectstore.Name)
-	_, err = c.makeMinioStatefulSet(objectstore.Name, objectstore.Namespace, objectstore.Spec, ownerRef, objectstore.Annotations)
+	// Create the stateful sets.
+	imageChanged := false
+	for _, p := range getPools(objectstore.Name, objectstore.Spec) {
+		if image := c.serverImage(objectstore.Namespace, p.statefulSetName); image != "" && image != c.rookImage {
+			logger.Infof("Upgrading Minio stateful set %s from image %s to %s.", p.statefulSetName, image, c.rookImage)
+			imageChanged = true
+		}
+		logger.Infof("Creating/Updating Minio stateful set %s.", p.statefulSetName)
+		if _, err := c.makeMinioStatefulSet(objectstore.Name, objectstore.Namespace, p, objectstore.Spec, ownerRef, objectstore.Annotations); err != nil {
+			return err
+		}
+		logger.Infof("Finished creating/updating Minio stateful set %s in namespace %s.", p.statefulSetName, objectstore.Namespace)
+	}
+
+	// servers of different minio versions don't form a quorum, so all the servers are restarted at once with the
+	// new version rather than one at a time by the rolling update of their stateful sets
+	if imageChanged {
+		return c.restartServers(objectstore)
+	}
+	return nil
+}
+
+// serverImage returns the image that the servers of a stateful set run, or an empty string if it doesn't exist yet
+func (c *Controller) serverImage(namespace, statefulSetName string) string {
+	sts, err := c.context.Clientset.AppsV1().StatefulSets(namespace).Get(statefulSetName, meta_v1.GetOptions{})
+	if err != nil {
+		return ""
+	}
+	for _, container := range sts.Spec.Template.Spec.Containers {
+		if container.Name == minioCtrName {
+			return container.Image
+		}
+	}
+	return ""
+}
+
+func (c *Controller) onAdd(obj interface{}) {
+	objectstore := obj.(*miniov1alpha1.ObjectStore).DeepCopy()
+
+	// Validate object store config.
+	err := validateObjectStoreSpec(objectstore.Spec)
 	if err != nil {
-		logger.Errorf(err.Error())
+		c.setObjectStoreError(objectstore, fmt.Sprintf("failed to validate object store config. %+v", err))
 		return
 	}
-	logger.Infof("Finished creating/updating Minio stateful set %s in namespace %s.", objectstore.Name, objectstore.Namespace)
+
+	if err := c.updateObjectStoreState(objectstore, miniov1alpha1.ObjectStoreStateCreating, ""); err != nil {
+		logger.Warning(err.Error())
+	}
+
+	if err := c.createOrUpdateObjectStore(objectstore); err != nil {
+		c.setObjectStoreError(objectstore, err.Error())
+		return
+	}
+
+	if err := c.updateObjectStoreState(objectstore, miniov1alpha1.ObjectStoreStateCreated, ""); err != nil {
+		logger.Warning(err.Error())
+	}
 }
 
 func (c *Controller) onUpdate(oldObj, newObj interface{}) {
+	oldStore := oldObj.(*miniov1alpha1.ObjectStore).DeepCopy()
 	newStore := newObj.(*miniov1alpha1.ObjectStore).DeepCopy()
 
-	c.onAdd(newStore)
+	// the status updates of the operator are watched too
+	if reflect.DeepEqual(oldStore.Spec, newStore.Spec) {
+		logger.Debugf("spec of object store %s in namespace %s did not change", newStore.Name, newStore.Namespace)
+		return
+	}
+	logger.Infof("Update Minio object store %s in namespace %s", newStore.Name, newStore.Namespace)
+
+	if err := validateObjectStoreSpec(newStore.Spec); err != nil {
+		c.setObjectStoreError(newStore, fmt.Sprintf("failed to validate object store config. %+v", err))
+		return
+	}
+	if err := validateObjectStoreUpdate(oldStore.Spec, newStore.Spec); err != nil {
+		c.setObjectStoreError(newStore, fmt.Sprintf("unsupported object store update. %+v", err))
+		return
+	}
+
+	if err := c.updateObjectStoreState(newStore, miniov1alpha1.ObjectStoreStateUpdating, ""); err != nil {
+		logger.Warning(err.Error())
+	}
+
+	if err := c.createOrUpdateObjectStore(newStore); err != nil {
+		c.setObjectStoreError(newStore, err.Error())
+		return
+	}
+
+	// all the servers must be restarted at once to join the new pools
+	if len(newStore.Spec.Pools) > len(oldStore.Spec.Pools) {
+		if err := c.restartServers(newStore); err != nil {
+			c.setObjectStoreError(newStore, err.Error())
+			return
+		}
+	}
+
+	if err := c.updateObjectStoreState(newStore, miniov1alpha1.ObjectStoreStateCreated, ""); err != nil {
+		logger.Warning(err.Error())
+	}
+
+	// the buckets and users are synced again by the status monitor if the servers are not ready yet
+	if !reflect.DeepEqual(oldStore.Spec.Buckets, newStore.Spec.Buckets) || !reflect.DeepEqual(oldStore.Spec.Users, newStore.Spec.Users) ||
+		!reflect.DeepEqual(oldStore.Spec.Policies, newStore.Spec.Policies) {
+		if err := c.syncIAM(newStore); err != nil {
+			logger.Warningf("failed to sync the buckets and users of object store %s. %+v", newStore.Name, err)
+		}
+	}
 }
 
 func (c *Controller) onDelete(obj interface{}) {
@@ -358,13 +668,95 @@ func (c *Controller) onDelete(obj interface{}) {
 	// Cleanup is handled by the owner references set in 'onAdd' and the k8s garbage collector.
 }
 
+// restartServers deletes the pods of all the servers of the object store, which are recreated by their stateful sets
+func (c *Controller) restartServers(objectstore *miniov1alpha1.ObjectStore) error {
+	logger.Infof("Restarting the servers of Minio object store %s in namespace %s.", objectstore.Name, objectstore.Namespace)
+	selector := fmt.Sprintf("%s=%s", minioObjectStoreLabel, objectstore.Name)
+	pods, err := c.context.Clientset.CoreV1().Pods(objectstore.Namespace).List(meta_v1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list minio pods. %+v", err)
+	}
+	for _, pod := range pods.Items {
+		err := c.context.Clientset.CoreV1().Pods(objectstore.Namespace).Delete(pod.Name, &meta_v1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete minio pod %s. %+v", pod.Name, err)
+		}
+	}
+	return nil
+}
+
+func (c *Controller) setObjectStoreError(objectstore *miniov1alpha1.ObjectStore, message string) {
+	logger.Errorf(message)
+	if err := c.updateObjectStoreState(objectstore, miniov1alpha1.ObjectStoreStateError, message); err != nil {
+		logger.Warning(err.Error())
+	}
+}
+
+func (c *Controller) updateObjectStoreState(objectstore *miniov1alpha1.ObjectStore, state miniov1alpha1.ObjectStoreState, message string) error {
+	// get the most recent object store, the servers and drives are reported by the status monitor
+	current, err := c.context.RookClientset.MinioV1alpha1().ObjectStores(objectstore.Namespace).Get(objectstore.Name, meta_v1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get object store %s prior to updating its status. %+v", objectstore.Name, err)
+	}
+
+	current.Status.State = state
+	current.Status.Message = message
+	if _, err := c.context.RookClientset.MinioV1alpha1().ObjectStores(objectstore.Namespace).Update(current); err != nil {
+		return fmt.Errorf("failed to update object store %s status. %+v", objectstore.Name, err)
+	}
+	return nil
+}
+
+func makeOwnerRef(objectstore *miniov1alpha1.ObjectStore) meta_v1.OwnerReference {
+	return meta_v1.OwnerReference{
+		APIVersion: ObjectStoreResource.Version,
+		Kind:       ObjectStoreResource.Kind,
+		Name:       objectstore.Namespace,
+		UID:        types.UID(objectstore.ObjectMeta.UID),
+	}
+}
+
 func getPVCDataDir(pvcName string) string {
 	return fmt.Sprintf(objectStoreDataDirTemplate, pvcName)
 }
 
+// getDataDirs returns the dirs of the drives of every server with the given storage
+func getDataDirs(storage rookalpha.StorageScopeSpec) []string {
+	if len(storage.VolumeClaimTemplates) == 0 {
+		return []string{fmt.Sprintf(objectStoreDataDirTemplate, objectStoreDataEmptyDirName)}
+	}
+	dirs := []string{}
+	for _, claim := range storage.VolumeClaimTemplates {
+		dirs = append(dirs, getPVCDataDir(claim.GetName()))
+	}
+	return dirs
+}
+
+func getPort(spec miniov1alpha1.ObjectStoreSpec) int32 {
+	if spec.Port == 0 {
+		return minioPort
+	}
+	return spec.Port
+}
+
+func getScheme(spec miniov1alpha1.ObjectStoreSpec) string {
+	if spec.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
 func getMinioLabels(name string) map[string]string {
 	return map[string]string{
 		k8sutil.AppAttr:       minioLabel,
 		minioObjectStoreLabel: name,
 	}
 }
+
+// getPoolLabels returns the labels of the servers of a pool, which is named after its stateful set so that the
+// selectors of the stateful sets of the pools don't overlap.
+func getPoolLabels(name, poolName string) map[string]string {
+	labels := getMinioLabels(name)
+	labels[minioPoolLabel] = poolName
+	return labels
+}
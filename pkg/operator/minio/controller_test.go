@@ -20,9 +20,11 @@ import (
 
 	miniov "github.com/rook/rook/pkg/apis/minio.rook.io/v1alpha1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
 	"github.com/rook/rook/pkg/clusterd"
 	testop "github.com/rook/rook/pkg/operator/test"
 	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -84,7 +86,7 @@ func TestOnAdd(t *testing.T) {
 
 	// Initialize the controller and its dependencies.
 	clientset := testop.New(3)
-	context := &clusterd.Context{Clientset: clientset}
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookfake.NewSimpleClientset(objectstore)}
 	controller := NewController(context, "rook/minio:mockTag")
 
 	// Make the credentials.
@@ -138,6 +140,17 @@ func TestOnAdd(t *testing.T) {
 		"http://some_object_store-5.some_object_store.rook-minio-123.svc.cluster.local/data/rook-minio-test2",
 	}
 	assert.Equal(t, expectedContainerArgs, ss.Spec.Template.Spec.Containers[0].Args)
+	assert.Equal(t, v1.URISchemeHTTP, container.ReadinessProbe.HTTPGet.Scheme)
+
+	// Verify the status.
+	current, err := context.RookClientset.MinioV1alpha1().ObjectStores(namespace).Get(appName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, miniov.ObjectStoreStateCreated, current.Status.State)
+
+	// Adding the object store again updates the existing resources.
+	controller.onAdd(objectstore)
+	_, err = clientset.AppsV1().StatefulSets(namespace).Get(appName, metav1.GetOptions{})
+	assert.Nil(t, err)
 }
 
 func TestSpecVerification(t *testing.T) {
@@ -200,14 +213,248 @@ func TestSpecVerification(t *testing.T) {
 
 func TestMakeServerAddress(t *testing.T) {
 	// pass empty string for cluster domain, default should be used
-	serverAddress := makeServerAddress("my-store", "my-store", "rook-minio", "", 3, "/my-cool-data/dir123")
+	serverAddress := makeServerAddress("http", "my-store", "my-store", "rook-minio", "", "3", "/my-cool-data/dir123")
 	assert.Equal(t, "http://my-store-3.my-store.rook-minio.svc.cluster.local/my-cool-data/dir123", serverAddress)
 
 	// pass custom cluster domain, it should be used
-	serverAddress = makeServerAddress("my-store", "my-store", "rook-minio", "acme.com", 3, "/data/mydir1")
+	serverAddress = makeServerAddress("http", "my-store", "my-store", "rook-minio", "acme.com", "3", "/data/mydir1")
 	assert.Equal(t, "http://my-store-3.my-store.rook-minio.svc.acme.com/data/mydir1", serverAddress)
+
+	// pass a range of servers and https
+	serverAddress = makeServerAddress("https", "my-store-pool1", "my-store", "rook-minio", "", "{0...3}", "/data/mydir1")
+	assert.Equal(t, "https://my-store-pool1-{0...3}.my-store.rook-minio.svc.cluster.local/data/mydir1", serverAddress)
 }
 
 func TestGetPVCDataDir(t *testing.T) {
 	assert.Equal(t, "/data/rook-test123", getPVCDataDir("rook-test123"))
 }
+
+func newObjectStoreForTest(namespace string) *miniov.ObjectStore {
+	return &miniov.ObjectStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-store", Namespace: namespace},
+		Spec: miniov.ObjectStoreSpec{
+			Storage:     rookalpha.StorageScopeSpec{NodeCount: 4},
+			Credentials: v1.SecretReference{Name: "access-keys", Namespace: namespace},
+		},
+	}
+}
+
+func TestOnAddTLSAndPort(t *testing.T) {
+	namespace := "rook-minio-124"
+	objectstore := newObjectStoreForTest(namespace)
+	objectstore.Spec.Port = 9443
+	objectstore.Spec.TLS = &miniov.TLSSpec{SecretName: "my-store-tls"}
+	objectstore.Spec.Resources = v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")}}
+
+	clientset := testop.New(3)
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookfake.NewSimpleClientset(objectstore)}
+	controller := NewController(context, "rook/minio:mockTag")
+	_, err := clientset.CoreV1().Secrets(namespace).Create(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "access-keys", Namespace: namespace}})
+	assert.Nil(t, err)
+
+	// the tls secret must exist
+	controller.onAdd(objectstore)
+	current, err := context.RookClientset.MinioV1alpha1().ObjectStores(namespace).Get("my-store", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, miniov.ObjectStoreStateError, current.Status.State)
+
+	_, err = clientset.CoreV1().Secrets(namespace).Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-store-tls", Namespace: namespace},
+		Data:       map[string][]byte{tlsCertName: []byte("cert"), tlsKeyName: []byte("key")},
+	})
+	assert.Nil(t, err)
+	controller.onAdd(objectstore)
+
+	svc, err := clientset.CoreV1().Services(namespace).Get("my-store", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(9443), svc.Spec.Ports[0].Port)
+
+	ss, err := clientset.AppsV1().StatefulSets(namespace).Get("my-store", metav1.GetOptions{})
+	assert.Nil(t, err)
+	container := ss.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, []v1.ContainerPort{{ContainerPort: 9443}}, container.Ports)
+	assert.Equal(t, objectstore.Spec.Resources, container.Resources)
+	assert.Equal(t, []string{"server", "--address", ":9443", "--certs-dir", minioCertsDir}, container.Args[:5])
+	assert.Equal(t, "https://my-store-0.my-store.rook-minio-124.svc.cluster.local/data/minio-data", container.Args[5])
+	assert.Equal(t, v1.URISchemeHTTPS, container.ReadinessProbe.HTTPGet.Scheme)
+	assert.Equal(t, 9443, container.LivenessProbe.HTTPGet.Port.IntValue())
+
+	// the certificate and key are mounted in the certs dir, without a ca
+	volume := ss.Spec.Template.Spec.Volumes[1]
+	assert.Equal(t, "my-store-tls", volume.Secret.SecretName)
+	assert.Equal(t, 2, len(volume.Secret.Items))
+	assert.Equal(t, minioCertsDir, container.VolumeMounts[1].MountPath)
+}
+
+func TestOnUpdateAddPool(t *testing.T) {
+	namespace := "rook-minio-125"
+	oldStore := newObjectStoreForTest(namespace)
+	clientset := testop.New(3)
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookfake.NewSimpleClientset(oldStore)}
+	controller := NewController(context, "rook/minio:mockTag")
+	_, err := clientset.CoreV1().Secrets(namespace).Create(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "access-keys", Namespace: namespace}})
+	assert.Nil(t, err)
+	controller.onAdd(oldStore)
+
+	// a running server of the first pool
+	_, err = clientset.CoreV1().Pods(namespace).Create(&v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "my-store-0", Namespace: namespace, Labels: getMinioLabels("my-store")}})
+	assert.Nil(t, err)
+
+	// changing the existing servers is rejected
+	newStore := oldStore.DeepCopy()
+	newStore.Spec.Storage.NodeCount = 6
+	controller.onUpdate(oldStore, newStore)
+	current, err := context.RookClientset.MinioV1alpha1().ObjectStores(namespace).Get("my-store", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, miniov.ObjectStoreStateError, current.Status.State)
+
+	newStore = oldStore.DeepCopy()
+	newStore.Spec.Pools = []miniov.PoolSpec{{Name: "pool1", Storage: rookalpha.StorageScopeSpec{NodeCount: 6}}}
+	controller.onUpdate(oldStore, newStore)
+	current, err = context.RookClientset.MinioV1alpha1().ObjectStores(namespace).Get("my-store", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, miniov.ObjectStoreStateCreated, current.Status.State)
+
+	// the pool is run by a new stateful set
+	ss, err := clientset.AppsV1().StatefulSets(namespace).Get("my-store-pool1", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(6), *ss.Spec.Replicas)
+	assert.Equal(t, "my-store", ss.Spec.ServiceName)
+	assert.Equal(t, "my-store-pool1", ss.Spec.Template.Labels[minioPoolLabel])
+	assert.Equal(t, apps.ParallelPodManagement, ss.Spec.PodManagementPolicy)
+
+	// all the servers join both pools
+	expectedArgs := []string{
+		"server",
+		"http://my-store-{0...3}.my-store.rook-minio-125.svc.cluster.local/data/minio-data",
+		"http://my-store-pool1-{0...5}.my-store.rook-minio-125.svc.cluster.local/data/minio-data",
+	}
+	assert.Equal(t, expectedArgs, ss.Spec.Template.Spec.Containers[0].Args)
+	ss, err = clientset.AppsV1().StatefulSets(namespace).Get("my-store", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(4), *ss.Spec.Replicas)
+	assert.Equal(t, expectedArgs, ss.Spec.Template.Spec.Containers[0].Args)
+
+	// the servers are restarted to join the new pool
+	pods, err := clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(pods.Items))
+}
+
+func TestOnAddImageChanged(t *testing.T) {
+	namespace := "rook-minio-126"
+	objectstore := newObjectStoreForTest(namespace)
+	clientset := testop.New(3)
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookfake.NewSimpleClientset(objectstore)}
+	_, err := clientset.CoreV1().Secrets(namespace).Create(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "access-keys", Namespace: namespace}})
+	assert.Nil(t, err)
+	NewController(context, "rook/minio:mockTag").onAdd(objectstore)
+
+	// a running server
+	_, err = clientset.CoreV1().Pods(namespace).Create(&v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "my-store-0", Namespace: namespace, Labels: getMinioLabels("my-store")}})
+	assert.Nil(t, err)
+
+	// the servers keep running when the operator restarts with the same image
+	NewController(context, "rook/minio:mockTag").onAdd(objectstore)
+	pods, err := clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(pods.Items))
+
+	// the servers are all restarted at once with a new image
+	NewController(context, "rook/minio:newTag").onAdd(objectstore)
+	ss, err := clientset.AppsV1().StatefulSets(namespace).Get("my-store", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "rook/minio:newTag", ss.Spec.Template.Spec.Containers[0].Image)
+	pods, err = clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(pods.Items))
+}
+
+func TestValidateObjectStoreUpdate(t *testing.T) {
+	oldSpec := newObjectStoreForTest("ns").Spec
+	oldSpec.Pools = []miniov.PoolSpec{{Name: "pool1", Storage: rookalpha.StorageScopeSpec{NodeCount: 4}}}
+
+	// adding a pool and changing the resources is supported
+	newSpec := *oldSpec.DeepCopy()
+	newSpec.Pools = append(newSpec.Pools, miniov.PoolSpec{Name: "pool2", Storage: rookalpha.StorageScopeSpec{NodeCount: 8}})
+	newSpec.Resources = v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}}
+	assert.Nil(t, validateObjectStoreUpdate(oldSpec, newSpec))
+
+	newSpec = *oldSpec.DeepCopy()
+	newSpec.Pools = nil
+	assert.NotNil(t, validateObjectStoreUpdate(oldSpec, newSpec))
+
+	newSpec = *oldSpec.DeepCopy()
+	newSpec.Pools[0].Storage.NodeCount = 6
+	assert.NotNil(t, validateObjectStoreUpdate(oldSpec, newSpec))
+
+	newSpec = *oldSpec.DeepCopy()
+	newSpec.Port = 9001
+	assert.NotNil(t, validateObjectStoreUpdate(oldSpec, newSpec))
+
+	newSpec = *oldSpec.DeepCopy()
+	newSpec.TLS = &miniov.TLSSpec{SecretName: "tls"}
+	assert.NotNil(t, validateObjectStoreUpdate(oldSpec, newSpec))
+}
+
+func TestValidatePools(t *testing.T) {
+	spec := newObjectStoreForTest("ns").Spec
+	spec.Pools = []miniov.PoolSpec{{Name: "pool1", Storage: rookalpha.StorageScopeSpec{NodeCount: 4}}}
+	assert.Nil(t, validateObjectStoreSpec(spec))
+
+	spec.Pools[0].Storage.NodeCount = 5
+	assert.NotNil(t, validateObjectStoreSpec(spec))
+
+	spec.Pools = []miniov.PoolSpec{{Name: "pool1", Storage: rookalpha.StorageScopeSpec{NodeCount: 4}}, {Name: "pool1", Storage: rookalpha.StorageScopeSpec{NodeCount: 4}}}
+	assert.NotNil(t, validateObjectStoreSpec(spec))
+
+	// the drives of every pool are given as a single argument
+	spec.Pools = []miniov.PoolSpec{{Name: "pool1", Storage: rookalpha.StorageScopeSpec{NodeCount: 4}}}
+	spec.Storage.VolumeClaimTemplates = []v1.PersistentVolumeClaim{
+		{ObjectMeta: metav1.ObjectMeta{Name: "data1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "data2"}},
+	}
+	assert.NotNil(t, validateObjectStoreSpec(spec))
+}
+
+func TestReplaceStatefulSet(t *testing.T) {
+	namespace := "rook-minio-126"
+	objectstore := newObjectStoreForTest(namespace)
+	clientset := testop.New(3)
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookfake.NewSimpleClientset(objectstore)}
+	controller := NewController(context, "rook/minio:mockTag")
+	_, err := clientset.CoreV1().Secrets(namespace).Create(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "access-keys", Namespace: namespace}})
+	assert.Nil(t, err)
+
+	// the stateful set of the first pool selected all the servers of the object store
+	labels := getMinioLabels("my-store")
+	_, err = clientset.AppsV1().StatefulSets(namespace).Create(&apps.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-store", Namespace: namespace, UID: "sts-uid", Labels: labels},
+		Spec:       apps.StatefulSetSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+	})
+	assert.Nil(t, err)
+	controlled := true
+	owner := []metav1.OwnerReference{{Kind: "StatefulSet", Name: "my-store", UID: "sts-uid", Controller: &controlled}}
+	for _, name := range []string{"my-store-0", "my-store-pool1-0"} {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: getMinioLabels("my-store")}}
+		if name == "my-store-0" {
+			pod.OwnerReferences = owner
+		}
+		_, err = clientset.CoreV1().Pods(namespace).Create(pod)
+		assert.Nil(t, err)
+	}
+
+	// the stateful set is recreated with the selector of the pool, and its pods get the label of the pool
+	controller.onAdd(objectstore)
+	ss, err := clientset.AppsV1().StatefulSets(namespace).Get("my-store", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, getPoolLabels("my-store", "my-store"), ss.Spec.Selector.MatchLabels)
+	pod, err := clientset.CoreV1().Pods(namespace).Get("my-store-0", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "my-store", pod.Labels[minioPoolLabel])
+	pod, err = clientset.CoreV1().Pods(namespace).Get("my-store-pool1-0", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "", pod.Labels[minioPoolLabel])
+}
@@ -0,0 +1,397 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package minio
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	miniov1alpha1 "github.com/rook/rook/pkg/apis/minio.rook.io/v1alpha1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/s3"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	mcBinary            = "/usr/bin/mc"
+	mcAlias             = "store"
+	userSecretNameFmt   = "%s-%s-access-keys"
+	userSecretEndpoint  = "endpoint"
+	anonymousAccessNone = "none"
+	userSecretKeyLength = 20
+	userPolicyTargetFmt = "user=%s"
+	mcCertsDir          = "certs/CAs"
+	policyFileNameFmt   = "%s.json"
+)
+
+var (
+	// the canned policies of Minio that can be attached to the users without being defined
+	cannedPolicies    = []string{"readonly", "readwrite", "writeonly"}
+	anonymousAccesses = []string{anonymousAccessNone, "download", "upload", "public"}
+	bucketNameRegexp  = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+	// the name of a user is its access key, which Minio limits to 20 characters
+	userNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9._-]{3,20}$`)
+)
+
+// mcRunner runs mc with the given args, extra env vars and input and returns its output
+type mcRunner func(env []string, input string, args ...string) (string, error)
+
+// runMC runs mc. The secret keys of the users are passed in the input of mc rather than in its args, so that they
+// don't show in the process list.
+func runMC(env []string, input string, args ...string) (string, error) {
+	cmd := exec.Command(mcBinary, args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = strings.NewReader(input)
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// mcSession runs mc against an object store with a temporary config dir
+type mcSession struct {
+	dir string
+	env []string
+	run mcRunner
+}
+
+// newMCSession creates the config dir of mc for the object store. The credentials are passed in the env of mc, and
+// the ca.crt of the tls secret is trusted.
+func (c *Controller) newMCSession(objectstore *miniov1alpha1.ObjectStore) (*mcSession, error) {
+	spec := objectstore.Spec
+	accessKey, secretKey, err := c.getAccessCredentials(spec.Credentials.Name, spec.Credentials.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	address, err := url.Parse(c.adminAddress(objectstore.Name, objectstore.Namespace, spec))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the address of object store %s. %+v", objectstore.Name, err)
+	}
+	address.User = url.UserPassword(accessKey, secretKey)
+
+	dir, err := ioutil.TempDir("", "mc-"+objectstore.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mc config dir. %+v", err)
+	}
+	session := &mcSession{
+		dir: dir,
+		env: []string{fmt.Sprintf("MC_HOST_%s=%s", mcAlias, address.String())},
+		run: c.runMC,
+	}
+
+	if spec.TLS != nil {
+		secret, err := c.getTLSSecret(objectstore.Namespace, spec.TLS)
+		if err != nil {
+			session.close()
+			return nil, err
+		}
+		if ca := secret.Data[tlsCAName]; len(ca) > 0 {
+			certsDir := filepath.Join(dir, mcCertsDir)
+			if err := os.MkdirAll(certsDir, 0700); err != nil {
+				session.close()
+				return nil, fmt.Errorf("failed to create mc certs dir. %+v", err)
+			}
+			if err := ioutil.WriteFile(filepath.Join(certsDir, tlsCAName), ca, 0600); err != nil {
+				session.close()
+				return nil, fmt.Errorf("failed to write %s. %+v", tlsCAName, err)
+			}
+		}
+	}
+	return session, nil
+}
+
+// mc runs an mc command. The action describes the command in the errors instead of its args.
+func (s *mcSession) mc(action string, args ...string) error {
+	return s.mcWithInput(action, "", args...)
+}
+
+// mcWithInput runs an mc command that reads the given input.
+func (s *mcSession) mcWithInput(action, input string, args ...string) error {
+	args = append([]string{"--config-dir", s.dir, "--quiet", "--no-color"}, args...)
+	out, err := s.run(s.env, input, args...)
+	if err != nil {
+		return fmt.Errorf("failed to %s. %+v. %s", action, err, strings.TrimSpace(out))
+	}
+	return nil
+}
+
+func (s *mcSession) close() {
+	os.RemoveAll(s.dir)
+}
+
+// syncIAM creates the custom policies, buckets and users of the object store, and removes the users and policies
+// that were removed from its spec. The buckets are never removed.
+func (c *Controller) syncIAM(objectstore *miniov1alpha1.ObjectStore) error {
+	spec := objectstore.Spec
+	status := objectstore.Status
+	if len(spec.Buckets) == 0 && len(spec.Users) == 0 && len(spec.Policies) == 0 &&
+		len(status.Users) == 0 && len(status.Policies) == 0 {
+		return nil
+	}
+
+	session, err := c.newMCSession(objectstore)
+	if err != nil {
+		return err
+	}
+	defer session.close()
+
+	for _, policy := range spec.Policies {
+		file := filepath.Join(session.dir, fmt.Sprintf(policyFileNameFmt, policy.Name))
+		if err := ioutil.WriteFile(file, []byte(policy.Document), 0600); err != nil {
+			return fmt.Errorf("failed to write policy %s. %+v", policy.Name, err)
+		}
+		if err := session.mc("add policy "+policy.Name, "admin", "policy", "add", mcAlias, policy.Name, file); err != nil {
+			return err
+		}
+	}
+
+	for _, bucket := range spec.Buckets {
+		target := fmt.Sprintf("%s/%s", mcAlias, bucket.Name)
+		if err := session.mc("create bucket "+bucket.Name, "mb", "--ignore-existing", target); err != nil {
+			return err
+		}
+		if bucket.Quota != "" {
+			// the monitoring syncs the object stores without validating them
+			quota, err := resource.ParseQuantity(bucket.Quota)
+			if err != nil {
+				return fmt.Errorf("invalid quota %s of bucket %s. %+v", bucket.Quota, bucket.Name, err)
+			}
+			if err := session.mc("set quota of bucket "+bucket.Name, "admin", "bucket", "quota", target, "--hard", strconv.FormatInt(quota.Value(), 10)); err != nil {
+				return err
+			}
+		}
+		access := bucket.AnonymousAccess
+		if access == "" {
+			access = anonymousAccessNone
+		}
+		if err := session.mc("set anonymous access to bucket "+bucket.Name, "policy", "set", access, target); err != nil {
+			return err
+		}
+	}
+
+	users := []string{}
+	for _, user := range spec.Users {
+		secretKey, err := c.getUserSecretKey(objectstore, user)
+		if err != nil {
+			return err
+		}
+		// mc reads the secret key of the user from its input when it's not in the args
+		if err := session.mcWithInput("add user "+user.Name, secretKey+"\n", "admin", "user", "add", mcAlias, user.Name); err != nil {
+			return err
+		}
+		if err := session.mc("set policy of user "+user.Name, "admin", "policy", "set", mcAlias, user.Policy, fmt.Sprintf(userPolicyTargetFmt, user.Name)); err != nil {
+			return err
+		}
+		users = append(users, user.Name)
+	}
+
+	// the users are removed before the policies they may still be attached to
+	for _, name := range status.Users {
+		if contains(users, name) {
+			continue
+		}
+		if err := session.mc("remove user "+name, "admin", "user", "remove", mcAlias, name); err != nil {
+			return err
+		}
+		if err := c.deleteUserSecrets(objectstore, name); err != nil {
+			return err
+		}
+		logger.Infof("removed user %s of object store %s in namespace %s", name, objectstore.Name, objectstore.Namespace)
+	}
+
+	policies := []string{}
+	for _, policy := range spec.Policies {
+		policies = append(policies, policy.Name)
+	}
+	for _, name := range status.Policies {
+		if contains(policies, name) {
+			continue
+		}
+		if err := session.mc("remove policy "+name, "admin", "policy", "remove", mcAlias, name); err != nil {
+			return err
+		}
+		logger.Infof("removed policy %s of object store %s in namespace %s", name, objectstore.Name, objectstore.Namespace)
+	}
+
+	return c.updateIAMStatus(objectstore, users, policies)
+}
+
+// getUserSecretKey returns the secret key of the user from its secret, which is created with a random secret key
+// if it does not exist yet
+func (c *Controller) getUserSecretKey(objectstore *miniov1alpha1.ObjectStore, user miniov1alpha1.UserSpec) (string, error) {
+	secretName := getUserSecretName(objectstore.Name, user)
+	secret, err := c.context.Clientset.CoreV1().Secrets(objectstore.Namespace).Get(secretName, meta_v1.GetOptions{})
+	if err == nil {
+		if secretKey := string(secret.Data[s3.SecretKeyName]); secretKey != "" {
+			return secretKey, nil
+		}
+		return "", fmt.Errorf("secret %s of user %s has no %s", secretName, user.Name, s3.SecretKeyName)
+	}
+	if !k8serrors.IsNotFound(err) {
+		return "", fmt.Errorf("failed to get secret %s of user %s. %+v", secretName, user.Name, err)
+	}
+
+	key := make([]byte, userSecretKeyLength)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate the secret key of user %s. %+v", user.Name, err)
+	}
+	secretKey := hex.EncodeToString(key)
+
+	secret = &v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      secretName,
+			Namespace: objectstore.Namespace,
+			Labels:    getMinioLabels(objectstore.Name),
+		},
+		Data: map[string][]byte{
+			s3.AccessKeyName:   []byte(user.Name),
+			s3.SecretKeyName:   []byte(secretKey),
+			userSecretEndpoint: []byte(createAdminAddress(objectstore.Name, objectstore.Namespace, objectstore.Spec)),
+		},
+		Type: v1.SecretTypeOpaque,
+	}
+	ownerRef := makeOwnerRef(objectstore)
+	k8sutil.SetOwnerRef(c.context.Clientset, objectstore.Namespace, &secret.ObjectMeta, &ownerRef)
+	if _, err := c.context.Clientset.CoreV1().Secrets(objectstore.Namespace).Create(secret); err != nil {
+		return "", fmt.Errorf("failed to create secret %s of user %s. %+v", secretName, user.Name, err)
+	}
+	logger.Infof("created secret %s with the credentials of user %s of object store %s", secretName, user.Name, objectstore.Name)
+	return secretKey, nil
+}
+
+// deleteUserSecrets deletes the secrets that the operator created with the credentials of a removed user. The secrets
+// that the operator did not create don't have the labels of the object store and are left alone.
+func (c *Controller) deleteUserSecrets(objectstore *miniov1alpha1.ObjectStore, userName string) error {
+	selector := labels.SelectorFromSet(getMinioLabels(objectstore.Name)).String()
+	secrets, err := c.context.Clientset.CoreV1().Secrets(objectstore.Namespace).List(meta_v1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list the secrets of object store %s. %+v", objectstore.Name, err)
+	}
+	for _, secret := range secrets.Items {
+		if string(secret.Data[s3.AccessKeyName]) != userName {
+			continue
+		}
+		err := c.context.Clientset.CoreV1().Secrets(objectstore.Namespace).Delete(secret.Name, &meta_v1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete secret %s of user %s. %+v", secret.Name, userName, err)
+		}
+		logger.Infof("deleted secret %s of user %s of object store %s", secret.Name, userName, objectstore.Name)
+	}
+	return nil
+}
+
+// updateIAMStatus records the users and policies created by the operator in the status of the object store
+func (c *Controller) updateIAMStatus(objectstore *miniov1alpha1.ObjectStore, users, policies []string) error {
+	current, err := c.context.RookClientset.MinioV1alpha1().ObjectStores(objectstore.Namespace).Get(objectstore.Name, meta_v1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get object store %s prior to updating its status. %+v", objectstore.Name, err)
+	}
+	if len(users) == 0 {
+		users = nil
+	}
+	if len(policies) == 0 {
+		policies = nil
+	}
+	if reflect.DeepEqual(current.Status.Users, users) && reflect.DeepEqual(current.Status.Policies, policies) {
+		return nil
+	}
+	current.Status.Users = users
+	current.Status.Policies = policies
+	if _, err := c.context.RookClientset.MinioV1alpha1().ObjectStores(objectstore.Namespace).Update(current); err != nil {
+		return fmt.Errorf("failed to update object store %s status. %+v", objectstore.Name, err)
+	}
+	return nil
+}
+
+func validateIAMSpec(spec miniov1alpha1.ObjectStoreSpec) error {
+	buckets := map[string]bool{}
+	for _, bucket := range spec.Buckets {
+		if !bucketNameRegexp.MatchString(bucket.Name) {
+			return fmt.Errorf("invalid bucket name %s", bucket.Name)
+		}
+		if buckets[bucket.Name] {
+			return fmt.Errorf("duplicate bucket %s", bucket.Name)
+		}
+		buckets[bucket.Name] = true
+		if bucket.Quota != "" {
+			if _, err := resource.ParseQuantity(bucket.Quota); err != nil {
+				return fmt.Errorf("invalid quota %s of bucket %s. %+v", bucket.Quota, bucket.Name, err)
+			}
+		}
+		if bucket.AnonymousAccess != "" && !contains(anonymousAccesses, bucket.AnonymousAccess) {
+			return fmt.Errorf("invalid anonymous access %s to bucket %s. Must be one of %v", bucket.AnonymousAccess, bucket.Name, anonymousAccesses)
+		}
+	}
+
+	policies := append([]string{}, cannedPolicies...)
+	for _, policy := range spec.Policies {
+		if policy.Name == "" {
+			return fmt.Errorf("the name of every policy is required")
+		}
+		if contains(policies, policy.Name) {
+			return fmt.Errorf("duplicate policy %s", policy.Name)
+		}
+		policies = append(policies, policy.Name)
+		if !json.Valid([]byte(policy.Document)) {
+			return fmt.Errorf("the document of policy %s is not valid json", policy.Name)
+		}
+	}
+
+	users := map[string]bool{}
+	for _, user := range spec.Users {
+		if !userNameRegexp.MatchString(user.Name) {
+			return fmt.Errorf("invalid user name %s. Must be 3 to 20 letters, digits, dots, dashes or underscores", user.Name)
+		}
+		if users[user.Name] {
+			return fmt.Errorf("duplicate user %s", user.Name)
+		}
+		users[user.Name] = true
+		if !contains(policies, user.Policy) {
+			return fmt.Errorf("unknown policy %s of user %s", user.Policy, user.Name)
+		}
+	}
+	return nil
+}
+
+func getUserSecretName(objectStoreName string, user miniov1alpha1.UserSpec) string {
+	if user.SecretName != "" {
+		return user.SecretName
+	}
+	return fmt.Sprintf(userSecretNameFmt, objectStoreName, user.Name)
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package minio
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	miniov1alpha1 "github.com/rook/rook/pkg/apis/minio.rook.io/v1alpha1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	statusInterval   = 1 * time.Minute
+	adminHTTPTimeout = 10 * time.Second
+	adminInfoPath    = "/minio/admin/v3/info"
+	// the requests to the admin API are signed like the requests to the s3 API
+	adminSigningService = "s3"
+	adminSigningRegion  = "us-east-1"
+	serverStateOnline   = "online"
+	driveStateOK        = "ok"
+)
+
+// adminInfoResponse is the part of the response of the info endpoint of the admin API that the operator uses
+type adminInfoResponse struct {
+	Servers []struct {
+		State    string `json:"state"`
+		Endpoint string `json:"endpoint"`
+		Disks    []struct {
+			State string `json:"state"`
+		} `json:"disks"`
+	} `json:"servers"`
+}
+
+// createAdminAddress creates the address of the admin API of an object store, served by any server behind the
+// headless service
+func createAdminAddress(name, namespace string, spec miniov1alpha1.ObjectStoreSpec) string {
+	return fmt.Sprintf("%s://%s.%s:%d", getScheme(spec), name, makeDNSSuffix(namespace, spec.ClusterDomain), getPort(spec))
+}
+
+// getServerStatus returns the status of the servers and drives of the object store reported by its admin API
+func (c *Controller) getServerStatus(objectstore *miniov1alpha1.ObjectStore) (miniov1alpha1.ObjectStoreStatus, error) {
+	status := miniov1alpha1.ObjectStoreStatus{}
+	spec := objectstore.Spec
+	accessKey, secretKey, err := c.getAccessCredentials(spec.Credentials.Name, spec.Credentials.Namespace)
+	if err != nil {
+		return status, err
+	}
+	client, err := c.createAdminClient(objectstore)
+	if err != nil {
+		return status, err
+	}
+
+	url := c.adminAddress(objectstore.Name, objectstore.Namespace, spec) + adminInfoPath
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return status, fmt.Errorf("failed to create request %s. %+v", url, err)
+	}
+	signer := v4.NewSigner(credentials.NewStaticCredentials(accessKey, secretKey, ""))
+	if _, err := signer.Sign(req, nil, adminSigningService, adminSigningRegion, time.Now()); err != nil {
+		return status, fmt.Errorf("failed to sign request %s. %+v", url, err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return status, fmt.Errorf("failed to get %s. %+v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return status, fmt.Errorf("failed to get %s. status %d", url, resp.StatusCode)
+	}
+	var info adminInfoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return status, fmt.Errorf("failed to decode %s. %+v", url, err)
+	}
+
+	for _, server := range info.Servers {
+		if server.State == serverStateOnline {
+			status.OnlineServers++
+		} else {
+			status.OfflineServers++
+		}
+		for _, disk := range server.Disks {
+			if disk.State == driveStateOK {
+				status.OnlineDrives++
+			} else {
+				status.OfflineDrives++
+			}
+		}
+	}
+	return status, nil
+}
+
+// createAdminClient creates the http client of the admin API, which trusts the ca.crt of the tls secret if any
+func (c *Controller) createAdminClient(objectstore *miniov1alpha1.ObjectStore) (*http.Client, error) {
+	client := &http.Client{Timeout: adminHTTPTimeout}
+	if objectstore.Spec.TLS == nil {
+		return client, nil
+	}
+
+	secret, err := c.getTLSSecret(objectstore.Namespace, objectstore.Spec.TLS)
+	if err != nil {
+		return nil, err
+	}
+	if ca := secret.Data[tlsCAName]; len(ca) > 0 {
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("failed to parse %s of tls secret %s", tlsCAName, secret.Name)
+		}
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}
+	}
+	return client, nil
+}
+
+// monitorObjectStores periodically reports the servers and drives of every object store in their status, and syncs
+// their buckets and users
+func (c *Controller) monitorObjectStores(namespace string, stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			logger.Infof("stopping monitoring of minio object stores")
+			return
+		case <-time.After(statusInterval):
+			objectstores, err := c.context.RookClientset.MinioV1alpha1().ObjectStores(namespace).List(meta_v1.ListOptions{})
+			if err != nil {
+				logger.Warningf("failed to list minio object stores: %+v", err)
+				continue
+			}
+			for i := range objectstores.Items {
+				objectstore := &objectstores.Items[i]
+				if err := c.updateServerStatus(objectstore); err != nil {
+					logger.Warningf("failed to update the status of object store %s in namespace %s: %+v", objectstore.Name, objectstore.Namespace, err)
+				}
+				// an invalid spec is reported in the state of the object store when it is added or updated
+				if err := validateObjectStoreSpec(objectstore.Spec); err != nil {
+					continue
+				}
+				if err := c.syncIAM(objectstore); err != nil {
+					logger.Warningf("failed to sync the buckets and users of object store %s in namespace %s: %+v", objectstore.Name, objectstore.Namespace, err)
+				}
+			}
+		}
+	}
+}
+
+// updateServerStatus reports the servers and drives of the object store in its status
+func (c *Controller) updateServerStatus(objectstore *miniov1alpha1.ObjectStore) error {
+	status, err := c.getServerStatus(objectstore)
+	if err != nil {
+		return err
+	}
+
+	current, err := c.context.RookClientset.MinioV1alpha1().ObjectStores(objectstore.Namespace).Get(objectstore.Name, meta_v1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get object store %s prior to updating its status. %+v", objectstore.Name, err)
+	}
+	// the status is only written when it changed, as every update of the object store is watched
+	status.State = current.Status.State
+	status.Message = current.Status.Message
+	status.Users = current.Status.Users
+	status.Policies = current.Status.Policies
+	if reflect.DeepEqual(current.Status, status) {
+		return nil
+	}
+	current.Status = status
+	if _, err := c.context.RookClientset.MinioV1alpha1().ObjectStores(objectstore.Namespace).Update(current); err != nil {
+		return fmt.Errorf("failed to update object store %s status. %+v", objectstore.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package minio
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	miniov "github.com/rook/rook/pkg/apis/minio.rook.io/v1alpha1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const adminInfoForTest = `{"mode":"online","servers":[
+{"state":"online","endpoint":"my-store-0.my-store.rook-minio-126.svc.cluster.local:9000","disks":[{"path":"/data/minio-data","state":"ok"}]},
+{"state":"online","endpoint":"my-store-1.my-store.rook-minio-126.svc.cluster.local:9000","disks":[{"path":"/data/minio-data","state":"ok"}]},
+{"state":"online","endpoint":"my-store-2.my-store.rook-minio-126.svc.cluster.local:9000","disks":[{"path":"/data/minio-data","state":"offline"}]},
+{"state":"offline","endpoint":"my-store-3.my-store.rook-minio-126.svc.cluster.local:9000"}]}`
+
+func TestUpdateServerStatus(t *testing.T) {
+	namespace := "rook-minio-126"
+	objectstore := newObjectStoreForTest(namespace)
+	objectstore.Status.State = miniov.ObjectStoreStateCreated
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, adminInfoPath, r.URL.Path)
+		// the request is signed with the credentials of the object store
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=user/"))
+		w.Write([]byte(adminInfoForTest))
+	}))
+	defer server.Close()
+
+	clientset := testop.New(3)
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookfake.NewSimpleClientset(objectstore)}
+	controller := NewController(context, "rook/minio:mockTag")
+	controller.adminAddress = func(name, namespace string, spec miniov.ObjectStoreSpec) string { return server.URL }
+	_, err := clientset.CoreV1().Secrets(namespace).Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "access-keys", Namespace: namespace},
+		Data:       map[string][]byte{"username": []byte("user"), "password": []byte("pass")},
+	})
+	assert.Nil(t, err)
+
+	assert.Nil(t, controller.updateServerStatus(objectstore))
+	assert.Equal(t, 1, requests)
+
+	current, err := context.RookClientset.MinioV1alpha1().ObjectStores(namespace).Get("my-store", metav1.GetOptions{})
+	assert.Nil(t, err)
+	expected := miniov.ObjectStoreStatus{
+		State:          miniov.ObjectStoreStateCreated,
+		OnlineServers:  3,
+		OfflineServers: 1,
+		OnlineDrives:   2,
+		OfflineDrives:  1,
+	}
+	assert.Equal(t, expected, current.Status)
+}
+
+func TestCreateAdminAddress(t *testing.T) {
+	spec := newObjectStoreForTest("rook-minio").Spec
+	assert.Equal(t, "http://my-store.rook-minio.svc.cluster.local:9000", createAdminAddress("my-store", "rook-minio", spec))
+
+	spec.Port = 9443
+	spec.TLS = &miniov.TLSSpec{SecretName: "tls"}
+	spec.ClusterDomain = "acme.com"
+	assert.Equal(t, "https://my-store.rook-minio.svc.acme.com:9443", createAdminAddress("my-store", "rook-minio", spec))
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	_, err := Parse("* * * *")
+	assert.NotNil(t, err)
+	_, err = Parse("60 * * * *")
+	assert.NotNil(t, err)
+	_, err = Parse("*/0 * * * *")
+	assert.NotNil(t, err)
+	_, err = Parse("5-1 * * * *")
+	assert.NotNil(t, err)
+
+	s, err := Parse("*/15 2,4 1-3 * 7")
+	assert.Nil(t, err)
+	assert.Equal(t, map[int]bool{0: true, 15: true, 30: true, 45: true}, s.minutes)
+	assert.Equal(t, map[int]bool{2: true, 4: true}, s.hours)
+	assert.Equal(t, map[int]bool{1: true, 2: true, 3: true}, s.days)
+	assert.Equal(t, 12, len(s.months))
+	assert.Equal(t, map[int]bool{0: true}, s.weekdays)
+}
+
+func TestNext(t *testing.T) {
+	start := time.Date(2019, time.March, 14, 10, 22, 31, 0, time.UTC)
+
+	s, _ := Parse("* * * * *")
+	assert.Equal(t, time.Date(2019, time.March, 14, 10, 23, 0, 0, time.UTC), s.Next(start))
+
+	s, _ = Parse("30 2 * * *")
+	assert.Equal(t, time.Date(2019, time.March, 15, 2, 30, 0, 0, time.UTC), s.Next(start))
+
+	s, _ = Parse("0 0 1 * *")
+	assert.Equal(t, time.Date(2019, time.April, 1, 0, 0, 0, 0, time.UTC), s.Next(start))
+
+	// sunday
+	s, _ = Parse("0 12 * * 0")
+	assert.Equal(t, time.Date(2019, time.March, 17, 12, 0, 0, 0, time.UTC), s.Next(start))
+
+	// the day of month or the day of week matches when both are restricted
+	s, _ = Parse("0 0 20 * 5")
+	assert.Equal(t, time.Date(2019, time.March, 15, 0, 0, 0, 0, time.UTC), s.Next(start))
+
+	// leap day
+	s, _ = Parse("0 0 29 2 *")
+	assert.Equal(t, time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC), s.Next(start))
+
+	// never fires
+	s, _ = Parse("0 0 31 2 *")
+	assert.True(t, s.Next(start).IsZero())
+}
+
+func TestDue(t *testing.T) {
+	s, _ := Parse("0 * * * *")
+	lastRun := time.Date(2019, time.March, 14, 10, 0, 0, 0, time.UTC)
+	assert.False(t, s.Due(lastRun, lastRun.Add(59*time.Minute)))
+	assert.True(t, s.Due(lastRun, lastRun.Add(time.Hour)))
+}
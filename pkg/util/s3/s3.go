@@ -0,0 +1,146 @@
+/*
+Copyright 2019 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package s3 is a small client for storing objects in an S3 compatible endpoint
+package s3
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	// AccessKeyName is the key in a secret that holds the S3 access key
+	AccessKeyName = "AWS_ACCESS_KEY_ID"
+	// SecretKeyName is the key in a secret that holds the S3 secret key
+	SecretKeyName = "AWS_SECRET_ACCESS_KEY"
+
+	// the region is ignored by most S3 compatible endpoints, but the sdk requires one
+	defaultRegion = "us-east-1"
+)
+
+// Config is the location and credentials of an S3 bucket
+type Config struct {
+	// Endpoint is the URL of the S3 endpoint, e.g. http://rook-ceph-rgw-my-store:80
+	Endpoint  string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// ConfigFromEnv returns the config for the endpoint and bucket with the credentials from the AWS env vars
+func ConfigFromEnv(endpoint, bucket string) Config {
+	return Config{
+		Endpoint:  endpoint,
+		Bucket:    bucket,
+		AccessKey: os.Getenv(AccessKeyName),
+		SecretKey: os.Getenv(SecretKeyName),
+	}
+}
+
+// Client reads and writes objects in a single bucket
+type Client struct {
+	bucket string
+	client *s3.S3
+}
+
+// NewClient creates a client for the bucket
+func NewClient(config Config) (*Client, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, fmt.Errorf("the s3 endpoint and bucket are required")
+	}
+
+	awsConfig := aws.NewConfig().
+		WithRegion(defaultRegion).
+		WithCredentials(credentials.NewStaticCredentials(config.AccessKey, config.SecretKey, "")).
+		WithEndpoint(config.Endpoint).
+		WithS3ForcePathStyle(true).
+		WithDisableSSL(strings.HasPrefix(config.Endpoint, "http://"))
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 session. %+v", err)
+	}
+
+	return &Client{bucket: config.Bucket, client: s3.New(sess)}, nil
+}
+
+// Put stores the content under the key
+func (c *Client) Put(key string, content io.ReadSeeker) error {
+	_, err := c.client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+		Body:   content,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put object %s in bucket %s. %+v", key, c.bucket, err)
+	}
+	return nil
+}
+
+// Get writes the content stored under the key
+func (c *Client) Get(key string, w io.Writer) error {
+	output, err := c.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get object %s from bucket %s. %+v", key, c.bucket, err)
+	}
+	defer output.Body.Close()
+
+	if _, err := io.Copy(w, output.Body); err != nil {
+		return fmt.Errorf("failed to read object %s from bucket %s. %+v", key, c.bucket, err)
+	}
+	return nil
+}
+
+// List returns the keys that start with the prefix
+func (c *Client) List(prefix string) ([]string, error) {
+	keys := []string{}
+	input := &s3.ListObjectsInput{
+		Bucket: aws.String(c.bucket),
+		Prefix: aws.String(prefix),
+	}
+	err := c.client.ListObjectsPages(input, func(page *s3.ListObjectsOutput, lastPage bool) bool {
+		for _, object := range page.Contents {
+			keys = append(keys, aws.StringValue(object.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects in bucket %s. %+v", c.bucket, err)
+	}
+	return keys, nil
+}
+
+// Delete removes the object stored under the key
+func (c *Client) Delete(key string) error {
+	_, err := c.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete object %s from bucket %s. %+v", key, c.bucket, err)
+	}
+	return nil
+}
@@ -253,25 +253,25 @@ metadata:
 spec:
   version: %[4]s
   mode: %[3]s
-  datacenter:
-    name: "us-east-1"
-    racks:
-      - name: "us-east-1a"
-        members: %[2]d
-        storage:
-          volumeClaimTemplates:
-                - metadata:
-                    name: %[1]s-data
-                  spec:
-                    resources:
-                      requests:
-                        storage: 5Gi
-        resources:
-          requests:
-            cpu: 1
-            memory: 2Gi
-          limits:
-            cpu: 1
-            memory: 2Gi
+  datacenters:
+    - name: "us-east-1"
+      racks:
+        - name: "us-east-1a"
+          members: %[2]d
+          storage:
+            volumeClaimTemplates:
+                  - metadata:
+                      name: %[1]s-data
+                    spec:
+                      resources:
+                        requests:
+                          storage: 5Gi
+          resources:
+            requests:
+              cpu: 1
+              memory: 2Gi
+            limits:
+              cpu: 1
+              memory: 2Gi
 `, namespace, count, mode, version)
 }
@@ -88,6 +88,27 @@ spec:
       properties:
         spec:
           properties:
+            backup:
+              properties:
+                schedule:
+                  type: string
+                retention:
+                  type: integer
+                  minimum: 0
+                persistentVolumeClaim:
+                  type: string
+                s3:
+                  properties:
+                    endpoint:
+                      type: string
+                    bucket:
+                      type: string
+                    secretName:
+                      type: string
+                  required:
+                  - endpoint
+                  - bucket
+                  - secretName
             cephVersion:
               properties:
                 allowUnsupported:
@@ -97,6 +118,26 @@ spec:
                 name:
                   pattern: ^(luminous|mimic|nautilus)$
                   type: string
+            cleanupPolicy:
+              properties:
+                confirmation:
+                  pattern: ^$|^yes-really-destroy-data$
+                  type: string
+                sanitizeDevices:
+                  type: boolean
+            disruptionManagement:
+              properties:
+                managePodBudgets:
+                  type: boolean
+                osdFailureDomain:
+                  type: string
+                osdMaintenanceTimeout:
+                  type: integer
+                  minimum: 0
+            csi:
+              properties:
+                createStorageClasses:
+                  type: boolean
             dashboard:
               properties:
                 enabled:
@@ -395,6 +436,8 @@ rules:
   - get
   - list
   - watch
+  - create
+  - delete
 - apiGroups:
   - batch
   resources:
@@ -406,6 +449,17 @@ rules:
   - create
   - update
   - delete
+- apiGroups:
+  - policy
+  resources:
+  - poddisruptionbudgets
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - delete
 - apiGroups:
   - ceph.rook.io
   resources:
@@ -870,11 +924,11 @@ spec:
         - name: ROOK_CSI_ENABLE_CEPHFS
           value: "true"
         - name: ROOK_CSI_CEPHFS_IMAGE
-          value: "quay.io/cephcsi/cephfsplugin:v1.0.0"
+          value: "quay.io/cephcsi/cephcsi:v1.1.0"
         - name: ROOK_CSI_ENABLE_RBD
           value: "true"
         - name: ROOK_CSI_RBD_IMAGE
-          value: "quay.io/cephcsi/rbdplugin:v1.0.0"
+          value: "quay.io/cephcsi/cephcsi:v1.1.0"
         - name: ROOK_CSI_REGISTRAR_IMAGE
           value: "quay.io/k8scsi/csi-node-driver-registrar:v1.0.2"
         - name: ROOK_CSI_PROVISIONER_IMAGE
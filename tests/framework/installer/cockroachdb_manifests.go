@@ -24,7 +24,7 @@ import (
 type CockroachDBManifests struct {
 }
 
-// GetCockroachDBCRDs return the CockroachDB Cluster CRD
+// GetCockroachDBCRDs return the CockroachDB Cluster and Restore CRDs
 func (i *CockroachDBManifests) GetCockroachDBCRDs() string {
 	return `apiVersion: apiextensions.k8s.io/v1beta1
 kind: CustomResourceDefinition
@@ -39,6 +39,20 @@ spec:
     singular: cluster
   scope: Namespaced
   version: v1alpha1
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: restores.cockroachdb.rook.io
+spec:
+  group: cockroachdb.rook.io
+  names:
+    kind: Restore
+    listKind: RestoreList
+    plural: restores
+    singular: restore
+  scope: Namespaced
+  version: v1alpha1
 `
 }
 
@@ -67,18 +81,40 @@ rules:
   - services
   verbs:
   - create
+  - delete
+- apiGroups:
+  - ""
+  resources:
+  - secrets
+  verbs:
+  - create
+  - get
+  - update
+  - delete
 - apiGroups:
   - apps
   resources:
   - statefulsets
   verbs:
   - create
+  - get
+  - update
+  - delete
 - apiGroups:
   - policy
   resources:
   - poddisruptionbudgets
   verbs:
   - create
+  - delete
+- apiGroups:
+  - batch
+  resources:
+  - jobs
+  verbs:
+  - create
+  - get
+  - delete
 - apiGroups:
   - cockroachdb.rook.io
   resources: